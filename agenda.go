@@ -0,0 +1,306 @@
+// agenda.go - Day agenda
+// Lays the day out as a timeline: tasks with a planned start time stay put, the rest fill the gaps, and clashes and overflow are flagged
+
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// agendaSlot is one stretch of the timeline. A task that doesn't fit in one
+// gap is split over several slots.
+type agendaSlot struct {
+	Index      int    // task index, -1 for lunch and reserved blocks
+	Label      string // what occupies a slot with no task
+	Start, End time.Time
+	Fixed      bool  // the task has a planned start time
+	Conflicts  []int // indexes of fixed tasks overlapping this one
+}
+
+// agendaMinutes is how long a task takes on the timeline: the tracked time once
+// done, else the estimate. Non-work, cancelled and checklist tasks take none.
+func agendaMinutes(t Task) int {
+	if isNonWork(t) || t.Status == "cancelled" {
+		return 0
+	}
+	if t.Status == "done" && t.Actual > 0 {
+		return t.Actual
+	}
+	return t.Estimated
+}
+
+// checkPlannedAt validates a planned start time, empty meaning none
+func checkPlannedAt(at string) (string, error) {
+	at = strings.TrimSpace(at)
+	if at == "" {
+		return "", nil
+	}
+	minutes, err := parseClock(at)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60), nil
+}
+
+// layoutAgenda places the fixed tasks at their planned time and fills the
+// gaps between them, lunch and reserved blocks excluded, with the other tasks in the order `next`
+// takes them. On today, open tasks are not scheduled in the past.
+func layoutAgenda(day time.Time, tasks []Task, now time.Time) []agendaSlot {
+	workStart, lunchStart, lunchEnd, _ := config.WorkHours.bounds(day)
+	var slots, busy []agendaSlot
+	if lunchEnd.After(lunchStart) {
+		busy = append(busy, agendaSlot{Index: -1, Label: "lunch", Start: lunchStart, End: lunchEnd})
+	}
+	for _, r := range reservationsOn(day) {
+		busy = append(busy, agendaSlot{Index: -1, Label: r.Name, Start: clockOn(day, r.Start), End: clockOn(day, r.End)})
+	}
+	var flexible []int
+	for i, t := range tasks {
+		minutes := agendaMinutes(t)
+		if minutes <= 0 {
+			continue
+		}
+		if t.PlannedAt == "" {
+			flexible = append(flexible, i)
+			continue
+		}
+		start := clockOn(day, t.PlannedAt)
+		busy = append(busy, agendaSlot{Index: i, Start: start, End: start.Add(time.Duration(minutes) * time.Minute), Fixed: true})
+	}
+	for i := range busy {
+		for j := range busy {
+			if i != j && busy[i].Fixed && busy[j].Fixed && busy[i].Start.Before(busy[j].End) && busy[j].Start.Before(busy[i].End) {
+				busy[i].Conflicts = append(busy[i].Conflicts, busy[j].Index)
+			}
+		}
+	}
+	sort.SliceStable(busy, func(i, j int) bool { return busy[i].Start.Before(busy[j].Start) })
+	slots = append(slots, busy...)
+
+	sortByPriority(tasks, flexible)
+	cursor := workStart
+	today := day.Format("2006-01-02") == dayOf(now).Format("2006-01-02")
+	for _, i := range flexible {
+		if today && isOpenStatus(tasks[i].Status) && cursor.Before(now) {
+			cursor = now.Truncate(time.Minute)
+		}
+		remaining := time.Duration(agendaMinutes(tasks[i])) * time.Minute
+		for remaining > 0 {
+			// Step over whatever occupies the cursor, then run up to the next busy slot
+			next := time.Time{}
+			for moved := true; moved; {
+				moved = false
+				for _, b := range busy {
+					if !cursor.Before(b.Start) && cursor.Before(b.End) {
+						cursor, moved = b.End, true
+					}
+				}
+			}
+			for _, b := range busy {
+				if b.Start.After(cursor) && (next.IsZero() || b.Start.Before(next)) {
+					next = b.Start
+				}
+			}
+			end := cursor.Add(remaining)
+			if !next.IsZero() && end.After(next) {
+				end = next
+			}
+			slots = append(slots, agendaSlot{Index: i, Start: cursor, End: end})
+			remaining -= end.Sub(cursor)
+			cursor = end
+		}
+	}
+	sort.SliceStable(slots, func(i, j int) bool { return slots[i].Start.Before(slots[j].Start) })
+	return slots
+}
+
+// showAgenda prints the day as a timeline
+func showAgenda(day string) error {
+	date, err := time.ParseInLocation("2006-01-02", day, time.Local)
+	if err != nil {
+		return fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", day)
+	}
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	tasks := data[day]
+	workStart, _, _, workEnd := config.WorkHours.bounds(date)
+	fmt.Printf("Agenda for %s (work %s-%s)\n\n", day, workStart.Format("15:04"), workEnd.Format("15:04"))
+	slots := layoutAgenda(date, tasks, time.Now())
+	if !slices.ContainsFunc(slots, func(s agendaSlot) bool { return s.Index >= 0 }) {
+		fmt.Println("No tasks to schedule.")
+		return nil
+	}
+
+	theme := activeTheme()
+	warn := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Bad))
+	dim := lipgloss.NewStyle().Faint(true)
+	seen := map[int]bool{}
+	var end time.Time
+	conflicts := 0
+	for _, s := range slots {
+		span := s.Start.Format("15:04") + "-" + s.End.Format("15:04")
+		if s.Index < 0 {
+			fmt.Println(dim.Render(fmt.Sprintf("  %s  %s", span, s.Label)))
+			continue
+		}
+		if s.End.After(end) {
+			end = s.End
+		}
+		t := tasks[s.Index]
+		label := t.Title
+		if seen[s.Index] {
+			label += " (cont.)"
+		}
+		seen[s.Index] = true
+		marker := " "
+		if s.Fixed {
+			marker = "@"
+		}
+		line := fmt.Sprintf("%s %s  %-40s %8s  %s", marker, span, label, fmtMinutes(int(s.End.Sub(s.Start).Minutes())), t.Status)
+		switch {
+		case len(s.Conflicts) > 0:
+			var with []string
+			for _, i := range s.Conflicts {
+				with = append(with, tasks[i].Title)
+			}
+			fmt.Println(warn.Render(line + "  ! overlaps " + strings.Join(with, ", ")))
+			conflicts++
+		case t.Status == "done" || t.Status == "cancelled":
+			fmt.Println(dim.Render(line))
+		default:
+			fmt.Println(line)
+		}
+	}
+
+	fmt.Println()
+	if conflicts > 0 {
+		fmt.Println(warn.Render(fmt.Sprintf("%d tasks with a start time overlap each other.", conflicts)))
+	}
+	if end.After(workEnd) {
+		fmt.Println(warn.Render(fmt.Sprintf("The plan runs until %s, %s past the end of work (%s).",
+			end.Format("15:04"), fmtMinutes(int(end.Sub(workEnd).Minutes())), workEnd.Format("15:04"))))
+	} else {
+		fmt.Printf("The plan ends at %s, %s before the end of work.\n", end.Format("15:04"), fmtMinutes(int(workEnd.Sub(end).Minutes())))
+	}
+	fmt.Println(dim.Render("@ = planned start time; other tasks fill the gaps in the order `daily next` takes them."))
+	return nil
+}
+
+// warnIfPastWorkEnd warns when a task planned at `at` would run past the end of work
+func warnIfPastWorkEnd(day, at string, minutes int) {
+	date, err := time.ParseInLocation("2006-01-02", day, time.Local)
+	if err != nil || at == "" {
+		return
+	}
+	_, _, _, workEnd := config.WorkHours.bounds(date)
+	if end := clockOn(date, at).Add(time.Duration(minutes) * time.Minute); end.After(workEnd) {
+		fmt.Printf("Warning: this task ends at %s, after the end of work (%s)\n", end.Format("15:04"), workEnd.Format("15:04"))
+	}
+}
+
+// meetingSqueeze is what the day's meetings leave of the remaining plan
+type meetingSqueeze struct {
+	Meetings int       // minutes of meetings still ahead
+	End      time.Time // when the packed plan ends
+	Defer    []int     // open tasks that no longer fit before the end of work
+	Clashes  [][2]int  // a task with a start time and the meeting it overlaps
+}
+
+// squeezeByMeetings packs the day like the agenda does and reports the open
+// tasks pushed past the end of work by meetings still ahead. Packing follows
+// `next` order, so the tasks to defer are the ones `next` would reach last.
+func squeezeByMeetings(date time.Time, tasks []Task, now time.Time) (meetingSqueeze, bool) {
+	var s meetingSqueeze
+	_, _, _, workEnd := config.WorkHours.bounds(date)
+	if !workEnd.After(now) {
+		return s, false
+	}
+	for _, t := range tasks {
+		if !hasTag(t, "meeting") || t.PlannedAt == "" || !isOpenStatus(t.Status) {
+			continue
+		}
+		start := clockOn(date, t.PlannedAt)
+		end := start.Add(time.Duration(t.Estimated) * time.Minute)
+		if start.Before(now) {
+			start = now
+		}
+		if end.After(start) && start.Before(workEnd) {
+			s.Meetings += int(minTime(end, workEnd).Sub(start).Minutes())
+		}
+	}
+	if s.Meetings == 0 {
+		return s, false
+	}
+	ends := map[int]time.Time{}
+	for _, slot := range layoutAgenda(date, tasks, now) {
+		if slot.Index < 0 {
+			continue
+		}
+		if slot.End.After(s.End) {
+			s.End = slot.End
+		}
+		t := tasks[slot.Index]
+		if slot.End.After(ends[slot.Index]) {
+			ends[slot.Index] = slot.End
+		}
+		if !slot.Fixed || hasTag(t, "meeting") || !isOpenStatus(t.Status) {
+			continue
+		}
+		for _, other := range slot.Conflicts {
+			if hasTag(tasks[other], "meeting") && isOpenStatus(tasks[other].Status) {
+				s.Clashes = append(s.Clashes, [2]int{slot.Index, other})
+			}
+		}
+	}
+	for i, t := range tasks {
+		if end, ok := ends[i]; ok && t.PlannedAt == "" && isOpenStatus(t.Status) && end.After(workEnd) {
+			s.Defer = append(s.Defer, i)
+		}
+	}
+	sort.SliceStable(s.Defer, func(a, b int) bool { return ends[s.Defer[a]].Before(ends[s.Defer[b]]) })
+	return s, len(s.Defer) > 0 || len(s.Clashes) > 0
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+// warnIfMeetingsCrowdOut warns when the meetings left on a day leave too little
+// time for the open tasks, and suggests which ones to move
+func warnIfMeetingsCrowdOut(day string, tasks []Task) {
+	date, err := time.ParseInLocation("2006-01-02", day, time.Local)
+	if err != nil || day < todayKey() {
+		return
+	}
+	s, ok := squeezeByMeetings(date, tasks, time.Now())
+	if !ok {
+		return
+	}
+	warn := lipgloss.NewStyle().Foreground(lipgloss.Color(activeTheme().Bad))
+	for _, c := range s.Clashes {
+		fmt.Println(warn.Render(fmt.Sprintf("Warning: '%s' at %s overlaps the meeting '%s' at %s.",
+			tasks[c[0]].Title, tasks[c[0]].PlannedAt, tasks[c[1]].Title, tasks[c[1]].PlannedAt)))
+	}
+	if len(s.Defer) == 0 {
+		return
+	}
+	_, _, _, workEnd := config.WorkHours.bounds(date)
+	fmt.Println(warn.Render(fmt.Sprintf("Warning: with %s of meetings ahead, the plan runs until %s, %s past the end of work.",
+		fmtMinutes(s.Meetings), s.End.Format("15:04"), fmtMinutes(int(s.End.Sub(workEnd).Minutes())))))
+	var names []string
+	for _, i := range s.Defer {
+		names = append(names, fmt.Sprintf("%s (%s)", tasks[i].Title, fmtMinutes(max(0, tasks[i].Estimated-tasks[i].Actual))))
+	}
+	fmt.Printf("Consider deferring: %s\n", strings.Join(names, ", "))
+}
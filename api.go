@@ -0,0 +1,387 @@
+// api.go - REST API
+// `daily serve --api` lets phone shortcuts and wall displays list, add and update tasks, run the timer and write notes
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// apiToken returns the secret the REST API requires; the environment wins
+// over the config file
+func apiToken() string {
+	if token := os.Getenv("DAILY_API_TOKEN"); token != "" {
+		return token
+	}
+	return config.Serve.APIToken
+}
+
+// apiTaskRef is a task with what identifies it in the API: its day and its
+// 1-based position in that day
+type apiTaskRef struct {
+	ID  int    `json:"id"`
+	Day string `json:"day"`
+	apiTask
+}
+
+func taskRefs(day string, tasks []Task) []apiTaskRef {
+	refs := []apiTaskRef{}
+	for i, t := range tasks {
+		refs = append(refs, apiTaskRef{ID: i + 1, Day: day, apiTask: toAPITask(t)})
+	}
+	return refs
+}
+
+// apiTaskInput is the body of POST /api/tasks and PATCH /api/tasks/{id};
+// fields left out of a PATCH keep their value
+type apiTaskInput struct {
+	Title    *string   `json:"title"`
+	Estimate *string   `json:"estimate"` // minutes ("45") or hours ("1.5h")
+	Status   *string   `json:"status"`
+	Priority *string   `json:"priority"`
+	Project  *string   `json:"project"`
+	Context  *string   `json:"context"`
+	Tags     *[]string `json:"tags"`
+	At       *string   `json:"at"`
+	Date     string    `json:"date"` // POST only, default today
+}
+
+// apply copies the given fields onto a task, validating each
+func (in apiTaskInput) apply(t *Task, now time.Time) error {
+	if in.Title != nil {
+		title := strings.TrimSpace(*in.Title)
+		if title == "" {
+			return fmt.Errorf("title cannot be empty")
+		}
+		t.Title = title
+	}
+	if in.Estimate != nil {
+		estimated, err := parseEstimate(*in.Estimate)
+		if err != nil {
+			return err
+		}
+		t.setEstimate(estimated, now)
+	}
+	if in.Priority != nil {
+		priority, err := parsePriority(*in.Priority)
+		if err != nil {
+			return err
+		}
+		t.Priority = priority
+	}
+	if in.At != nil {
+		at, err := checkPlannedAt(*in.At)
+		if err != nil {
+			return err
+		}
+		t.PlannedAt = at
+	}
+	if in.Project != nil {
+		t.Project = strings.TrimSpace(*in.Project)
+	}
+	if in.Context != nil {
+		t.Context = normalizeContext(*in.Context)
+	}
+	if in.Tags != nil {
+		t.Tags = normalizeTags(*in.Tags)
+	}
+	if in.Status != nil && *in.Status != t.Status {
+		status := strings.ToLower(*in.Status)
+		if _, ok := statusOrder[status]; !ok || status == "started" {
+			return fmt.Errorf("invalid status %q (use POST /api/tasks/{id}/start to start a task)", *in.Status)
+		}
+		t.setStatus(status, now)
+	}
+	return nil
+}
+
+// apiError carries the HTTP status an API failure should be reported with
+type apiError struct {
+	status int
+	err    error
+}
+
+func (e apiError) Error() string { return e.err.Error() }
+
+func apiFail(status int, format string, args ...any) error {
+	return apiError{status, fmt.Errorf(format, args...)}
+}
+
+// writeAPIError reports err with its status, 500 for unexpected failures
+func writeAPIError(w http.ResponseWriter, err error) {
+	if e, ok := err.(apiError); ok {
+		writeError(w, e.status, e.err)
+		return
+	}
+	writeError(w, http.StatusInternalServerError, err)
+}
+
+// requestDay returns the ?date= of a request, default today
+func requestDay(r *http.Request, fallback string) (string, error) {
+	day := r.URL.Query().Get("date")
+	if day == "" {
+		day = fallback
+	}
+	if day == "" {
+		return todayKey(), nil
+	}
+	if _, err := time.Parse("2006-01-02", day); err != nil {
+		return "", apiFail(http.StatusBadRequest, "invalid date %q (expected YYYY-MM-DD)", day)
+	}
+	return day, nil
+}
+
+// taskIndex resolves the {id} of a path within a day
+func taskIndex(r *http.Request, tasks []Task) (int, error) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id < 1 || id > len(tasks) {
+		return 0, apiFail(http.StatusNotFound, "no task %s", r.PathValue("id"))
+	}
+	return id - 1, nil
+}
+
+// updateTasks runs a change to one day's tasks under the store lock and saves
+// it, unless the request was based on another version of the day. It sets
+// the new version on the response and wakes the event streams.
+func updateTasks(w http.ResponseWriter, r *http.Request, day string, change func(tasks []Task) ([]Task, error)) error {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	if err := checkVersion(day, expectedVersion(r), data[day]); err != nil {
+		return err
+	}
+	tasks, err := change(data[day])
+	if err != nil {
+		return err
+	}
+	data[day] = tasks
+	if err := saveTasks(data); err != nil {
+		return err
+	}
+	setVersion(w, dayVersion(tasks))
+	serverEvents.notify()
+	return nil
+}
+
+// registerAPI adds the REST API, every endpoint requiring the API token:
+//
+//	GET   /api/tasks?date=             the tasks of a day, with the day's version as ETag
+//	GET   /api/tasks/events?date=      server-sent events with the tasks of a day on every new version
+//	POST  /api/tasks                   add a task: title, estimate, date, priority, project, context, tags, at
+//	PATCH /api/tasks/{id}?date=        change any of those fields, or the status
+//	POST  /api/tasks/{id}/start        start a task of today
+//	POST  /api/stop                    stop the running task (optional note)
+//	GET   /api/notes?date=             the notes of a day
+//	POST  /api/notes                   add a note: text, date
+//
+// Changes to tasks accept the version they were based on as If-Match (or
+// ?version=) and fail with 409 Conflict when the day has changed since.
+func registerAPI(mux *http.ServeMux, token string) {
+	guard := func(h func(http.ResponseWriter, *http.Request) error) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if !authorized(r, token) {
+				writeError(w, http.StatusUnauthorized, fmt.Errorf("invalid or missing token"))
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+			if err := h(w, r); err != nil {
+				writeAPIError(w, err)
+			}
+		}
+	}
+	decode := func(r *http.Request, v any) error {
+		if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+			return apiFail(http.StatusBadRequest, "invalid JSON body: %v", err)
+		}
+		return nil
+	}
+
+	mux.HandleFunc("GET /api/tasks", guard(func(w http.ResponseWriter, r *http.Request) error {
+		day, err := requestDay(r, "")
+		if err != nil {
+			return err
+		}
+		data, err := readTasks()
+		if err != nil {
+			return err
+		}
+		setVersion(w, dayVersion(data[day]))
+		writeJSON(w, http.StatusOK, taskRefs(day, data[day]))
+		return nil
+	}))
+	mux.HandleFunc("GET /api/tasks/events", guard(streamDay))
+	mux.HandleFunc("POST /api/tasks", guard(func(w http.ResponseWriter, r *http.Request) error {
+		var in apiTaskInput
+		if err := decode(r, &in); err != nil {
+			return err
+		}
+		day, err := requestDay(r, in.Date)
+		if err != nil {
+			return err
+		}
+		if in.Title == nil {
+			return apiFail(http.StatusBadRequest, "title is required")
+		}
+		var ref apiTaskRef
+		err = updateTasks(w, r, day, func(tasks []Task) ([]Task, error) {
+			task := Task{Status: "pending"}
+			if err := in.apply(&task, time.Now()); err != nil {
+				return nil, apiError{http.StatusBadRequest, err}
+			}
+			applyTagRules(&task)
+			tasks = append(tasks, task)
+			ref = apiTaskRef{ID: len(tasks), Day: day, apiTask: toAPITask(task)}
+			return tasks, nil
+		})
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusCreated, ref)
+		return nil
+	}))
+	mux.HandleFunc("PATCH /api/tasks/{id}", guard(func(w http.ResponseWriter, r *http.Request) error {
+		var in apiTaskInput
+		if err := decode(r, &in); err != nil {
+			return err
+		}
+		day, err := requestDay(r, "")
+		if err != nil {
+			return err
+		}
+		var ref apiTaskRef
+		err = updateTasks(w, r, day, func(tasks []Task) ([]Task, error) {
+			i, err := taskIndex(r, tasks)
+			if err != nil {
+				return nil, err
+			}
+			if err := in.apply(&tasks[i], time.Now()); err != nil {
+				return nil, apiError{http.StatusBadRequest, err}
+			}
+			ref = apiTaskRef{ID: i + 1, Day: day, apiTask: toAPITask(tasks[i])}
+			return tasks, nil
+		})
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, ref)
+		return nil
+	}))
+	mux.HandleFunc("POST /api/tasks/{id}/start", guard(func(w http.ResponseWriter, r *http.Request) error {
+		day := todayKey()
+		var ref apiTaskRef
+		err := updateTasks(w, r, day, func(tasks []Task) ([]Task, error) {
+			i, err := taskIndex(r, tasks)
+			if err != nil {
+				return nil, err
+			}
+			for _, t := range tasks {
+				if t.Status == "started" {
+					return nil, apiFail(http.StatusConflict, "'%s' is already running; stop it first", t.Title)
+				}
+			}
+			if !isOpenStatus(tasks[i].Status) {
+				return nil, apiFail(http.StatusConflict, "'%s' is %s", tasks[i].Title, tasks[i].Status)
+			}
+			tasks[i].setStatus("started", time.Now())
+			ref = apiTaskRef{ID: i + 1, Day: day, apiTask: toAPITask(tasks[i])}
+			return tasks, nil
+		})
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, ref)
+		return nil
+	}))
+	mux.HandleFunc("POST /api/stop", guard(func(w http.ResponseWriter, r *http.Request) error {
+		var in struct {
+			Note string `json:"note"`
+		}
+		if r.ContentLength != 0 {
+			if err := decode(r, &in); err != nil {
+				return err
+			}
+		}
+		day := todayKey()
+		var ref apiTaskRef
+		err := updateTasks(w, r, day, func(tasks []Task) ([]Task, error) {
+			for i := range tasks {
+				if tasks[i].Status != "started" {
+					continue
+				}
+				tasks[i].setStatus("pending", time.Now())
+				if note := strings.TrimSpace(in.Note); note != "" {
+					tasks[i].ResumeNote = note
+				}
+				ref = apiTaskRef{ID: i + 1, Day: day, apiTask: toAPITask(tasks[i])}
+				return tasks, nil
+			}
+			return nil, apiFail(http.StatusConflict, "no task is running")
+		})
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, ref)
+		return nil
+	}))
+	mux.HandleFunc("GET /api/notes", guard(func(w http.ResponseWriter, r *http.Request) error {
+		day, err := requestDay(r, "")
+		if err != nil {
+			return err
+		}
+		data, err := loadNotes()
+		if err != nil {
+			return err
+		}
+		if isSealed(data[day]) {
+			return apiFail(http.StatusLocked, "the notes of %s are encrypted", day)
+		}
+		notes := data[day]
+		if notes == nil {
+			notes = []string{}
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"day": day, "notes": notes})
+		return nil
+	}))
+	mux.HandleFunc("POST /api/notes", guard(func(w http.ResponseWriter, r *http.Request) error {
+		var in struct {
+			Text string `json:"text"`
+			Date string `json:"date"`
+		}
+		if err := decode(r, &in); err != nil {
+			return err
+		}
+		day, err := requestDay(r, in.Date)
+		if err != nil {
+			return err
+		}
+		text := strings.TrimSpace(in.Text)
+		if text == "" {
+			return apiFail(http.StatusBadRequest, "text is required")
+		}
+		storeMu.Lock()
+		defer storeMu.Unlock()
+		data, err := loadNotes()
+		if err != nil {
+			return err
+		}
+		if isSealed(data[day]) {
+			return apiFail(http.StatusLocked, "the notes of %s are encrypted", day)
+		}
+		data[day] = append(data[day], text)
+		if err := saveNotes(data); err != nil {
+			return err
+		}
+		serverEvents.notify()
+		writeJSON(w, http.StatusCreated, map[string]any{"day": day, "notes": data[day]})
+		return nil
+	}))
+}
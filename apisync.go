@@ -0,0 +1,149 @@
+// apisync.go - Concurrent API clients
+// Every day's tasks carry a version: API changes based on an outdated one are refused, and subscribed clients get the new state of a day as soon as it changes
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dayVersion identifies the state of a day's tasks. It changes with every
+// edit, whichever process or machine made it, and needs no bookkeeping.
+func dayVersion(tasks []Task) string {
+	if len(tasks) == 0 {
+		return "0"
+	}
+	content, _ := json.Marshal(tasks)
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:8])
+}
+
+// expectedVersion returns the version a change was based on, from If-Match
+// or ?version=; empty when the client didn't say
+func expectedVersion(r *http.Request) string {
+	if match := strings.TrimSpace(r.Header.Get("If-Match")); match != "" && match != "*" {
+		return strings.Trim(strings.TrimPrefix(match, "W/"), `"`)
+	}
+	return r.URL.Query().Get("version")
+}
+
+// checkVersion refuses a change based on another version of the day
+func checkVersion(day, expected string, tasks []Task) error {
+	if current := dayVersion(tasks); expected != "" && expected != current {
+		return apiFail(http.StatusConflict, "the tasks of %s changed since version %s (now %s); reload them and try again", day, expected, current)
+	}
+	return nil
+}
+
+// setVersion tells the client the version of the day it now has
+func setVersion(w http.ResponseWriter, version string) {
+	w.Header().Set("ETag", `"`+version+`"`)
+}
+
+// eventHub wakes the event streams when the server changed the data, so
+// clients don't wait for the next check
+type eventHub struct {
+	mu   sync.Mutex
+	subs map[chan struct{}]bool
+}
+
+var serverEvents = &eventHub{subs: map[chan struct{}]bool{}}
+
+// subscribe returns a channel that receives after every change, and the
+// function that closes it
+func (h *eventHub) subscribe() (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	h.mu.Lock()
+	h.subs[ch] = true
+	h.mu.Unlock()
+	return ch, func() {
+		h.mu.Lock()
+		delete(h.subs, ch)
+		h.mu.Unlock()
+	}
+}
+
+// notify wakes every subscriber; one that is already due to look is skipped
+func (h *eventHub) notify() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// readTasks loads the tasks for a request. Loads are serialized with changes
+// so one can't reset the merge base in the middle of another request's change.
+func readTasks() (TaskData, error) {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	return loadTasks()
+}
+
+// dayEvent is what a subscriber receives for each new version of the day
+type dayEvent struct {
+	Day     string       `json:"day"`
+	Version string       `json:"version"`
+	Tasks   []apiTaskRef `json:"tasks"`
+}
+
+// streamDay sends the tasks of ?date= (default today, following midnight)
+// whenever their version changes, until the client goes away. Changes made
+// through the server are sent at once; others are seen within
+// dashboardRefresh.
+func streamDay(w http.ResponseWriter, r *http.Request) error {
+	fixed := r.URL.Query().Get("date")
+	if _, err := requestDay(r, ""); err != nil {
+		return err
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return fmt.Errorf("streaming is not supported")
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	fmt.Fprint(w, "retry: 5000\n\n")
+	flusher.Flush()
+
+	changed, unsubscribe := serverEvents.subscribe()
+	defer unsubscribe()
+	ticker := time.NewTicker(dashboardRefresh)
+	defer ticker.Stop()
+	last, lastSent := "", time.Now()
+	for {
+		day := fixed
+		if day == "" {
+			day = todayKey()
+		}
+		if data, err := readTasks(); err == nil {
+			if version := dayVersion(data[day]); day+version != last {
+				last = day + version
+				payload, _ := json.Marshal(dayEvent{Day: day, Version: version, Tasks: taskRefs(day, data[day])})
+				fmt.Fprintf(w, "event: day\nid: %s\ndata: %s\n\n", version, payload)
+				flusher.Flush()
+				lastSent = time.Now()
+			}
+		}
+		if time.Since(lastSent) >= dashboardKeepAlive {
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+			lastSent = time.Now()
+		}
+		select {
+		case <-r.Context().Done():
+			return nil
+		case <-changed:
+		case <-ticker.C:
+		}
+	}
+}
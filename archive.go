@@ -0,0 +1,224 @@
+// archive.go - Archive of old days
+// `daily archive` moves old days out of the live task store so everyday commands load less; reports read back only the months they cover
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TaskArchive keeps days moved out of the live task store
+type TaskArchive interface {
+	// ArchiveTasks adds days to the archive, appending to days already there
+	ArchiveTasks(data TaskData) error
+	// LoadArchivedTasks returns the archived days from `from` to `to`
+	// inclusive; empty bounds are open
+	LoadArchivedTasks(from, to string) (TaskData, error)
+}
+
+// inRange reports whether day lies within from..to, empty bounds being open
+func inRange(day, from, to string) bool {
+	return (from == "" || day >= from) && (to == "" || day <= to)
+}
+
+// archiveDir holds the YAML archive, one file per month
+func archiveDir() (string, error) {
+	dir, err := dataFilePath("archive")
+	if err != nil {
+		return "", err
+	}
+	return dir, os.MkdirAll(dir, 0755)
+}
+
+// archiveMonthPath returns the archive file of a month (YYYY-MM)
+func archiveMonthPath(month string) (string, error) {
+	dir, err := archiveDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "tasks-"+month+".yaml"), nil
+}
+
+func readArchiveMonth(path string) (TaskData, error) {
+	file, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return TaskData{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	data, _, err := decodeTaskData(file)
+	return data, err
+}
+
+func (yamlStore) ArchiveTasks(data TaskData) error {
+	byMonth := map[string]TaskData{}
+	for day, tasks := range data {
+		month := day[:7]
+		if byMonth[month] == nil {
+			byMonth[month] = TaskData{}
+		}
+		byMonth[month][day] = tasks
+	}
+	for month, days := range byMonth {
+		path, err := archiveMonthPath(month)
+		if err != nil {
+			return err
+		}
+		archived, err := readArchiveMonth(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		for day, tasks := range days {
+			archived[day] = append(archived[day], tasks...)
+		}
+		file, err := yaml.Marshal(&archived)
+		if err != nil {
+			return err
+		}
+		if err := writeFileAtomic(path, file, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (yamlStore) LoadArchivedTasks(from, to string) (TaskData, error) {
+	dir, err := dataFilePath("archive")
+	if err != nil {
+		return nil, err
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "tasks-*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	data := TaskData{}
+	for _, path := range paths {
+		month := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), "tasks-"), ".yaml")
+		// Skip the files of months entirely outside the range
+		if from != "" && month < from[:min(7, len(from))] || to != "" && month > to[:min(7, len(to))] {
+			continue
+		}
+		archived, err := readArchiveMonth(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		for day, tasks := range archived {
+			if inRange(day, from, to) {
+				data[day] = tasks
+			}
+		}
+	}
+	return data, nil
+}
+
+// loadTasksRange returns the live tasks together with the archived days from
+// `from` to `to`, for reports that look back in time. The result is for
+// reading only: saving it would move archived days back into the live store.
+func loadTasksRange(from, to string) (TaskData, error) {
+	data, err := loadTasks()
+	if err != nil {
+		return nil, err
+	}
+	store, err := openStore()
+	if err != nil {
+		return nil, err
+	}
+	archive, ok := store.(TaskArchive)
+	if !ok {
+		return data, nil
+	}
+	archived, err := archive.LoadArchivedTasks(from, to)
+	if err != nil {
+		return nil, err
+	}
+	for day, tasks := range archived {
+		data[day] = append(tasks, data[day]...)
+	}
+	upgradeLegacyStarts(data)
+	return data, nil
+}
+
+// archiveTasks moves the days before `before` into the archive. Days with a
+// running or paused task stay live.
+func archiveTasks(before string, dryRun bool) error {
+	if _, err := time.Parse("2006-01-02", before); err != nil {
+		return fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", before)
+	}
+	if before > todayKey() {
+		return fmt.Errorf("--before must not be after today")
+	}
+	store, err := openStore()
+	if err != nil {
+		return err
+	}
+	archive, ok := store.(TaskArchive)
+	if !ok {
+		return fmt.Errorf("the %s backend does not support archiving", config.Storage.Backend)
+	}
+	// Hold the lock from loading to saving, so that a change another command
+	// makes meanwhile is neither lost nor archived half-way. Commands that
+	// loaded the days before merge their save with the archived state.
+	unlock, err := lockDataDir()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	old := TaskData{}
+	count := 0
+	var kept []string
+	for day, tasks := range data {
+		if day >= before {
+			continue
+		}
+		running := false
+		for _, t := range tasks {
+			running = running || t.Status == "started" || t.Status == "paused"
+		}
+		if running {
+			kept = append(kept, day)
+			continue
+		}
+		old[day] = tasks
+		count += len(tasks)
+	}
+	sort.Strings(kept)
+	for _, day := range kept {
+		fmt.Printf("Keeping %s: it has a running or paused task.\n", day)
+	}
+	if len(old) == 0 {
+		fmt.Printf("No days before %s to archive.\n", before)
+		return nil
+	}
+	days := sortedDays(old)
+	if dryRun {
+		fmt.Printf("Would archive %d day(s) (%d tasks) from %s to %s.\n", len(days), count, days[0], days[len(days)-1])
+		return nil
+	}
+	// Write the archive first: if that fails, nothing is removed
+	if err := archive.ArchiveTasks(old); err != nil {
+		return err
+	}
+	for day := range old {
+		delete(data, day)
+	}
+	if err := store.SaveTasks(data); err != nil {
+		return err
+	}
+	rememberTasks(data)
+	fmt.Printf("Archived %d day(s) (%d tasks) from %s to %s.\n", len(days), count, days[0], days[len(days)-1])
+	fmt.Println("Reports such as search, stats, project and export still include them.")
+	return nil
+}
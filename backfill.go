@@ -0,0 +1,316 @@
+// backfill.go - Idle days
+// Workdays with nothing recorded between active days are offered for backfill: mark them off so reports leave them out, or reconstruct a minimal record of what was done
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/manifoldco/promptui"
+	"gopkg.in/yaml.v3"
+)
+
+// DaysOffData stores the reason of each day off by day, e.g. "pto" or "sick"
+type DaysOffData map[string]string
+
+// idleLookback is how far back the first command of a day looks for idle days
+const idleLookback = 30
+
+// backfillTag marks the tasks of a reconstructed day
+const backfillTag = "backfill"
+
+func getDaysOffFilePath() (string, error) {
+	return dataFilePath("daysoff.yaml")
+}
+
+func loadDaysOff() (DaysOffData, error) {
+	path, err := getDaysOffFilePath()
+	if err != nil {
+		return nil, err
+	}
+	data := DaysOffData{}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return data, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = yaml.Unmarshal(content, &data)
+	return data, err
+}
+
+func saveDaysOff(data DaysOffData) error {
+	path, err := getDaysOffFilePath()
+	if err != nil {
+		return err
+	}
+	content, err := yaml.Marshal(&data)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, content, 0644)
+}
+
+// dayOffReason tells why a day has no work: a configured holiday, a day
+// marked off, or a weekend. ok is false for a workday.
+func dayOffReason(day time.Time, off DaysOffData) (reason string, ok bool) {
+	key := day.Format("2006-01-02")
+	for _, h := range config.Holidays {
+		if h == key {
+			return "holiday", true
+		}
+	}
+	if reason, ok := off[key]; ok {
+		return reason, true
+	}
+	if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+		return "weekend", true
+	}
+	return "", false
+}
+
+// idleDays returns the workdays of the last `lookback` days before today with
+// no tasks and no notes, counting only those after the first day with any: a
+// fresh install has no idle days
+func idleDays(now time.Time, lookback int) ([]string, error) {
+	today := dayOf(now)
+	from := today.AddDate(0, 0, -lookback).Format("2006-01-02")
+	data, err := loadTasksRange(from, "")
+	if err != nil {
+		return nil, err
+	}
+	notes, err := loadNotes()
+	if err != nil {
+		return nil, err
+	}
+	off, err := loadDaysOff()
+	if err != nil {
+		return nil, err
+	}
+	var idle []string
+	active := false
+	for i := lookback; i >= 1; i-- {
+		day := today.AddDate(0, 0, -i)
+		key := day.Format("2006-01-02")
+		if len(data[key]) > 0 || len(notes[key]) > 0 {
+			active = true
+			continue
+		}
+		if _, ok := dayOffReason(day, off); ok || !active {
+			continue
+		}
+		idle = append(idle, key)
+	}
+	return idle, nil
+}
+
+// offerBackfill asks about idle days on the first command of a day, when
+// daily wasn't seen yet since the day started
+func offerBackfill(now time.Time) error {
+	if !isTerminal() {
+		return nil
+	}
+	if seen, ok := lastHeartbeat(); ok && !seen.Before(dayStart(dayOf(now).Format("2006-01-02"))) {
+		return nil
+	}
+	idle, err := idleDays(now, idleLookback)
+	if err != nil || len(idle) == 0 {
+		return err
+	}
+	fmt.Printf("Nothing was recorded on %s.\n", describeDays(idle))
+	prompt := promptui.Select{
+		Label: "Fill them in so reports stay honest",
+		Items: []string{"Now", "Later: run `daily backfill` when you're ready"},
+	}
+	choice, _, err := prompt.Run()
+	if err != nil || choice != 0 {
+		return nil
+	}
+	return backfillDays(idle)
+}
+
+// describeDays lists days as "Mon Oct 12, Tue Oct 13"
+func describeDays(days []string) string {
+	var labels []string
+	for _, day := range days {
+		if date, err := time.Parse("2006-01-02", day); err == nil {
+			labels = append(labels, date.Format("Mon Jan 2"))
+		}
+	}
+	return strings.Join(labels, ", ")
+}
+
+// backfillDays asks, day by day, whether it was a day off or a day that
+// wasn't tracked, and records the answer
+func backfillDays(days []string) error {
+	off, err := loadDaysOff()
+	if err != nil {
+		return err
+	}
+	reasons := []string{"pto", "sick", "holiday"}
+	items := []string{"I was off (PTO)", "I was off sick", "It was a public holiday", "I worked: add what I did", "Skip"}
+	offChanged := false
+	for _, day := range days {
+		date, _ := time.Parse("2006-01-02", day)
+		prompt := promptui.Select{
+			Label: date.Format("Monday Jan 2") + ": were you off, or did you forget to track",
+			Items: items,
+		}
+		choice, _, err := prompt.Run()
+		if err != nil {
+			break
+		}
+		switch {
+		case choice < len(reasons):
+			off[day] = reasons[choice]
+			offChanged = true
+		case choice == len(reasons):
+			if err := reconstructDay(day); err != nil {
+				return err
+			}
+		}
+	}
+	if !offChanged {
+		return nil
+	}
+	return saveDaysOff(off)
+}
+
+// reconstructDay adds done tasks with the time they took, as well as it is
+// remembered, tagged backfill
+func reconstructDay(day string) error {
+	var tasks []Task
+	for {
+		title, err := (&promptui.Prompt{Label: "What did you work on (empty when done)"}).Run()
+		if err != nil || strings.TrimSpace(title) == "" {
+			break
+		}
+		duration, err := (&promptui.Prompt{
+			Label: "How long (e.g. 45 or 1.5h)",
+			Validate: func(s string) error {
+				_, err := parseEstimate(s)
+				return err
+			},
+		}).Run()
+		if err != nil {
+			break
+		}
+		minutes, _ := parseEstimate(duration)
+		tasks = append(tasks, Task{
+			Title:     strings.TrimSpace(title),
+			Estimated: minutes,
+			Actual:    minutes,
+			Status:    "done",
+			Tags:      []string{backfillTag},
+		})
+	}
+	if len(tasks) == 0 {
+		fmt.Printf("Nothing added for %s; it will come up again.\n", day)
+		return nil
+	}
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	data[day] = append(data[day], tasks...)
+	if err := saveTasks(data); err != nil {
+		return err
+	}
+	total := 0
+	for _, t := range tasks {
+		total += t.Actual
+	}
+	fmt.Printf("Added %d tasks (%s) to %s.\n", len(tasks), fmtMinutes(total), day)
+	return nil
+}
+
+// runBackfill walks through the idle days of the last `days` days
+func runBackfill(days int) error {
+	if days < 1 {
+		return fmt.Errorf("--days must be at least 1")
+	}
+	idle, err := idleDays(time.Now(), days)
+	if err != nil {
+		return err
+	}
+	if len(idle) == 0 {
+		fmt.Printf("No untracked workdays in the last %d days.\n", days)
+		return nil
+	}
+	fmt.Printf("Nothing was recorded on %s.\n", describeDays(idle))
+	return backfillDays(idle)
+}
+
+// markDayOff records a day off, ahead of time or after the fact
+func markDayOff(day, reason string) error {
+	if _, err := time.Parse("2006-01-02", day); err != nil {
+		return fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", day)
+	}
+	reason = strings.ToLower(strings.TrimSpace(reason))
+	if reason == "" {
+		reason = "pto"
+	}
+	off, err := loadDaysOff()
+	if err != nil {
+		return err
+	}
+	off[day] = reason
+	if err := saveDaysOff(off); err != nil {
+		return err
+	}
+	fmt.Printf("Marked %s as off (%s).\n", day, reason)
+	return nil
+}
+
+// clearDayOff removes the mark of a day off
+func clearDayOff(day string) error {
+	off, err := loadDaysOff()
+	if err != nil {
+		return err
+	}
+	if _, ok := off[day]; !ok {
+		return fmt.Errorf("%s isn't marked off", day)
+	}
+	delete(off, day)
+	if err := saveDaysOff(off); err != nil {
+		return err
+	}
+	fmt.Printf("%s is a workday again.\n", day)
+	return nil
+}
+
+// listDaysOff prints the days marked off from `from` on
+func listDaysOff(from string) error {
+	off, err := loadDaysOff()
+	if err != nil {
+		return err
+	}
+	var days []string
+	for day := range off {
+		if day >= from {
+			days = append(days, day)
+		}
+	}
+	for _, h := range config.Holidays {
+		if _, ok := off[h]; !ok && h >= from {
+			days = append(days, h)
+		}
+	}
+	if len(days) == 0 {
+		fmt.Printf("No days off since %s.\n", from)
+		return nil
+	}
+	sort.Strings(days)
+	for _, day := range days {
+		date, _ := time.Parse("2006-01-02", day)
+		reason, _ := dayOffReason(date, off)
+		fmt.Printf("  %s  %s\n", date.Format("Mon 2006-01-02"), reason)
+	}
+	return nil
+}
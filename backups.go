@@ -0,0 +1,191 @@
+// backups.go - Safe writes and backups
+// Data files are replaced atomically, tasks.yaml and notes.yaml keep rotated backups, and `daily restore` rolls back to one
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// defaultBackups is how many backups of tasks.yaml and notes.yaml are kept
+// unless storage.backups says otherwise
+const defaultBackups = 5
+
+// backupCount returns how many rotated backups to keep
+func backupCount() int {
+	if config.Storage.Backups != nil {
+		return max(0, *config.Storage.Backups)
+	}
+	return defaultBackups
+}
+
+// writeFileAtomic writes content to a temporary file next to path and renames
+// it into place, so a crash leaves either the old or the new file, never half
+// of one. Data files get their checksum updated.
+func writeFileAtomic(path string, content []byte, perm os.FileMode) error {
+	checksummed := isChecksummed(path)
+	outside := ""
+	if checksummed {
+		outside = changedOutside(path)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	if checksummed {
+		// The data is saved; a checksum that can't be written only means the
+		// next check reports the file
+		writeChecksum(path, fileChecksum{SHA256: hashContent(content), Outside: outside})
+	}
+	return nil
+}
+
+// backupPath returns the path of the n-th backup of a file, 1 being the newest
+func backupPath(path string, n int) string {
+	return path + ".bak." + strconv.Itoa(n)
+}
+
+// rotateBackups shifts the backups of path by one and copies the current file
+// to backup 1, dropping the oldest beyond `keep`
+func rotateBackups(path string, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+	current, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	os.Remove(backupPath(path, keep))
+	for n := keep - 1; n >= 1; n-- {
+		if err := os.Rename(backupPath(path, n), backupPath(path, n+1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return writeFileAtomic(backupPath(path, 1), current, 0644)
+}
+
+// rewriteBackups passes each backup of a data file through fn and writes back
+// the ones it changes, keeping their order
+func rewriteBackups(name string, fn func(content []byte) ([]byte, error)) error {
+	path, err := dataFilePath(name)
+	if err != nil {
+		return err
+	}
+	for n := 1; ; n++ {
+		content, err := os.ReadFile(backupPath(path, n))
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		updated, err := fn(content)
+		if err != nil {
+			return fmt.Errorf("%s: %w", filepath.Base(backupPath(path, n)), err)
+		}
+		if string(updated) == string(content) {
+			continue
+		}
+		if err := writeFileAtomic(backupPath(path, n), updated, 0644); err != nil {
+			return err
+		}
+	}
+}
+
+// writeWithBackup backs up the current file, then replaces it atomically
+func writeWithBackup(path string, content []byte) error {
+	if err := rotateBackups(path, backupCount()); err != nil {
+		return fmt.Errorf("backing up %s: %w", filepath.Base(path), err)
+	}
+	return writeFileAtomic(path, content, 0644)
+}
+
+// listBackups prints the backups of tasks.yaml or notes.yaml
+func listBackups(name string) error {
+	path, err := dataFilePath(name)
+	if err != nil {
+		return err
+	}
+	found := false
+	for n := 1; ; n++ {
+		info, err := os.Stat(backupPath(path, n))
+		if err != nil {
+			break
+		}
+		if !found {
+			fmt.Printf("Backups of %s, newest first:\n", name)
+			found = true
+		}
+		fmt.Printf("  %d  %s  %6d bytes\n", n, info.ModTime().Format("2006-01-02 15:04:05"), info.Size())
+	}
+	if !found {
+		fmt.Printf("No backups of %s yet.\n", name)
+		return nil
+	}
+	fmt.Println("Restore one with `daily restore <number>`.")
+	return nil
+}
+
+// restoreBackup replaces tasks.yaml or notes.yaml with its n-th backup. The
+// current file becomes backup 1, so a restore can itself be rolled back.
+func restoreBackup(name string, n int) error {
+	if config.Storage.Backend == "sqlite" {
+		return fmt.Errorf("backups are kept for the YAML files only; back up daily.db with SQLite tools")
+	}
+	path, err := dataFilePath(name)
+	if err != nil {
+		return err
+	}
+	source := backupPath(path, n)
+	content, err := os.ReadFile(source)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("no backup %d of %s (run `daily restore` to list them)", n, name)
+	}
+	if err != nil {
+		return err
+	}
+	// Refuse backups that would not load
+	if name == "tasks.yaml" {
+		if _, _, err := decodeTaskData(content); err != nil {
+			return fmt.Errorf("%s is not valid: %w", filepath.Base(source), err)
+		}
+	}
+	info, err := os.Stat(source)
+	if err != nil {
+		return err
+	}
+	ok, err := confirmPrompt(fmt.Sprintf("Replace %s with the backup from %s", name, info.ModTime().Format("2006-01-02 15:04")))
+	if err != nil || !ok {
+		return err
+	}
+	if err := writeWithBackup(path, content); err != nil {
+		return err
+	}
+	fmt.Printf("Restored %s from the backup of %s. The replaced version is now backup 1.\n", name, info.ModTime().Format("2006-01-02 15:04"))
+	return nil
+}
@@ -0,0 +1,256 @@
+// board.go - Kanban board
+// `daily board` shows the day's tasks in a column per status (pending, started, done, cancelled) and moves them from one column to another with a key
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// boardColumns are the statuses shown as columns, left to right. Paused
+// tasks sit in the started column.
+var boardColumns = []string{"pending", "started", "done", "cancelled"}
+
+// boardColumn returns the column of a status
+func boardColumn(status string) int {
+	if status == "paused" {
+		return 1
+	}
+	for i, s := range boardColumns {
+		if s == status {
+			return i
+		}
+	}
+	return 0
+}
+
+type boardModel struct {
+	day     string
+	tasks   []Task
+	columns [][]int // indexes into tasks, per column
+	column  int
+	cursor  []int // selected row per column
+	width   int
+	message string
+}
+
+func newBoardModel(day string) boardModel {
+	m := boardModel{day: day, cursor: make([]int, len(boardColumns))}
+	m.reload()
+	return m
+}
+
+func (m *boardModel) reload() {
+	data, err := loadTasks()
+	if err != nil {
+		m.message = "Error: " + err.Error()
+		return
+	}
+	m.tasks = data[m.day]
+	m.columns = make([][]int, len(boardColumns))
+	for i, t := range m.tasks {
+		// Breaks and logged time are not work to move around
+		if isNonWork(t) {
+			continue
+		}
+		c := boardColumn(t.Status)
+		m.columns[c] = append(m.columns[c], i)
+	}
+	for c := range m.columns {
+		if m.cursor[c] >= len(m.columns[c]) {
+			m.cursor[c] = max(0, len(m.columns[c])-1)
+		}
+	}
+}
+
+// selected returns the index of the selected task, or -1
+func (m boardModel) selected() int {
+	if col := m.columns[m.column]; len(col) > 0 {
+		return col[m.cursor[m.column]]
+	}
+	return -1
+}
+
+// runningIndex returns the index of the started task, or -1
+func (m boardModel) runningIndex() int {
+	for i, t := range m.tasks {
+		if t.Status == "started" {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m boardModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m boardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+	case tea.KeyMsg:
+		return m.updateKeys(msg)
+	}
+	return m, nil
+}
+
+func (m boardModel) updateKeys(k tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.message = ""
+	switch {
+	case keys.IsQuit(k) || k.Type == tea.KeyEsc:
+		return m, tea.Quit
+	case k.Type == tea.KeyShiftLeft || k.String() == "<":
+		m.move(m.column - 1)
+	case k.Type == tea.KeyShiftRight || k.String() == ">":
+		m.move(m.column + 1)
+	case key.Matches(k, keys.Left) || k.Type == tea.KeyLeft:
+		if m.column > 0 {
+			m.column--
+		}
+	case key.Matches(k, keys.Right) || k.Type == tea.KeyRight:
+		if m.column < len(boardColumns)-1 {
+			m.column++
+		}
+	case key.Matches(k, keys.Up) || k.Type == tea.KeyUp:
+		if m.cursor[m.column] > 0 {
+			m.cursor[m.column]--
+		}
+	case key.Matches(k, keys.Down) || k.Type == tea.KeyDown:
+		if m.cursor[m.column] < len(m.columns[m.column])-1 {
+			m.cursor[m.column]++
+		}
+	case key.Matches(k, keys.Start):
+		m.move(1)
+	case key.Matches(k, keys.Stop):
+		m.move(0)
+	case key.Matches(k, keys.Finish):
+		m.move(2)
+	case k.String() == "c":
+		m.move(3)
+	}
+	return m, nil
+}
+
+// move puts the selected task in another column, and follows it there
+func (m *boardModel) move(column int) {
+	index := m.selected()
+	if index < 0 || column < 0 || column >= len(boardColumns) {
+		return
+	}
+	t := m.tasks[index]
+	status := boardColumns[column]
+	if t.Status == status {
+		return
+	}
+	if status == "started" {
+		if m.day != todayKey() {
+			m.message = "Only tasks of today can be started."
+			return
+		}
+		if running := m.runningIndex(); running >= 0 {
+			m.message = fmt.Sprintf("'%s' is already running; stop it first.", m.tasks[running].Title)
+			return
+		}
+	}
+	if err := setDayTaskStatus(m.day, index, status); err != nil {
+		m.message = "Error: " + err.Error()
+		return
+	}
+	m.message = fmt.Sprintf("'%s' is now %s.", t.Title, status)
+	m.reload()
+	m.column = column
+	for row, i := range m.columns[column] {
+		if i == index {
+			m.cursor[column] = row
+		}
+	}
+}
+
+// setDayTaskStatus changes the status of a task of a day, by index
+func setDayTaskStatus(day string, index int, status string) error {
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	tasks := data[day]
+	if index < 0 || index >= len(tasks) {
+		return fmt.Errorf("invalid task index")
+	}
+	tasks[index].setStatus(status, time.Now())
+	data[day] = tasks
+	return saveTasks(data)
+}
+
+func (m boardModel) View() string {
+	width := m.width
+	if width == 0 {
+		width = 100
+	}
+	// The width of a pane counts its padding, not its border
+	columnWidth := max(16, width/len(boardColumns)-2)
+	now := time.Now().Unix()
+
+	var panes []string
+	for c, status := range boardColumns {
+		var b strings.Builder
+		minutes := 0
+		for _, i := range m.columns[c] {
+			minutes += m.tasks[i].Estimated
+		}
+		b.WriteString(titleStyle.Render(fmt.Sprintf("%s %d", strings.ToUpper(status[:1])+status[1:], len(m.columns[c]))))
+		b.WriteString(dimStyle.Render("  "+fmtMinutes(minutes)) + "\n\n")
+		if len(m.columns[c]) == 0 {
+			b.WriteString(dimStyle.Render("-"))
+		}
+		for row, i := range m.columns[c] {
+			t := m.tasks[i]
+			pointer := "  "
+			if c == m.column && row == m.cursor[c] {
+				pointer = "→ "
+			}
+			title := t.Title
+			if t.Status == "paused" {
+				title = "⏸ " + title
+			}
+			line := fmt.Sprintf("%s%s\n  %s", pointer, shorten(title, columnWidth-4), dimStyle.Render(fmtRatio(t.trackedMinutes(now), t.Estimated)))
+			if c == m.column && row == m.cursor[c] {
+				line = titleStyle.Render(line)
+			}
+			b.WriteString(line + "\n")
+		}
+		style := paneStyle.Width(columnWidth)
+		if c == m.column {
+			style = style.BorderForeground(lipgloss.Color(activeTheme().Accent))
+		}
+		panes = append(panes, style.Render(strings.TrimRight(b.String(), "\n")))
+	}
+
+	date, _ := time.Parse("2006-01-02", m.day)
+	header := titleStyle.Render("Board " + date.Format("Monday Jan 2"))
+	footer := m.message
+	if footer == "" {
+		footer = dimStyle.Render(fmt.Sprintf("%s/%s column • %s/%s task • shift+←/→ or </> move • %s start • %s stop • %s done • c cancel • %s quit",
+			keys.Left.Help().Key, keys.Right.Help().Key, keys.Up.Help().Key, keys.Down.Help().Key,
+			keys.Start.Help().Key, keys.Stop.Help().Key, keys.Finish.Help().Key, keys.Quit.Help().Key))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, header, lipgloss.JoinHorizontal(lipgloss.Top, panes...), footer)
+}
+
+// runBoard opens the board of a day
+func runBoard(day string) error {
+	if _, err := time.Parse("2006-01-02", day); err != nil {
+		return fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", day)
+	}
+	if !isTerminal() {
+		return fmt.Errorf("board needs a terminal; use `daily ls` to list the tasks")
+	}
+	_, err := tea.NewProgram(newBoardModel(day), tea.WithAltScreen()).Run()
+	return err
+}
@@ -0,0 +1,106 @@
+// breaks.go - Breaks and interruptions
+// `daily break` and `daily interrupt` pause the running task and time the break or interruption as its own entry
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// interruptionCategory marks entries logged with `daily interrupt`. Unlike
+// breaks they count as work, but not against the plan.
+const interruptionCategory = "interruption"
+
+// isAside reports whether a task is a break or interruption entry rather than
+// planned work
+func isAside(t Task) bool {
+	return strings.EqualFold(t.Category, "break") || strings.EqualFold(t.Category, interruptionCategory)
+}
+
+// startAside pauses the running task and starts timing a break or an
+// interruption in its place. A break or interruption already running ends
+// first; the task paused before it stays paused.
+func startAside(category, title string) error {
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	today := todayKey()
+	tasks := data[today]
+	now := time.Now().Unix()
+	for i := range tasks {
+		t := &tasks[i]
+		if t.Status != "started" {
+			continue
+		}
+		t.endSession(now)
+		t.FinishBy = 0
+		if isAside(*t) {
+			t.logChange("status", t.Status, "done", time.Unix(now, 0))
+			t.Status = "done"
+			fmt.Printf("Ended %s '%s' after %s.\n", t.Category, t.Title, fmtMinutes(t.Actual))
+			break
+		}
+		t.logChange("status", t.Status, "paused", time.Unix(now, 0))
+		t.Status = "paused"
+		if category == interruptionCategory {
+			t.Interruptions++
+		}
+		fmt.Printf("Paused '%s' (%s so far).\n", t.Title, fmtMinutes(t.Actual))
+	}
+	entry := Task{Title: title, Status: "started", Category: category}
+	entry.startSession(now)
+	data[today] = append(tasks, entry)
+	if err := saveTasks(data); err != nil {
+		return err
+	}
+	fmt.Printf("%s started: %s. Run `daily resume` when you're back.\n", strings.ToUpper(category[:1])+category[1:], title)
+	return nil
+}
+
+// startBreak pauses the running task for a break
+func startBreak(reason string) error {
+	if reason = strings.TrimSpace(reason); reason == "" {
+		reason = "Break"
+	}
+	return startAside("break", reason)
+}
+
+// startInterruption pauses the running task, counts an interruption on it,
+// and times what interrupted it
+func startInterruption(description string) error {
+	description = strings.TrimSpace(description)
+	if description == "" {
+		return fmt.Errorf("describe the interruption, e.g. `daily interrupt Question from Sam`")
+	}
+	return startAside(interruptionCategory, description)
+}
+
+// endAside finishes a running break or interruption, reporting whether there was one
+func endAside(data TaskData, day string) bool {
+	for i := range data[day] {
+		t := &data[day][i]
+		if t.Status == "started" && isAside(*t) {
+			t.endSession(time.Now().Unix())
+			t.logChange("status", t.Status, "done", time.Now())
+			t.Status = "done"
+			fmt.Printf("Ended %s '%s' after %s.\n", t.Category, t.Title, fmtMinutes(t.Actual))
+			return true
+		}
+	}
+	return false
+}
+
+// interruptionTotals counts the interruptions of a day's tasks and the time
+// logged on interruption entries
+func interruptionTotals(tasks []Task) (count, minutes int) {
+	for _, t := range tasks {
+		count += t.Interruptions
+		if strings.EqualFold(t.Category, interruptionCategory) {
+			minutes += t.Actual
+		}
+	}
+	return count, minutes
+}
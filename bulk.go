@@ -0,0 +1,149 @@
+// bulk.go - Bulk task changes
+// `daily done`, `daily cancel` and `daily delete` take several tasks at once, by ID, number or title, or every pending task; `status` and `delete` without arguments pick the tasks from a list where space toggles them
+
+package main
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// taskLabels labels the tasks of a day for a multi-select list
+func taskLabels(tasks []Task) []string {
+	labels := make([]string, len(tasks))
+	for i, t := range tasks {
+		labels[i] = fmt.Sprintf("%s %s (%s)", t.ID, t.Title, t.Status)
+	}
+	return labels
+}
+
+// resolveDayTasks finds tasks of a day by ID, number or title, plus every
+// pending task with allPending. Indexes are in day order, without repeats.
+func resolveDayTasks(tasks []Task, queries []string, allPending bool) ([]int, error) {
+	var indexes []int
+	for _, q := range queries {
+		i, err := findDayTask(tasks, q)
+		if err != nil {
+			return nil, err
+		}
+		indexes = append(indexes, i)
+	}
+	if allPending {
+		for i, t := range tasks {
+			if t.Status == "pending" && !isNonWork(t) {
+				indexes = append(indexes, i)
+			}
+		}
+	}
+	slices.Sort(indexes)
+	return slices.Compact(indexes), nil
+}
+
+// setTaskStatuses moves tasks of a day, by index, to a status and saves
+func setTaskStatuses(data TaskData, day string, indexes []int, status string) error {
+	tasks := data[day]
+	if status == "started" {
+		if len(indexes) > 1 {
+			return fmt.Errorf("only one task can be started at a time")
+		}
+		if day != todayKey() {
+			return fmt.Errorf("only tasks of today can be started")
+		}
+	}
+	now := time.Now()
+	var closed []string
+	for _, i := range indexes {
+		t := &tasks[i]
+		if t.Status == status {
+			fmt.Printf("'%s' is already %s.\n", t.Title, status)
+			continue
+		}
+		if isOpenStatus(t.Status) && !isOpenStatus(status) {
+			closed = append(closed, t.ID)
+		}
+		t.setStatus(status, now)
+		fmt.Printf("'%s' is now %s.\n", t.Title, status)
+	}
+	data[day] = tasks
+	if err := saveTasks(data); err != nil {
+		return err
+	}
+	seen := map[string]bool{}
+	for _, id := range closed {
+		for _, t := range unblockedBy(tasks, id) {
+			if !seen[t.ID] {
+				seen[t.ID] = true
+				fmt.Printf("Unblocked: %s %s\n", t.ID, describeTask(t))
+			}
+		}
+	}
+	return nil
+}
+
+// runBulkStatus is `daily done` and `daily cancel`: it moves the given tasks
+// of a day to a status
+func runBulkStatus(day string, queries []string, allPending bool, status string) error {
+	if len(queries) == 0 && !allPending {
+		return fmt.Errorf("give the tasks by ID, number or title, or use --all-pending")
+	}
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	indexes, err := resolveDayTasks(data[day], queries, allPending)
+	if err != nil {
+		return err
+	}
+	if len(indexes) == 0 {
+		fmt.Println("No pending tasks.")
+		return nil
+	}
+	return setTaskStatuses(data, day, indexes, status)
+}
+
+// deleteTasks removes tasks of a day, by index, and saves
+func deleteTasks(data TaskData, day string, indexes []int) error {
+	var kept []Task
+	for i, t := range data[day] {
+		if slices.Contains(indexes, i) {
+			fmt.Printf("Deleted '%s'.\n", t.Title)
+			continue
+		}
+		kept = append(kept, t)
+	}
+	data[day] = kept
+	return saveTasks(data)
+}
+
+// runDelete deletes the given tasks of a day, or the ones picked from a list
+func runDelete(day string, queries []string, allPending bool) error {
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	tasks := data[day]
+	if len(tasks) == 0 {
+		fmt.Println("No tasks to delete.")
+		return nil
+	}
+	var indexes []int
+	if len(queries) > 0 || allPending {
+		if indexes, err = resolveDayTasks(tasks, queries, allPending); err != nil {
+			return err
+		}
+	} else {
+		indexes, err = multiSelect("Select the tasks to delete", taskLabels(tasks), nil)
+		if err != nil {
+			if err.Error() == "interrupt" {
+				return nil
+			}
+			return err
+		}
+	}
+	if len(indexes) == 0 {
+		fmt.Println("Nothing deleted.")
+		return nil
+	}
+	return deleteTasks(data, day, indexes)
+}
@@ -0,0 +1,361 @@
+// calendar.go - Calendar import
+// Pulls the day's meetings from a calendar backend into the task list as #meeting tasks
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// CalendarConfig is the `calendar` section of config.yaml
+type CalendarConfig struct {
+	Source string      `yaml:"source"`
+	Graph  GraphConfig `yaml:"graph"`
+}
+
+// GraphConfig configures the Microsoft Graph (Outlook) backend
+type GraphConfig struct {
+	ClientID string `yaml:"client_id"`
+	Tenant   string `yaml:"tenant"`
+}
+
+// calendarEvent is a meeting as reported by a calendar backend
+type calendarEvent struct {
+	ID      string
+	Subject string
+	Start   time.Time
+	End     time.Time
+}
+
+// calendarBackend fetches the events of one day
+type calendarBackend interface {
+	Events(day time.Time) ([]calendarEvent, error)
+}
+
+// newCalendarBackend picks the backend for a source name
+func newCalendarBackend(source string) (calendarBackend, error) {
+	if err := requireOnline("Calendar import"); err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(source) {
+	case "outlook", "graph":
+		if config.Calendar.Graph.ClientID == "" {
+			return nil, fmt.Errorf("calendar.graph.client_id is not configured")
+		}
+		return &graphBackend{cfg: config.Calendar.Graph}, nil
+	case "":
+		return nil, fmt.Errorf("no calendar source: pass --source or set calendar.source")
+	}
+	return nil, fmt.Errorf("unknown calendar source %q", source)
+}
+
+// importCalendar adds the day's meetings as tasks, skipping ones already imported
+func importCalendar(day string, source string) error {
+	date, err := time.ParseInLocation("2006-01-02", day, time.Local)
+	if err != nil {
+		return fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", day)
+	}
+	if source == "" {
+		source = config.Calendar.Source
+	}
+	backend, err := newCalendarBackend(source)
+	if err != nil {
+		return err
+	}
+	events, err := backend.Events(date)
+	if err != nil {
+		return err
+	}
+
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	known := map[string]bool{}
+	for _, t := range data[day] {
+		if t.ExternalID != "" {
+			known[t.ExternalID] = true
+		}
+	}
+	added := 0
+	for _, ev := range events {
+		if known[ev.ID] {
+			continue
+		}
+		minutes := int(ev.End.Sub(ev.Start).Minutes())
+		if minutes <= 0 {
+			continue
+		}
+		task := Task{
+			Title:      ev.Subject,
+			Estimated:  minutes,
+			Status:     "pending",
+			Tags:       []string{"meeting"},
+			ExternalID: ev.ID,
+			PlannedAt:  ev.Start.Local().Format("15:04"),
+		}
+		applyTagRules(&task)
+		data[day] = append(data[day], task)
+		fmt.Printf("  %s-%s  %s\n", ev.Start.Local().Format("15:04"), ev.End.Local().Format("15:04"), ev.Subject)
+		added++
+	}
+	if added == 0 {
+		fmt.Printf("No new meetings for %s.\n", day)
+		return nil
+	}
+	if _, err := offerDuplicateMerge(data, day); err != nil && err.Error() != "interrupt" {
+		return err
+	}
+	if err := saveTasks(data); err != nil {
+		return err
+	}
+	fmt.Printf("Imported %d meetings for %s.\n", added, day)
+	warnIfMeetingsCrowdOut(day, data[day])
+	return nil
+}
+
+// --- Microsoft Graph backend ---
+
+const graphScopes = "Calendars.Read offline_access"
+
+// graphToken is the cached OAuth token for Microsoft Graph
+type graphToken struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresAt    int64  `json:"expires_at"`
+}
+
+type graphBackend struct {
+	cfg GraphConfig
+}
+
+func (g *graphBackend) tenant() string {
+	if g.cfg.Tenant == "" {
+		return "common"
+	}
+	return g.cfg.Tenant
+}
+
+func (g *graphBackend) tokenURL() string {
+	return "https://login.microsoftonline.com/" + g.tenant() + "/oauth2/v2.0/token"
+}
+
+func getGraphTokenFilePath() (string, error) {
+	return dataFilePath("graph_token.json")
+}
+
+func loadGraphToken() (graphToken, error) {
+	var tok graphToken
+	filePath, err := getGraphTokenFilePath()
+	if err != nil {
+		return tok, err
+	}
+	file, err := os.ReadFile(filePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return tok, nil
+		}
+		return tok, err
+	}
+	err = json.Unmarshal(file, &tok)
+	return tok, err
+}
+
+func saveGraphToken(tok graphToken) error {
+	filePath, err := getGraphTokenFilePath()
+	if err != nil {
+		return err
+	}
+	file, err := json.Marshal(tok)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filePath, file, 0600)
+}
+
+// tokenResponse is the OAuth token endpoint reply
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+	Error        string `json:"error"`
+	Description  string `json:"error_description"`
+}
+
+func postForm(endpoint string, form url.Values, out interface{}) error {
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.PostForm(endpoint, form)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// accessToken returns a valid token, refreshing or signing in as needed
+func (g *graphBackend) accessToken() (string, error) {
+	tok, err := loadGraphToken()
+	if err != nil {
+		return "", err
+	}
+	if tok.AccessToken != "" && time.Now().Unix() < tok.ExpiresAt-60 {
+		return tok.AccessToken, nil
+	}
+	if tok.RefreshToken != "" {
+		var resp tokenResponse
+		err := postForm(g.tokenURL(), url.Values{
+			"client_id":     {g.cfg.ClientID},
+			"grant_type":    {"refresh_token"},
+			"refresh_token": {tok.RefreshToken},
+			"scope":         {graphScopes},
+		}, &resp)
+		if err == nil && resp.Error == "" {
+			return g.store(resp)
+		}
+	}
+	return g.deviceCodeLogin()
+}
+
+func (g *graphBackend) store(resp tokenResponse) (string, error) {
+	tok := graphToken{
+		AccessToken:  resp.AccessToken,
+		RefreshToken: resp.RefreshToken,
+		ExpiresAt:    time.Now().Unix() + resp.ExpiresIn,
+	}
+	if err := saveGraphToken(tok); err != nil {
+		return "", err
+	}
+	return tok.AccessToken, nil
+}
+
+// deviceCodeLogin runs the OAuth device code flow: the user signs in from a
+// browser while we poll for the token
+func (g *graphBackend) deviceCodeLogin() (string, error) {
+	var code struct {
+		DeviceCode string `json:"device_code"`
+		Message    string `json:"message"`
+		Interval   int    `json:"interval"`
+		ExpiresIn  int    `json:"expires_in"`
+		Error      string `json:"error"`
+	}
+	err := postForm("https://login.microsoftonline.com/"+g.tenant()+"/oauth2/v2.0/devicecode", url.Values{
+		"client_id": {g.cfg.ClientID},
+		"scope":     {graphScopes},
+	}, &code)
+	if err != nil {
+		return "", err
+	}
+	if code.Error != "" {
+		return "", fmt.Errorf("device code request failed: %s", code.Error)
+	}
+	fmt.Println(code.Message)
+
+	interval := time.Duration(code.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	deadline := time.Now().Add(time.Duration(code.ExpiresIn) * time.Second)
+	for time.Now().Before(deadline) {
+		time.Sleep(interval)
+		var resp tokenResponse
+		err := postForm(g.tokenURL(), url.Values{
+			"client_id":   {g.cfg.ClientID},
+			"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+			"device_code": {code.DeviceCode},
+		}, &resp)
+		if err != nil {
+			return "", err
+		}
+		switch resp.Error {
+		case "":
+			return g.store(resp)
+		case "authorization_pending":
+			continue
+		case "slow_down":
+			interval += 5 * time.Second
+		default:
+			return "", fmt.Errorf("sign-in failed: %s", resp.Description)
+		}
+	}
+	return "", fmt.Errorf("sign-in timed out")
+}
+
+// Events lists the day's busy, non-cancelled, timed events from the user's calendar
+func (g *graphBackend) Events(day time.Time) ([]calendarEvent, error) {
+	token, err := g.accessToken()
+	if err != nil {
+		return nil, err
+	}
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	end := start.AddDate(0, 0, 1)
+	query := url.Values{
+		"startDateTime": {start.UTC().Format(time.RFC3339)},
+		"endDateTime":   {end.UTC().Format(time.RFC3339)},
+		"$select":       {"id,subject,start,end,isAllDay,isCancelled,showAs"},
+		"$orderby":      {"start/dateTime"},
+		"$top":          {"100"},
+	}
+	req, err := http.NewRequest(http.MethodGet, "https://graph.microsoft.com/v1.0/me/calendarView?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Prefer", `outlook.timezone="UTC"`)
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Microsoft Graph returned %s", resp.Status)
+	}
+
+	var result struct {
+		Value []struct {
+			ID          string `json:"id"`
+			Subject     string `json:"subject"`
+			IsAllDay    bool   `json:"isAllDay"`
+			IsCancelled bool   `json:"isCancelled"`
+			ShowAs      string `json:"showAs"`
+			Start       struct {
+				DateTime string `json:"dateTime"`
+			} `json:"start"`
+			End struct {
+				DateTime string `json:"dateTime"`
+			} `json:"end"`
+		} `json:"value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	var events []calendarEvent
+	for _, v := range result.Value {
+		if v.IsAllDay || v.IsCancelled || v.ShowAs == "free" {
+			continue
+		}
+		s, err1 := parseGraphTime(v.Start.DateTime)
+		e, err2 := parseGraphTime(v.End.DateTime)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		events = append(events, calendarEvent{ID: v.ID, Subject: v.Subject, Start: s, End: e})
+	}
+	return events, nil
+}
+
+// parseGraphTime parses Graph's UTC timestamps, which have no offset and up
+// to 7 fractional digits
+func parseGraphTime(s string) (time.Time, error) {
+	if len(s) > 19 {
+		s = s[:19]
+	}
+	return time.Parse("2006-01-02T15:04:05", s)
+}
@@ -0,0 +1,219 @@
+// calibrate.go - Estimate calibration
+// `daily calibrate` asks for fresh estimates of tasks you've done before, then reveals what they really took
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+)
+
+// similarityThreshold is the share of title words two tasks must have in
+// common to count as similar
+const similarityThreshold = 0.5
+
+// titleWords returns the lower-cased words of a title, without punctuation
+// and single characters
+func titleWords(title string) map[string]bool {
+	words := map[string]bool{}
+	for _, w := range strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r > 127)
+	}) {
+		if len(w) > 1 {
+			words[w] = true
+		}
+	}
+	return words
+}
+
+// titleSimilarity is the Jaccard index of the titles' words
+func titleSimilarity(a, b string) float64 {
+	wa, wb := titleWords(a), titleWords(b)
+	if len(wa) == 0 || len(wb) == 0 {
+		return 0
+	}
+	common := 0
+	for w := range wa {
+		if wb[w] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(wa)+len(wb)-common)
+}
+
+// pastTask is a finished task with the day it was done
+type pastTask struct {
+	day  string
+	task Task
+}
+
+// similarDone returns finished tasks with tracked time whose titles resemble
+// title, newest first, leaving out the task at skipDay/skipIndex
+func similarDone(data TaskData, title, skipDay string, skipIndex int) []pastTask {
+	var found []pastTask
+	for day, tasks := range data {
+		for i, t := range tasks {
+			if (day == skipDay && i == skipIndex) || t.Status != "done" || t.Actual <= 0 || isNonWork(t) {
+				continue
+			}
+			if titleSimilarity(title, t.Title) >= similarityThreshold {
+				found = append(found, pastTask{day, t})
+			}
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].day > found[j].day })
+	return found
+}
+
+func medianOf(values []int) int {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]int(nil), values...)
+	sort.Ints(sorted)
+	return sorted[len(sorted)/2]
+}
+
+// calibrationRound is one task to estimate blind
+type calibrationRound struct {
+	day     string
+	index   int
+	task    Task
+	similar []pastTask
+}
+
+// calibrationRounds picks the open tasks of today and tomorrow that have
+// similar history, topped up with random past tasks
+func calibrationRounds(data TaskData, rounds int) []calibrationRound {
+	var picked []calibrationRound
+	seen := map[string]bool{}
+	for _, day := range []string{todayKey(), dayOf(time.Now()).AddDate(0, 0, 1).Format("2006-01-02")} {
+		for i, t := range data[day] {
+			if !isOpenStatus(t.Status) || isNonWork(t) || seen[strings.ToLower(t.Title)] {
+				continue
+			}
+			if similar := similarDone(data, t.Title, day, i); len(similar) > 0 {
+				seen[strings.ToLower(t.Title)] = true
+				picked = append(picked, calibrationRound{day, i, t, similar})
+			}
+		}
+	}
+	var past []calibrationRound
+	for day, tasks := range data {
+		for i, t := range tasks {
+			if t.Status != "done" || t.Actual <= 0 || isNonWork(t) || seen[strings.ToLower(t.Title)] {
+				continue
+			}
+			seen[strings.ToLower(t.Title)] = true
+			past = append(past, calibrationRound{day, i, t, similarDone(data, t.Title, day, i)})
+		}
+	}
+	rand.Shuffle(len(past), func(i, j int) { past[i], past[j] = past[j], past[i] })
+	picked = append(picked, past...)
+	if len(picked) > rounds {
+		picked = picked[:rounds]
+	}
+	return picked
+}
+
+// runCalibration plays the rounds: a blind estimate first, then the history
+func runCalibration(rounds int) error {
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	plan := calibrationRounds(data, rounds)
+	if len(plan) == 0 {
+		fmt.Println("No finished tasks with tracked time yet; come back after a few days of tracking.")
+		return nil
+	}
+	fmt.Println("Estimate each task without looking at the past, then compare with what similar tasks really took.")
+
+	var freshErr, oldErr []float64
+	// New estimates by day and position
+	type taskRef struct {
+		day   string
+		index int
+	}
+	updates := map[taskRef]int{}
+	for n, r := range plan {
+		fmt.Printf("\n%d/%d  %s\n", n+1, len(plan), r.task.Title)
+		input, err := validatedPrompt("Your estimate (min, or 1.5h)", "", func(input string) error {
+			minutes, err := parseEstimate(input)
+			if err == nil && minutes <= 0 {
+				err = fmt.Errorf("enter a positive estimate")
+			}
+			return err
+		})
+		if err != nil {
+			if err.Error() == "interrupt" || err.Error() == "^C" {
+				break
+			}
+			return err
+		}
+		fresh, _ := parseEstimate(input)
+
+		var estimates, actuals []int
+		for _, p := range r.similar {
+			estimates = append(estimates, p.task.Estimated)
+			actuals = append(actuals, p.task.Actual)
+		}
+		if r.task.Status == "done" && r.task.Actual > 0 {
+			// A past task: its own outcome is the answer
+			estimates = append([]int{r.task.Estimated}, estimates...)
+			actuals = append([]int{r.task.Actual}, actuals...)
+		}
+		actual := medianOf(actuals)
+		fmt.Printf("  Similar tasks: %d, estimated %d min, took %d min (medians)\n", len(actuals), medianOf(estimates), actual)
+		for _, p := range r.similar[:min(3, len(r.similar))] {
+			fmt.Printf("    %s  %s: %d → %d min\n", p.day, p.task.Title, p.task.Estimated, p.task.Actual)
+		}
+		freshErr = append(freshErr, math.Abs(float64(fresh-actual))/float64(actual))
+		oldErr = append(oldErr, math.Abs(float64(medianOf(estimates)-actual))/float64(actual))
+		fmt.Printf("  Your guess was off by %.0f%%, your earlier estimates by %.0f%%.\n", freshErr[len(freshErr)-1]*100, oldErr[len(oldErr)-1]*100)
+
+		if isOpenStatus(r.task.Status) && fresh != r.task.Estimated {
+			use, err := confirmPrompt(fmt.Sprintf("Use %d min for this task (now %d)", fresh, r.task.Estimated))
+			if err != nil {
+				return err
+			}
+			if use {
+				updates[taskRef{r.day, r.index}] = fresh
+			}
+		}
+	}
+
+	if len(freshErr) > 0 {
+		mean := func(values []float64) float64 {
+			sum := 0.0
+			for _, v := range values {
+				sum += v
+			}
+			return sum / float64(len(values))
+		}
+		fmt.Printf("\nAverage error: fresh guesses %.0f%%, earlier estimates %.0f%% over %d tasks.\n", mean(freshErr)*100, mean(oldErr)*100, len(freshErr))
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	// Reload so that time tracked during the session is kept
+	data, err = loadTasks()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for r, minutes := range updates {
+		if r.index < len(data[r.day]) {
+			data[r.day][r.index].setEstimate(minutes, now)
+		}
+	}
+	if err := saveTasks(data); err != nil {
+		return err
+	}
+	fmt.Printf("Updated %d estimate(s).\n", len(updates))
+	return nil
+}
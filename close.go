@@ -0,0 +1,191 @@
+// close.go - Day close and per-day metrics
+// Closing a day carries open tasks over and records a compact metrics record
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DayMetrics is the summary recorded when a day is closed
+type DayMetrics struct {
+	Planned        int      `yaml:"planned"`
+	Worked         int      `yaml:"worked"`
+	Achieved       int      `yaml:"achieved"`
+	Tasks          int      `yaml:"tasks"`
+	Done           int      `yaml:"done"`
+	CarriedOver    int      `yaml:"carried_over"`
+	CarriedMinutes int      `yaml:"carried_minutes"`
+	Meetings       int      `yaml:"meetings"`
+	NonWork        int      `yaml:"non_work"`
+	Interruptions  int      `yaml:"interruptions"`
+	Interrupted    int      `yaml:"interrupted"` // minutes logged with `daily interrupt`
+	Focus          DayFocus `yaml:"focus"`
+	ClosedAt       int64    `yaml:"closed_at"`
+}
+
+// MetricsData stores closed-day metrics per day
+type MetricsData map[string]DayMetrics
+
+func getMetricsFilePath() (string, error) {
+	return dataFilePath("metrics.yaml")
+}
+
+func loadMetrics() (MetricsData, error) {
+	filePath, err := getMetricsFilePath()
+	if err != nil {
+		return nil, err
+	}
+	data := MetricsData{}
+	file, err := os.ReadFile(filePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return MetricsData{}, nil
+		}
+		return nil, err
+	}
+	err = yaml.Unmarshal(file, &data)
+	return data, err
+}
+
+func saveMetrics(data MetricsData) error {
+	filePath, err := getMetricsFilePath()
+	if err != nil {
+		return err
+	}
+	file, err := yaml.Marshal(&data)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filePath, file, 0644)
+}
+
+// computeDayMetrics summarizes a day's tasks; carry-over fields are filled by closeDay
+func computeDayMetrics(tasks []Task) DayMetrics {
+	var m DayMetrics
+	for _, t := range tasks {
+		if isNonWork(t) {
+			m.NonWork += t.Actual
+			continue
+		}
+		if hasTag(t, "meeting") {
+			m.Meetings += t.Actual
+			continue
+		}
+		if isAside(t) {
+			m.Worked += t.Actual
+			m.Interrupted += t.Actual
+			continue
+		}
+		m.Tasks++
+		m.Planned += t.Estimated
+		m.Worked += t.Actual
+		m.Interruptions += t.Interruptions
+		if t.Status == "done" {
+			m.Done++
+			m.Achieved += t.Estimated
+		}
+	}
+	return m
+}
+
+// isOpenStatus reports whether a task still needs work
+func isOpenStatus(status string) bool {
+	return status != "done" && status != "cancelled"
+}
+
+// closeDay stops any running task, carries open tasks to the next workday,
+// and records the day's metrics. Closing an already closed day only refreshes
+// its metrics.
+func closeDay(day string) error {
+	if _, err := time.Parse("2006-01-02", day); err != nil {
+		return fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", day)
+	}
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	metrics, err := loadMetrics()
+	if err != nil {
+		return err
+	}
+	previous, alreadyClosed := metrics[day]
+
+	tasks := data[day]
+	now := time.Now().Unix()
+	for i := range tasks {
+		t := &tasks[i]
+		if t.Status == "started" || t.Status == "paused" {
+			t.endSession(now)
+			t.FinishBy = 0
+			t.logChange("status", t.Status, "pending", time.Unix(now, 0))
+			t.Status = "pending"
+		}
+	}
+
+	m := computeDayMetrics(tasks)
+	m.Focus = computeFocus(tasks)
+	if alreadyClosed {
+		m.CarriedOver = previous.CarriedOver
+		m.CarriedMinutes = previous.CarriedMinutes
+	} else {
+		// Friday's leftovers go to Monday, past any holiday or day off
+		next := nextWorkday(day)
+		// Tasks a running view already carried over at midnight stay single
+		present := map[string]bool{}
+		for _, t := range data[next] {
+			if isOpenStatus(t.Status) {
+				present[t.Title] = true
+			}
+		}
+		for _, t := range tasks {
+			if !isOpenStatus(t.Status) || isNonWork(t) || present[t.Title] {
+				continue
+			}
+			carried := carryOver(t)
+			data[next] = append(data[next], carried)
+			m.CarriedOver++
+			m.CarriedMinutes += carried.Estimated
+		}
+	}
+	m.ClosedAt = now
+	data[day] = tasks
+	if err := saveTasks(data); err != nil {
+		return err
+	}
+	metrics[day] = m
+	if err := saveMetrics(metrics); err != nil {
+		return err
+	}
+
+	fmt.Printf("Day %s closed.\n\n", day)
+	printDayMetrics(m)
+	return maybeArchiveWeek(day)
+}
+
+// carryOver returns the rest of an open task, to continue on another day:
+// what is left of the estimate, with no time on it yet
+func carryOver(t Task) Task {
+	t.Estimated = max(0, t.Estimated-t.Actual)
+	t.Actual = 0
+	t.Interruptions = 0
+	t.Sessions = nil
+	return t
+}
+
+// printDayMetrics prints a closed day's metrics record
+func printDayMetrics(m DayMetrics) {
+	fmt.Printf("Planned:       %s\n", fmtMinutes(m.Planned))
+	fmt.Printf("Worked:        %s\n", fmtMinutes(m.Worked))
+	fmt.Printf("Achieved:      %s (%d/%d tasks done)\n", fmtMinutes(m.Achieved), m.Done, m.Tasks)
+	fmt.Printf("Carried over:  %d tasks (%s)\n", m.CarriedOver, fmtMinutes(m.CarriedMinutes))
+	fmt.Printf("Meetings:      %s\n", fmtMinutes(m.Meetings))
+	fmt.Printf("Non-work:      %s\n", fmtMinutes(m.NonWork))
+	fmt.Printf("Interruptions: %d (%s)\n", m.Interruptions, fmtMinutes(m.Interrupted))
+	printFocus(m.Focus)
+}
@@ -0,0 +1,274 @@
+// config.go - User configuration
+// Loads ~/.config/daily/config.yaml at startup; missing files fall back to defaults
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/yourname/daily-cli/internal/keymap"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the content of config.yaml
+type Config struct {
+	Team          string              `yaml:"team"`     // shared team config layered underneath: a path or URL
+	Projects      []string            `yaml:"projects"` // known projects; others get a warning
+	Tags          []string            `yaml:"tags"`     // known tags; others get a warning
+	DataDir       string              `yaml:"data_dir"`
+	Editor        string              `yaml:"editor"`
+	Theme         string              `yaml:"theme"`
+	Background    string              `yaml:"background"` // auto (default), dark, or light
+	Locale        string              `yaml:"locale"`
+	WorkHours     WorkHours           `yaml:"work_hours"`
+	Keymap        keymap.Config       `yaml:"keymap"`
+	Publish       PublishConfig       `yaml:"publish"`
+	Calendar      CalendarConfig      `yaml:"calendar"`
+	Jira          JiraConfig          `yaml:"jira"`
+	Serve         ServeConfig         `yaml:"serve"`
+	Inbox         InboxConfig         `yaml:"inbox"`
+	Transcribe    TranscribeConfig    `yaml:"transcribe"`
+	TagRules      []TagRule           `yaml:"tag_rules"`
+	Journal       JournalConfig       `yaml:"journal"`
+	Storage       StorageConfig       `yaml:"storage"`
+	Export        ExportConfig        `yaml:"export"`
+	Watch         WatchConfig         `yaml:"watch"`
+	Display       DisplayConfig       `yaml:"display"`
+	Notifications NotificationsConfig `yaml:"notifications"`
+	Rollover      RolloverConfig      `yaml:"rollover"`
+	Sync          SyncConfig          `yaml:"sync"`
+	Heartbeat     HeartbeatConfig     `yaml:"heartbeat"`
+	Idle          IdleConfig          `yaml:"idle"`
+	Reservations  []Reservation       `yaml:"reservations"`
+	Standup       StandupConfig       `yaml:"standup"`
+	People        []Person            `yaml:"people"`
+	Holidays      []string            `yaml:"holidays"` // public holidays, YYYY-MM-DD
+	Push          PushConfig          `yaml:"push"`
+	GitHub        GitHubConfig        `yaml:"github"`
+	Recurring     []RecurringTask     `yaml:"recurring"`
+}
+
+// WorkHours describes the working day used for capacity and progress bars.
+// Times are HH:MM in local time; an empty lunch_start means no lunch break,
+// whatever lunch_end says.
+type WorkHours struct {
+	Start           string `yaml:"start"`
+	End             string `yaml:"end"`
+	LunchStart      string `yaml:"lunch_start"`
+	LunchEnd        string `yaml:"lunch_end"`
+	MaxDailyMinutes int    `yaml:"max_daily_minutes"`
+	DayBoundary     string `yaml:"day_boundary"` // when a day ends, e.g. "03:00"; default midnight
+}
+
+// defaultConfig matches the schedule the tool always used: 8:30–17:30 with
+// lunch from 12:30 to 13:30 and an 8 hour cap
+func defaultConfig() Config {
+	return Config{
+		WorkHours: WorkHours{
+			Start:           "08:30",
+			End:             "17:30",
+			LunchStart:      "12:30",
+			LunchEnd:        "13:30",
+			MaxDailyMinutes: 480,
+		},
+	}
+}
+
+// config is the active configuration, loaded once in main
+var config = defaultConfig()
+
+// keys is the resolved keymap used by the Bubble Tea views
+var keys = keymap.Arrows()
+
+// getConfigFilePath returns the config file: DAILY_CONFIG if set, else
+// config.yaml, or config-<name>.yaml when DAILY_PROFILE names a profile
+func getConfigFilePath() (string, error) {
+	if path := os.Getenv("DAILY_CONFIG"); path != "" {
+		return expandHome(path), nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	name := "config.yaml"
+	if profile := os.Getenv("DAILY_PROFILE"); profile != "" {
+		name = "config-" + profile + ".yaml"
+	}
+	return filepath.Join(dir, "daily", name), nil
+}
+
+func loadConfig() (Config, error) {
+	cfg := defaultConfig()
+	filePath, err := getConfigFilePath()
+	if err != nil {
+		return cfg, err
+	}
+	file, err := os.ReadFile(filePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return cfg, err
+	}
+	// The team config goes first, so every personal setting overrides it
+	if source := teamSource(file); source != "" {
+		team, err := readTeamConfig(source, filePath)
+		if err != nil {
+			return defaultConfig(), fmt.Errorf("team config: %w", err)
+		}
+		if err := yaml.Unmarshal(team, &cfg); err != nil {
+			return defaultConfig(), fmt.Errorf("team config %s: %w", source, err)
+		}
+		cfg.Team = source
+	}
+	if err == nil {
+		if err := yaml.Unmarshal(file, &cfg); err != nil {
+			return defaultConfig(), fmt.Errorf("%s: %w", filePath, err)
+		}
+	}
+	if err := applyEnvOverrides(&cfg); err != nil {
+		return defaultConfig(), err
+	}
+	if err := cfg.WorkHours.validate(); err != nil {
+		return defaultConfig(), fmt.Errorf("%s: %w", filePath, err)
+	}
+	if err := checkTheme(cfg.Theme); err != nil {
+		return defaultConfig(), fmt.Errorf("%s: %w", filePath, err)
+	}
+	if err := checkBackground(cfg.Background); err != nil {
+		return defaultConfig(), fmt.Errorf("%s: %w", filePath, err)
+	}
+	if err := checkLocale(cfg.Locale); err != nil {
+		return defaultConfig(), fmt.Errorf("%s: %w", filePath, err)
+	}
+	if err := cfg.Display.validate(); err != nil {
+		return defaultConfig(), fmt.Errorf("%s: %w", filePath, err)
+	}
+	if err := cfg.Notifications.validate(); err != nil {
+		return defaultConfig(), fmt.Errorf("%s: %w", filePath, err)
+	}
+	if err := cfg.Rollover.validate(); err != nil {
+		return defaultConfig(), fmt.Errorf("%s: %w", filePath, err)
+	}
+	if err := cfg.Idle.validate(); err != nil {
+		return defaultConfig(), fmt.Errorf("%s: %w", filePath, err)
+	}
+	for _, r := range cfg.Reservations {
+		if err := r.check(); err != nil {
+			return defaultConfig(), fmt.Errorf("%s: reservations: %w", filePath, err)
+		}
+	}
+	for _, p := range cfg.People {
+		if _, err := p.rule(); err != nil {
+			return defaultConfig(), fmt.Errorf("%s: %w", filePath, err)
+		}
+	}
+	for _, r := range cfg.Recurring {
+		if _, err := r.dueOn(time.Now()); err != nil {
+			return defaultConfig(), fmt.Errorf("%s: %w", filePath, err)
+		}
+	}
+	for _, h := range cfg.Holidays {
+		if _, err := time.Parse("2006-01-02", h); err != nil {
+			return defaultConfig(), fmt.Errorf("%s: holidays: invalid date %q (expected YYYY-MM-DD)", filePath, h)
+		}
+	}
+	return cfg, nil
+}
+
+// validate checks that the configured times parse and are in order
+func (w WorkHours) validate() error {
+	start, err := parseClock(w.Start)
+	if err != nil {
+		return fmt.Errorf("work_hours.start: %w", err)
+	}
+	end, err := parseClock(w.End)
+	if err != nil {
+		return fmt.Errorf("work_hours.end: %w", err)
+	}
+	if end <= start {
+		return fmt.Errorf("work_hours.end must be after work_hours.start")
+	}
+	if w.LunchStart != "" {
+		lunchStart, err := parseClock(w.LunchStart)
+		if err != nil {
+			return fmt.Errorf("work_hours.lunch_start: %w", err)
+		}
+		lunchEnd, err := parseClock(w.LunchEnd)
+		if err != nil {
+			return fmt.Errorf("work_hours.lunch_end: %w", err)
+		}
+		if lunchStart < start || lunchEnd > end || lunchEnd < lunchStart {
+			return fmt.Errorf("lunch window must fall within work hours")
+		}
+	}
+	if w.MaxDailyMinutes <= 0 {
+		return fmt.Errorf("work_hours.max_daily_minutes must be positive")
+	}
+	if w.DayBoundary != "" {
+		boundary, err := parseClock(w.DayBoundary)
+		if err != nil {
+			return fmt.Errorf("work_hours.day_boundary: %w", err)
+		}
+		if boundary >= start {
+			return fmt.Errorf("work_hours.day_boundary must be before work_hours.start")
+		}
+	}
+	return nil
+}
+
+// parseClock parses "HH:MM" into minutes after midnight
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q (expected HH:MM)", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// clockOn returns the given HH:MM time on the day of `day`
+func clockOn(day time.Time, clock string) time.Time {
+	minutes, _ := parseClock(clock)
+	return time.Date(day.Year(), day.Month(), day.Day(), minutes/60, minutes%60, 0, 0, day.Location())
+}
+
+// dayBoundary returns how long after midnight a day ends
+func dayBoundary() time.Duration {
+	minutes, _ := parseClock(config.WorkHours.DayBoundary)
+	return time.Duration(minutes) * time.Minute
+}
+
+// dayOf returns a time whose date is the day `now` counts towards: until
+// work_hours.day_boundary, work after midnight still belongs to the day before
+func dayOf(now time.Time) time.Time {
+	if config.WorkHours.DayBoundary == "" {
+		return now
+	}
+	return now.Add(-dayBoundary())
+}
+
+// bounds returns the work and lunch boundaries for the day of `now`. Without a
+// lunch window, both lunch bounds equal the end of the working day.
+func (w WorkHours) bounds(now time.Time) (workStart, lunchStart, lunchEnd, workEnd time.Time) {
+	workStart = clockOn(now, w.Start)
+	workEnd = clockOn(now, w.End)
+	lunchStart, lunchEnd = workEnd, workEnd
+	if w.LunchStart != "" && w.LunchEnd != "" {
+		lunchStart = clockOn(now, w.LunchStart)
+		lunchEnd = clockOn(now, w.LunchEnd)
+	}
+	return
+}
+
+// applyConfig loads the config file and derives the runtime settings from it
+func applyConfig() error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	config = cfg
+	km, err := keymap.FromConfig(cfg.Keymap)
+	keys = km
+	return err
+}
@@ -0,0 +1,280 @@
+// dashboard.go - Browser dashboard
+// `daily serve --ui` serves a read-only page of today's tasks, the running timer and the week, built from a small JSON API and kept live with server-sent events
+
+package main
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"time"
+)
+
+// webFiles holds the dashboard page and its assets, built into the binary
+//
+//go:embed web
+var webFiles embed.FS
+
+const (
+	// dashboardRefresh is how often the event stream looks for changes
+	dashboardRefresh = 2 * time.Second
+	// dashboardKeepAlive is the longest the stream stays silent, so proxies
+	// don't close it
+	dashboardKeepAlive = 30 * time.Second
+)
+
+// apiTask is a task as the JSON API shows it
+type apiTask struct {
+	Title     string   `json:"title"`
+	Status    string   `json:"status"`
+	Estimated int      `json:"estimated"`
+	Actual    int      `json:"actual"`
+	Project   string   `json:"project,omitempty"`
+	Context   string   `json:"context,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+	Priority  string   `json:"priority,omitempty"`
+	PlannedAt string   `json:"planned_at,omitempty"`
+	StartedAt int64    `json:"started_at,omitempty"`
+}
+
+func toAPITask(t Task) apiTask {
+	return apiTask{
+		Title:     t.Title,
+		Status:    t.Status,
+		Estimated: t.Estimated,
+		Actual:    t.Actual,
+		Project:   t.Project,
+		Context:   t.Context,
+		Tags:      t.Tags,
+		Priority:  priorityLabel(t.Priority),
+		PlannedAt: t.PlannedAt,
+		StartedAt: t.runningSince(),
+	}
+}
+
+// apiDay is one day of tasks with its totals
+type apiDay struct {
+	Day     string    `json:"day"`
+	Tasks   []apiTask `json:"tasks"`
+	Planned int       `json:"planned"`
+	Worked  int       `json:"worked"`
+	Done    int       `json:"done"`
+	Budget  int       `json:"budget"`
+	Now     int64     `json:"now"`
+}
+
+// apiWeekDay is the summary of one day of the week chart
+type apiWeekDay struct {
+	Day     string `json:"day"`
+	Planned int    `json:"planned"`
+	Worked  int    `json:"worked"`
+	Tasks   int    `json:"tasks"`
+	Done    int    `json:"done"`
+}
+
+func dayView(data TaskData, day string) apiDay {
+	m := computeDayMetrics(data[day])
+	view := apiDay{
+		Day:     day,
+		Tasks:   []apiTask{},
+		Planned: m.Planned,
+		Worked:  m.Worked,
+		Done:    m.Done,
+		Budget:  config.WorkHours.MaxDailyMinutes,
+		Now:     time.Now().Unix(),
+	}
+	if date, err := time.ParseInLocation("2006-01-02", day, time.Local); err == nil {
+		view.Budget = dayCapacity(date)
+	}
+	for _, t := range data[day] {
+		view.Tasks = append(view.Tasks, toAPITask(t))
+	}
+	return view
+}
+
+// weekView summarizes Monday to Sunday of the week containing date
+func weekView(data TaskData, date time.Time) []apiWeekDay {
+	monday := weekMonday(date)
+	week := make([]apiWeekDay, 7)
+	for i := range week {
+		day := monday.AddDate(0, 0, i).Format("2006-01-02")
+		m := computeDayMetrics(data[day])
+		week[i] = apiWeekDay{Day: day, Planned: m.Planned, Worked: m.Worked, Tasks: m.Tasks, Done: m.Done}
+	}
+	return week
+}
+
+// dashboardUpdate is what the event stream pushes: everything the page shows
+type dashboardUpdate struct {
+	Current currentSnapshot `json:"current"`
+	Day     apiDay          `json:"day"`
+	Week    []apiWeekDay    `json:"week"`
+}
+
+// takeDashboardUpdate reads the store for today's page
+func takeDashboardUpdate() (dashboardUpdate, error) {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	now := time.Now()
+	monday := weekMonday(dayOf(now))
+	data, err := loadTasksRange(monday.Format("2006-01-02"), monday.AddDate(0, 0, 6).Format("2006-01-02"))
+	if err != nil {
+		return dashboardUpdate{}, err
+	}
+	snap, err := takeCurrentSnapshot()
+	if err != nil {
+		return dashboardUpdate{}, err
+	}
+	return dashboardUpdate{Current: snap, Day: dayView(data, todayKey()), Week: weekView(data, now)}, nil
+}
+
+// streamDashboard sends an update whenever the plan or the timer changes,
+// until the browser goes away
+func streamDashboard(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming is not supported"))
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	fmt.Fprint(w, "retry: 5000\n\n")
+	flusher.Flush()
+
+	changed, unsubscribe := serverEvents.subscribe()
+	defer unsubscribe()
+	var last []byte
+	lastSent := time.Now()
+	ticker := time.NewTicker(dashboardRefresh)
+	defer ticker.Stop()
+	for {
+		update, err := takeDashboardUpdate()
+		if err == nil {
+			// the clocks move on every read; only a change to the data is news
+			update.Current.Now, update.Day.Now = 0, 0
+			key, _ := json.Marshal(update)
+			if !bytes.Equal(key, last) {
+				last = key
+				update.Current.Now = time.Now().Unix()
+				update.Day.Now = update.Current.Now
+				payload, _ := json.Marshal(update)
+				fmt.Fprintf(w, "event: update\ndata: %s\n\n", payload)
+				flusher.Flush()
+				lastSent = time.Now()
+			}
+		}
+		if time.Since(lastSent) >= dashboardKeepAlive {
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+			lastSent = time.Now()
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-changed:
+		case <-ticker.C:
+		}
+	}
+}
+
+// lanURLs lists the addresses other devices on the network can open the
+// dashboard at
+func lanURLs(port int) []string {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil
+	}
+	var urls []string
+	for _, addr := range addrs {
+		ip, ok := addr.(*net.IPNet)
+		if ok && ip.IP.To4() != nil && !ip.IP.IsLoopback() && !ip.IP.IsLinkLocalUnicast() {
+			urls = append(urls, fmt.Sprintf("http://%s:%d/", ip.IP, port))
+		}
+	}
+	return urls
+}
+
+// registerDashboard adds the dashboard page and the read-only API behind it:
+//
+//	GET /              the dashboard
+//	GET /static/       its stylesheet and script
+//	GET /api/events    server-sent events with the current task, today and the week on every change
+//	GET /api/day       tasks and totals of ?date= (default today)
+//	GET /api/week      planned and worked minutes per day of ?date='s week
+//	GET /api/current   the running task
+//
+// The API needs the token like the REST API; the page passes on the
+// ?token= it was opened with.
+func registerDashboard(mux *http.ServeMux, token string) {
+	readOnly := func(h func(http.ResponseWriter, *http.Request, TaskData, time.Time)) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				w.Header().Set("Allow", http.MethodGet)
+				writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("use GET"))
+				return
+			}
+			if !authorized(r, token) {
+				writeError(w, http.StatusUnauthorized, fmt.Errorf("invalid or missing token"))
+				return
+			}
+			date := dayOf(time.Now())
+			if value := r.URL.Query().Get("date"); value != "" {
+				parsed, err := time.ParseInLocation("2006-01-02", value, time.Local)
+				if err != nil {
+					writeError(w, http.StatusBadRequest, fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", value))
+					return
+				}
+				date = parsed
+			}
+			monday := weekMonday(date)
+			storeMu.Lock()
+			data, err := loadTasksRange(monday.Format("2006-01-02"), monday.AddDate(0, 0, 6).Format("2006-01-02"))
+			storeMu.Unlock()
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, err)
+				return
+			}
+			h(w, r, data, date)
+		}
+	}
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeFileFS(w, r, webFiles, "web/index.html")
+	})
+	static, _ := fs.Sub(webFiles, "web")
+	mux.Handle("/static/", http.StripPrefix("/static/", http.FileServerFS(static)))
+	mux.HandleFunc("GET /api/events", func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(r, token) {
+			writeError(w, http.StatusUnauthorized, fmt.Errorf("invalid or missing token"))
+			return
+		}
+		streamDashboard(w, r)
+	})
+	mux.HandleFunc("/api/day", readOnly(func(w http.ResponseWriter, r *http.Request, data TaskData, date time.Time) {
+		day := date.Format("2006-01-02")
+		if r.URL.Query().Get("date") == "" {
+			day = todayKey()
+		}
+		writeJSON(w, http.StatusOK, dayView(data, day))
+	}))
+	mux.HandleFunc("/api/week", readOnly(func(w http.ResponseWriter, r *http.Request, data TaskData, date time.Time) {
+		writeJSON(w, http.StatusOK, weekView(data, date))
+	}))
+	mux.HandleFunc("/api/current", readOnly(func(w http.ResponseWriter, r *http.Request, data TaskData, date time.Time) {
+		storeMu.Lock()
+		snap, err := takeCurrentSnapshot()
+		storeMu.Unlock()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, snap)
+	}))
+}
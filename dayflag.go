@@ -0,0 +1,101 @@
+// dayflag.go - Day selection
+// The global --date flag points the day-based commands (ls, add, status, delete, note, close...) at another day than today, by date or relative to today
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// selectedDay is the day given with --date, "" for today
+var selectedDay string
+
+// relativeDayPattern matches an offset from today in days: -2, +3
+var relativeDayPattern = regexp.MustCompile(`^[+-]\d+$`)
+
+// parseDay reads a day: YYYY-MM-DD, today, yesterday, tomorrow, an offset
+// from today (-2, +3), or a weekday, which is the last one, today included
+func parseDay(s string, now time.Time) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	today := dayOf(now)
+	switch {
+	case s == "" || s == "today":
+		return today.Format("2006-01-02"), nil
+	case s == "yesterday":
+		return today.AddDate(0, 0, -1).Format("2006-01-02"), nil
+	case s == "tomorrow":
+		return today.AddDate(0, 0, 1).Format("2006-01-02"), nil
+	case relativeDayPattern.MatchString(s):
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return "", fmt.Errorf("invalid day offset %q", s)
+		}
+		return today.AddDate(0, 0, n).Format("2006-01-02"), nil
+	}
+	if _, err := time.Parse("2006-01-02", s); err == nil {
+		return s, nil
+	}
+	if weekday, err := parseWeekday(s); err == nil {
+		back := (int(today.Weekday()) - int(weekday) + 7) % 7
+		return today.AddDate(0, 0, -back).Format("2006-01-02"), nil
+	}
+	return "", fmt.Errorf("invalid day %q (expected YYYY-MM-DD, today, yesterday, tomorrow, -2, +3 or a weekday)", s)
+}
+
+// workDay is the day the day-based commands work on: --date, or today
+func workDay() string {
+	if selectedDay != "" {
+		return selectedDay
+	}
+	return todayKey()
+}
+
+// dayLabel is "today" for today, or the day
+func dayLabel(day string) string {
+	if day == todayKey() {
+		return "today"
+	}
+	return day
+}
+
+// dayAfter returns the day following a YYYY-MM-DD day
+func dayAfter(day string) string {
+	date, err := time.Parse("2006-01-02", day)
+	if err != nil {
+		return day
+	}
+	return date.AddDate(0, 0, 1).Format("2006-01-02")
+}
+
+// dayValue is the --date flag: it is checked as it is parsed, so a wrong
+// day is reported with the usage like any bad flag
+type dayValue struct {
+	day *string
+}
+
+func newDayValue(day *string) *dayValue {
+	// Each command tree starts from today; the shell builds one per line
+	*day = ""
+	return &dayValue{day: day}
+}
+
+func (v *dayValue) Set(s string) error {
+	day, err := parseDay(s, time.Now())
+	if err != nil {
+		return err
+	}
+	*v.day = day
+	return nil
+}
+
+func (v *dayValue) String() string {
+	return *v.day
+}
+
+func (v *dayValue) Type() string {
+	return "day"
+}
@@ -0,0 +1,204 @@
+// dedupe.go - Duplicate task detection and merge
+// Finds the same task entered twice in a day (by external ID, issue key, or title) and merges them
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/manifoldco/promptui"
+	"gopkg.in/yaml.v3"
+)
+
+// decodeTaskData reads tasks.yaml, combining the task lists of day keys that
+// appear more than once (as left behind by file sync conflicts or naive
+// concatenation) instead of failing. It returns the days that were combined.
+func decodeTaskData(file []byte) (TaskData, []string, error) {
+	data := TaskData{}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(file, &doc); err != nil {
+		return nil, nil, err
+	}
+	if len(doc.Content) == 0 {
+		return data, nil, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("tasks file is not a mapping of days to tasks")
+	}
+	var combined []string
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		day := root.Content[i].Value
+		var tasks []Task
+		if err := root.Content[i+1].Decode(&tasks); err != nil {
+			return nil, nil, fmt.Errorf("day %s: %w", day, err)
+		}
+		if _, seen := data[day]; seen {
+			combined = append(combined, day)
+		}
+		data[day] = append(data[day], tasks...)
+	}
+	return data, combined, nil
+}
+
+// duplicateKey identifies a task for duplicate detection; empty means the
+// task is never considered a duplicate
+func duplicateKey(t Task) string {
+	switch {
+	case t.ExternalID != "":
+		return "ext:" + t.ExternalID
+	case t.IssueKey != "":
+		return "issue:" + t.IssueKey
+	case isNonWork(t):
+		// Several breaks or commutes in a day are expected
+		return ""
+	default:
+		return "title:" + strings.ToLower(strings.TrimSpace(t.Title))
+	}
+}
+
+// findDuplicates returns groups of task indexes that share a duplicate key
+func findDuplicates(tasks []Task) [][]int {
+	byKey := map[string][]int{}
+	var order []string
+	for i, t := range tasks {
+		key := duplicateKey(t)
+		if key == "" {
+			continue
+		}
+		if _, ok := byKey[key]; !ok {
+			order = append(order, key)
+		}
+		byKey[key] = append(byKey[key], i)
+	}
+	var groups [][]int
+	for _, key := range order {
+		if len(byKey[key]) > 1 {
+			groups = append(groups, byKey[key])
+		}
+	}
+	return groups
+}
+
+// statusRank orders statuses by how far a task has progressed
+var statusRank = map[string]int{"cancelled": 0, "pending": 1, "paused": 2, "started": 3, "done": 4}
+
+// mergeTasks folds b into a, keeping the furthest status and the larger
+// numbers, and unioning tags and sessions
+func mergeTasks(a, b Task) Task {
+	if statusRank[b.Status] > statusRank[a.Status] {
+		a.Status, a.FinishBy = b.Status, b.FinishBy
+	}
+	a.Estimated = max(a.Estimated, b.Estimated)
+	a.Actual = max(a.Actual, b.Actual)
+	a.Interruptions = max(a.Interruptions, b.Interruptions)
+	a.JiraLogged = max(a.JiraLogged, b.JiraLogged)
+	a.Tags = normalizeTags(append(a.Tags, b.Tags...))
+	for _, field := range []struct{ dst, src *string }{
+		{&a.Project, &b.Project}, {&a.Category, &b.Category}, {&a.ResumeNote, &b.ResumeNote},
+		{&a.ExternalID, &b.ExternalID}, {&a.PlannedAt, &b.PlannedAt}, {&a.IssueKey, &b.IssueKey},
+	} {
+		if *field.dst == "" {
+			*field.dst = *field.src
+		}
+	}
+	starts := map[int64]bool{}
+	for _, s := range a.Sessions {
+		starts[s.Start] = true
+	}
+	for _, s := range b.Sessions {
+		if !starts[s.Start] {
+			a.Sessions = append(a.Sessions, s)
+		}
+	}
+	sort.Slice(a.Sessions, func(i, j int) bool { return a.Sessions[i].Start < a.Sessions[j].Start })
+	return a
+}
+
+// mergeDuplicates merges every duplicate group into its first task, keeping
+// the day's order, and returns how many tasks were folded away
+func mergeDuplicates(tasks []Task) ([]Task, int) {
+	groups := findDuplicates(tasks)
+	drop := map[int]bool{}
+	for _, group := range groups {
+		first := group[0]
+		for _, i := range group[1:] {
+			tasks[first] = mergeTasks(tasks[first], tasks[i])
+			drop[i] = true
+		}
+	}
+	if len(drop) == 0 {
+		return tasks, 0
+	}
+	var kept []Task
+	for i, t := range tasks {
+		if !drop[i] {
+			kept = append(kept, t)
+		}
+	}
+	return kept, len(drop)
+}
+
+// offerDuplicateMerge lists the day's duplicates and merges them if confirmed.
+// It reports whether anything changed.
+func offerDuplicateMerge(data TaskData, day string) (bool, error) {
+	tasks := data[day]
+	groups := findDuplicates(tasks)
+	if len(groups) == 0 {
+		return false, nil
+	}
+	fmt.Printf("Possible duplicates on %s:\n", day)
+	for _, group := range groups {
+		for _, i := range group {
+			t := tasks[i]
+			fmt.Printf("  %-40s %-9s est: %dmin, act: %dmin\n", t.Title, t.Status, t.Estimated, t.Actual)
+		}
+		fmt.Println()
+	}
+	prompt := promptui.Prompt{Label: "Merge them", IsConfirm: true, Default: "y"}
+	if _, err := prompt.Run(); err != nil {
+		if errors.Is(err, promptui.ErrAbort) {
+			return false, nil
+		}
+		return false, err
+	}
+	merged, count := mergeDuplicates(tasks)
+	data[day] = merged
+	fmt.Printf("Merged %d duplicate tasks on %s.\n", count, day)
+	return true, nil
+}
+
+// dedupeTasks checks one day, or every day with all, for duplicate tasks
+func dedupeTasks(day string, all bool) error {
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	days := []string{day}
+	if all {
+		days = days[:0]
+		for d := range data {
+			days = append(days, d)
+		}
+		sort.Strings(days)
+	}
+	changed := false
+	for _, d := range days {
+		ok, err := offerDuplicateMerge(data, d)
+		if err != nil {
+			if err.Error() == "interrupt" || err.Error() == "q" {
+				break
+			}
+			return err
+		}
+		changed = changed || ok
+	}
+	if !changed {
+		fmt.Println("No duplicates merged.")
+		return nil
+	}
+	return saveTasks(data)
+}
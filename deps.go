@@ -0,0 +1,226 @@
+// deps.go - Task dependencies
+// A task can wait for other tasks of its day: `daily depend` records it by task ID, `next` skips blocked tasks, `ls` marks them, and finishing a task tells which tasks it unblocked
+
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// taskIDLength is the number of hex digits of a task ID. IDs only need to be
+// unique within a day.
+const taskIDLength = 3
+
+// newTaskID derives an ID from the task, so that two processes loading the
+// same file give its tasks the same IDs
+func newTaskID(t Task, salt int) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s|%d|%d", t.Title, t.CreatedAt, salt)))
+	return hex.EncodeToString(sum[:])[:taskIDLength]
+}
+
+// assignTaskIDs gives an ID to the tasks without one, and a new one to a
+// task whose ID is already taken on its day
+func assignTaskIDs(data TaskData) {
+	for _, tasks := range data {
+		taken := map[string]bool{}
+		for i := range tasks {
+			t := &tasks[i]
+			if t.ID == "" || taken[t.ID] {
+				for salt := 0; t.ID == "" || taken[t.ID]; salt++ {
+					t.ID = newTaskID(*t, salt)
+				}
+			}
+			taken[t.ID] = true
+		}
+	}
+}
+
+// findDayTask finds a task of a day by ID, by its number in the day, or by
+// title
+func findDayTask(tasks []Task, query string) (int, error) {
+	query = strings.TrimSpace(query)
+	for i, t := range tasks {
+		if strings.EqualFold(t.ID, query) {
+			return i, nil
+		}
+	}
+	if n, err := strconv.Atoi(query); err == nil && n >= 1 && n <= len(tasks) {
+		return n - 1, nil
+	}
+	needle := strings.ToLower(query)
+	var partial []int
+	for i, t := range tasks {
+		title := strings.ToLower(t.Title)
+		if title == needle {
+			return i, nil
+		}
+		if strings.Contains(title, needle) {
+			partial = append(partial, i)
+		}
+	}
+	switch len(partial) {
+	case 0:
+		return 0, fmt.Errorf("no task matches %q", query)
+	case 1:
+		return partial[0], nil
+	}
+	var titles []string
+	for _, i := range partial {
+		titles = append(titles, fmt.Sprintf("%s %s", tasks[i].ID, tasks[i].Title))
+	}
+	return 0, fmt.Errorf("%q matches several tasks: %s", query, strings.Join(titles, ", "))
+}
+
+// listItem is a task as `ls` lists it
+type listItem struct {
+	Task
+	WaitsFor string // IDs of the open tasks it waits for
+}
+
+// waitsForTemplate marks a blocked task in the `ls` templates
+const waitsForTemplate = `{{ if .WaitsFor }} {{ printf "waits for %s" .WaitsFor | red }}{{ end }}`
+
+// waitingFor returns the IDs of the open tasks a task waits for. A
+// prerequisite that is done, cancelled or no longer on the day doesn't block.
+func waitingFor(tasks []Task, t Task) []string {
+	var ids []string
+	for _, id := range t.DependsOn {
+		for _, other := range tasks {
+			if other.ID == id && isOpenStatus(other.Status) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// isBlocked reports whether a task waits for an open task
+func isBlocked(tasks []Task, t Task) bool {
+	return len(waitingFor(tasks, t)) > 0
+}
+
+// unblockedBy returns the open tasks that wait for the task with this ID and
+// nothing else, so that closing it unblocks them. The task may already be
+// closed in tasks.
+func unblockedBy(tasks []Task, id string) []Task {
+	var unblocked []Task
+	for _, t := range tasks {
+		if !isOpenStatus(t.Status) || !slices.Contains(t.DependsOn, id) {
+			continue
+		}
+		if waits := waitingFor(tasks, t); len(waits) == 0 || len(waits) == 1 && waits[0] == id {
+			unblocked = append(unblocked, t)
+		}
+	}
+	return unblocked
+}
+
+// printUnblocked lists the tasks closing the task with this ID unblocks
+func printUnblocked(tasks []Task, id string) {
+	for _, t := range unblockedBy(tasks, id) {
+		fmt.Printf("Unblocked: %s %s\n", t.ID, describeTask(t))
+	}
+}
+
+// dependsOnPath reports whether the task with ID from waits for the task
+// with ID to, directly or through other tasks
+func dependsOnPath(tasks []Task, from, to string) bool {
+	seen := map[string]bool{}
+	var visit func(id string) bool
+	visit = func(id string) bool {
+		if id == to {
+			return true
+		}
+		if seen[id] {
+			return false
+		}
+		seen[id] = true
+		for _, t := range tasks {
+			if t.ID == id {
+				for _, next := range t.DependsOn {
+					if visit(next) {
+						return true
+					}
+				}
+			}
+		}
+		return false
+	}
+	return visit(from)
+}
+
+// runDepend makes a task of a day wait for prerequisites, clears its
+// dependencies, or shows them when neither is given
+func runDepend(day, query string, prerequisites []string, clear bool) error {
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	tasks := data[day]
+	index, err := findDayTask(tasks, query)
+	if err != nil {
+		return err
+	}
+	t := &tasks[index]
+	if clear {
+		if len(t.DependsOn) == 0 {
+			fmt.Printf("'%s' waits for no task.\n", t.Title)
+			return nil
+		}
+		t.DependsOn = nil
+		if err := saveTasks(data); err != nil {
+			return err
+		}
+		fmt.Printf("'%s' no longer waits for other tasks.\n", t.Title)
+		return nil
+	}
+	if len(prerequisites) == 0 {
+		printDependencies(tasks, *t)
+		return nil
+	}
+
+	for _, p := range prerequisites {
+		i, err := findDayTask(tasks, p)
+		if err != nil {
+			return err
+		}
+		prerequisite := tasks[i]
+		if i == index {
+			return fmt.Errorf("'%s' can't wait for itself", t.Title)
+		}
+		if dependsOnPath(tasks, prerequisite.ID, t.ID) {
+			return fmt.Errorf("'%s' already waits for '%s'", prerequisite.Title, t.Title)
+		}
+		if slices.Contains(t.DependsOn, prerequisite.ID) {
+			continue
+		}
+		t.DependsOn = append(t.DependsOn, prerequisite.ID)
+		fmt.Printf("'%s' waits for %s '%s'\n", t.Title, prerequisite.ID, prerequisite.Title)
+	}
+	return saveTasks(data)
+}
+
+// printDependencies shows what a task waits for and what waits for it
+func printDependencies(tasks []Task, t Task) {
+	fmt.Printf("%s %s\n", t.ID, t.Title)
+	if len(t.DependsOn) == 0 {
+		fmt.Println("  Waits for no task.")
+	}
+	for _, id := range t.DependsOn {
+		for _, other := range tasks {
+			if other.ID == id {
+				fmt.Printf("  Waits for %s %s (%s)\n", other.ID, other.Title, other.Status)
+			}
+		}
+	}
+	for _, other := range tasks {
+		if slices.Contains(other.DependsOn, t.ID) {
+			fmt.Printf("  Needed by %s %s (%s)\n", other.ID, other.Title, other.Status)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// display.go - Duration display
+// Formats minutes in the configured unit (minutes, hours and minutes, or decimal hours) with optional rounding
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/manifoldco/promptui"
+)
+
+// DisplayConfig is the `display` section of config.yaml
+type DisplayConfig struct {
+	Units    string `yaml:"units"`    // minutes (default), hm, or hours
+	Rounding int    `yaml:"rounding"` // round to this many minutes, 0 for none
+	Round    string `yaml:"round"`    // nearest (default), up, or down
+	Decimals *int   `yaml:"decimals"` // decimal places for hours, default 2
+}
+
+// validate checks the display settings
+func (d DisplayConfig) validate() error {
+	switch d.Units {
+	case "", "minutes", "hm", "hours":
+	default:
+		return fmt.Errorf("display.units must be minutes, hm, or hours")
+	}
+	switch d.Round {
+	case "", "nearest", "up", "down":
+	default:
+		return fmt.Errorf("display.round must be nearest, up, or down")
+	}
+	if d.Rounding < 0 {
+		return fmt.Errorf("display.rounding must not be negative")
+	}
+	return nil
+}
+
+// roundMinutes applies the configured rounding
+func roundMinutes(m int) int {
+	step := config.Display.Rounding
+	if step <= 1 {
+		return m
+	}
+	units := float64(m) / float64(step)
+	switch config.Display.Round {
+	case "up":
+		units = math.Ceil(units)
+	case "down":
+		units = math.Floor(units)
+	default:
+		units = math.Round(units)
+	}
+	return int(units) * step
+}
+
+func hoursDecimals() int {
+	if config.Display.Decimals != nil {
+		return *config.Display.Decimals
+	}
+	return 2
+}
+
+// fmtMinutes renders a duration for summaries: "75 min", "1h15m", or "1.25h"
+func fmtMinutes(m int) string {
+	m = roundMinutes(m)
+	switch config.Display.Units {
+	case "hm":
+		sign := ""
+		if m < 0 {
+			sign, m = "-", -m
+		}
+		if m < 60 {
+			return fmt.Sprintf("%s%dm", sign, m)
+		}
+		if m%60 == 0 {
+			return fmt.Sprintf("%s%dh", sign, m/60)
+		}
+		return fmt.Sprintf("%s%dh%02dm", sign, m/60, m%60)
+	case "hours":
+		return strconv.FormatFloat(float64(m)/60, 'f', hoursDecimals(), 64) + "h"
+	default:
+		return fmt.Sprintf("%d min", m)
+	}
+}
+
+// fmtRatio renders "done/total" durations sharing one unit, e.g. "30/45 min"
+func fmtRatio(done, total int) string {
+	a, b := fmtMinutes(done), fmtMinutes(total)
+	if config.Display.Units == "" || config.Display.Units == "minutes" {
+		return strings.TrimSuffix(a, " min") + "/" + b
+	}
+	return a + "/" + b
+}
+
+// exportMinutes renders a duration as a bare number for exports: minutes,
+// decimal hours, or h:mm
+func exportMinutes(m int) string {
+	m = roundMinutes(m)
+	switch config.Display.Units {
+	case "hm":
+		return fmt.Sprintf("%d:%02d", m/60, m%60)
+	case "hours":
+		return strconv.FormatFloat(float64(m)/60, 'f', hoursDecimals(), 64)
+	default:
+		return strconv.Itoa(m)
+	}
+}
+
+func init() {
+	// Available in promptui templates as {{ .Estimated | dur }}
+	promptui.FuncMap["dur"] = fmtMinutes
+}
@@ -0,0 +1,185 @@
+// dropfolder.go - Drop-folder intake
+// Ingests small JSON/text files synced from a phone as tasks and notes, then archives them
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InboxConfig is the `inbox` section of config.yaml
+type InboxConfig struct {
+	Dir      string `yaml:"dir"`
+	Interval int    `yaml:"interval"` // seconds between scans in watch mode
+}
+
+// dropItem is one capture read from a drop file. JSON files hold a single
+// object or a list of them; text files hold one task per line, read like
+// quick-add ("Call the bank 15min #admin"), with lines starting with "note:"
+// stored as notes.
+type dropItem struct {
+	Type     string `json:"type"` // "task" (default) or "note"
+	Title    string `json:"title"`
+	Estimate string `json:"estimate"`
+	Tags     string `json:"tags"`
+	Text     string `json:"text"`
+}
+
+func inboxDir(flagDir string) (string, error) {
+	dir := flagDir
+	if dir == "" {
+		dir = config.Inbox.Dir
+	}
+	if dir == "" {
+		return "", fmt.Errorf("no drop folder: set inbox.dir in config.yaml or pass --dir")
+	}
+	dir = expandHome(dir)
+	if err := os.MkdirAll(filepath.Join(dir, "archive"), 0755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+func parseDropFile(path string) ([]dropItem, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		trimmed := strings.TrimSpace(string(content))
+		if strings.HasPrefix(trimmed, "[") {
+			var items []dropItem
+			return items, json.Unmarshal(content, &items)
+		}
+		var item dropItem
+		return []dropItem{item}, json.Unmarshal(content, &item)
+	}
+	var items []dropItem
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if text, ok := strings.CutPrefix(line, "note:"); ok {
+			items = append(items, dropItem{Type: "note", Text: text})
+			continue
+		}
+		q := parseQuickAdd(line, dayOf(time.Now()))
+		item := dropItem{Type: "task", Title: q.Title, Tags: strings.Join(q.Tags, ",")}
+		if q.HasEstimate {
+			item.Estimate = strconv.Itoa(q.Estimated)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func ingestDropItem(item dropItem) error {
+	switch strings.ToLower(item.Type) {
+	case "", "task":
+		_, err := addInboxTask(item.Title, item.Estimate, strings.Split(item.Tags, ","))
+		return err
+	case "note":
+		return addInboxNote(item.Text)
+	default:
+		return fmt.Errorf("unknown type %q", item.Type)
+	}
+}
+
+// ingestDropFolder processes every .json/.txt file in dir once. Files ingested
+// in full move to dir/archive; a file that fails to parse, or holds an item
+// that fails, is renamed to *.failed for the user to fix, so it isn't lost
+// or read again on every scan.
+func ingestDropFolder(dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+	var names []string
+	for _, e := range entries {
+		ext := strings.ToLower(filepath.Ext(e.Name()))
+		// Skip hidden and partial files written by sync tools
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") || (ext != ".json" && ext != ".txt") {
+			continue
+		}
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+
+	count := 0
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		items, err := parseDropFile(path)
+		if err != nil {
+			fmt.Printf("Error: %s: %v; renamed to %s.failed\n", name, err, name)
+			if err := os.Rename(path, path+".failed"); err != nil {
+				return count, err
+			}
+			continue
+		}
+		added, failed := 0, 0
+		for _, item := range items {
+			if err := ingestDropItem(item); err != nil {
+				fmt.Printf("Error: %s: %v\n", name, err)
+				failed++
+				continue
+			}
+			added++
+		}
+		count += added
+		if failed > 0 {
+			retry := ""
+			if added > 0 {
+				retry = fmt.Sprintf(", remove the %d added before trying it again", added)
+			}
+			fmt.Printf("%d of %d items of %s failed; renamed to %s.failed%s\n", failed, len(items), name, name, retry)
+			if err := os.Rename(path, path+".failed"); err != nil {
+				return count, err
+			}
+			continue
+		}
+		archived := filepath.Join(dir, "archive", time.Now().Format("20060102-150405")+"-"+name)
+		if err := os.Rename(path, archived); err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+// runInbox ingests the drop folder once, or keeps scanning it when watch is set
+func runInbox(flagDir string, watch bool) error {
+	dir, err := inboxDir(flagDir)
+	if err != nil {
+		return err
+	}
+	if !watch {
+		count, err := ingestDropFolder(dir)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Ingested %d items from %s.\n", count, dir)
+		return nil
+	}
+
+	interval := time.Duration(config.Inbox.Interval) * time.Second
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	fmt.Printf("Watching %s every %s. Press Ctrl+C to stop.\n", dir, interval)
+	for {
+		count, err := ingestDropFolder(dir)
+		if err != nil {
+			fmt.Println("Error:", err)
+		} else if count > 0 {
+			fmt.Printf("%s ingested %d items.\n", time.Now().Format("15:04"), count)
+		}
+		time.Sleep(interval)
+	}
+}
@@ -0,0 +1,337 @@
+// editday.go - Editing a day in the editor
+// `daily edit-day` opens a day's tasks as a Markdown table in the editor and applies the edited table after a preview of the changes
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// editDayHeader explains the table at the top of the file
+const editDayHeader = `<!--
+Tasks of %s. Edit the cells and save to apply; close without saving to cancel.
+  - Delete a row to delete the task; reorder rows to reorder tasks.
+  - Add a task with a row whose # is empty.
+  - status: pending, started, paused, done or cancelled
+  - est and actual: minutes (45) or hours (1.5h); priority: high, medium, low or 1-9
+  - at: planned start time (HH:MM); context: where it can be done, empty for anywhere; tags: comma-separated
+  - Write \| for a | in a title.
+-->
+`
+
+// editDayColumns are the columns of the table, in order
+var editDayColumns = []string{"#", "status", "est", "actual", "priority", "at", "project", "context", "tags", "title"}
+
+// escapeCell keeps a | inside a cell from ending it
+func escapeCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// formatDayTable renders tasks as the editable Markdown table
+func formatDayTable(day string, tasks []Task) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, editDayHeader, day)
+	b.WriteString("\n| " + strings.Join(editDayColumns, " | ") + " |\n")
+	b.WriteString("|" + strings.Repeat("---|", len(editDayColumns)) + "\n")
+	for i, t := range tasks {
+		cells := []string{
+			strconv.Itoa(i + 1),
+			t.Status,
+			strconv.Itoa(t.Estimated),
+			strconv.Itoa(t.Actual),
+			priorityLabel(t.Priority),
+			t.PlannedAt,
+			escapeCell(t.Project),
+			t.Context,
+			escapeCell(strings.Join(t.Tags, ", ")),
+			escapeCell(t.Title),
+		}
+		b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+	return b.String()
+}
+
+// splitRow splits a table row into its cells, honouring \|
+func splitRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// isSeparatorRow reports whether a row is the |---|---| line under the header
+func isSeparatorRow(cells []string) bool {
+	for _, c := range cells {
+		if strings.Trim(c, "-: ") != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// parseDayTable applies an edited table to the day's tasks. Rows keep the
+// tasks they came from by their # column, so sessions and other fields not in
+// the table survive; origins holds that index for each task, -1 for new ones.
+// All problems are reported at once, by line.
+func parseDayTable(day string, content string, original []Task, now time.Time) (tasks []Task, origins []int, err error) {
+	var problems []string
+	seen := map[int]int{}
+	inComment := false
+	started := 0
+	for n, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if inComment || strings.HasPrefix(trimmed, "<!--") {
+			inComment = !strings.Contains(trimmed, "-->")
+			continue
+		}
+		if !strings.HasPrefix(trimmed, "|") {
+			if trimmed != "" {
+				problems = append(problems, fmt.Sprintf("line %d: not a table row", n+1))
+			}
+			continue
+		}
+		cells := splitRow(trimmed)
+		if isSeparatorRow(cells) || strings.EqualFold(cells[0], "#") {
+			continue
+		}
+		fail := func(format string, args ...any) {
+			problems = append(problems, fmt.Sprintf("line %d: ", n+1)+fmt.Sprintf(format, args...))
+		}
+		if len(cells) != len(editDayColumns) {
+			fail("expected %d cells, found %d", len(editDayColumns), len(cells))
+			continue
+		}
+		var t Task
+		origin := -1
+		if cells[0] == "" {
+			t = Task{Status: "pending"}
+		} else {
+			id, err := strconv.Atoi(cells[0])
+			if err != nil || id < 1 || id > len(original) {
+				fail("unknown task # %q (leave it empty for a new task)", cells[0])
+				continue
+			}
+			if first, ok := seen[id]; ok {
+				fail("task #%d already appears on line %d", id, first)
+				continue
+			}
+			seen[id] = n + 1
+			t = original[id-1]
+			origin = id - 1
+		}
+
+		status := strings.ToLower(cells[1])
+		if _, ok := statusOrder[status]; !ok {
+			fail("unknown status %q", cells[1])
+			continue
+		}
+		estimated, err := parseEstimate(cells[2])
+		if err != nil {
+			fail("est: %v", err)
+			continue
+		}
+		actual, err := parseEstimate(cells[3])
+		if err != nil {
+			fail("actual: %v", err)
+			continue
+		}
+		priority, err := parsePriority(cells[4])
+		if err != nil {
+			fail("%v", err)
+			continue
+		}
+		plannedAt, err := checkPlannedAt(cells[5])
+		if err != nil {
+			fail("at: %v", err)
+			continue
+		}
+		if cells[9] == "" {
+			fail("the title is empty")
+			continue
+		}
+		if status == "started" && t.Status != "started" && day != todayKey() {
+			fail("only tasks of today can be started")
+			continue
+		}
+
+		t.Title = cells[9]
+		t.setEstimate(estimated, now)
+		t.Actual = actual
+		t.Priority = priority
+		t.PlannedAt = plannedAt
+		t.Project = cells[6]
+		t.Context = normalizeContext(cells[7])
+		t.Tags = parseTagList(cells[8])
+		if status != t.Status {
+			t.setStatus(status, now)
+		}
+		if origin < 0 {
+			applyTagRules(&t)
+		}
+		if t.Status == "started" {
+			started++
+		}
+		tasks = append(tasks, t)
+		origins = append(origins, origin)
+	}
+	if started > 1 {
+		problems = append(problems, "only one task can be started at a time")
+	}
+	if len(problems) > 0 {
+		return nil, nil, fmt.Errorf("%s", strings.Join(problems, "\n  "))
+	}
+	return tasks, origins, nil
+}
+
+// describeEdits lists what an edit changes, one line per task
+func describeEdits(before, after []Task, origins []int) []string {
+	var lines []string
+	kept := map[int]bool{}
+	for i, t := range after {
+		if origins[i] < 0 {
+			lines = append(lines, fmt.Sprintf("+ %s (%s, %s)", t.Title, t.Status, fmtMinutes(t.Estimated)))
+			continue
+		}
+		old := before[origins[i]]
+		kept[origins[i]] = true
+		var changes []string
+		field := func(name, from, to string) {
+			if from != to {
+				changes = append(changes, fmt.Sprintf("%s %q → %q", name, from, to))
+			}
+		}
+		field("title", old.Title, t.Title)
+		field("status", old.Status, t.Status)
+		field("est", fmtMinutes(old.Estimated), fmtMinutes(t.Estimated))
+		field("actual", fmtMinutes(old.Actual), fmtMinutes(t.Actual))
+		field("priority", priorityLabel(old.Priority), priorityLabel(t.Priority))
+		field("at", old.PlannedAt, t.PlannedAt)
+		field("project", old.Project, t.Project)
+		field("context", old.Context, t.Context)
+		field("tags", strings.Join(old.Tags, ", "), strings.Join(t.Tags, ", "))
+		if len(changes) > 0 {
+			lines = append(lines, fmt.Sprintf("~ %s: %s", t.Title, strings.Join(changes, ", ")))
+		}
+	}
+	for i, t := range before {
+		if !kept[i] {
+			lines = append(lines, "- "+t.Title)
+		}
+	}
+	moved := false
+	for i, origin := range origins {
+		if origin >= 0 && i < len(before) && origin != i {
+			moved = true
+		}
+	}
+	if moved && len(lines) == 0 {
+		lines = append(lines, "tasks reordered")
+	}
+	return lines
+}
+
+// editDay opens the tasks of a day in the editor as a Markdown table and
+// applies the edited table after showing what changes
+func editDay(day string) error {
+	if _, err := time.Parse("2006-01-02", day); err != nil {
+		return fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", day)
+	}
+	if err := requireOnline("Opening an editor"); err != nil {
+		return err
+	}
+	editor, err := editorCommand()
+	if err != nil {
+		return err
+	}
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	original := data[day]
+	content := formatDayTable(day, original)
+	for {
+		edited, err := editInEditor(editor, content)
+		if err != nil {
+			return err
+		}
+		if edited == content {
+			fmt.Println("No changes.")
+			return nil
+		}
+		tasks, origins, err := parseDayTable(day, edited, original, time.Now())
+		if err != nil {
+			fmt.Printf("The table has problems:\n  %v\n", err)
+			again, err := confirmPrompt("Fix them in the editor")
+			if err != nil || !again {
+				fmt.Println("Nothing saved.")
+				return nil
+			}
+			content = edited
+			continue
+		}
+		changes := describeEdits(original, tasks, origins)
+		if len(changes) == 0 {
+			fmt.Println("No changes.")
+			return nil
+		}
+		fmt.Printf("Changes to %s:\n", day)
+		for _, line := range changes {
+			fmt.Println("  " + line)
+		}
+		ok, err := confirmPrompt("Save these changes")
+		if err != nil || !ok {
+			fmt.Println("Nothing saved.")
+			return nil
+		}
+		data[day] = tasks
+		if len(tasks) == 0 {
+			delete(data, day)
+		}
+		return saveTasks(data)
+	}
+}
+
+// editInEditor lets the user edit text in the editor and returns the result
+func editInEditor(editor []string, content string) (string, error) {
+	tmpfile, err := os.CreateTemp("", "daily_day_*.md")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmpfile.Name())
+	if _, err := tmpfile.WriteString(content); err != nil {
+		tmpfile.Close()
+		return "", err
+	}
+	tmpfile.Close()
+
+	cmd := exec.Command(editor[0], append(editor[1:], tmpfile.Name())...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+	edited, err := os.ReadFile(tmpfile.Name())
+	return string(edited), err
+}
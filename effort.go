@@ -0,0 +1,136 @@
+// effort.go - Effort breakdown
+// `daily effort` adds up the time invested in long-running work day by day: a task carried across days, or every task of an epic
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// effortDay is the time invested on one day
+type effortDay struct {
+	Day     string   `json:"day"`
+	Minutes int      `json:"minutes"`
+	Tasks   []string `json:"tasks"`
+}
+
+// effortReport is the breakdown `effort --json` prints
+type effortReport struct {
+	Subject   string      `json:"subject"`
+	Epic      bool        `json:"epic"`
+	Estimated int         `json:"estimated"` // the first estimate of each task, before any carry-over
+	Minutes   int         `json:"minutes"`
+	Days      []effortDay `json:"days"`
+}
+
+// sameWork reports whether two tasks are the same piece of work on different
+// days: a carried-over task keeps its title, and its issue key when it has one
+func sameWork(a, b Task) bool {
+	if a.IssueKey != "" || b.IssueKey != "" {
+		return a.IssueKey == b.IssueKey
+	}
+	return strings.EqualFold(a.Title, b.Title)
+}
+
+// isEpic reports whether name is the epic of any task
+func isEpic(data TaskData, name string) bool {
+	for _, tasks := range data {
+		for _, t := range tasks {
+			if t.Epic != "" && strings.EqualFold(t.Epic, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// buildEffort gathers, day by day, the time tracked on the tasks `belongs`
+// accepts
+func buildEffort(data TaskData, subject string, epic bool, belongs func(Task) bool, now time.Time) effortReport {
+	r := effortReport{Subject: subject, Epic: epic, Days: []effortDay{}}
+	first := map[string]int{}
+	for _, day := range sortedDays(data) {
+		entry := effortDay{Day: day}
+		for _, t := range data[day] {
+			if !belongs(t) {
+				continue
+			}
+			if _, ok := first[strings.ToLower(t.Title)]; !ok {
+				first[strings.ToLower(t.Title)] = t.Estimated
+			}
+			minutes := t.trackedMinutes(now.Unix())
+			if minutes == 0 {
+				continue
+			}
+			entry.Minutes += minutes
+			entry.Tasks = append(entry.Tasks, t.Title)
+		}
+		if entry.Minutes > 0 {
+			r.Days = append(r.Days, entry)
+			r.Minutes += entry.Minutes
+		}
+	}
+	for _, minutes := range first {
+		r.Estimated += minutes
+	}
+	return r
+}
+
+// showEffort prints the per-day breakdown of an epic, or of the task found
+// by its number on day or by its title
+func showEffort(query, day string, asJSON bool) error {
+	data, err := loadTasksRange("", "")
+	if err != nil {
+		return err
+	}
+	var r effortReport
+	if isEpic(data, query) {
+		r = buildEffort(data, query, true, func(t Task) bool { return strings.EqualFold(t.Epic, query) }, time.Now())
+	} else {
+		found, i, err := findTaskRecord(data, day, query)
+		if err != nil {
+			return err
+		}
+		task := data[found][i]
+		r = buildEffort(data, task.Title, false, func(t Task) bool { return sameWork(t, task) }, time.Now())
+	}
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	}
+	kind := "Task"
+	if r.Epic {
+		kind = "Epic"
+	}
+	fmt.Printf("%s: %s\n\n", kind, r.Subject)
+	if len(r.Days) == 0 {
+		fmt.Println("No time tracked yet.")
+		return nil
+	}
+	for _, d := range r.Days {
+		date, _ := time.Parse("2006-01-02", d.Day)
+		line := fmt.Sprintf("  %s  %8s", date.Format("Mon 2006-01-02"), fmtMinutes(d.Minutes))
+		if r.Epic {
+			sort.Strings(d.Tasks)
+			line += "  " + strings.Join(d.Tasks, ", ")
+		}
+		fmt.Println(line)
+	}
+	first, last := r.Days[0].Day, r.Days[len(r.Days)-1].Day
+	if first == last {
+		fmt.Printf("\nTotal: %s on %s", fmtMinutes(r.Minutes), first)
+	} else {
+		fmt.Printf("\nTotal: %s over %d days (%s to %s)", fmtMinutes(r.Minutes), len(r.Days), first, last)
+	}
+	if r.Estimated > 0 {
+		fmt.Printf(", estimate %s", fmtMinutes(r.Estimated))
+	}
+	fmt.Println()
+	return nil
+}
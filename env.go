@@ -0,0 +1,74 @@
+// env.go - Environment overrides
+// DAILY_* variables override any config.yaml key, e.g. DAILY_THEME or DAILY_WORK_HOURS_START
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// envPrefix starts every override; the rest is the yaml path of the key in
+// upper case with sections joined by underscores
+const envPrefix = "DAILY_"
+
+// applyEnvOverrides sets every config field whose DAILY_* variable is set.
+// Strings are taken as-is, lists of strings may be comma-separated, and
+// everything else is parsed as YAML (numbers, booleans, `[...]` lists).
+func applyEnvOverrides(cfg *Config) error {
+	return overrideFields(reflect.ValueOf(cfg).Elem(), envPrefix)
+}
+
+func overrideFields(v reflect.Value, prefix string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if tag == "" || tag == "-" || !field.IsExported() {
+			continue
+		}
+		name := prefix + strings.ToUpper(tag)
+		value := v.Field(i)
+		if value.Kind() == reflect.Struct {
+			if err := overrideFields(value, name+"_"); err != nil {
+				return err
+			}
+			continue
+		}
+		raw, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := setFromEnv(value, raw); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func setFromEnv(value reflect.Value, raw string) error {
+	switch {
+	case value.Kind() == reflect.String:
+		value.SetString(raw)
+		return nil
+	case value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.String && !strings.HasPrefix(strings.TrimSpace(raw), "["):
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		value.Set(reflect.ValueOf(items).Convert(value.Type()))
+		return nil
+	}
+	parsed := reflect.New(value.Type())
+	if err := yaml.Unmarshal([]byte(raw), parsed.Interface()); err != nil {
+		return fmt.Errorf("invalid value %q for %s", raw, value.Type())
+	}
+	value.Set(parsed.Elem())
+	return nil
+}
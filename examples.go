@@ -0,0 +1,487 @@
+// examples.go - Help and examples
+// Every command carries examples ready to paste; `daily help` starts from the essentials and the topics, `daily help <command>` shows a command's examples and its related commands, and `daily examples [topic]` lists the examples of a topic
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// commandExample is one invocation of a command
+type commandExample struct {
+	Does string // what it does, shown as a comment above the command line
+	Run  string // the command line, ready to paste
+}
+
+// helpTopic groups related commands for `daily examples <topic>`
+type helpTopic struct {
+	Name     string
+	Summary  string
+	Commands []string // command paths without "daily"
+}
+
+// essentialCommands are listed first by `daily help`: a day with daily
+var essentialCommands = []string{"plan", "add", "ls", "next", "finish", "review"}
+
+var helpTopics = []helpTopic{
+	{"tracking", "Start, pause and finish tasks; breaks, interruptions and meetings",
+		[]string{"add", "addt", "ls", "lst", "next", "current", "pause", "resume", "stop", "finish", "status", "done", "cancel", "delete", "break", "interrupt", "meeting", "log", "follow", "watch", "tui", "board", "statusline"}},
+	{"planning", "Plan a day or a week and check that it fits",
+		[]string{"plan", "plan check", "plan fit", "depend", "mv", "cp", "plan week", "plan forecast", "plan jira", "agenda", "reorder", "reserve", "off", "calendar import", "calibrate"}},
+	{"review", "Close the day and look back on days, weeks and tasks",
+		[]string{"review", "close", "yesterday", "week", "diff", "stats", "trend", "unplanned", "effort", "show", "project show", "standup"}},
+	{"notes", "Day notes, task notes, 1:1 threads and search",
+		[]string{"note", "person", "journal", "search"}},
+	{"sharing", "Publish, export, invoice and push time to other tools",
+		[]string{"publish", "export", "export ical", "export sessions-csv", "invoice", "push", "jira pull", "jira push", "pair", "serve", "inbox"}},
+	{"data", "Undo, backups, sync, checks and imports",
+		[]string{"undo", "restore", "verify", "sync", "archive", "dedupe", "edit-day", "backfill", "migrate from", "init"}},
+}
+
+var commandExamples = map[string][]commandExample{
+	"add": {
+		{"Add a task with the prompts", "daily add"},
+		{"Estimate, day and tag in the text", "daily add Fix login bug 45min tomorrow #work"},
+		{"Plan it at a time", "daily add Team demo 14:00 30min"},
+		{"Only doable in a context", "daily add Buy stamps 10min @errand"},
+		{"No prompts, for scripts", `daily add --title "Fix bug" --est 45 --tag work`},
+		{"On another day", `daily add --title "Plan sprint" --est 1.5h --date 2024-06-01`},
+	},
+	"addt": {
+		{"Add a task for tomorrow with the prompts", "daily addt"},
+	},
+	"ls": {
+		{"List and edit today's tasks", "daily ls"},
+		{"Only one tag", "daily ls --tag work"},
+		{"Grouped by project", "daily ls --group-by project"},
+		{"What can be done at home", "daily ls --context home"},
+	},
+	"next": {
+		{"Start the next pending task", "daily next"},
+		{"The next one that can be done at the office", "daily next --context office"},
+	},
+	"pause": {
+		{"Pause the running task, without counting an interruption", "daily pause"},
+	},
+	"resume": {
+		{"Start the paused task again", "daily resume"},
+	},
+	"stop": {
+		{"Stop the running task, asking where you left off", "daily stop"},
+		{"Leave a note for when it is resumed", `daily stop -m "halfway through the migration script"`},
+	},
+	"finish": {
+		{"Mark the running task as done", "daily finish"},
+	},
+	"status": {
+		{"Pick tasks with space, then give them a status", "daily status"},
+	},
+	"done": {
+		{"Mark tasks as done by the IDs ls shows", "daily done a3f b7c"},
+		{"Everything left yesterday", "daily done --all-pending --date yesterday"},
+	},
+	"cancel": {
+		{"Cancel a task by title", `daily cancel "Write docs"`},
+		{"Clear what is left of the day", "daily cancel --all-pending"},
+	},
+	"delete": {
+		{"Pick tasks to delete with space", "daily delete"},
+		{"Delete tasks by ID", "daily delete a3f b7c"},
+	},
+	"break": {
+		{"Time a break", "daily break coffee"},
+	},
+	"interrupt": {
+		{"Time what interrupted the running task", `daily interrupt "prod alert on checkout"`},
+	},
+	"meeting": {
+		{"Log a meeting of 30 minutes", `daily meeting "Sprint planning" 30`},
+	},
+	"log": {
+		{"Log time that doesn't count as work", "daily log commute 40"},
+		{"With a description", "daily log lunch 45 with the team"},
+	},
+	"board": {
+		{"Today's tasks in a column per status", "daily board"},
+		{"Yesterday's board, to settle what was left", "daily board yesterday"},
+	},
+	"statusline": {
+		{"One line for a status bar", "daily statusline"},
+		{"Colored for tmux, with a short title", "daily statusline --color tmux --max-title 20"},
+		{"Your own format", `daily statusline --format '{{if .Title}}{{.Title}} {{.Elapsed}}{{end}} {{.DayPercent}}%'`},
+	},
+	"plan": {
+		{"Plan today: leftovers, recurring tasks, then new ones", "daily plan"},
+		{"Plan Monday on Friday evening", "daily plan 2024-06-10"},
+	},
+	"plan check": {
+		{"Check that today's work fits in the time left", "daily plan check"},
+		{"The same for another day", "daily plan check 2024-06-03"},
+	},
+	"plan fit": {
+		{"Move low-priority tasks to the next workday until today fits", "daily plan fit"},
+	},
+	"plan week": {
+		{"Spread the inbox, GitHub and Jira over this week", "daily plan week"},
+		{"Only from the inbox", "daily plan week --from inbox"},
+		{"The week of a day", "daily plan week 2024-06-10 --from inbox,github"},
+	},
+	"plan forecast": {
+		{"The chance of finishing today's plan", "daily plan forecast"},
+	},
+	"plan jira": {
+		{"Pick issues from the active sprint", "daily plan jira"},
+	},
+	"depend": {
+		{"Task b7c waits for task a3f, by the IDs ls shows", "daily depend b7c a3f"},
+		{"By title", `daily depend "Deploy" "Code review" "QA sign-off"`},
+		{"What a task waits for and what waits for it", "daily depend b7c"},
+		{"Stop it waiting", "daily depend b7c --clear"},
+	},
+	"mv": {
+		{"Push a task to tomorrow", "daily mv a3f --to tomorrow"},
+		{"Two tasks to Thursday", "daily mv a3f b7c --to thu"},
+		{"A task of yesterday to a date", `daily mv "Write docs" --date yesterday --to 2024-06-10`},
+	},
+	"cp": {
+		{"Do it again on Friday", "daily cp a3f --to fri"},
+	},
+	"agenda": {
+		{"Today as a timeline", "daily agenda"},
+		{"Another day", "daily agenda 2024-06-03"},
+	},
+	"reserve": {
+		{"Reserve a block today", "daily reserve add support rotation 13:30-15:30"},
+		{"Free a block on one day", "daily reserve remove support rotation --date 2024-06-14"},
+	},
+	"off": {
+		{"List the days off", "daily off"},
+		{"Take a day off", "daily off 2026-12-24"},
+		{"A sick day", "daily off 2026-10-12 sick"},
+		{"Remove a day off", "daily off 2026-12-24 --clear"},
+	},
+	"calendar import": {
+		{"Import today's meetings", "daily calendar import"},
+	},
+	"calibrate": {
+		{"Estimate 5 past tasks blind, then compare", "daily calibrate"},
+	},
+	"review": {
+		{"Settle today's tasks, write a retro and close the day", "daily review"},
+		{"Review a day you forgot to close", "daily review 2024-06-03"},
+	},
+	"close": {
+		{"Close today without questions", "daily close"},
+	},
+	"week": {
+		{"This week's report", "daily week"},
+		{"The week of a day", "daily week 2024-06-03"},
+	},
+	"diff": {
+		{"This morning's plan against the day", "daily diff"},
+	},
+	"stats": {
+		{"Estimates against tracked time, all history", "daily stats"},
+		{"The last month", "daily stats --days 30"},
+	},
+	"trend": {
+		{"The last 30 days, with a bar per day", "daily trend --bars"},
+	},
+	"unplanned": {
+		{"Work added during the day, the last 8 weeks", "daily unplanned --weeks 8"},
+	},
+	"effort": {
+		{"Time on today's task 2, day by day", "daily effort 2"},
+		{"A task by title", `daily effort "Migrate billing"`},
+		{"An epic, as JSON", "daily effort PROJ-120 --json"},
+	},
+	"show": {
+		{"Everything about today's task 3", "daily show 3"},
+		{"As Markdown, to a file", `daily show "Review PR" --md > review-pr.md`},
+		{"A task of another day, as JSON", "daily show 2 --date 2026-03-02 --json"},
+	},
+	"project show": {
+		{"A project's time by month", `daily project show "Project X"`},
+		{"Over a range", `daily project show "Project X" --from 2024-06-01 --to 2024-06-30`},
+	},
+	"standup": {
+		{"Note a blocker for the next standup", `daily note "blocked: waiting on the staging credentials"`},
+		{"Preview the message for Slack", "daily standup --post slack --dry-run"},
+		{"Post it to Teams", "daily standup --post teams"},
+	},
+	"note": {
+		{"Add a note for today", `daily note "Sync with Ana about the invoices #meeting"`},
+		{"Show today's notes", "daily note"},
+		{"Another day's notes, as written", "daily note show 2024-06-03 --raw"},
+		{"Find the notes with a tag", "daily note search '#meeting'"},
+		{"Add a note to today's task 2", `daily note --task 2 "asked QA for a second look"`},
+		{"Edit today's notes in your editor", "daily note edit"},
+	},
+	"person": {
+		{"List the people with a 1:1 thread", "daily person"},
+		{"Prepare the next 1:1", "daily person ana"},
+		{"Add a talking point", "daily person ana ask about the conference budget"},
+		{"Close the points after the 1:1", "daily person ana --discussed"},
+	},
+	"journal": {
+		{"Write this week's notes to the journal", "daily journal"},
+	},
+	"search": {
+		{"Tasks and notes mentioning a phrase", `daily search "billing migration"`},
+		{"With a regular expression", `daily search --regex "PROJ-1[0-9]+"`},
+	},
+	"publish": {
+		{"Today as Markdown, without private tasks", "daily publish"},
+		{"As HTML with the notes", "daily publish --format html --with-notes"},
+		{"To a GitHub gist", "daily publish --gist"},
+	},
+	"export": {
+		{"A month of tracked time as CSV", "daily export --format csv --from 2024-06-01 --to 2024-06-30 -o june.csv"},
+	},
+	"export ical": {
+		{"Today's plan as calendar events", "daily export ical -o today.ics"},
+	},
+	"export sessions-csv": {
+		{"Every work session since a day", "daily export sessions-csv --from 2024-06-01"},
+	},
+	"invoice": {
+		{"Last month's invoice for a client", "daily invoice acme --month 2024-05"},
+		{"As a PDF, at another rate", "daily invoice acme --format pdf --rate 90 -o acme-may.pdf"},
+	},
+	"push": {
+		{"Push today's sessions to Toggl", "daily push toggl"},
+		{"A past day to Clockify", "daily push clockify 2026-03-02"},
+		{"Preview the last week", "daily push toggl --days 7 --dry-run"},
+	},
+	"jira pull": {
+		{"Add the issues assigned to you as tasks", "daily jira pull"},
+	},
+	"jira push": {
+		{"Preview the worklogs of today", "daily jira push --dry-run"},
+	},
+	"pair": {
+		{"Share the current task on the network", "daily pair --serve"},
+	},
+	"serve": {
+		{"The dashboard in a browser tab", "daily serve --ui"},
+		{"The REST API on another port", "daily serve --api --port 9090"},
+	},
+	"inbox": {
+		{"Ingest the files of the drop folder", "daily inbox"},
+		{"Keep watching it", "daily inbox --watch"},
+	},
+	"undo": {
+		{"Revert the last change", "daily undo"},
+		{"See what can be undone", "daily undo --list"},
+	},
+	"restore": {
+		{"List the backups", "daily restore"},
+		{"Replace tasks.yaml with backup 2", "daily restore 2"},
+		{"The same for notes.yaml", "daily restore --notes 1"},
+	},
+	"verify": {
+		{"Check the data files", "daily verify"},
+		{"Trust every change made outside daily", "daily verify --accept"},
+		{"Trust one file", "daily verify --accept tasks.yaml"},
+	},
+	"sync": {
+		{"Commit, pull and push the data", "daily sync"},
+	},
+	"archive": {
+		{"See what would be archived", "daily archive --before 2024-01-01 --dry-run"},
+		{"Archive it", "daily archive --before 2024-01-01"},
+	},
+	"dedupe": {
+		{"Merge today's duplicates", "daily dedupe"},
+		{"Check every day", "daily dedupe --all"},
+	},
+	"edit-day": {
+		{"Edit yesterday as a table", "daily edit-day 2024-06-03"},
+	},
+	"backfill": {
+		{"Fill in the idle days of the last two weeks", "daily backfill --days 14"},
+	},
+	"migrate from": {
+		{"Pick an export found in ~/Downloads", "daily migrate from"},
+		{"Import a Toggl export", "daily migrate from toggl-2024.csv"},
+	},
+}
+
+// formatExamples renders examples for the Example field of a command
+func formatExamples(examples []commandExample) string {
+	var lines []string
+	for _, e := range examples {
+		lines = append(lines, "  # "+e.Does, "  "+e.Run)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// findCommand returns the command at a path such as "plan week", or nil
+func findCommand(root *cobra.Command, path string) *cobra.Command {
+	cmd, rest, err := root.Find(strings.Fields(path))
+	if err != nil || cmd == root || len(rest) > 0 {
+		return nil
+	}
+	return cmd
+}
+
+// commandPath is the path of a command without "daily"
+func commandPath(cmd *cobra.Command) string {
+	return strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+}
+
+// topicOf returns the topic a command is listed under
+func topicOf(path string) (helpTopic, bool) {
+	for _, topic := range helpTopics {
+		for _, p := range topic.Commands {
+			if p == path {
+				return topic, true
+			}
+		}
+	}
+	return helpTopic{}, false
+}
+
+// printCommandList prints command paths with their short descriptions
+func printCommandList(root *cobra.Command, paths []string, indent string) {
+	width := 0
+	for _, p := range paths {
+		width = max(width, len(p))
+	}
+	for _, p := range paths {
+		if cmd := findCommand(root, p); cmd != nil {
+			fmt.Printf("%s%-*s  %s\n", indent, width, p, cmd.Short)
+		}
+	}
+}
+
+// printRootHelp is `daily help`: the essentials and the topics rather than
+// every command
+func printRootHelp(root *cobra.Command) {
+	fmt.Printf("%s\n\nUsage:\n  %s [command]\n\nStart with:\n", root.Short, root.Name())
+	printCommandList(root, essentialCommands, "  ")
+	fmt.Println("\nTopics:")
+	for _, topic := range helpTopics {
+		fmt.Printf("  %-9s %s\n", topic.Name, topic.Summary)
+	}
+	fmt.Println("\nRun `daily help <command>` for a command's flags and examples, `daily examples <topic>`")
+	fmt.Println("for examples to copy, or `daily help all` for every command.")
+}
+
+// printSeeAlso follows a command's help with the other commands of its topic
+func printSeeAlso(cmd *cobra.Command) {
+	path := commandPath(cmd)
+	topic, ok := topicOf(path)
+	if !ok {
+		return
+	}
+	var others []string
+	for _, p := range topic.Commands {
+		if p != path && findCommand(cmd.Root(), p) != nil {
+			others = append(others, p)
+		}
+	}
+	fmt.Printf("\nSee also (%s): %s\n", topic.Name, strings.Join(others, ", "))
+	fmt.Printf("More examples: daily examples %s\n", topic.Name)
+}
+
+// printCommandExamples prints a command's examples under its path
+func printCommandExamples(cmd *cobra.Command) bool {
+	examples := commandExamples[commandPath(cmd)]
+	if len(examples) == 0 {
+		return false
+	}
+	fmt.Printf("%s - %s\n%s\n", cmd.CommandPath(), cmd.Short, formatExamples(examples))
+	return true
+}
+
+// runExamples prints the examples of a topic or a command, or lists the
+// topics
+func runExamples(root *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		for _, topic := range helpTopics {
+			fmt.Printf("%s: %s\n", topic.Name, topic.Summary)
+			var names []string
+			for _, p := range topic.Commands {
+				if len(commandExamples[p]) > 0 {
+					names = append(names, p)
+				}
+			}
+			fmt.Printf("  %s\n\n", strings.Join(names, ", "))
+		}
+		fmt.Println("Run `daily examples <topic>` or `daily examples <command>`.")
+		return nil
+	}
+	query := strings.ToLower(strings.Join(args, " "))
+	for _, topic := range helpTopics {
+		if topic.Name != query {
+			continue
+		}
+		first := true
+		for _, p := range topic.Commands {
+			cmd := findCommand(root, p)
+			if cmd == nil || len(commandExamples[p]) == 0 {
+				continue
+			}
+			if !first {
+				fmt.Println()
+			}
+			first = false
+			printCommandExamples(cmd)
+		}
+		return nil
+	}
+	cmd := findCommand(root, query)
+	if cmd == nil {
+		var names []string
+		for _, topic := range helpTopics {
+			names = append(names, topic.Name)
+		}
+		return fmt.Errorf("no topic or command %q (topics: %s)", query, strings.Join(names, ", "))
+	}
+	if !printCommandExamples(cmd) {
+		fmt.Printf("No examples for %s yet; see `daily help %s`.\n", commandPath(cmd), commandPath(cmd))
+	}
+	return nil
+}
+
+// setupHelp attaches the examples to the commands and replaces the help so
+// it starts small: `daily help all` has every command
+func setupHelp(root *cobra.Command) {
+	for path, examples := range commandExamples {
+		if cmd := findCommand(root, path); cmd != nil {
+			cmd.Example = formatExamples(examples)
+		}
+	}
+
+	defaultHelp := root.HelpFunc()
+	root.SetHelpFunc(func(cmd *cobra.Command, args []string) {
+		if cmd == root {
+			printRootHelp(root)
+			return
+		}
+		defaultHelp(cmd, args)
+		printSeeAlso(cmd)
+	})
+	root.SetHelpCommand(&cobra.Command{
+		Use:   "help [command|all]",
+		Short: "Help about any command",
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 1 && args[0] == "all" {
+				defaultHelp(root, nil)
+				return
+			}
+			target, _, err := root.Find(args)
+			if target == nil || err != nil {
+				fmt.Printf("Unknown help topic %q\n", strings.Join(args, " "))
+				printRootHelp(root)
+				return
+			}
+			target.InitDefaultHelpFlag()
+			target.Help()
+		},
+	})
+}
@@ -0,0 +1,338 @@
+// export.go - Data exports
+// Writes tracked time in the flat formats payroll and ERP imports expect
+
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExportConfig is the `export` section of config.yaml
+type ExportConfig struct {
+	Sessions SessionsCSVConfig `yaml:"sessions"`
+}
+
+// SessionsCSVConfig controls the columns and formats of `export sessions-csv`
+type SessionsCSVConfig struct {
+	Columns    []string `yaml:"columns"`
+	DateFormat string   `yaml:"date_format"` // Go layout, default 2006-01-02
+	TimeFormat string   `yaml:"time_format"` // Go layout, default 15:04
+	Delimiter  string   `yaml:"delimiter"`   // default ","
+	Header     *bool    `yaml:"header"`      // default true
+}
+
+var defaultSessionColumns = []string{"date", "start", "end", "task", "project"}
+
+// sessionColumns maps column names to how they are rendered
+var sessionColumns = map[string]func(day string, t Task, s Session, cfg SessionsCSVConfig) string{
+	"date": func(day string, t Task, s Session, cfg SessionsCSVConfig) string {
+		return time.Unix(s.Start, 0).Format(cfg.DateFormat)
+	},
+	"day": func(day string, t Task, s Session, cfg SessionsCSVConfig) string { return day },
+	"start": func(day string, t Task, s Session, cfg SessionsCSVConfig) string {
+		return time.Unix(s.Start, 0).Format(cfg.TimeFormat)
+	},
+	"end": func(day string, t Task, s Session, cfg SessionsCSVConfig) string {
+		return time.Unix(s.End, 0).Format(cfg.TimeFormat)
+	},
+	"minutes": func(day string, t Task, s Session, cfg SessionsCSVConfig) string {
+		return strconv.FormatInt((s.End-s.Start)/60, 10)
+	},
+	"hours": func(day string, t Task, s Session, cfg SessionsCSVConfig) string {
+		return strconv.FormatFloat(float64(s.End-s.Start)/3600, 'f', 2, 64)
+	},
+	"duration": func(day string, t Task, s Session, cfg SessionsCSVConfig) string {
+		return exportMinutes(int((s.End - s.Start) / 60))
+	},
+	"task":    func(day string, t Task, s Session, cfg SessionsCSVConfig) string { return t.Title },
+	"project": func(day string, t Task, s Session, cfg SessionsCSVConfig) string { return t.Project },
+	"tags": func(day string, t Task, s Session, cfg SessionsCSVConfig) string {
+		return strings.Join(t.Tags, " ")
+	},
+	"status": func(day string, t Task, s Session, cfg SessionsCSVConfig) string { return t.Status },
+	"issue":  func(day string, t Task, s Session, cfg SessionsCSVConfig) string { return t.IssueKey },
+}
+
+func sessionsCSVConfig() (SessionsCSVConfig, error) {
+	cfg := config.Export.Sessions
+	if len(cfg.Columns) == 0 {
+		cfg.Columns = defaultSessionColumns
+	}
+	for _, col := range cfg.Columns {
+		if _, ok := sessionColumns[col]; !ok {
+			var known []string
+			for name := range sessionColumns {
+				known = append(known, name)
+			}
+			sort.Strings(known)
+			return cfg, fmt.Errorf("unknown column %q in export.sessions.columns (known: %s)", col, strings.Join(known, ", "))
+		}
+	}
+	if cfg.DateFormat == "" {
+		cfg.DateFormat = "2006-01-02"
+	}
+	if cfg.TimeFormat == "" {
+		cfg.TimeFormat = "15:04"
+	}
+	if cfg.Delimiter == "" {
+		cfg.Delimiter = ","
+	}
+	if len([]rune(cfg.Delimiter)) != 1 {
+		return cfg, fmt.Errorf("export.sessions.delimiter must be a single character")
+	}
+	return cfg, nil
+}
+
+// writeSessionsCSV writes one row per recorded work session between from and
+// to (inclusive), oldest first. Non-work entries are skipped.
+func writeSessionsCSV(w io.Writer, data TaskData, from, to string, cfg SessionsCSVConfig) (int, error) {
+	type row struct {
+		day     string
+		task    Task
+		session Session
+	}
+	var rows []row
+	for day, tasks := range data {
+		if day < from || day > to {
+			continue
+		}
+		for _, t := range tasks {
+			if isNonWork(t) {
+				continue
+			}
+			for _, s := range t.Sessions {
+				if s.End == 0 {
+					continue
+				}
+				rows = append(rows, row{day, t, s})
+			}
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].session.Start < rows[j].session.Start })
+
+	out := csv.NewWriter(w)
+	out.Comma = []rune(cfg.Delimiter)[0]
+	if cfg.Header == nil || *cfg.Header {
+		out.Write(cfg.Columns)
+	}
+	for _, r := range rows {
+		record := make([]string, len(cfg.Columns))
+		for i, col := range cfg.Columns {
+			record[i] = sessionColumns[col](r.day, r.task, r.session, cfg)
+		}
+		out.Write(record)
+	}
+	out.Flush()
+	return len(rows), out.Error()
+}
+
+// exportRange fills in and checks the --from/--to dates
+func exportRange(from, to string) (string, string, error) {
+	if to == "" {
+		to = todayKey()
+	}
+	if from == "" {
+		// Default to the current month
+		from = to[:8] + "01"
+	}
+	for _, d := range []string{from, to} {
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			return "", "", fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", d)
+		}
+	}
+	return from, to, nil
+}
+
+// writeExport runs write against output, or stdout when output is empty, and
+// returns the number of rows written
+func writeExport(output string, write func(io.Writer) (int, error)) (int, error) {
+	if output == "" {
+		return write(os.Stdout)
+	}
+	file, err := os.Create(output)
+	if err != nil {
+		return 0, err
+	}
+	count, err := write(file)
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	return count, err
+}
+
+// exportSessionsCSV writes the sessions of a date range to output, or stdout
+// when empty, optionally only those of one project
+func exportSessionsCSV(from, to, output, project string) error {
+	from, to, err := exportRange(from, to)
+	if err != nil {
+		return err
+	}
+	cfg, err := sessionsCSVConfig()
+	if err != nil {
+		return err
+	}
+	data, err := loadTasksRange(from, to)
+	if err != nil {
+		return err
+	}
+	data = filterProject(data, project)
+	count, err := writeExport(output, func(w io.Writer) (int, error) {
+		return writeSessionsCSV(w, data, from, to, cfg)
+	})
+	if err != nil || output == "" {
+		return err
+	}
+	fmt.Printf("Wrote %d sessions from %s to %s into %s\n", count, from, to, output)
+	return nil
+}
+
+var timesheetColumns = []string{"date", "title", "estimated", "actual", "status", "tags"}
+
+// writeTimesheetCSV writes one row per task between from and to (inclusive),
+// oldest day first and in list order within a day. Running tasks count the
+// time tracked so far.
+func writeTimesheetCSV(w io.Writer, data TaskData, from, to string, now time.Time) (int, error) {
+	var days []string
+	for day := range data {
+		if day >= from && day <= to {
+			days = append(days, day)
+		}
+	}
+	sort.Strings(days)
+
+	out := csv.NewWriter(w)
+	out.Write(timesheetColumns)
+	count := 0
+	for _, day := range days {
+		for _, t := range data[day] {
+			out.Write([]string{
+				day,
+				t.Title,
+				exportMinutes(t.Estimated),
+				exportMinutes(t.trackedMinutes(now.Unix())),
+				t.Status,
+				strings.Join(t.Tags, " "),
+			})
+			count++
+		}
+	}
+	out.Flush()
+	return count, out.Error()
+}
+
+// exportTasks writes the tasks of a date range in the given format, optionally
+// only those of one project
+func exportTasks(format, from, to, output, project string) error {
+	if format != "csv" {
+		return fmt.Errorf("unknown format %q (supported: csv)", format)
+	}
+	from, to, err := exportRange(from, to)
+	if err != nil {
+		return err
+	}
+	data, err := loadTasksRange(from, to)
+	if err != nil {
+		return err
+	}
+	data = filterProject(data, project)
+	count, err := writeExport(output, func(w io.Writer) (int, error) {
+		return writeTimesheetCSV(w, data, from, to, time.Now())
+	})
+	if err != nil || output == "" {
+		return err
+	}
+	fmt.Printf("Wrote %d tasks from %s to %s into %s\n", count, from, to, output)
+	return nil
+}
+
+// icalEscape escapes text values as required by RFC 5545
+func icalEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// writeDayICal lays the day's open and finished tasks out back to back from
+// the start of the working day, in the order `next` works through them.
+// Tasks that would run into lunch continue after it.
+func writeDayICal(w io.Writer, day string, tasks []Task, now time.Time) (int, error) {
+	date, err := time.ParseInLocation("2006-01-02", day, time.Local)
+	if err != nil {
+		return 0, fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", day)
+	}
+	workStart, lunchStart, lunchEnd, _ := config.WorkHours.bounds(date)
+	indexes := make([]int, len(tasks))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sortByPriority(tasks, indexes)
+
+	stamp := now.UTC().Format("20060102T150405Z")
+	var b strings.Builder
+	b.WriteString("BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//daily-cli//day plan//EN\r\nCALSCALE:GREGORIAN\r\n")
+	b.WriteString("X-WR-CALNAME:" + icalEscape("daily plan "+day) + "\r\n")
+	event := func(uid string, start, end time.Time, t Task) {
+		b.WriteString("BEGIN:VEVENT\r\n")
+		b.WriteString("UID:" + uid + "@daily-cli\r\n")
+		b.WriteString("DTSTAMP:" + stamp + "\r\n")
+		b.WriteString("DTSTART:" + start.UTC().Format("20060102T150405Z") + "\r\n")
+		b.WriteString("DTEND:" + end.UTC().Format("20060102T150405Z") + "\r\n")
+		b.WriteString("SUMMARY:" + icalEscape(t.Title) + "\r\n")
+		description := fmt.Sprintf("%s, estimated %d min", t.Status, t.Estimated)
+		if len(t.Tags) > 0 {
+			description += ", #" + strings.Join(t.Tags, " #")
+		}
+		b.WriteString("DESCRIPTION:" + icalEscape(description) + "\r\n")
+		b.WriteString("END:VEVENT\r\n")
+	}
+
+	cursor, count := workStart, 0
+	for _, i := range indexes {
+		t := tasks[i]
+		minutes := t.Estimated
+		if t.Status == "done" && t.Actual > 0 {
+			minutes = t.Actual
+		}
+		if isNonWork(t) || t.Status == "cancelled" || minutes <= 0 {
+			continue
+		}
+		if !cursor.Before(lunchStart) && cursor.Before(lunchEnd) {
+			cursor = lunchEnd
+		}
+		end := cursor.Add(time.Duration(minutes) * time.Minute)
+		uid := fmt.Sprintf("%s-%d", day, i)
+		if cursor.Before(lunchStart) && end.After(lunchStart) && lunchEnd.After(lunchStart) {
+			event(uid+"a", cursor, lunchStart, t)
+			end = lunchEnd.Add(end.Sub(lunchStart))
+			event(uid+"b", lunchEnd, end, t)
+		} else {
+			event(uid, cursor, end, t)
+		}
+		cursor = end
+		count++
+	}
+	b.WriteString("END:VCALENDAR\r\n")
+	_, err = io.WriteString(w, b.String())
+	return count, err
+}
+
+// exportICal writes a day's plan as an iCalendar file to output, or stdout when empty
+func exportICal(day, output string) error {
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	count, err := writeExport(output, func(w io.Writer) (int, error) {
+		return writeDayICal(w, day, data[day], time.Now())
+	})
+	if err != nil || output == "" {
+		return err
+	}
+	fmt.Printf("Wrote %d tasks of %s into %s\n", count, day, output)
+	return nil
+}
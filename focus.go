@@ -0,0 +1,161 @@
+// focus.go - Per-day focus score and weekly report
+// Scores a day from its longest uninterrupted session, context switches, and interruptions
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Focus score weights: a 90-minute session earns the full session share,
+// while 10 context switches or 6 interruptions wipe out their share
+const (
+	focusFullSessionMinutes = 90
+	focusMaxSwitches        = 10
+	focusMaxInterruptions   = 6
+)
+
+// DayFocus describes how fragmented a day's work was
+type DayFocus struct {
+	Score           int `yaml:"score"`
+	LongestSession  int `yaml:"longest_session"`
+	ContextSwitches int `yaml:"context_switches"`
+	Interruptions   int `yaml:"interruptions"`
+	Sessions        int `yaml:"sessions"`
+}
+
+// computeFocus scores a day's tasks from 0 to 100
+func computeFocus(tasks []Task) DayFocus {
+	type span struct {
+		task  int
+		start int64
+		end   int64
+	}
+	var spans []span
+	var f DayFocus
+	for i, t := range tasks {
+		if isNonWork(t) {
+			continue
+		}
+		f.Interruptions += t.Interruptions
+		for _, s := range t.Sessions {
+			if s.End == 0 {
+				continue
+			}
+			spans = append(spans, span{task: i, start: s.Start, end: s.End})
+		}
+	}
+	if len(spans) == 0 {
+		return f
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	f.Sessions = len(spans)
+	for i, s := range spans {
+		minutes := int((s.end - s.start) / 60)
+		if minutes > f.LongestSession {
+			f.LongestSession = minutes
+		}
+		if i > 0 && spans[i-1].task != s.task {
+			f.ContextSwitches++
+		}
+	}
+
+	session := math.Min(float64(f.LongestSession)/focusFullSessionMinutes, 1)
+	switches := math.Max(0, 1-float64(f.ContextSwitches)/focusMaxSwitches)
+	interruptions := math.Max(0, 1-float64(f.Interruptions)/focusMaxInterruptions)
+	f.Score = int(math.Round(40*session + 30*switches + 30*interruptions))
+	return f
+}
+
+// printFocus prints a day's focus breakdown
+func printFocus(f DayFocus) {
+	if f.Sessions == 0 {
+		fmt.Println("Focus score:   n/a (no tracked sessions)")
+		return
+	}
+	fmt.Printf("Focus score:   %d/100 (longest session %d min, %d context switches, %d interruptions)\n",
+		f.Score, f.LongestSession, f.ContextSwitches, f.Interruptions)
+}
+
+// showWeekReport prints one line per day of the week containing `day`,
+// preferring recorded close metrics over recomputing from raw tasks
+func showWeekReport(day string) error {
+	date, err := time.Parse("2006-01-02", day)
+	if err != nil {
+		return fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", day)
+	}
+	monday := weekMonday(date)
+	data, err := loadTasksRange(monday.Format("2006-01-02"), monday.AddDate(0, 0, 6).Format("2006-01-02"))
+	if err != nil {
+		return err
+	}
+	metrics, err := loadMetrics()
+	if err != nil {
+		return err
+	}
+	off, err := loadDaysOff()
+	if err != nil {
+		return err
+	}
+	today := dayOf(time.Now()).Format("2006-01-02")
+
+	fmt.Printf("Week of %s:\n\n", monday.Format("2006-01-02"))
+	fmt.Printf("%-14s %9s %9s %8s %7s %10s\n", "Day", "Planned", "Worked", "Done", "Focus", "Unplanned")
+
+	totalPlanned, totalWorked, scoreSum, scored, daysOff := 0, 0, 0, 0, 0
+	var split planSplit
+	for i := 0; i < 7; i++ {
+		d := monday.AddDate(0, 0, i)
+		key := d.Format("2006-01-02")
+		m, closed := metrics[key]
+		if !closed {
+			if len(data[key]) == 0 {
+				// A workday without anything recorded is left out of the
+				// totals, but said to be missing rather than skipped quietly
+				reason, isOff := dayOffReason(d, off)
+				switch {
+				case isOff && reason != "weekend":
+					fmt.Printf("%-14s off (%s)\n", d.Format("Mon 2006-01-02"), reason)
+					daysOff++
+				case !isOff && key < today:
+					fmt.Printf("%-14s untracked, see `daily backfill`\n", d.Format("Mon 2006-01-02"))
+				}
+				continue
+			}
+			m = computeDayMetrics(data[key])
+			m.Focus = computeFocus(data[key])
+		}
+		focus := "n/a"
+		if m.Focus.Sessions > 0 {
+			focus = fmt.Sprintf("%d", m.Focus.Score)
+			scoreSum += m.Focus.Score
+			scored++
+		}
+		daySplit := splitDay(data[key], key)
+		split.add(daySplit)
+		unplanned := "n/a"
+		if daySplit.known() {
+			unplanned = fmt.Sprintf("%.0f%%", daySplit.ratio()*100)
+		}
+		fmt.Printf("%-14s %9s %9s %5d/%-2d %7s %10s\n",
+			d.Format("Mon 2006-01-02"), fmtMinutes(m.Planned), fmtMinutes(m.Worked), m.Done, m.Tasks, focus, unplanned)
+		totalPlanned += m.Planned
+		totalWorked += m.Worked
+	}
+	fmt.Printf("\nTotal: %s planned, %s worked", fmtMinutes(totalPlanned), fmtMinutes(totalWorked))
+	if daysOff > 0 {
+		fmt.Printf(", %d off", daysOff)
+	}
+	fmt.Println()
+	if scored > 0 {
+		fmt.Printf("Average focus score: %d/100\n", scoreSum/scored)
+	}
+	if split.known() {
+		fmt.Printf("Unplanned: %s\n", describeSplit(split))
+	}
+	return nil
+}
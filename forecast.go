@@ -0,0 +1,137 @@
+// forecast.go - Plan forecasting
+// Estimates the chance of finishing a planned day from how similar past days went
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	// forecastLoadTolerance is how far (as a share of the planned minutes)
+	// a past day's load may be from the plan to count as similar
+	forecastLoadTolerance = 0.25
+	// forecastMinSamples is the fewest similar days needed for a forecast
+	forecastMinSamples = 3
+	// forecastWarnBelow is the finish probability under which plan warns
+	forecastWarnBelow = 0.5
+)
+
+// PlanForecast summarizes the past days with a similar load
+type PlanForecast struct {
+	Planned    int     // minutes planned on the forecast day
+	Samples    int     // similar past days
+	Finished   int     // of which had no open tasks left
+	Completion float64 // average share of planned minutes achieved
+	Weekday    bool    // whether samples are restricted to the same weekday
+}
+
+// Probability is the share of similar days where everything got done
+func (f PlanForecast) Probability() float64 {
+	return ratioOf(f.Finished, f.Samples)
+}
+
+// forecastPlan looks at days before `day` whose planned minutes are within
+// forecastLoadTolerance of the plan, preferring the same weekday when there
+// are enough of them. ok is false without enough history.
+func forecastPlan(data TaskData, day string, tasks []Task) (f PlanForecast, ok bool) {
+	date, err := time.Parse("2006-01-02", day)
+	if err != nil {
+		return f, false
+	}
+	f.Planned = computeDayMetrics(tasks).Planned
+	if f.Planned == 0 {
+		return f, false
+	}
+	tolerance := math.Max(30, float64(f.Planned)*forecastLoadTolerance)
+
+	var all, sameWeekday PlanForecast
+	for d, past := range data {
+		if d >= day {
+			continue
+		}
+		pastDate, err := time.Parse("2006-01-02", d)
+		if err != nil {
+			continue
+		}
+		m := computeDayMetrics(past)
+		if m.Planned == 0 || math.Abs(float64(m.Planned-f.Planned)) > tolerance {
+			continue
+		}
+		finished := true
+		for _, t := range past {
+			if !isNonWork(t) && !hasTag(t, "meeting") && isOpenStatus(t.Status) {
+				finished = false
+				break
+			}
+		}
+		completion := math.Min(1, float64(m.Achieved)/float64(m.Planned))
+		for _, bucket := range []*PlanForecast{&all, &sameWeekday} {
+			if bucket == &sameWeekday && pastDate.Weekday() != date.Weekday() {
+				continue
+			}
+			bucket.Samples++
+			bucket.Completion += completion
+			if finished {
+				bucket.Finished++
+			}
+		}
+	}
+
+	chosen := all
+	if sameWeekday.Samples >= forecastMinSamples {
+		chosen = sameWeekday
+		chosen.Weekday = true
+	}
+	if chosen.Samples < forecastMinSamples {
+		return f, false
+	}
+	chosen.Planned = f.Planned
+	chosen.Completion /= float64(chosen.Samples)
+	return chosen, true
+}
+
+// describe renders the forecast as one or two sentences
+func (f PlanForecast) describe(day string) string {
+	kind := "days"
+	if f.Weekday {
+		if date, err := time.Parse("2006-01-02", day); err == nil {
+			kind = date.Weekday().String() + "s"
+		}
+	}
+	return fmt.Sprintf("On %d past %s with about %d min planned, you finished everything %d times (%.0f%%) and got through %.0f%% of the planned minutes on average.",
+		f.Samples, kind, f.Planned, f.Finished, f.Probability()*100, f.Completion*100)
+}
+
+// confirmForecast prints the forecast for a plan about to be saved and, when
+// the day looks overambitious, asks whether to save it anyway
+func confirmForecast(data TaskData, day string) (bool, error) {
+	f, ok := forecastPlan(data, day, data[day])
+	if !ok {
+		return true, nil
+	}
+	fmt.Println(f.describe(day))
+	if f.Probability() >= forecastWarnBelow {
+		return true, nil
+	}
+	fmt.Println("This plan may be more than fits in a day; consider moving something to tomorrow.")
+	return confirmPrompt("Save this plan anyway")
+}
+
+// showForecast prints the forecast for a day's current plan
+func showForecast(day string) error {
+	data, err := loadTasksRange("", day)
+	if err != nil {
+		return err
+	}
+	f, ok := forecastPlan(data, day, data[day])
+	if ok {
+		fmt.Println(f.describe(day))
+	} else {
+		fmt.Printf("Not enough history yet to forecast %s (need %d past days with a similar load).\n", day, forecastMinSamples)
+	}
+	warnIfMeetingsCrowdOut(day, data[day])
+	return nil
+}
@@ -0,0 +1,104 @@
+// github.go - GitHub issues
+// Searches the GitHub issues assigned to me so `daily plan week` can offer them as tasks
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// GitHubConfig is the `github` section of config.yaml. The token is read
+// from GITHUB_TOKEN, as for publishing gists.
+type GitHubConfig struct {
+	BaseURL        string `yaml:"base_url"`        // default https://api.github.com; GitHub Enterprise uses https://host/api/v3
+	Query          string `yaml:"query"`           // issue search the planner offers
+	DefaultMinutes int    `yaml:"default_minutes"` // estimate of an issue, which GitHub doesn't have
+}
+
+const (
+	defaultGitHubURL   = "https://api.github.com"
+	defaultGitHubQuery = "is:open is:issue assignee:@me archived:false"
+)
+
+// githubIssue is the subset of an issue or pull request the planner needs
+type githubIssue struct {
+	Repo   string // owner/name
+	Number int
+	Title  string
+	URL    string
+}
+
+// Ref is how the issue is written in titles and links, e.g. owner/repo#12
+func (i githubIssue) Ref() string {
+	return fmt.Sprintf("%s#%d", i.Repo, i.Number)
+}
+
+// searchGitHubIssues runs the configured issue search
+func searchGitHubIssues() ([]githubIssue, error) {
+	if err := requireOnline("GitHub"); err != nil {
+		return nil, err
+	}
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("GITHUB_TOKEN is not set")
+	}
+	cfg := config.GitHub
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaultGitHubURL
+	}
+	if cfg.Query == "" {
+		cfg.Query = defaultGitHubQuery
+	}
+	query := url.Values{"q": {cfg.Query}, "per_page": {"100"}}
+	req, err := http.NewRequest(http.MethodGet, strings.TrimRight(cfg.BaseURL, "/")+"/search/issues?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GitHub returned %s", resp.Status)
+	}
+	var result struct {
+		Items []struct {
+			Number        int    `json:"number"`
+			Title         string `json:"title"`
+			HTMLURL       string `json:"html_url"`
+			RepositoryURL string `json:"repository_url"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	var issues []githubIssue
+	for _, item := range result.Items {
+		// repository_url ends with /repos/owner/name
+		_, repo, _ := strings.Cut(item.RepositoryURL, "/repos/")
+		issues = append(issues, githubIssue{Repo: repo, Number: item.Number, Title: item.Title, URL: item.HTMLURL})
+	}
+	return issues, nil
+}
+
+// githubTask is the task an issue becomes, its reference leading the title
+func githubTask(issue githubIssue) Task {
+	task := Task{
+		Title:      issue.Ref() + " " + issue.Title,
+		Estimated:  config.GitHub.DefaultMinutes,
+		Status:     "pending",
+		ExternalID: "github:" + issue.Ref(),
+	}
+	applyTagRules(&task)
+	return task
+}
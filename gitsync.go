@@ -0,0 +1,393 @@
+// gitsync.go - Git sync
+// `daily sync` commits the data directory to git and pulls and pushes a remote, merging the YAML files day by day
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SyncConfig is the `sync` section of config.yaml
+type SyncConfig struct {
+	Remote string `yaml:"remote"` // git URL to pull from and push to
+	Branch string `yaml:"branch"` // default main
+}
+
+func (s SyncConfig) branch() string {
+	if s.Branch == "" {
+		return "main"
+	}
+	return s.Branch
+}
+
+// syncIgnore keeps files that only make sense on one machine out of the repo
+const syncIgnore = `# Written by daily sync
+.lock
+.*.tmp-*
+*.bak.*
+undo.yaml
+daily.db
+heartbeat
+.*.sum
+`
+
+// gitRunner runs git in the data directory
+type gitRunner struct {
+	dir      string
+	identity []string // -c options used when git has no user configured
+}
+
+func (g gitRunner) run(args ...string) (string, error) {
+	cmd := exec.Command("git", append(append([]string{}, g.identity...), args...)...)
+	cmd.Dir = g.dir
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := cmd.Run()
+	if err != nil {
+		return out.String(), fmt.Errorf("git %s: %v\n%s", args[0], err, strings.TrimSpace(out.String()))
+	}
+	return out.String(), nil
+}
+
+// show returns a file as stored in a stage of the index (1 base, 2 ours,
+// 3 theirs), empty when that stage has no such file
+func (g gitRunner) show(stage int, name string) []byte {
+	out, err := exec.Command("git", "-C", g.dir, "show", fmt.Sprintf(":%d:%s", stage, name)).Output()
+	if err != nil {
+		return nil
+	}
+	return out
+}
+
+// openSyncRepo turns the data directory into a git repository on first use
+// and points `origin` at sync.remote
+func openSyncRepo(dir string) (gitRunner, error) {
+	g := gitRunner{dir: dir}
+	if _, err := exec.LookPath("git"); err != nil {
+		return g, fmt.Errorf("git is not installed")
+	}
+	if out, err := exec.Command("git", "-C", dir, "config", "user.email").Output(); err != nil || strings.TrimSpace(string(out)) == "" {
+		host, _ := os.Hostname()
+		g.identity = []string{"-c", "user.name=daily", "-c", "user.email=daily@" + host}
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".git")); os.IsNotExist(err) {
+		if _, err := g.run("init", "-q", "-b", config.Sync.branch()); err != nil {
+			return g, err
+		}
+		if err := os.WriteFile(filepath.Join(dir, ".gitignore"), []byte(syncIgnore), 0644); err != nil {
+			return g, err
+		}
+		fmt.Printf("Created a git repository in %s.\n", dir)
+	}
+	if config.Sync.Remote == "" {
+		return g, nil
+	}
+	if url, err := g.run("remote", "get-url", "origin"); err != nil {
+		if _, err := g.run("remote", "add", "origin", config.Sync.Remote); err != nil {
+			return g, err
+		}
+	} else if strings.TrimSpace(url) != config.Sync.Remote {
+		if _, err := g.run("remote", "set-url", "origin", config.Sync.Remote); err != nil {
+			return g, err
+		}
+	}
+	return g, nil
+}
+
+// commitLocal commits any change to the data files
+func (g gitRunner) commitLocal() (bool, error) {
+	unlock, err := lockDataDir()
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+	if _, err := g.run("add", "-A"); err != nil {
+		return false, err
+	}
+	if _, err := g.run("diff", "--cached", "--quiet"); err == nil {
+		return false, nil
+	}
+	host, _ := os.Hostname()
+	_, err = g.run("commit", "-q", "-m", "daily sync from "+host)
+	return err == nil, err
+}
+
+// mergeRemote merges the fetched branch, resolving conflicts in the data
+// files by date. It returns the days where both sides had changed. The merge
+// runs under the data directory lock, and a command that loaded the files
+// before merges its save with the synced state.
+func (g gitRunner) mergeRemote() ([]string, error) {
+	unlock, err := lockDataDir()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if _, err := g.run("merge", "-q", "--no-edit", "--allow-unrelated-histories", "FETCH_HEAD"); err == nil {
+		return nil, nil
+	}
+	out, err := g.run("diff", "--name-only", "--diff-filter=U")
+	if err != nil {
+		return nil, err
+	}
+	files := strings.Fields(out)
+	if len(files) == 0 {
+		g.run("merge", "--abort")
+		return nil, fmt.Errorf("git merge failed; see `git -C %s status`", g.dir)
+	}
+	var both []string
+	for _, name := range files {
+		merged, days, err := mergeDataFile(name, g.show(1, name), g.show(2, name), g.show(3, name))
+		if err != nil {
+			g.run("merge", "--abort")
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if err := writeFileAtomic(filepath.Join(g.dir, name), merged, 0644); err != nil {
+			g.run("merge", "--abort")
+			return nil, err
+		}
+		if _, err := g.run("add", name); err != nil {
+			return nil, err
+		}
+		for _, day := range days {
+			both = append(both, name+" "+day)
+		}
+	}
+	if _, err := g.run("commit", "-q", "--no-edit"); err != nil {
+		return nil, err
+	}
+	return both, nil
+}
+
+// mergeDataFile merges three versions of a data file by their date keys.
+// Days changed on one side take that side. Days changed on both are merged
+// task by task, or keep every note of both, unless the notes of one side are
+// encrypted; the tasks changed on both sides, or the days of such notes, are
+// returned.
+func mergeDataFile(name string, base, ours, theirs []byte) ([]byte, []string, error) {
+	switch {
+	case name == "tasks.yaml" || strings.HasPrefix(name, "archive/"):
+		var versions [3]TaskData
+		for i, content := range [][]byte{base, ours, theirs} {
+			data, _, err := decodeTaskData(content)
+			if err != nil {
+				return nil, nil, err
+			}
+			// IDs are derived from the task, so each side gives the same
+			// task the same ID even in files written before there were IDs
+			assignTaskIDs(data)
+			versions[i] = data
+		}
+		merged, days := mergeDays(versions[0], versions[1], versions[2])
+		var both []string
+		for _, day := range days {
+			tasks, titles := mergeDayTasks(versions[0][day], versions[1][day], versions[2][day])
+			if len(tasks) > 0 {
+				merged[day] = tasks
+			}
+			for _, title := range titles {
+				both = append(both, fmt.Sprintf("%s '%s'", day, title))
+			}
+		}
+		out, err := yaml.Marshal(TaskData(merged))
+		return out, both, err
+	case name == "notes.yaml":
+		var b, o, t NoteData
+		if err := decodeAll([][]byte{base, ours, theirs}, &b, &o, &t); err != nil {
+			return nil, nil, err
+		}
+		merged, days := mergeDays(b, o, t)
+		for i, day := range days {
+			// Encrypted notes are a single entry: two can't be combined
+			if isSealed(o[day]) || isSealed(t[day]) {
+				if merged[day] = o[day]; len(o[day]) == 0 {
+					merged[day] = t[day]
+				}
+				days[i] = day + " (encrypted notes, kept this machine's)"
+				continue
+			}
+			merged[day] = unionNotes(o[day], t[day])
+		}
+		out, err := yaml.Marshal(NoteData(merged))
+		return out, days, err
+	case name == "metrics.yaml":
+		var b, o, t MetricsData
+		if err := decodeAll([][]byte{base, ours, theirs}, &b, &o, &t); err != nil {
+			return nil, nil, err
+		}
+		out, err := yaml.Marshal(MetricsData(mergeKeys(b, o, t)))
+		return out, nil, err
+	case name == "plans.yaml":
+		var b, o, t SnapshotData
+		if err := decodeAll([][]byte{base, ours, theirs}, &b, &o, &t); err != nil {
+			return nil, nil, err
+		}
+		out, err := yaml.Marshal(SnapshotData(mergeKeys(b, o, t)))
+		return out, nil, err
+	case name == "people.yaml":
+		var b, o, t PeopleData
+		if err := decodeAll([][]byte{base, ours, theirs}, &b, &o, &t); err != nil {
+			return nil, nil, err
+		}
+		out, err := yaml.Marshal(PeopleData(mergeKeys(b, o, t)))
+		return out, nil, err
+	case name == "reservations.yaml":
+		var b, o, t ReservationData
+		if err := decodeAll([][]byte{base, ours, theirs}, &b, &o, &t); err != nil {
+			return nil, nil, err
+		}
+		out, err := yaml.Marshal(ReservationData(mergeKeys(b, o, t)))
+		return out, nil, err
+	case name == "daysoff.yaml":
+		var b, o, t DaysOffData
+		if err := decodeAll([][]byte{base, ours, theirs}, &b, &o, &t); err != nil {
+			return nil, nil, err
+		}
+		out, err := yaml.Marshal(DaysOffData(mergeKeys(b, o, t)))
+		return out, nil, err
+	}
+	return nil, nil, fmt.Errorf("changed on both machines and not a file daily can merge; resolve it with git")
+}
+
+// decodeAll decodes each version into its target; a missing version is empty
+func decodeAll(versions [][]byte, targets ...any) error {
+	for i, content := range versions {
+		if err := yaml.Unmarshal(content, targets[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeKeys merges maps keyed by date, ours winning where both changed a key
+func mergeKeys[V any](base, ours, theirs map[string]V) map[string]V {
+	merged := map[string]V{}
+	for key, value := range theirs {
+		merged[key] = value
+	}
+	for key, value := range ours {
+		if old, ok := base[key]; !ok || !reflect.DeepEqual(old, value) {
+			merged[key] = value
+		}
+	}
+	for key := range base {
+		if _, ok := ours[key]; !ok {
+			delete(merged, key)
+		}
+	}
+	return merged
+}
+
+// mergeDayTasks merges a day changed on both sides task by task, matching
+// tasks by ID against the base: a task changed, added or removed on one side
+// takes that side. A task changed on both keeps our version, or the version
+// of the side that still has it, and its title is returned.
+func mergeDayTasks(base, ours, theirs []Task) ([]Task, []string) {
+	byID := func(tasks []Task) map[string][]Task {
+		m := map[string][]Task{}
+		for _, t := range tasks {
+			m[t.ID] = []Task{t}
+		}
+		return m
+	}
+	merged, conflicts := mergeDays(byID(base), byID(ours), byID(theirs))
+	var titles []string
+	for _, id := range conflicts {
+		for _, side := range [][]Task{theirs, ours} {
+			for _, t := range side {
+				if t.ID == id {
+					merged[id] = []Task{t}
+				}
+			}
+		}
+		titles = append(titles, merged[id][0].Title)
+	}
+	// Our order first, then the tasks only they have
+	var result []Task
+	seen := map[string]bool{}
+	for _, side := range [][]Task{ours, theirs} {
+		for _, t := range side {
+			if m, ok := merged[t.ID]; ok && !seen[t.ID] {
+				seen[t.ID] = true
+				result = append(result, m[0])
+			}
+		}
+	}
+	return result, titles
+}
+
+// unionNotes keeps our notes and adds theirs that we don't have
+func unionNotes(ours, theirs []string) []string {
+	merged := append([]string{}, ours...)
+	for _, n := range theirs {
+		if !slices.Contains(merged, n) {
+			merged = append(merged, n)
+		}
+	}
+	return merged
+}
+
+// syncData commits local changes, merges the remote and pushes the result
+func syncData() error {
+	if err := requireOnline("Git sync"); err != nil {
+		return err
+	}
+	if config.Storage.Backend == "sqlite" {
+		return fmt.Errorf("sync works with the YAML files only; the sqlite backend keeps everything in daily.db")
+	}
+	dir, err := getDataDir()
+	if err != nil {
+		return err
+	}
+	g, err := openSyncRepo(dir)
+	if err != nil {
+		return err
+	}
+	// What the sync changes is daily's doing; changes from before it are not
+	before, err := checkIntegrity()
+	if err != nil {
+		return err
+	}
+	defer acceptSynced(before)
+	committed, err := g.commitLocal()
+	if err != nil {
+		return err
+	}
+	if committed {
+		fmt.Println("Committed local changes.")
+	}
+	if config.Sync.Remote == "" {
+		fmt.Println("No sync.remote configured; set it to share the data with other machines.")
+		return nil
+	}
+	branch := config.Sync.branch()
+	if out, err := g.run("fetch", "-q", "origin", branch); err != nil {
+		if !strings.Contains(out, "couldn't find remote ref") {
+			return err
+		}
+	} else {
+		both, err := g.mergeRemote()
+		if err != nil {
+			return err
+		}
+		sort.Strings(both)
+		for _, day := range both {
+			fmt.Printf("Changed on both machines, kept this machine's task or the notes of both: %s\n", day)
+		}
+	}
+	if _, err := g.run("push", "-q", "origin", "HEAD:"+branch); err != nil {
+		return err
+	}
+	fmt.Printf("Synced with %s (%s).\n", config.Sync.Remote, branch)
+	return nil
+}
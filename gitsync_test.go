@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestMergeDataFileKeepsOneSealedSide(t *testing.T) {
+	encode := func(data NoteData) []byte {
+		out, err := yaml.Marshal(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+	day := "2026-10-16"
+	base := encode(NoteData{day: {"plain"}})
+	ours := encode(NoteData{day: {sealedPrefix + "ours"}})
+	theirs := encode(NoteData{day: {sealedPrefix + "theirs"}})
+
+	out, days, err := mergeDataFile("notes.yaml", base, ours, theirs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var merged NoteData
+	if err := yaml.Unmarshal(out, &merged); err != nil {
+		t.Fatal(err)
+	}
+	if !isSealed(merged[day]) || merged[day][0] != sealedPrefix+"ours" {
+		t.Errorf("merged notes = %q, want our sealed entry only", merged[day])
+	}
+	if len(days) != 1 {
+		t.Errorf("reported days = %q, want the sealed day", days)
+	}
+}
@@ -0,0 +1,127 @@
+// group.go - Grouped task listing
+// Renders tasks in sections by project, tag, or status with per-group subtotals
+
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/manifoldco/promptui"
+)
+
+const (
+	noProjectGroup = "(no project)"
+	noTagGroup     = "(untagged)"
+)
+
+var validGroupBy = []string{"project", "tag", "status"}
+
+// listRow is a single line in the grouped list: either a group header or a task
+type listRow struct {
+	Header    bool
+	Group     string
+	Collapsed bool
+	Count     int
+	Estimated int
+	Actual    int
+	Index     int
+	Task      Task
+	WaitsFor  string // IDs of the open tasks the task waits for
+}
+
+// checkGroupBy validates the value of the --group-by flag
+func checkGroupBy(groupBy string) error {
+	if groupBy == "" {
+		return nil
+	}
+	for _, g := range validGroupBy {
+		if g == groupBy {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid --group-by %q (expected project, tag, or status)", groupBy)
+}
+
+// groupKeys returns the groups a task belongs to; a task may carry several tags
+func groupKeys(t Task, groupBy string) []string {
+	switch groupBy {
+	case "project":
+		if t.Project == "" {
+			return []string{noProjectGroup}
+		}
+		return []string{t.Project}
+	case "tag":
+		if len(t.Tags) == 0 {
+			return []string{noTagGroup}
+		}
+		return t.Tags
+	default:
+		return []string{t.Status}
+	}
+}
+
+// buildGroupRows lays out the tasks passing `include` under their group
+// headers, hiding the tasks of collapsed groups
+func buildGroupRows(tasks []Task, groupBy string, collapsed map[string]bool, include func(Task) bool) []listRow {
+	members := map[string][]int{}
+	var order []string
+	for i, t := range tasks {
+		if !include(t) {
+			continue
+		}
+		for _, key := range groupKeys(t, groupBy) {
+			if _, ok := members[key]; !ok {
+				order = append(order, key)
+			}
+			members[key] = append(members[key], i)
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		// Keep the catch-all groups at the bottom
+		iNone := order[i] == noProjectGroup || order[i] == noTagGroup
+		jNone := order[j] == noProjectGroup || order[j] == noTagGroup
+		if iNone != jNone {
+			return jNone
+		}
+		return order[i] < order[j]
+	})
+
+	var rows []listRow
+	for _, key := range order {
+		sortByPriority(tasks, members[key])
+		header := listRow{Header: true, Group: key, Collapsed: collapsed[key]}
+		for _, i := range members[key] {
+			header.Count++
+			header.Estimated += tasks[i].Estimated
+			header.Actual += tasks[i].Actual
+		}
+		rows = append(rows, header)
+		if collapsed[key] {
+			continue
+		}
+		for _, i := range members[key] {
+			rows = append(rows, listRow{Group: key, Index: i, Task: tasks[i]})
+		}
+	}
+	return rows
+}
+
+// groupedSelect builds the select prompt used by ls when grouping is enabled
+func groupedSelect(rows []listRow) promptui.Select {
+	templates := &promptui.SelectTemplates{
+		Label: "{{ . }}",
+		Active: `{{ if .Header }}→ {{ if .Collapsed }}▸{{ else }}▾{{ end }} {{ .Group | bold }} ({{ .Count }} tasks, est: {{ .Estimated | dur }}, act: {{ .Actual | dur }})` +
+			`{{ else }}→   {{ .Task.ID | faint }} {{ with .Task }}` + priorityTemplate + `{{ end }}{{ .Task.Title | cyan }} ({{ .Task.Status | yellow }}, est: {{ .Task.Estimated | dur }}, act: {{ .Task.Actual | dur }})` + waitsForTemplate + `{{ end }}`,
+		Inactive: `{{ if .Header }}  {{ if .Collapsed }}▸{{ else }}▾{{ end }} {{ .Group | bold }} ({{ .Count }} tasks, est: {{ .Estimated | dur }}, act: {{ .Actual | dur }})` +
+			`{{ else }}    {{ .Task.ID | faint }} {{ with .Task }}` + priorityTemplate + `{{ end }}{{ .Task.Title }} ({{ .Task.Status | yellow }}, est: {{ .Task.Estimated | dur }}, act: {{ .Task.Actual | dur }})` + waitsForTemplate + `{{ end }}`,
+		Selected: `{{ if .Header }}{{ .Group }}{{ else }}✔ {{ .Task.Title }}{{ end }}`,
+	}
+	return promptui.Select{
+		Label:     "View/Edit Tasks (select a group to collapse/expand)",
+		Items:     rows,
+		Templates: templates,
+		Size:      15,
+		HideHelp:  true,
+	}
+}
@@ -0,0 +1,172 @@
+// heartbeat.go - Crash-safe timer
+// Running daily processes leave a heartbeat; when a task is still running long after the last one, or the machine restarted since, the next command asks how much of the gap to count
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/manifoldco/promptui"
+)
+
+// HeartbeatConfig is the `heartbeat` section of config.yaml
+type HeartbeatConfig struct {
+	StaleAfter *time.Duration `yaml:"stale_after"` // gap that needs confirming, default 2h; 0s turns the check off
+}
+
+const (
+	defaultStaleAfter = 2 * time.Hour
+	heartbeatEvery    = time.Minute
+)
+
+func (h HeartbeatConfig) staleAfter() time.Duration {
+	if h.StaleAfter == nil {
+		return defaultStaleAfter
+	}
+	return *h.StaleAfter
+}
+
+func heartbeatPath() (string, error) {
+	return dataFilePath("heartbeat")
+}
+
+// touchHeartbeat records that a daily process was alive at `now`
+func touchHeartbeat(now time.Time) {
+	path, err := heartbeatPath()
+	if err != nil {
+		return
+	}
+	writeFileAtomic(path, []byte(strconv.FormatInt(now.Unix(), 10)+"\n"), 0644)
+}
+
+// lastHeartbeat returns when a daily process was last seen alive
+func lastHeartbeat() (time.Time, bool) {
+	path, err := heartbeatPath()
+	if err != nil {
+		return time.Time{}, false
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return time.Time{}, false
+	}
+	seen, err := strconv.ParseInt(strings.TrimSpace(string(content)), 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(seen, 0), true
+}
+
+// bootTime returns when the machine started, where the platform tells
+func bootTime() (time.Time, bool) {
+	file, err := os.Open("/proc/stat")
+	if err != nil {
+		return time.Time{}, false
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), "btime "); ok {
+			if secs, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64); err == nil {
+				return time.Unix(secs, 0), true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// heartbeatOnce keeps to one heartbeat loop per process: the shell runs a
+// command for every line it reads
+var heartbeatOnce sync.Once
+
+// startHeartbeat keeps the heartbeat fresh for as long as the process runs,
+// which covers follow, watch, tui and serve
+func startHeartbeat() {
+	touchHeartbeat(time.Now())
+	heartbeatOnce.Do(func() {
+		go func() {
+			for now := range time.Tick(heartbeatEvery) {
+				touchHeartbeat(now)
+			}
+		}()
+	})
+}
+
+// checkRunningTimer asks what to do with a running task whose clock may be
+// stale: the machine restarted after the last heartbeat, or nothing has been
+// heard from daily for longer than heartbeat.stale_after. It reports whether
+// it asked.
+func checkRunningTimer(now time.Time) (bool, error) {
+	stale := config.Heartbeat.staleAfter()
+	if stale <= 0 || !isTerminal() {
+		return false, nil
+	}
+	seen, ok := lastHeartbeat()
+	if !ok {
+		return false, nil
+	}
+	data, err := loadTasks()
+	if err != nil {
+		return false, err
+	}
+	for _, day := range sortedDays(data) {
+		for i := range data[day] {
+			t := &data[day][i]
+			since := t.runningSince()
+			if t.Status != "started" || since == 0 {
+				continue
+			}
+			last := seen
+			if last.Unix() < since {
+				last = time.Unix(since, 0)
+			}
+			boot, booted := bootTime()
+			restarted := booted && boot.After(last)
+			if !restarted && now.Sub(last) < stale {
+				continue
+			}
+			reason := fmt.Sprintf("nothing was heard from daily for %s", fmtMinutes(int(now.Sub(last).Minutes())))
+			if restarted {
+				reason = "the machine restarted since"
+			}
+			fmt.Printf("'%s' has been running since %s, but daily was last seen at %s and %s.\n",
+				t.Title, time.Unix(since, 0).Format("Jan 2 15:04"), last.Format("Jan 2 15:04"), reason)
+			prompt := promptui.Select{
+				Label: "How much of that time counts",
+				Items: []string{
+					"All of it: keep the timer running",
+					fmt.Sprintf("Until %s: stop the timer there", last.Format("15:04")),
+					fmt.Sprintf("Until %s, and keep the timer running from now", last.Format("15:04")),
+				},
+				HideHelp: true,
+			}
+			choice, _, err := prompt.Run()
+			if err != nil {
+				if err.Error() == "interrupt" {
+					return true, nil
+				}
+				return true, err
+			}
+			switch choice {
+			case 0:
+				// The rollover and the idle check count it in full too
+				return true, acknowledgeIdle(since)
+			case 1:
+				t.endSession(last.Unix())
+				t.setStatus("paused", now)
+				fmt.Printf("Paused '%s' at %s; resume it with `daily resume`.\n", t.Title, last.Format("15:04"))
+			case 2:
+				t.endSession(last.Unix())
+				t.setStatus("started", now)
+				fmt.Printf("Counted '%s' until %s; the timer runs again from now.\n", t.Title, last.Format("15:04"))
+			}
+			return true, saveTasks(data)
+		}
+	}
+	return false, nil
+}
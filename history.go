@@ -0,0 +1,297 @@
+// history.go - Task history
+// Keeps each task's status changes and estimate revisions, and `daily show` prints a task's whole record as Markdown or JSON to attach to a ticket or a retro
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TaskEvent is one change in a task's history
+type TaskEvent struct {
+	At    int64  `yaml:"at" json:"at"`
+	Field string `yaml:"field" json:"field"` // status or estimate
+	From  string `yaml:"from,omitempty" json:"from,omitempty"`
+	To    string `yaml:"to" json:"to"`
+}
+
+// logChange records a change of a field, when it is one
+func (t *Task) logChange(field, from, to string, now time.Time) {
+	if from == to {
+		return
+	}
+	t.History = append(t.History, TaskEvent{At: now.Unix(), Field: field, From: from, To: to})
+}
+
+// setEstimate revises the estimate, keeping the previous one in the history
+func (t *Task) setEstimate(minutes int, now time.Time) {
+	t.logChange("estimate", strconv.Itoa(t.Estimated), strconv.Itoa(minutes), now)
+	t.Estimated = minutes
+}
+
+// taskRecord is everything known about a task, as `show --json` prints it
+type taskRecord struct {
+	Day        string           `json:"day"`
+	Title      string           `json:"title"`
+	Status     string           `json:"status"`
+	Estimated  int              `json:"estimated"`
+	Actual     int              `json:"actual"`
+	Priority   string           `json:"priority,omitempty"`
+	Project    string           `json:"project,omitempty"`
+	Epic       string           `json:"epic,omitempty"`
+	Context    string           `json:"context,omitempty"`
+	Tags       []string         `json:"tags,omitempty"`
+	PlannedAt  string           `json:"planned_at,omitempty"`
+	CreatedAt  string           `json:"created_at,omitempty"`
+	Links      []taskLink       `json:"links,omitempty"`
+	Comments   []string         `json:"comments,omitempty"`
+	Sessions   []sessionRecord  `json:"sessions"`
+	History    []taskEventEntry `json:"history"`
+	Interrupts int              `json:"interruptions,omitempty"`
+}
+
+type taskLink struct {
+	Kind string `json:"kind"`
+	Ref  string `json:"ref"`
+	URL  string `json:"url,omitempty"`
+}
+
+type sessionRecord struct {
+	Start   string `json:"start"`
+	End     string `json:"end,omitempty"` // empty while running
+	Minutes int    `json:"minutes"`
+}
+
+type taskEventEntry struct {
+	At    string `json:"at"`
+	Field string `json:"field"`
+	From  string `json:"from,omitempty"`
+	To    string `json:"to"`
+}
+
+const recordTime = "2006-01-02 15:04"
+
+// taskLinks lists what a task points to outside daily
+func taskLinks(t Task) []taskLink {
+	var links []taskLink
+	if t.IssueKey != "" {
+		link := taskLink{Kind: "jira", Ref: t.IssueKey}
+		if base := strings.TrimRight(config.Jira.BaseURL, "/"); base != "" {
+			link.URL = base + "/browse/" + t.IssueKey
+		}
+		links = append(links, link)
+	}
+	if key, ok := strings.CutPrefix(t.ExternalID, "person:"); ok {
+		links = append(links, taskLink{Kind: "person", Ref: key})
+	} else if ref, ok := strings.CutPrefix(t.ExternalID, "github:"); ok {
+		link := taskLink{Kind: "github", Ref: ref}
+		if repo, number, ok := strings.Cut(ref, "#"); ok && config.GitHub.BaseURL == "" {
+			link.URL = "https://github.com/" + repo + "/issues/" + number
+		}
+		links = append(links, link)
+	} else if t.ExternalID != "" {
+		links = append(links, taskLink{Kind: "calendar", Ref: t.ExternalID})
+	}
+	return links
+}
+
+func buildTaskRecord(day string, t Task, now time.Time) taskRecord {
+	r := taskRecord{
+		Day:        day,
+		Title:      t.Title,
+		Status:     t.Status,
+		Estimated:  t.Estimated,
+		Actual:     t.trackedMinutes(now.Unix()),
+		Priority:   priorityLabel(t.Priority),
+		Project:    t.Project,
+		Epic:       t.Epic,
+		Context:    t.Context,
+		Tags:       t.Tags,
+		PlannedAt:  t.PlannedAt,
+		Links:      taskLinks(t),
+		Sessions:   []sessionRecord{},
+		History:    []taskEventEntry{},
+		Interrupts: t.Interruptions,
+	}
+	if t.CreatedAt > 0 {
+		r.CreatedAt = time.Unix(t.CreatedAt, 0).Format(recordTime)
+	}
+	if t.ResumeNote != "" {
+		r.Comments = append(r.Comments, t.ResumeNote)
+	}
+	for _, n := range t.Notes {
+		r.Comments = append(r.Comments, time.Unix(n.At, 0).Format(recordTime)+" "+n.Text)
+	}
+	for _, s := range t.Sessions {
+		entry := sessionRecord{Start: time.Unix(s.Start, 0).Format(recordTime)}
+		end := s.End
+		if end == 0 {
+			end = now.Unix()
+		} else {
+			entry.End = time.Unix(s.End, 0).Format(recordTime)
+		}
+		entry.Minutes = int((end - s.Start) / 60)
+		r.Sessions = append(r.Sessions, entry)
+	}
+	for _, e := range t.History {
+		r.History = append(r.History, taskEventEntry{At: time.Unix(e.At, 0).Format(recordTime), Field: e.Field, From: e.From, To: e.To})
+	}
+	return r
+}
+
+// renderTaskRecord writes the record as a Markdown document
+func renderTaskRecord(r taskRecord) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", r.Title)
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "- **%s:** %s\n", name, value)
+		}
+	}
+	field("Day", r.Day)
+	field("Status", r.Status)
+	field("Estimate", fmtMinutes(r.Estimated))
+	field("Tracked", fmtMinutes(r.Actual))
+	field("Priority", r.Priority)
+	field("Project", r.Project)
+	field("Epic", r.Epic)
+	if r.Context != "" {
+		field("Context", "@"+r.Context)
+	}
+	if len(r.Tags) > 0 {
+		field("Tags", "#"+strings.Join(r.Tags, ", #"))
+	}
+	field("Planned at", r.PlannedAt)
+	field("Created", r.CreatedAt)
+	if r.Interrupts > 0 {
+		field("Interruptions", strconv.Itoa(r.Interrupts))
+	}
+	if len(r.Links) > 0 {
+		b.WriteString("\n## Links\n\n")
+		for _, l := range r.Links {
+			if l.URL != "" {
+				fmt.Fprintf(&b, "- %s: [%s](%s)\n", l.Kind, l.Ref, l.URL)
+			} else {
+				fmt.Fprintf(&b, "- %s: %s\n", l.Kind, l.Ref)
+			}
+		}
+	}
+	if len(r.Comments) > 0 {
+		b.WriteString("\n## Comments\n\n")
+		for _, c := range r.Comments {
+			fmt.Fprintf(&b, "- %s\n", c)
+		}
+	}
+	b.WriteString("\n## Sessions\n\n")
+	if len(r.Sessions) == 0 {
+		b.WriteString("No time tracked.\n")
+	} else {
+		b.WriteString("| start | end | minutes |\n|---|---|---|\n")
+		for _, s := range r.Sessions {
+			end := s.End
+			if end == "" {
+				end = "running"
+			}
+			fmt.Fprintf(&b, "| %s | %s | %d |\n", s.Start, end, s.Minutes)
+		}
+	}
+	b.WriteString("\n## History\n\n")
+	if len(r.History) == 0 {
+		b.WriteString("No changes recorded.\n")
+	}
+	for _, e := range r.History {
+		from, to := e.From, e.To
+		if e.Field == "estimate" {
+			from, to = minutesLabel(from), minutesLabel(to)
+		}
+		if from == "" {
+			fmt.Fprintf(&b, "- %s %s set to %s\n", e.At, e.Field, to)
+		} else {
+			fmt.Fprintf(&b, "- %s %s %s → %s\n", e.At, e.Field, from, to)
+		}
+	}
+	return b.String()
+}
+
+func minutesLabel(s string) string {
+	if minutes, err := strconv.Atoi(s); err == nil {
+		return fmtMinutes(minutes)
+	}
+	return s
+}
+
+// findTaskRecord resolves a task by its number on day or by its title, and
+// returns its day and index. A title is looked up on every loaded day, most
+// recent first; a fragment must match a single task.
+func findTaskRecord(data TaskData, day, query string) (string, int, error) {
+	if n, err := strconv.Atoi(query); err == nil {
+		if n < 1 || n > len(data[day]) {
+			return "", 0, fmt.Errorf("%s has no task %d", day, n)
+		}
+		return day, n - 1, nil
+	}
+	type match struct {
+		day string
+		i   int
+		t   Task
+	}
+	var exact, partial []match
+	days := sortedDays(data)
+	sort.Sort(sort.Reverse(sort.StringSlice(days)))
+	needle := strings.ToLower(strings.TrimSpace(query))
+	for _, d := range days {
+		for i, t := range data[d] {
+			title := strings.ToLower(t.Title)
+			switch {
+			case title == needle:
+				exact = append(exact, match{d, i, t})
+			case strings.Contains(title, needle):
+				partial = append(partial, match{d, i, t})
+			}
+		}
+	}
+	if len(exact) > 0 {
+		return exact[0].day, exact[0].i, nil
+	}
+	switch len(partial) {
+	case 0:
+		return "", 0, fmt.Errorf("no task matches %q", query)
+	case 1:
+		return partial[0].day, partial[0].i, nil
+	}
+	var names []string
+	for i, m := range partial {
+		if i == 5 {
+			names = append(names, "...")
+			break
+		}
+		names = append(names, fmt.Sprintf("%s (%s)", m.t.Title, m.day))
+	}
+	return "", 0, fmt.Errorf("%d tasks match %q: %s", len(partial), query, strings.Join(names, ", "))
+}
+
+// showTaskRecord prints the record of a task as Markdown or JSON
+func showTaskRecord(query, day string, asJSON bool) error {
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	found, i, err := findTaskRecord(data, day, query)
+	if err != nil {
+		return err
+	}
+	r := buildTaskRecord(found, data[found][i], time.Now())
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	}
+	fmt.Print(renderTaskRecord(r))
+	return nil
+}
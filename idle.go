@@ -0,0 +1,149 @@
+// idle.go - Forgotten timers
+// A task left running far longer than its estimate, like overnight, was most likely forgotten: the next command asks whether to count all of it, or to cap it at the end of work hours or at a threshold
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/manifoldco/promptui"
+)
+
+// IdleConfig is the `idle` section of config.yaml
+type IdleConfig struct {
+	Factor    float64        `yaml:"factor"`    // sessions longer than this many times the estimate are suspicious, default 3
+	Threshold *time.Duration `yaml:"threshold"` // and any session longer than this, default 4h; 0s turns the check off
+}
+
+const (
+	defaultIdleFactor    = 3
+	defaultIdleThreshold = 4 * time.Hour
+	// minIdleLimit keeps short tasks from being questioned after a few minutes
+	minIdleLimit = time.Hour
+)
+
+// validate checks the idle settings
+func (c IdleConfig) validate() error {
+	if c.Factor < 0 {
+		return fmt.Errorf("idle.factor: must be positive, got %g", c.Factor)
+	}
+	if c.Threshold != nil && *c.Threshold < 0 {
+		return fmt.Errorf("idle.threshold: must be positive, got %s", *c.Threshold)
+	}
+	return nil
+}
+
+func (c IdleConfig) threshold() time.Duration {
+	if c.Threshold == nil {
+		return defaultIdleThreshold
+	}
+	return *c.Threshold
+}
+
+// limit is how long a session of a task can run before it is questioned:
+// the threshold, or sooner for a task whose estimate is much shorter
+func (c IdleConfig) limit(t Task) time.Duration {
+	limit := c.threshold()
+	if t.Estimated > 0 {
+		factor := c.Factor
+		if factor == 0 {
+			factor = defaultIdleFactor
+		}
+		byEstimate := time.Duration(factor * float64(t.Estimated) * float64(time.Minute))
+		limit = min(limit, max(minIdleLimit, byEstimate))
+	}
+	return limit
+}
+
+func idleAckPath() (string, error) {
+	return dataFilePath("idle-ok")
+}
+
+// idleAcknowledged reports whether the session starting at since was
+// already confirmed to count in full
+func idleAcknowledged(since int64) bool {
+	path, err := idleAckPath()
+	if err != nil {
+		return false
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	ack, err := strconv.ParseInt(strings.TrimSpace(string(content)), 10, 64)
+	return err == nil && ack == since
+}
+
+// acknowledgeIdle remembers that the session starting at since counts in
+// full, so it isn't questioned again
+func acknowledgeIdle(since int64) error {
+	path, err := idleAckPath()
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, []byte(strconv.FormatInt(since, 10)+"\n"), 0644)
+}
+
+// checkLongSession asks what to do with a running task whose current
+// session is far longer than it should be, offering to stop the clock at
+// the end of work hours or at the idle limit
+func checkLongSession(now time.Time) error {
+	if config.Idle.threshold() <= 0 || !isTerminal() {
+		return nil
+	}
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	for _, day := range sortedDays(data) {
+		for i := range data[day] {
+			t := &data[day][i]
+			since := t.runningSince()
+			if t.Status != "started" || since == 0 || idleAcknowledged(since) {
+				continue
+			}
+			start := time.Unix(since, 0)
+			limit := config.Idle.limit(*t)
+			if now.Sub(start) < limit {
+				continue
+			}
+			estimate := "no estimate"
+			if t.Estimated > 0 {
+				estimate = "an estimate of " + fmtMinutes(t.Estimated)
+			}
+			fmt.Printf("'%s' has been running since %s: %s in one go, with %s. Was the timer forgotten?\n",
+				t.Title, start.Format("Jan 2 15:04"), fmtMinutes(int(now.Sub(start).Minutes())), estimate)
+			items := []string{"All of it: keep the timer running"}
+			caps := []time.Time{{}}
+			_, _, _, workEnd := config.WorkHours.bounds(start)
+			if start.Before(workEnd) && now.After(workEnd) {
+				items = append(items, fmt.Sprintf("Until the end of work hours, %s: stop the timer there", workEnd.Format("Jan 2 15:04")))
+				caps = append(caps, workEnd)
+			}
+			capped := start.Add(limit)
+			items = append(items, fmt.Sprintf("Until %s, after %s: stop the timer there", capped.Format("Jan 2 15:04"), fmtMinutes(int(limit.Minutes()))))
+			caps = append(caps, capped)
+			prompt := promptui.Select{Label: "How much of that time counts", Items: items, HideHelp: true}
+			choice, _, err := prompt.Run()
+			if err != nil {
+				if err.Error() == "interrupt" {
+					return nil
+				}
+				return err
+			}
+			if choice == 0 {
+				return acknowledgeIdle(since)
+			}
+			at := caps[choice]
+			t.endSession(at.Unix())
+			t.setStatus("paused", now)
+			fmt.Printf("Paused '%s' at %s with %s tracked; resume it with `daily resume`.\n", t.Title, at.Format("Jan 2 15:04"), fmtMinutes(t.Actual))
+			return saveTasks(data)
+		}
+	}
+	return nil
+}
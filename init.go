@@ -0,0 +1,290 @@
+// init.go - Guided onboarding
+// Interactive `daily init`: writes config.yaml, seeds an example day and checks the environment
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/manifoldco/promptui"
+	"gopkg.in/yaml.v3"
+)
+
+// exampleTasks seed the first day so that ls, next and follow have something to show
+var exampleTasks = []Task{
+	{Title: "Read the daily README", Estimated: 15, Priority: priorityHigh},
+	{Title: "Plan tomorrow with `daily addt`", Estimated: 10, Priority: priorityMedium},
+	{Title: "Try `daily next` then `daily follow`", Estimated: 25},
+}
+
+// confirmPrompt asks a yes/no question; declining is not an error
+func confirmPrompt(label string) (bool, error) {
+	prompt := promptui.Prompt{Label: label, IsConfirm: true, Default: "y"}
+	if _, err := prompt.Run(); err != nil {
+		if errors.Is(err, promptui.ErrAbort) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// validatedPrompt is promptWithCursor with input validation
+func validatedPrompt(label, defaultVal string, validate func(string) error) (string, error) {
+	prompt := promptui.Prompt{Label: label, Default: defaultVal, Validate: validate}
+	result, err := prompt.Run()
+	return strings.TrimSpace(result), err
+}
+
+func validateClock(input string) error {
+	_, err := parseClock(strings.TrimSpace(input))
+	return err
+}
+
+func validateOptionalClock(input string) error {
+	if strings.TrimSpace(input) == "" {
+		return nil
+	}
+	return validateClock(input)
+}
+
+// promptWorkHours asks for the working day until it validates
+func promptWorkHours(current WorkHours) (WorkHours, error) {
+	for {
+		var w WorkHours
+		var err error
+		if w.Start, err = validatedPrompt("Work starts at (HH:MM)", current.Start, validateClock); err != nil {
+			return w, err
+		}
+		if w.End, err = validatedPrompt("Work ends at (HH:MM)", current.End, validateClock); err != nil {
+			return w, err
+		}
+		if w.LunchStart, err = validatedPrompt("Lunch starts at (HH:MM, empty for none)", current.LunchStart, validateOptionalClock); err != nil {
+			return w, err
+		}
+		if w.LunchStart != "" {
+			if w.LunchEnd, err = validatedPrompt("Lunch ends at (HH:MM)", current.LunchEnd, validateClock); err != nil {
+				return w, err
+			}
+		}
+		maxMinutes, err := validatedPrompt("Maximum minutes of work per day", strconv.Itoa(current.MaxDailyMinutes), func(input string) error {
+			if n, err := strconv.Atoi(strings.TrimSpace(input)); err != nil || n <= 0 {
+				return fmt.Errorf("enter a positive number of minutes")
+			}
+			return nil
+		})
+		if err != nil {
+			return w, err
+		}
+		w.MaxDailyMinutes, _ = strconv.Atoi(maxMinutes)
+		if err := w.validate(); err != nil {
+			fmt.Println("Error:", err)
+			current = w
+			continue
+		}
+		return w, nil
+	}
+}
+
+// writeInitConfig merges the onboarding answers into config.yaml, keeping any
+// other sections the file already has
+func writeInitConfig(path string, settings map[string]interface{}) error {
+	raw := map[string]interface{}{}
+	if content, err := os.ReadFile(path); err == nil {
+		if err := yaml.Unmarshal(content, &raw); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if raw == nil {
+			raw = map[string]interface{}{}
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	for key, value := range settings {
+		raw[key] = value
+	}
+	content, err := yaml.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0644)
+}
+
+// seedExampleDay adds the example tasks to today, unless today already has tasks
+func seedExampleDay() error {
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	today := todayKey()
+	if len(data[today]) > 0 {
+		fmt.Printf("Today already has %d tasks, not adding examples.\n", len(data[today]))
+		return nil
+	}
+	for _, t := range exampleTasks {
+		t.Status = "pending"
+		t.Tags = []string{"example"}
+		data[today] = append(data[today], t)
+	}
+	if err := saveTasks(data); err != nil {
+		return err
+	}
+	fmt.Printf("Added %d example tasks to today, tagged \"example\".\n", len(exampleTasks))
+	return nil
+}
+
+// checkEnvironment reports whether the editor, notifications and data
+// directory work, and returns the number of problems found
+func checkEnvironment() int {
+	problems := 0
+	report := func(name string, err error, ok string) {
+		if err != nil {
+			problems++
+			fmt.Printf("  ✗ %-14s %v\n", name, err)
+			return
+		}
+		fmt.Printf("  ✓ %-14s %s\n", name, ok)
+	}
+
+	editor, err := editorCommand()
+	report("Editor", err, strings.Join(editor, " "))
+
+	notifier, err := notifierCommand()
+	if err == nil {
+		err = showNotification("daily", "Notifications are working")
+	}
+	report("Notifications", err, notifier)
+
+	dir, err := getDataDir()
+	if err == nil {
+		err = os.MkdirAll(dir, 0755)
+	}
+	if err == nil {
+		var probe *os.File
+		if probe, err = os.CreateTemp(dir, ".daily-init-*"); err == nil {
+			probe.Close()
+			os.Remove(probe.Name())
+		}
+	}
+	report("Data directory", err, dir)
+	return problems
+}
+
+// runInit walks through the first-time setup
+func runInit() error {
+	path, err := getConfigFilePath()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err == nil {
+		ok, err := confirmPrompt(fmt.Sprintf("%s exists, update it", path))
+		if err != nil || !ok {
+			return err
+		}
+	}
+	current := config
+	if current.WorkHours.validate() != nil {
+		current.WorkHours = defaultConfig().WorkHours
+	}
+
+	fmt.Println("Working day")
+	hours, err := promptWorkHours(current.WorkHours)
+	if err != nil {
+		return err
+	}
+
+	oldDataDir, err := getDataDir()
+	if err != nil {
+		return err
+	}
+	dataDir, err := chooseDataDir(oldDataDir)
+	if err != nil {
+		return err
+	}
+
+	editor := getEditor()
+	if editor == "" {
+		if found, err := editorCommand(); err == nil {
+			editor = strings.Join(found, " ")
+		}
+	}
+	if editor, err = validatedPrompt("Editor for notes (e.g. code --wait)", editor, func(input string) error {
+		args := splitCommand(input)
+		if len(args) == 0 {
+			return fmt.Errorf("enter an editor command")
+		}
+		if _, err := exec.LookPath(args[0]); err != nil {
+			return fmt.Errorf("%s not found", args[0])
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	names := themeNames()
+	cursor := 0
+	for i, name := range names {
+		if name == current.Theme || (current.Theme == "" && name == "default") {
+			cursor = i
+		}
+	}
+	themePrompt := promptui.Select{Label: "Theme", Items: names, CursorPos: cursor, HideHelp: true}
+	_, theme, err := themePrompt.Run()
+	if err != nil {
+		return err
+	}
+
+	settings := map[string]interface{}{
+		"work_hours": hours,
+		"data_dir":   dataDir,
+		"editor":     editor,
+		"theme":      theme,
+	}
+	if err := writeInitConfig(path, settings); err != nil {
+		return err
+	}
+	fmt.Println("Wrote", path)
+	if err := applyConfig(); err != nil {
+		return err
+	}
+	if newDataDir, err := getDataDir(); err == nil && hasDataFiles(oldDataDir) && filepath.Clean(newDataDir) != filepath.Clean(oldDataDir) {
+		move, err := confirmPrompt(fmt.Sprintf("Move your data from %s to %s", oldDataDir, newDataDir))
+		if err != nil {
+			return err
+		}
+		if move {
+			moved, err := moveDataFiles(oldDataDir, newDataDir)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Moved %d file(s).\n", moved)
+		}
+	}
+
+	seed, err := confirmPrompt("Add example tasks to today")
+	if err != nil {
+		return err
+	}
+	if seed {
+		if err := seedExampleDay(); err != nil {
+			return err
+		}
+	}
+
+	fmt.Println("\nChecking the environment")
+	if problems := checkEnvironment(); problems > 0 {
+		fmt.Printf("\n%d problem(s) found; daily works without them but some commands will be limited.\n", problems)
+		return nil
+	}
+	fmt.Println("\nAll set. Run `daily ls` to see today.")
+	return nil
+}
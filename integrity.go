@@ -0,0 +1,239 @@
+// integrity.go - Data checksums
+// Every YAML data file daily writes gets a checksum next to it, so changes made outside daily (hand edits, sync conflicts) are noticed and reported until they are accepted with `daily verify --accept`
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"gopkg.in/yaml.v3"
+)
+
+// fileChecksum is kept in .<name>.sum next to each data file
+type fileChecksum struct {
+	SHA256 string `yaml:"sha256"`
+	// Outside is when a change made outside daily was found; it stays set,
+	// through later saves, until the change is accepted
+	Outside string `yaml:"outside,omitempty"`
+}
+
+// integrityIssue is a data file that doesn't match what daily wrote
+type integrityIssue struct {
+	Name    string
+	Since   string // when the change was found; empty when found just now
+	Missing bool
+}
+
+func checksumPath(path string) string {
+	return filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".sum")
+}
+
+func hashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// isChecksummed reports whether a file gets a checksum: the YAML files of
+// the data directory, not their backups
+func isChecksummed(path string) bool {
+	dir, err := getDataDir()
+	if err != nil || filepath.Clean(filepath.Dir(path)) != filepath.Clean(dir) {
+		return false
+	}
+	name := filepath.Base(path)
+	return strings.HasSuffix(name, ".yaml") && !strings.HasPrefix(name, ".")
+}
+
+func readChecksum(path string) (fileChecksum, bool) {
+	var sum fileChecksum
+	content, err := os.ReadFile(checksumPath(path))
+	if err != nil || yaml.Unmarshal(content, &sum) != nil || sum.SHA256 == "" {
+		return fileChecksum{}, false
+	}
+	return sum, true
+}
+
+func writeChecksum(path string, sum fileChecksum) error {
+	content, err := yaml.Marshal(&sum)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(checksumPath(path), content, 0644)
+}
+
+// changedOutside returns when the file on disk was found changed outside
+// daily, or "" when it is what daily last wrote. Called before a save, so
+// the save doesn't hide the change.
+func changedOutside(path string) string {
+	sum, ok := readChecksum(path)
+	if !ok {
+		return ""
+	}
+	if sum.Outside != "" {
+		return sum.Outside
+	}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ""
+	}
+	if err == nil && hashContent(content) == sum.SHA256 {
+		return ""
+	}
+	return time.Now().Format(time.RFC3339)
+}
+
+// checkIntegrity compares every checksummed file with its checksum
+func checkIntegrity() ([]integrityIssue, error) {
+	dir, err := getDataDir()
+	if err != nil {
+		return nil, err
+	}
+	sums, err := filepath.Glob(filepath.Join(dir, ".*.yaml.sum"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(sums)
+	var issues []integrityIssue
+	for _, sumPath := range sums {
+		name := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(sumPath), "."), ".sum")
+		path := filepath.Join(dir, name)
+		sum, ok := readChecksum(path)
+		if !ok {
+			continue
+		}
+		content, err := os.ReadFile(path)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			issues = append(issues, integrityIssue{Name: name, Missing: true})
+		case err != nil:
+			return nil, err
+		case sum.Outside != "" || hashContent(content) != sum.SHA256:
+			issues = append(issues, integrityIssue{Name: name, Since: sum.Outside})
+		}
+	}
+	return issues, nil
+}
+
+// warnIfTampered prints the data files changed outside daily
+func warnIfTampered() {
+	issues, err := checkIntegrity()
+	if err != nil || len(issues) == 0 {
+		return
+	}
+	var names []string
+	for _, issue := range issues {
+		names = append(names, issue.Name)
+	}
+	warn := lipgloss.NewStyle().Foreground(lipgloss.Color(activeTheme().Bad))
+	fmt.Println(warn.Render(fmt.Sprintf("Warning: %s changed outside daily (a hand edit or a sync conflict?). Review with `daily verify`, then trust it with `daily verify --accept`.",
+		strings.Join(names, ", "))))
+}
+
+// acceptChecksums records the current content of the named files as
+// trusted; no names means every file with an issue
+func acceptChecksums(names []string) (int, error) {
+	dir, err := getDataDir()
+	if err != nil {
+		return 0, err
+	}
+	if len(names) == 0 {
+		issues, err := checkIntegrity()
+		if err != nil {
+			return 0, err
+		}
+		for _, issue := range issues {
+			names = append(names, issue.Name)
+		}
+	}
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		content, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			os.Remove(checksumPath(path))
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		if err := writeChecksum(path, fileChecksum{SHA256: hashContent(content)}); err != nil {
+			return 0, err
+		}
+	}
+	return len(names), nil
+}
+
+// acceptSynced accepts the files a sync changed: every checksummed file but
+// those that had already changed outside daily before it
+func acceptSynced(before []integrityIssue) error {
+	dir, err := getDataDir()
+	if err != nil {
+		return err
+	}
+	sums, err := filepath.Glob(filepath.Join(dir, ".*.yaml.sum"))
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, sumPath := range sums {
+		name := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(sumPath), "."), ".sum")
+		if !slices.ContainsFunc(before, func(issue integrityIssue) bool { return issue.Name == name }) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	_, err = acceptChecksums(names)
+	return err
+}
+
+// runVerify lists the checksummed files that changed outside daily, or
+// accepts them
+func runVerify(accept bool, names []string) error {
+	if accept {
+		n, err := acceptChecksums(names)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			fmt.Println("Nothing to accept: every data file matches its checksum.")
+			return nil
+		}
+		fmt.Printf("Accepted the current content of %d files.\n", n)
+		return nil
+	}
+	issues, err := checkIntegrity()
+	if err != nil {
+		return err
+	}
+	if len(issues) == 0 {
+		fmt.Println("Every data file matches its checksum.")
+		return nil
+	}
+	for _, issue := range issues {
+		switch {
+		case issue.Missing:
+			fmt.Printf("  %-18s missing\n", issue.Name)
+		case issue.Since != "":
+			since := issue.Since
+			if at, err := time.Parse(time.RFC3339, since); err == nil {
+				since = at.Format("2006-01-02 15:04")
+			}
+			fmt.Printf("  %-18s changed outside daily, found %s\n", issue.Name, since)
+		default:
+			fmt.Printf("  %-18s changed outside daily\n", issue.Name)
+		}
+	}
+	fmt.Println("Compare with the backups (`daily restore`) or the sync history, then run `daily verify --accept` to trust the files as they are.")
+	return nil
+}
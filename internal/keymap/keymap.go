@@ -0,0 +1,111 @@
+// Package keymap holds the key bindings shared by the full-screen views
+// (follow, dashboard, board) so they can be rebound from the config file.
+package keymap
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// Config is the `keymap` section of config.yaml. Preset picks the base
+// layout ("arrows" or "vim"); any action listed overrides the preset keys.
+type Config struct {
+	Preset string   `yaml:"preset"`
+	Quit   []string `yaml:"quit"`
+	Up     []string `yaml:"up"`
+	Down   []string `yaml:"down"`
+	Left   []string `yaml:"left"`
+	Right  []string `yaml:"right"`
+	Select []string `yaml:"select"`
+	Start  []string `yaml:"start"`
+	Stop   []string `yaml:"stop"`
+	Finish []string `yaml:"finish"`
+	Edit   []string `yaml:"edit"`
+	Help   []string `yaml:"help"`
+}
+
+// KeyMap is the resolved set of bindings used by the TUIs
+type KeyMap struct {
+	Quit   key.Binding
+	Up     key.Binding
+	Down   key.Binding
+	Left   key.Binding
+	Right  key.Binding
+	Select key.Binding
+	Start  key.Binding
+	Stop   key.Binding
+	Finish key.Binding
+	Edit   key.Binding
+	Help   key.Binding
+}
+
+// Arrows is the default layout using the arrow keys for navigation
+func Arrows() KeyMap {
+	return KeyMap{
+		Quit:   key.NewBinding(key.WithKeys("q", "ctrl+c"), key.WithHelp("q", "quit")),
+		Up:     key.NewBinding(key.WithKeys("up"), key.WithHelp("↑", "up")),
+		Down:   key.NewBinding(key.WithKeys("down"), key.WithHelp("↓", "down")),
+		Left:   key.NewBinding(key.WithKeys("left"), key.WithHelp("←", "left")),
+		Right:  key.NewBinding(key.WithKeys("right"), key.WithHelp("→", "right")),
+		Select: key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "select")),
+		Start:  key.NewBinding(key.WithKeys("s"), key.WithHelp("s", "start")),
+		Stop:   key.NewBinding(key.WithKeys("p"), key.WithHelp("p", "stop")),
+		Finish: key.NewBinding(key.WithKeys("f"), key.WithHelp("f", "finish")),
+		Edit:   key.NewBinding(key.WithKeys("e"), key.WithHelp("e", "edit")),
+		Help:   key.NewBinding(key.WithKeys("?"), key.WithHelp("?", "help")),
+	}
+}
+
+// Vim is the vim-style layout using h/j/k/l for navigation
+func Vim() KeyMap {
+	km := Arrows()
+	km.Up = key.NewBinding(key.WithKeys("k"), key.WithHelp("k", "up"))
+	km.Down = key.NewBinding(key.WithKeys("j"), key.WithHelp("j", "down"))
+	km.Left = key.NewBinding(key.WithKeys("h"), key.WithHelp("h", "left"))
+	km.Right = key.NewBinding(key.WithKeys("l"), key.WithHelp("l", "right"))
+	km.Stop = key.NewBinding(key.WithKeys("x"), key.WithHelp("x", "stop"))
+	km.Edit = key.NewBinding(key.WithKeys("i"), key.WithHelp("i", "edit"))
+	return km
+}
+
+// FromConfig resolves the configured preset and overrides
+func FromConfig(c Config) (KeyMap, error) {
+	var km KeyMap
+	switch strings.ToLower(c.Preset) {
+	case "", "arrows":
+		km = Arrows()
+	case "vim":
+		km = Vim()
+	default:
+		return Arrows(), fmt.Errorf("unknown keymap preset %q (expected arrows or vim)", c.Preset)
+	}
+	override(&km.Quit, c.Quit)
+	override(&km.Up, c.Up)
+	override(&km.Down, c.Down)
+	override(&km.Left, c.Left)
+	override(&km.Right, c.Right)
+	override(&km.Select, c.Select)
+	override(&km.Start, c.Start)
+	override(&km.Stop, c.Stop)
+	override(&km.Finish, c.Finish)
+	override(&km.Edit, c.Edit)
+	override(&km.Help, c.Help)
+	return km, nil
+}
+
+// override replaces a binding's keys, keeping its help description
+func override(b *key.Binding, keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	b.SetKeys(keys...)
+	b.SetHelp(strings.Join(keys, "/"), b.Help().Desc)
+}
+
+// IsQuit reports whether a key press should leave a view. Ctrl+C always
+// quits so a bad config can never trap the user.
+func (km KeyMap) IsQuit(k fmt.Stringer) bool {
+	return k.String() == "ctrl+c" || key.Matches(k, km.Quit)
+}
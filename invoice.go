@@ -0,0 +1,311 @@
+// invoice.go - Per-client invoices from tracked time
+// Builds an invoice-ready breakdown for a client and month as Markdown, HTML, or PDF
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ClientBilling holds the billing settings for one client
+type ClientBilling struct {
+	Name     string  `yaml:"name"`
+	Rate     float64 `yaml:"rate"`
+	Currency string  `yaml:"currency"`
+}
+
+// BillingConfig is the content of billing.yaml
+type BillingConfig struct {
+	Clients map[string]ClientBilling `yaml:"clients"`
+}
+
+// invoiceLine is one billed task on one day
+type invoiceLine struct {
+	Day     string
+	Title   string
+	Minutes int
+}
+
+// invoice is the computed breakdown for a client and month
+type invoice struct {
+	Client   ClientBilling
+	Month    string
+	Lines    []invoiceLine
+	Minutes  int
+	Hours    float64
+	Total    float64
+	IssuedOn string
+}
+
+func getBillingFilePath() (string, error) {
+	return dataFilePath("billing.yaml")
+}
+
+func loadBilling() (BillingConfig, error) {
+	cfg := BillingConfig{Clients: map[string]ClientBilling{}}
+	filePath, err := getBillingFilePath()
+	if err != nil {
+		return cfg, err
+	}
+	file, err := os.ReadFile(filePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return cfg, nil
+		}
+		return cfg, err
+	}
+	if err := yaml.Unmarshal(file, &cfg); err != nil {
+		return cfg, err
+	}
+	if cfg.Clients == nil {
+		cfg.Clients = map[string]ClientBilling{}
+	}
+	return cfg, nil
+}
+
+// taskBelongsToClient matches a task to a client by project or tag
+func taskBelongsToClient(t Task, client string) bool {
+	return strings.EqualFold(t.Project, client) || hasTag(t, client)
+}
+
+// buildInvoice collects the client's tracked time for the month (YYYY-MM)
+func buildInvoice(client string, month string, rate float64) (invoice, error) {
+	if _, err := time.Parse("2006-01", month); err != nil {
+		return invoice{}, fmt.Errorf("invalid month %q (expected YYYY-MM)", month)
+	}
+	billing, err := loadBilling()
+	if err != nil {
+		return invoice{}, err
+	}
+	settings, ok := billing.Clients[client]
+	if !ok {
+		for key, c := range billing.Clients {
+			if strings.EqualFold(key, client) {
+				settings, ok = c, true
+			}
+		}
+	}
+	if settings.Name == "" {
+		settings.Name = client
+	}
+	if settings.Currency == "" {
+		settings.Currency = "EUR"
+	}
+	if rate > 0 {
+		settings.Rate = rate
+	}
+	if settings.Rate <= 0 {
+		return invoice{}, fmt.Errorf("no rate for client %q: add it to billing.yaml or pass --rate", client)
+	}
+
+	data, err := loadTasksRange(month+"-01", month+"-31")
+	if err != nil {
+		return invoice{}, err
+	}
+	var days []string
+	for day := range data {
+		if strings.HasPrefix(day, month+"-") {
+			days = append(days, day)
+		}
+	}
+	sort.Strings(days)
+
+	inv := invoice{Client: settings, Month: month, IssuedOn: todayKey()}
+	for _, day := range days {
+		for _, t := range data[day] {
+			if t.Actual <= 0 || isNonWork(t) || !taskBelongsToClient(t, client) {
+				continue
+			}
+			inv.Lines = append(inv.Lines, invoiceLine{Day: day, Title: t.Title, Minutes: t.Actual})
+			inv.Minutes += t.Actual
+		}
+	}
+	if len(inv.Lines) == 0 {
+		return invoice{}, fmt.Errorf("no tracked time for %q in %s", client, month)
+	}
+	inv.Hours = float64(inv.Minutes) / 60
+	inv.Total = inv.Hours * settings.Rate
+	return inv, nil
+}
+
+// daysBilled counts the distinct days that appear on the invoice
+func (inv invoice) daysBilled() int {
+	seen := map[string]bool{}
+	for _, l := range inv.Lines {
+		seen[l.Day] = true
+	}
+	return len(seen)
+}
+
+func renderInvoiceMarkdown(inv invoice) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Invoice — %s\n\n", inv.Client.Name)
+	fmt.Fprintf(&b, "Period: %s  \nIssued: %s\n\n", inv.Month, inv.IssuedOn)
+	b.WriteString("| Date | Task | Hours |\n|------|------|------:|\n")
+	for _, l := range inv.Lines {
+		fmt.Fprintf(&b, "| %s | %s | %.2f |\n", l.Day, strings.ReplaceAll(l.Title, "|", "\\|"), float64(l.Minutes)/60)
+	}
+	fmt.Fprintf(&b, "\n**Days:** %d  \n**Hours:** %.2f  \n**Rate:** %.2f %s/h  \n**Total:** %.2f %s\n",
+		inv.daysBilled(), inv.Hours, inv.Client.Rate, inv.Client.Currency, inv.Total, inv.Client.Currency)
+	return b.String()
+}
+
+func renderInvoiceHTML(inv invoice) string {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&b, "<title>Invoice %s %s</title>\n", html.EscapeString(inv.Client.Name), inv.Month)
+	b.WriteString("<style>body{font-family:sans-serif;margin:2em}table{border-collapse:collapse;width:100%}" +
+		"td,th{border-bottom:1px solid #ddd;padding:4px 8px;text-align:left}td.num,th.num{text-align:right}</style>\n")
+	b.WriteString("</head>\n<body>\n")
+	fmt.Fprintf(&b, "<h1>Invoice — %s</h1>\n", html.EscapeString(inv.Client.Name))
+	fmt.Fprintf(&b, "<p>Period: %s<br>Issued: %s</p>\n", inv.Month, inv.IssuedOn)
+	b.WriteString("<table>\n<tr><th>Date</th><th>Task</th><th class=\"num\">Hours</th></tr>\n")
+	for _, l := range inv.Lines {
+		fmt.Fprintf(&b, "<tr><td>%s</td><td>%s</td><td class=\"num\">%.2f</td></tr>\n",
+			l.Day, html.EscapeString(l.Title), float64(l.Minutes)/60)
+	}
+	b.WriteString("</table>\n")
+	fmt.Fprintf(&b, "<p>Days: %d<br>Hours: %.2f<br>Rate: %.2f %s/h<br><strong>Total: %.2f %s</strong></p>\n",
+		inv.daysBilled(), inv.Hours, inv.Client.Rate, html.EscapeString(inv.Client.Currency),
+		inv.Total, html.EscapeString(inv.Client.Currency))
+	b.WriteString("</body>\n</html>\n")
+	return b.String()
+}
+
+func renderInvoicePDF(inv invoice) []byte {
+	lines := []string{
+		"Invoice - " + inv.Client.Name,
+		"",
+		"Period: " + inv.Month,
+		"Issued: " + inv.IssuedOn,
+		"",
+		fmt.Sprintf("%-12s %-52s %8s", "Date", "Task", "Hours"),
+	}
+	for _, l := range inv.Lines {
+		title := l.Title
+		if len(title) > 52 {
+			title = title[:49] + "..."
+		}
+		lines = append(lines, fmt.Sprintf("%-12s %-52s %8.2f", l.Day, title, float64(l.Minutes)/60))
+	}
+	lines = append(lines,
+		"",
+		fmt.Sprintf("Days:  %d", inv.daysBilled()),
+		fmt.Sprintf("Hours: %.2f", inv.Hours),
+		fmt.Sprintf("Rate:  %.2f %s/h", inv.Client.Rate, inv.Client.Currency),
+		fmt.Sprintf("Total: %.2f %s", inv.Total, inv.Client.Currency),
+	)
+	return textPDF(lines)
+}
+
+// textPDF renders plain text lines into a minimal multi-page PDF using a
+// built-in monospaced font, which is enough for a tabular invoice.
+func textPDF(lines []string) []byte {
+	const linesPerPage = 60
+	var pages [][]string
+	for len(lines) > linesPerPage {
+		pages = append(pages, lines[:linesPerPage])
+		lines = lines[linesPerPage:]
+	}
+	pages = append(pages, lines)
+
+	var objects []string
+	// 1: catalog, 2: pages, 3: font, then a page and a content object per page
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+i*2)
+	}
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+	)
+	for i, page := range pages {
+		var content strings.Builder
+		content.WriteString("BT /F1 10 Tf 12 TL 50 800 Td\n")
+		for _, line := range page {
+			fmt.Fprintf(&content, "(%s) '\n", pdfEscape(line))
+		}
+		content.WriteString("ET")
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 595 842] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", 5+i*2),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()),
+		)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+// pdfEscape escapes a line for a PDF string literal, dropping characters the
+// base font cannot show
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case r == '—' || r == '–':
+			b.WriteRune('-')
+		case r < 32 || r > 126:
+			b.WriteRune('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// writeInvoice renders the invoice and writes it to output, or stdout for text formats
+func writeInvoice(client, month, format, output string, rate float64) error {
+	inv, err := buildInvoice(client, month, rate)
+	if err != nil {
+		return err
+	}
+	var content []byte
+	switch format {
+	case "md", "markdown":
+		content = []byte(renderInvoiceMarkdown(inv))
+	case "html":
+		content = []byte(renderInvoiceHTML(inv))
+	case "pdf":
+		content = renderInvoicePDF(inv)
+		if output == "" {
+			output = fmt.Sprintf("invoice-%s-%s.pdf", strings.ToLower(client), month)
+		}
+	default:
+		return fmt.Errorf("unknown format %q (expected md, html, or pdf)", format)
+	}
+	if output == "" {
+		fmt.Print(string(content))
+		return nil
+	}
+	if err := os.WriteFile(output, content, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("Invoice for %s (%s): %.2f h, %.2f %s written to %s\n",
+		inv.Client.Name, month, inv.Hours, inv.Total, inv.Client.Currency, output)
+	return nil
+}
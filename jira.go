@@ -0,0 +1,400 @@
+// jira.go - Jira integration
+// Talks to the Jira REST API to bring assigned issues into the day's plan and to log the time tracked on them as worklogs
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// JiraConfig is the `jira` section of config.yaml. The API token is read from
+// JIRA_API_TOKEN so it never has to be written to disk.
+type JiraConfig struct {
+	BaseURL          string      `yaml:"base_url"`
+	Email            string      `yaml:"email"`
+	StoryPointsField string      `yaml:"story_points_field"`
+	MinutesPerPoint  int         `yaml:"minutes_per_point"`
+	PointsToMinutes  map[int]int `yaml:"points_to_minutes"`
+	DefaultMinutes   int         `yaml:"default_minutes"`
+	PullJQL          string      `yaml:"pull_jql"` // issues `jira pull` imports
+}
+
+// defaultPullJQL is what `jira pull` imports unless jira.pull_jql says otherwise
+const defaultPullJQL = "assignee = currentUser() AND statusCategory != Done ORDER BY priority DESC, updated DESC"
+
+// jiraIssue is the subset of an issue the planner needs
+type jiraIssue struct {
+	Key     string
+	Summary string
+	Status  string
+	Points  float64
+	Epic    string // key of the parent epic
+}
+
+type jiraClient struct {
+	cfg   JiraConfig
+	token string
+	http  *http.Client
+}
+
+func newJiraClient() (*jiraClient, error) {
+	if err := requireOnline("Jira"); err != nil {
+		return nil, err
+	}
+	cfg := config.Jira
+	if cfg.BaseURL == "" || cfg.Email == "" {
+		return nil, fmt.Errorf("jira.base_url and jira.email must be configured")
+	}
+	token := os.Getenv("JIRA_API_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("JIRA_API_TOKEN is not set")
+	}
+	if cfg.StoryPointsField == "" {
+		cfg.StoryPointsField = "customfield_10016"
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	return &jiraClient{cfg: cfg, token: token, http: &http.Client{Timeout: 30 * time.Second}}, nil
+}
+
+// do sends an authenticated request and decodes the JSON reply into out
+func (c *jiraClient) do(method, path string, body interface{}, out interface{}) error {
+	var reader *strings.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = strings.NewReader(string(payload))
+	} else {
+		reader = strings.NewReader("")
+	}
+	req, err := http.NewRequest(method, c.cfg.BaseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.cfg.Email, c.token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("Jira returned %s for %s", resp.Status, path)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// search runs a JQL query and returns the matching issues
+func (c *jiraClient) search(jql string) ([]jiraIssue, error) {
+	query := url.Values{
+		"jql":        {jql},
+		"fields":     {"summary,status,parent," + c.cfg.StoryPointsField},
+		"maxResults": {"100"},
+	}
+	var result struct {
+		Issues []struct {
+			Key    string                     `json:"key"`
+			Fields map[string]json.RawMessage `json:"fields"`
+		} `json:"issues"`
+	}
+	if err := c.do(http.MethodGet, "/rest/api/2/search?"+query.Encode(), nil, &result); err != nil {
+		return nil, err
+	}
+	var issues []jiraIssue
+	for _, raw := range result.Issues {
+		issue := jiraIssue{Key: raw.Key}
+		json.Unmarshal(raw.Fields["summary"], &issue.Summary)
+		var status struct {
+			Name string `json:"name"`
+		}
+		json.Unmarshal(raw.Fields["status"], &status)
+		issue.Status = status.Name
+		json.Unmarshal(raw.Fields[c.cfg.StoryPointsField], &issue.Points)
+		var parent struct {
+			Key    string `json:"key"`
+			Fields struct {
+				IssueType struct {
+					Name string `json:"name"`
+				} `json:"issuetype"`
+			} `json:"fields"`
+		}
+		json.Unmarshal(raw.Fields["parent"], &parent)
+		if parent.Fields.IssueType.Name == "Epic" {
+			issue.Epic = parent.Key
+		}
+		issues = append(issues, issue)
+	}
+	return issues, nil
+}
+
+// assignedIssues lists the issues jira.pull_jql selects, by default the open
+// ones assigned to me
+func (c *jiraClient) assignedIssues() ([]jiraIssue, error) {
+	jql := c.cfg.PullJQL
+	if jql == "" {
+		jql = defaultPullJQL
+	}
+	return c.search(jql)
+}
+
+// activeSprintIssues lists the open issues assigned to me in active sprints
+func (c *jiraClient) activeSprintIssues() ([]jiraIssue, error) {
+	return c.search("assignee = currentUser() AND sprint in openSprints() AND statusCategory != Done ORDER BY rank")
+}
+
+// estimateFromPoints converts story points to minutes using the configured
+// mapping, then minutes_per_point, then default_minutes
+func estimateFromPoints(cfg JiraConfig, points float64) int {
+	if points > 0 {
+		if minutes, ok := cfg.PointsToMinutes[int(points)]; ok && float64(int(points)) == points {
+			return minutes
+		}
+		if cfg.MinutesPerPoint > 0 {
+			return int(math.Round(points * float64(cfg.MinutesPerPoint)))
+		}
+		if len(cfg.PointsToMinutes) > 0 {
+			// Use the closest configured point value
+			var known []int
+			for p := range cfg.PointsToMinutes {
+				known = append(known, p)
+			}
+			sort.Ints(known)
+			best := known[0]
+			for _, p := range known {
+				if math.Abs(float64(p)-points) < math.Abs(float64(best)-points) {
+					best = p
+				}
+			}
+			return cfg.PointsToMinutes[best]
+		}
+	}
+	return cfg.DefaultMinutes
+}
+
+// jiraTask is the task an issue becomes, its key leading the title
+func jiraTask(cfg JiraConfig, issue jiraIssue) Task {
+	task := Task{
+		Title:     issue.Key + " " + issue.Summary,
+		Estimated: estimateFromPoints(cfg, issue.Points),
+		Status:    "pending",
+		IssueKey:  issue.Key,
+		Epic:      issue.Epic,
+	}
+	applyTagRules(&task)
+	return task
+}
+
+// planFromJira lets the user pick active-sprint issues to bring into a day
+func planFromJira(day string) error {
+	if _, err := time.Parse("2006-01-02", day); err != nil {
+		return fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", day)
+	}
+	client, err := newJiraClient()
+	if err != nil {
+		return err
+	}
+	issues, err := client.activeSprintIssues()
+	if err != nil {
+		return err
+	}
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	planned := map[string]bool{}
+	for _, t := range data[day] {
+		if t.IssueKey != "" {
+			planned[t.IssueKey] = true
+		}
+	}
+	var candidates []jiraIssue
+	var labels []string
+	for _, issue := range issues {
+		if planned[issue.Key] {
+			continue
+		}
+		est := estimateFromPoints(client.cfg, issue.Points)
+		candidates = append(candidates, issue)
+		labels = append(labels, fmt.Sprintf("%-10s %s (%s, %.0f pts → %d min)", issue.Key, issue.Summary, issue.Status, issue.Points, est))
+	}
+	if len(candidates) == 0 {
+		fmt.Println("No unplanned issues in the active sprint.")
+		return nil
+	}
+
+	picked, err := multiSelect(fmt.Sprintf("Sprint issues to plan for %s", day), labels, nil)
+	if err != nil {
+		if err.Error() == "interrupt" {
+			return nil
+		}
+		return err
+	}
+	for _, i := range picked {
+		data[day] = append(data[day], jiraTask(client.cfg, candidates[i]))
+	}
+	if len(picked) == 0 {
+		fmt.Println("Nothing selected.")
+		return nil
+	}
+	if _, err := offerDuplicateMerge(data, day); err != nil && err.Error() != "interrupt" {
+		return err
+	}
+	warnIfOverCapacity(day, data[day], 0)
+	warnIfMeetingsCrowdOut(day, data[day])
+	if save, err := confirmForecast(data, day); err != nil || !save {
+		if err == nil || err.Error() == "interrupt" {
+			fmt.Println("Plan not saved.")
+			return nil
+		}
+		return err
+	}
+	if err := saveTasks(data); err != nil {
+		return err
+	}
+	fmt.Printf("Added %d issues to %s.\n", len(picked), day)
+	return nil
+}
+
+// pullFromJira adds the issues assigned to me to a day without asking,
+// skipping those already on that day or still open on another one
+func pullFromJira(day string) error {
+	if _, err := time.Parse("2006-01-02", day); err != nil {
+		return fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", day)
+	}
+	client, err := newJiraClient()
+	if err != nil {
+		return err
+	}
+	issues, err := client.assignedIssues()
+	if err != nil {
+		return err
+	}
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	known := map[string]string{}
+	for d, tasks := range data {
+		for _, t := range tasks {
+			if t.IssueKey != "" && (d == day || isOpenStatus(t.Status)) {
+				known[t.IssueKey] = d
+			}
+		}
+	}
+	added := 0
+	for _, issue := range issues {
+		if d, ok := known[issue.Key]; ok {
+			if d != day {
+				fmt.Printf("  %-10s already planned on %s\n", issue.Key, d)
+			}
+			continue
+		}
+		task := jiraTask(client.cfg, issue)
+		data[day] = append(data[day], task)
+		fmt.Printf("+ %-10s %s (%s)\n", issue.Key, issue.Summary, fmtMinutes(task.Estimated))
+		added++
+	}
+	if added == 0 {
+		fmt.Printf("No new Jira issues for %s.\n", day)
+		return nil
+	}
+	if err := saveTasks(data); err != nil {
+		return err
+	}
+	fmt.Printf("Added %d issues to %s.\n", added, day)
+	warnIfOverCapacity(day, data[day], 0)
+	warnIfMeetingsCrowdOut(day, data[day])
+	return nil
+}
+
+// worklogStart is when the time being logged started: the first session for
+// a first worklog, the last one for time added since, else the start of work
+func worklogStart(t Task, day string) time.Time {
+	if len(t.Sessions) > 0 {
+		s := t.Sessions[0]
+		if t.JiraLogged > 0 {
+			s = t.Sessions[len(t.Sessions)-1]
+		}
+		return time.Unix(s.Start, 0)
+	}
+	date, _ := time.ParseInLocation("2006-01-02", day, time.Local)
+	return clockOn(date, config.WorkHours.Start)
+}
+
+// addWorklog logs minutes of work on an issue
+func (c *jiraClient) addWorklog(key string, minutes int, started time.Time) error {
+	body := map[string]interface{}{
+		"timeSpentSeconds": minutes * 60,
+		"started":          started.Format("2006-01-02T15:04:05.000-0700"),
+		"comment":          "Logged with daily",
+	}
+	return c.do(http.MethodPost, "/rest/api/2/issue/"+url.PathEscape(key)+"/worklog", body, nil)
+}
+
+// pushToJira logs the time of a day's finished Jira tasks as worklogs. Each
+// task remembers what it has logged, so pushing again only sends time
+// tracked since.
+func pushToJira(day string, dryRun bool) error {
+	if _, err := time.Parse("2006-01-02", day); err != nil {
+		return fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", day)
+	}
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	var pending []int
+	for i, t := range data[day] {
+		if t.IssueKey != "" && t.Status == "done" && t.Actual > t.JiraLogged {
+			pending = append(pending, i)
+		}
+	}
+	if len(pending) == 0 {
+		fmt.Printf("No finished Jira tasks with time to log on %s.\n", day)
+		return nil
+	}
+	var client *jiraClient
+	if !dryRun {
+		if client, err = newJiraClient(); err != nil {
+			return err
+		}
+	}
+	logged := 0
+	for _, i := range pending {
+		t := &data[day][i]
+		minutes := t.Actual - t.JiraLogged
+		started := worklogStart(*t, day)
+		if dryRun {
+			fmt.Printf("  %-10s %8s from %s  %s\n", t.IssueKey, fmtMinutes(minutes), started.Format("15:04"), t.Title)
+			continue
+		}
+		if err := client.addWorklog(t.IssueKey, minutes, started); err != nil {
+			if logged > 0 {
+				saveTasks(data)
+			}
+			return fmt.Errorf("%s: %w", t.IssueKey, err)
+		}
+		t.JiraLogged = t.Actual
+		logged++
+		fmt.Printf("Logged %s on %s.\n", fmtMinutes(minutes), t.IssueKey)
+	}
+	if dryRun {
+		fmt.Println("(dry run: nothing was logged)")
+		return nil
+	}
+	return saveTasks(data)
+}
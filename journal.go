@@ -0,0 +1,146 @@
+// journal.go - Weekly notes journal
+// Appends each closed week's notes to a per-year Markdown journal outside the YAML store
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// JournalConfig is the `journal` section of config.yaml
+type JournalConfig struct {
+	Enabled bool   `yaml:"enabled"`  // archive automatically when the week's last day is closed
+	Dir     string `yaml:"dir"`      // default: the data directory's journal folder
+	LastDay string `yaml:"last_day"` // weekday that ends the working week, default friday
+}
+
+// weekMonday returns the Monday starting the week of date
+func weekMonday(date time.Time) time.Time {
+	offset := (int(date.Weekday()) + 6) % 7
+	return date.AddDate(0, 0, -offset)
+}
+
+// journalLastDay returns the configured last working day of the week
+func journalLastDay() (time.Weekday, error) {
+	name := strings.ToLower(config.Journal.LastDay)
+	if name == "" {
+		return time.Friday, nil
+	}
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.ToLower(d.String()) == name {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid journal.last_day %q", config.Journal.LastDay)
+}
+
+func journalFilePath(year int) (string, error) {
+	dir := expandHome(config.Journal.Dir)
+	if dir == "" {
+		dataDir, err := getDataDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(dataDir, "journal")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, fmt.Sprintf("journal-%d.md", year)), nil
+}
+
+// renderWeekNotes renders the notes of the week starting on monday as a
+// Markdown section, or "" when the week has no notes
+func renderWeekNotes(notes NoteData, monday time.Time) (heading, section string) {
+	year, week := monday.ISOWeek()
+	heading = fmt.Sprintf("## %d-W%02d", year, week)
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (%s – %s)\n", heading, monday.Format("Jan 2"), monday.AddDate(0, 0, 6).Format("Jan 2"))
+	empty := true
+	for i := 0; i < 7; i++ {
+		day := monday.AddDate(0, 0, i)
+		dayNotes := visibleNotes(notes[day.Format("2006-01-02")])
+		if len(dayNotes) == 0 {
+			continue
+		}
+		empty = false
+		fmt.Fprintf(&b, "\n### %s\n\n", day.Format("Monday 2006-01-02"))
+		for _, note := range dayNotes {
+			fmt.Fprintf(&b, "- %s\n", strings.ReplaceAll(strings.TrimSpace(note), "\n", "\n  "))
+		}
+	}
+	if empty {
+		return heading, ""
+	}
+	return heading, b.String()
+}
+
+// archiveWeekNotes writes the notes of day's week into that year's journal,
+// replacing the week's section if it was archived before
+func archiveWeekNotes(day string) error {
+	date, err := time.Parse("2006-01-02", day)
+	if err != nil {
+		return fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", day)
+	}
+	notes, err := loadNotes()
+	if err != nil {
+		return err
+	}
+	monday := weekMonday(date)
+	heading, section := renderWeekNotes(notes, monday)
+	if section == "" {
+		fmt.Printf("No notes in the week of %s.\n", monday.Format("2006-01-02"))
+		return nil
+	}
+	year, _ := monday.ISOWeek()
+	path, err := journalFilePath(year)
+	if err != nil {
+		return err
+	}
+	existing, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	content := string(existing)
+	if content == "" {
+		content = fmt.Sprintf("# Journal %d\n", year)
+	}
+	if start := strings.Index(content, heading+" "); start >= 0 {
+		end := len(content)
+		if next := strings.Index(content[start+len(heading):], "\n## "); next >= 0 {
+			end = start + len(heading) + next + 1
+		}
+		content = content[:start] + section + "\n" + content[end:]
+	} else {
+		content = strings.TrimRight(content, "\n") + "\n\n" + section
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return err
+	}
+	fmt.Printf("Notes for the week of %s written to %s\n", monday.Format("2006-01-02"), path)
+	return nil
+}
+
+// maybeArchiveWeek archives the week's notes when closing its last working day
+func maybeArchiveWeek(day string) error {
+	if !config.Journal.Enabled {
+		return nil
+	}
+	date, err := time.Parse("2006-01-02", day)
+	if err != nil {
+		return err
+	}
+	lastDay, err := journalLastDay()
+	if err != nil {
+		return err
+	}
+	if date.Weekday() != lastDay {
+		return nil
+	}
+	return archiveWeekNotes(day)
+}
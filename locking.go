@@ -0,0 +1,206 @@
+// locking.go - Concurrent invocations
+// Saves take a lock file in the data directory and merge with changes other daily processes made since the data was loaded
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// lockWait is how long a save waits for another process to release the lock
+	lockWait = 5 * time.Second
+	// lockStale is the age after which a lock left by a crashed process is broken
+	lockStale = 30 * time.Second
+)
+
+// lockDataDir takes the lock file of the data directory, waiting for another
+// process holding it, and returns the function that releases it
+func lockDataDir() (func(), error) {
+	path, err := dataFilePath(".lock")
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(lockWait)
+	for {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			fmt.Fprintf(file, "%d\n", os.Getpid())
+			file.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > lockStale {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			holder, _ := os.ReadFile(path)
+			return nil, fmt.Errorf("the data directory is locked by another daily process (pid %s); if none is running, delete %s",
+				strings.TrimSpace(string(holder)), path)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// loaded remembers the tasks and notes as this process last read or wrote
+// them, the common ancestor when merging a save with another process's changes
+var loaded struct {
+	sync.Mutex
+	tasks TaskData
+	notes NoteData
+}
+
+// cloneTaskData copies data deeply enough that later edits to tasks, their
+// tags, sessions, history or notes don't reach the copy
+func cloneTaskData(data TaskData) TaskData {
+	out := make(TaskData, len(data))
+	for day, tasks := range data {
+		copied := make([]Task, len(tasks))
+		for i, t := range tasks {
+			t.Tags = slices.Clone(t.Tags)
+			t.Sessions = slices.Clone(t.Sessions)
+			t.History = slices.Clone(t.History)
+			t.Notes = slices.Clone(t.Notes)
+			copied[i] = t
+		}
+		out[day] = copied
+	}
+	return out
+}
+
+func cloneNoteData(data NoteData) NoteData {
+	out := make(NoteData, len(data))
+	for day, notes := range data {
+		out[day] = slices.Clone(notes)
+	}
+	return out
+}
+
+func rememberTasks(data TaskData) {
+	loaded.Lock()
+	loaded.tasks = cloneTaskData(data)
+	loaded.Unlock()
+}
+
+func rememberNotes(data NoteData) {
+	loaded.Lock()
+	loaded.notes = cloneNoteData(data)
+	loaded.Unlock()
+}
+
+// sameItems compares two days, treating a missing day as an empty one
+func sameItems[T any](a, b []T) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return len(a) == len(b)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// mergeDays merges our version of day-keyed data with theirs, base being the
+// version both started from. A day changed on one side only takes that side.
+// A day changed on both sides is merged item by item when neither side added
+// or removed items; otherwise it is a conflict, reported by day.
+func mergeDays[T any](base, ours, theirs map[string][]T) (map[string][]T, []string) {
+	days := map[string]bool{}
+	for _, m := range []map[string][]T{base, ours, theirs} {
+		for day := range m {
+			days[day] = true
+		}
+	}
+	merged := map[string][]T{}
+	var conflicts []string
+	for day := range days {
+		b, o, t := base[day], ours[day], theirs[day]
+		var result []T
+		switch {
+		case sameItems(b, o) || sameItems(o, t):
+			result = t
+		case sameItems(b, t):
+			result = o
+		case len(b) == len(o) && len(b) == len(t):
+			result = make([]T, len(b))
+			for i := range b {
+				switch {
+				case reflect.DeepEqual(b[i], o[i]):
+					result[i] = t[i]
+				case reflect.DeepEqual(b[i], t[i]) || reflect.DeepEqual(o[i], t[i]):
+					result[i] = o[i]
+				default:
+					result = nil
+				}
+				if result == nil {
+					break
+				}
+			}
+			if result == nil {
+				conflicts = append(conflicts, day)
+				continue
+			}
+		default:
+			conflicts = append(conflicts, day)
+			continue
+		}
+		if len(result) > 0 {
+			merged[day] = result
+		}
+	}
+	sort.Strings(conflicts)
+	return merged, conflicts
+}
+
+// conflictError explains a save that would overwrite another process's changes
+func conflictError(name string, days []string) error {
+	return fmt.Errorf("%s was changed by another daily command while this one ran (%s); nothing was saved, run the command again",
+		name, strings.Join(days, ", "))
+}
+
+// replaceDays makes data hold the merged days, so a caller that keeps editing
+// and saving the same map works on top of the other process's changes
+func replaceDays[T any](data, merged map[string][]T) {
+	maps.DeleteFunc(data, func(string, []T) bool { return true })
+	maps.Copy(data, merged)
+}
+
+// mergeConcurrentTasks reconciles the tasks being saved with what is in the store now
+func mergeConcurrentTasks(ours, current TaskData) (TaskData, error) {
+	loaded.Lock()
+	base := loaded.tasks
+	loaded.Unlock()
+	if base == nil || reflect.DeepEqual(base, current) {
+		return ours, nil
+	}
+	merged, conflicts := mergeDays(base, ours, current)
+	if len(conflicts) > 0 {
+		return nil, conflictError("tasks.yaml", conflicts)
+	}
+	replaceDays(ours, merged)
+	return ours, nil
+}
+
+// mergeConcurrentNotes reconciles the notes being saved with what is in the store now
+func mergeConcurrentNotes(ours, current NoteData) (NoteData, error) {
+	loaded.Lock()
+	base := loaded.notes
+	loaded.Unlock()
+	if base == nil || reflect.DeepEqual(base, current) {
+		return ours, nil
+	}
+	merged, conflicts := mergeDays(base, ours, current)
+	if len(conflicts) > 0 {
+		return nil, conflictError("notes.yaml", conflicts)
+	}
+	replaceDays(ours, merged)
+	return ours, nil
+}
@@ -12,7 +12,8 @@ import (
 	"math"
 	"os"
 	"os/exec"
-	"path/filepath"
+	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -22,7 +23,6 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/manifoldco/promptui"
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v3"
 )
 
 // --- Bubble Tea Progress Model (for followStartedTask) ---
@@ -32,6 +32,29 @@ type taskModel struct {
 	task          *Task
 	startTime     time.Time
 	totalDuration time.Duration
+	day           dayWatcher
+	message       string
+}
+
+// startedTaskToday returns a copy of today's running task, or nil
+func startedTaskToday() (*Task, error) {
+	data, err := loadTasks()
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range data[todayKey()] {
+		if t.Status == "started" {
+			return &t, nil
+		}
+	}
+	return nil, nil
+}
+
+// follow points the model at a running task
+func (m *taskModel) follow(t *Task) {
+	m.task = t
+	m.startTime = time.Unix(time.Now().Unix()-int64(t.trackedMinutes(time.Now().Unix())*60), 0)
+	m.totalDuration = time.Duration(t.Estimated) * time.Minute
 }
 
 type tickMsg struct{}
@@ -45,10 +68,23 @@ func (m taskModel) Init() tea.Cmd {
 func (m taskModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		if msg.Type == tea.KeyCtrlC || msg.String() == "q" {
+		if keys.IsQuit(msg) {
 			return m, tea.Quit
 		}
 	case tickMsg:
+		if changed, message, err := m.day.check(); changed {
+			if err != nil {
+				m.message = "Error: " + err.Error()
+				return m, tea.Quit
+			}
+			t, err := startedTaskToday()
+			if err != nil || t == nil || t.Estimated == 0 {
+				m.message = strings.TrimSpace(message + " Nothing is running on " + todayKey() + ".")
+				return m, tea.Quit
+			}
+			m.message = message
+			m.follow(t)
+		}
 		elapsed := time.Since(m.startTime)
 		percent := math.Min(1.0, float64(elapsed)/float64(m.totalDuration))
 		m.progress.SetPercent(percent)
@@ -65,13 +101,17 @@ func (m taskModel) View() string {
 	if remaining < 0 {
 		remaining = 0
 	}
-	return fmt.Sprintf(
+	view := fmt.Sprintf(
 		"%s\n%s\nElapsed: %s\nRemaining: %s\n",
 		m.task.Title,
 		m.progress.ViewAs(elapsed.Seconds()/m.totalDuration.Seconds()),
 		formatDuration(elapsed),
 		formatDuration(remaining),
 	)
+	if m.message != "" {
+		view += "\n" + m.message + "\n"
+	}
+	return view
 }
 
 // formatDuration formats a time.Duration for display
@@ -92,11 +132,120 @@ func formatDuration(d time.Duration) string {
 
 // Task represents a single task entry
 type Task struct {
-	Title     string `yaml:"title"`
-	Estimated int    `yaml:"estimated"`
-	Actual    int    `yaml:"actual"`
-	Status    string `yaml:"status"`
-	StartedAt int64  `yaml:"started_at"`
+	ID            string      `yaml:"id,omitempty"` // unique within its day, see assignTaskIDs
+	Title         string      `yaml:"title"`
+	Estimated     int         `yaml:"estimated"`
+	Actual        int         `yaml:"actual"`
+	Status        string      `yaml:"status"`
+	FinishBy      int64       `yaml:"finish_by,omitempty"`
+	Project       string      `yaml:"project,omitempty"`
+	Tags          []string    `yaml:"tags,omitempty"`
+	Interruptions int         `yaml:"interruptions,omitempty"`
+	Category      string      `yaml:"category,omitempty"`
+	ResumeNote    string      `yaml:"resume_note,omitempty"`
+	Sessions      []Session   `yaml:"sessions,omitempty"`
+	ExternalID    string      `yaml:"external_id,omitempty"`
+	PlannedAt     string      `yaml:"planned_at,omitempty"`
+	Context       string      `yaml:"context,omitempty"`
+	IssueKey      string      `yaml:"issue_key,omitempty"`
+	JiraLogged    int         `yaml:"jira_logged,omitempty"` // minutes already pushed as Jira worklogs
+	Priority      int         `yaml:"priority,omitempty"`
+	CreatedAt     int64       `yaml:"created_at,omitempty"`
+	History       []TaskEvent `yaml:"history,omitempty"` // status changes and estimate revisions
+	Notes         []TaskNote  `yaml:"notes,omitempty"`
+	Epic          string      `yaml:"epic,omitempty"`       // the epic the task is part of, e.g. a Jira epic key
+	DependsOn     []string    `yaml:"depends_on,omitempty"` // IDs of the tasks of the day to finish first
+
+	// LegacyStartedAt is only read, to upgrade files written before the
+	// running segment was kept in Sessions
+	LegacyStartedAt int64 `yaml:"started_at,omitempty" json:"StartedAt,omitempty"`
+}
+
+// Session is one uninterrupted stretch of work on a task. The running
+// segment of a started task has no End yet.
+type Session struct {
+	Start    int64  `yaml:"start"`
+	End      int64  `yaml:"end"`
+	Toggl    string `yaml:"toggl,omitempty"`    // ID of the time entry pushed to Toggl
+	Clockify string `yaml:"clockify,omitempty"` // ID of the time entry pushed to Clockify
+}
+
+// runningSession returns the open segment of a started task, or nil
+func (t *Task) runningSession() *Session {
+	if n := len(t.Sessions); n > 0 && t.Sessions[n-1].End == 0 {
+		return &t.Sessions[n-1]
+	}
+	return nil
+}
+
+// runningSince returns when the open segment started, or 0 when the clock is stopped
+func (t Task) runningSince() int64 {
+	if s := t.runningSession(); s != nil {
+		return s.Start
+	}
+	return 0
+}
+
+// startSession opens a new segment unless one is already running
+func (t *Task) startSession(now int64) {
+	if t.runningSession() == nil {
+		t.Sessions = append(t.Sessions, Session{Start: now})
+	}
+}
+
+// closedSeconds sums the finished segments
+func (t Task) closedSeconds() int64 {
+	var total int64
+	for _, s := range t.Sessions {
+		if s.End != 0 {
+			total += s.End - s.Start
+		}
+	}
+	return total
+}
+
+// endSession closes the running segment and adds the newly completed minutes
+// to Actual. Seconds carry over between segments, so stopping and starting
+// again does not lose time to rounding.
+func (t *Task) endSession(now int64) {
+	s := t.runningSession()
+	if s == nil {
+		return
+	}
+	before := t.closedSeconds() / 60
+	s.End = now
+	t.Actual += int(t.closedSeconds()/60 - before)
+}
+
+// sessionSeconds returns how long the open segment has been running, or 0
+// when the clock is stopped
+func (t Task) sessionSeconds(now int64) int64 {
+	if since := t.runningSince(); since != 0 {
+		return now - since
+	}
+	return 0
+}
+
+// trackedMinutes returns Actual plus the time in the running segment
+func (t Task) trackedMinutes(now int64) int {
+	since := t.runningSince()
+	if since == 0 {
+		return t.Actual
+	}
+	return t.Actual + int((t.closedSeconds()%60+now-since)/60)
+}
+
+// upgradeLegacyStarts turns the started_at of older files into an open segment
+func upgradeLegacyStarts(data TaskData) {
+	for _, tasks := range data {
+		for i := range tasks {
+			t := &tasks[i]
+			if t.LegacyStartedAt != 0 && t.Status == "started" {
+				t.startSession(t.LegacyStartedAt)
+			}
+			t.LegacyStartedAt = 0
+		}
+	}
 }
 
 type TaskData map[string][]Task
@@ -104,22 +253,140 @@ type TaskData map[string][]Task
 // NoteData stores notes per day
 type NoteData map[string][]string
 
-const maxDailyMinutes = 480
+// parseEstimate parses an estimate given in minutes ("45", "45m") or in
+// fractional hours ("1.5h"). Zero is allowed for checklist-style tasks.
+func parseEstimate(input string) (int, error) {
+	input = strings.ToLower(strings.TrimSpace(input))
+	if input == "" {
+		return 0, nil
+	}
+	if strings.HasSuffix(input, "h") {
+		hours, err := strconv.ParseFloat(strings.TrimSuffix(input, "h"), 64)
+		if err != nil || hours < 0 {
+			return 0, fmt.Errorf("please enter minutes (45) or hours (1.5h)")
+		}
+		return int(math.Round(hours * 60)), nil
+	}
+	minutes, err := strconv.Atoi(strings.TrimSuffix(input, "m"))
+	if err != nil || minutes < 0 {
+		return 0, fmt.Errorf("please enter minutes (45) or hours (1.5h)")
+	}
+	return minutes, nil
+}
+
+// ratioOf returns part/whole, or 0 when there is nothing to compare against
+func ratioOf(part, whole int) float64 {
+	if whole <= 0 {
+		return 0
+	}
+	return float64(part) / float64(whole)
+}
+
+// projectedFinish returns the clock time at which a task started at `from`
+// should be done, given its estimate and the time already spent on it
+func projectedFinish(t Task, from time.Time) time.Time {
+	left := t.Estimated - t.Actual
+	if left < 0 {
+		left = 0
+	}
+	return from.Add(time.Duration(left) * time.Minute)
+}
 
 // --- Notes Logic ---
 
-// getEditor returns the user's preferred editor or a sensible default
+// fallbackEditors are tried in order when no editor is configured
+var fallbackEditors = map[string][]string{
+	"windows": {"notepad"},
+	"darwin":  {"nano", "vim", "vi", "open -W -t"},
+	"default": {"nano", "vim", "vi"},
+}
+
+// getEditor returns the configured editor command: the `editor` setting,
+// then $VISUAL, then $EDITOR. It is empty when none is set.
 func getEditor() string {
-	return "nano"
+	for _, editor := range []string{config.Editor, os.Getenv("VISUAL"), os.Getenv("EDITOR")} {
+		if strings.TrimSpace(editor) != "" {
+			return editor
+		}
+	}
+	return ""
+}
+
+// splitCommand splits an editor command such as `code --wait` or
+// `"C:\Program Files\Notepad++\notepad++.exe" -multiInst` into its arguments
+func splitCommand(command string) []string {
+	var args []string
+	var current strings.Builder
+	inQuotes, hasArg := false, false
+	for _, r := range command {
+		switch {
+		case r == '"' || r == '\'':
+			inQuotes = !inQuotes
+			hasArg = true
+		case (r == ' ' || r == '\t') && !inQuotes:
+			if hasArg {
+				args = append(args, current.String())
+				current.Reset()
+				hasArg = false
+			}
+		default:
+			current.WriteRune(r)
+			hasArg = true
+		}
+	}
+	if hasArg {
+		args = append(args, current.String())
+	}
+	return args
+}
+
+// editorCommand resolves the editor to an executable and its arguments,
+// falling back to a platform default that is installed
+func editorCommand() ([]string, error) {
+	if editor := getEditor(); editor != "" {
+		args := splitCommand(editor)
+		if len(args) == 0 {
+			return nil, fmt.Errorf("empty editor command")
+		}
+		path, err := exec.LookPath(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("editor %q not found; fix `editor` in the config or $VISUAL/$EDITOR", args[0])
+		}
+		return append([]string{path}, args[1:]...), nil
+	}
+	candidates, ok := fallbackEditors[runtime.GOOS]
+	if !ok {
+		candidates = fallbackEditors["default"]
+	}
+	for _, candidate := range candidates {
+		args := splitCommand(candidate)
+		if path, err := exec.LookPath(args[0]); err == nil {
+			return append([]string{path}, args[1:]...), nil
+		}
+	}
+	return nil, fmt.Errorf("no editor found (tried %s); set `editor` in the config or $EDITOR", strings.Join(candidates, ", "))
 }
 
 // editNoteForDay opens the note for a given day in the user's editor
 func editNoteForDay(day string) error {
+	if _, err := time.Parse("2006-01-02", day); err != nil {
+		return fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", day)
+	}
+	if err := requireOnline("Opening an editor"); err != nil {
+		return err
+	}
 	data, err := loadNotes()
 	if err != nil {
 		return err
 	}
-	notes := data[day]
+	notes, passphrase, err := readDayNotes(data, day)
+	if err != nil {
+		return err
+	}
+	editor, err := editorCommand()
+	if err != nil {
+		return err
+	}
 	tmpfile, err := ioutil.TempFile("", "daily_note_*.md")
 	if err != nil {
 		return err
@@ -133,7 +400,7 @@ func editNoteForDay(day string) error {
 	tmpfile.Close()
 
 	// Open editor
-	cmd := exec.Command(getEditor(), tmpfile.Name())
+	cmd := exec.Command(editor[0], append(editor[1:], tmpfile.Name())...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -154,123 +421,175 @@ func editNoteForDay(day string) error {
 			newNotes = append(newNotes, line)
 		}
 	}
-	data[day] = newNotes
+	if err := writeDayNotes(data, day, newNotes, passphrase); err != nil {
+		return err
+	}
 	return saveNotes(data)
 }
 
-// Parse date string or return today if empty
+// parseNoteDayArg returns the day given as an argument, in any form --date
+// takes, or the --date day. A day that doesn't parse is returned as is, for
+// the caller to report.
 func parseNoteDayArg(args []string) string {
 	if len(args) > 0 && args[0] != "" {
+		if day, err := parseDay(args[0], time.Now()); err == nil {
+			return day
+		}
 		return args[0]
 	}
-	return todayKey()
-}
-
-func getNoteFilePath() (string, error) {
-	exePath, err := os.Executable()
-	if err != nil {
-		return "", err
-	}
-	dir := filepath.Dir(exePath)
-	return filepath.Join(dir, "notes.yaml"), nil
+	return workDay()
 }
 
 func loadNotes() (NoteData, error) {
-	filePath, err := getNoteFilePath()
+	store, err := openStore()
 	if err != nil {
 		return nil, err
 	}
-	data := NoteData{}
-	file, err := os.ReadFile(filePath)
+	data, err := store.LoadNotes()
 	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return NoteData{}, nil
-		}
 		return nil, err
 	}
-	err = yaml.Unmarshal(file, &data)
-	return data, err
+	rememberNotes(data)
+	return data, nil
 }
 
 func saveNotes(data NoteData) error {
-	filePath, err := getNoteFilePath()
+	store, err := openStore()
 	if err != nil {
 		return err
 	}
-	file, err := yaml.Marshal(&data)
+	unlock, err := lockDataDir()
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(filePath, file, 0644)
+	defer unlock()
+	before, err := store.LoadNotes()
+	if err != nil {
+		return err
+	}
+	if data, err = mergeConcurrentNotes(data, before); err != nil {
+		return err
+	}
+	if !undoing {
+		if err := journalNotes(before, data); err != nil {
+			return err
+		}
+	}
+	if err := store.SaveNotes(data); err != nil {
+		return err
+	}
+	rememberNotes(data)
+	return nil
 }
 
 func addNoteForToday(note string) error {
+	return addDayNote(todayKey(), note)
+}
+
+// addDayNote appends a note to a day, re-sealing the day if it is locked
+func addDayNote(day, note string) error {
 	data, err := loadNotes()
 	if err != nil {
 		return err
 	}
-	today := todayKey()
-	data[today] = append(data[today], note)
+	notes, passphrase, err := readDayNotes(data, day)
+	if err != nil {
+		return err
+	}
+	if err := writeDayNotes(data, day, append(notes, note), passphrase); err != nil {
+		return err
+	}
 	return saveNotes(data)
 }
 
-func showNotesForToday() error {
+// showNotesForDay prints a day's notes, rendered as Markdown unless raw is set
+func showNotesForDay(day string, raw bool) error {
+	if _, err := time.Parse("2006-01-02", day); err != nil {
+		return fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", day)
+	}
 	data, err := loadNotes()
 	if err != nil {
 		return err
 	}
-	today := todayKey()
-	notes := data[today]
+	notes, _, err := readDayNotes(data, day)
+	if err != nil {
+		return err
+	}
+	label := day
+	if day == todayKey() {
+		label = "today"
+	}
 	if len(notes) == 0 {
-		fmt.Println("No notes for today.")
+		fmt.Printf("No notes for %s.\n", label)
 		return nil
 	}
-	fmt.Printf("Notes for today (%s):\n", today)
-	for i, note := range notes {
-		fmt.Printf("%d. %s\n", i+1, note)
+	if day == todayKey() {
+		fmt.Printf("Notes for today (%s):\n", day)
+	} else {
+		fmt.Printf("Notes for %s:\n", day)
 	}
+	printNotes(notes, raw)
 	return nil
 }
 
 // --- Task Logic ---
 
-func getTaskFilePath() (string, error) {
-	exePath, err := os.Executable()
-	if err != nil {
-		return "", err
-	}
-	dir := filepath.Dir(exePath)
-	return filepath.Join(dir, "tasks.yaml"), nil
-}
-
 func loadTasks() (TaskData, error) {
-	filePath, err := getTaskFilePath()
+	store, err := openStore()
 	if err != nil {
 		return nil, err
 	}
-
-	data := TaskData{}
-	file, err := os.ReadFile(filePath)
+	data, err := store.LoadTasks()
 	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return TaskData{}, nil
-		}
 		return nil, err
 	}
-	err = yaml.Unmarshal(file, &data)
-	return data, err
+	upgradeLegacyStarts(data)
+	assignTaskIDs(data)
+	rememberTasks(data)
+	return data, nil
 }
 
+// saveTasks saves all tasks. Days another daily process changed since this
+// one loaded the tasks are merged rather than overwritten.
 func saveTasks(data TaskData) error {
-	filePath, err := getTaskFilePath()
+	store, err := openStore()
 	if err != nil {
 		return err
 	}
-	file, err := yaml.Marshal(&data)
+	unlock, err := lockDataDir()
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(filePath, file, 0644)
+	defer unlock()
+	before, err := store.LoadTasks()
+	if err != nil {
+		return err
+	}
+	upgradeLegacyStarts(before)
+	assignTaskIDs(before)
+	if data, err = mergeConcurrentTasks(data, before); err != nil {
+		return err
+	}
+	// New tasks get their ID here
+	assignTaskIDs(data)
+	if !undoing {
+		if err := journalTasks(before, data); err != nil {
+			return err
+		}
+	}
+	for day, tasks := range data {
+		if !undoing {
+			stampCreated(before[day], tasks, time.Now().Unix())
+		}
+		if err := snapshotPlan(day, before[day], tasks); err != nil {
+			return err
+		}
+	}
+	if err := store.SaveTasks(data); err != nil {
+		return err
+	}
+	rememberTasks(data)
+	return nil
 }
 
 func promptWithCursor(label string, defaultVal string) (string, error) {
@@ -288,11 +607,11 @@ func promptWithCursor(label string, defaultVal string) (string, error) {
 }
 
 func todayKey() string {
-	return time.Now().Format("2006-01-02")
+	return dayOf(time.Now()).Format("2006-01-02")
 }
 
 func yesterdayKey() string {
-	return time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	return dayOf(time.Now()).AddDate(0, 0, -1).Format("2006-01-02")
 }
 
 func showYesterdayTasks() error {
@@ -313,26 +632,66 @@ func showYesterdayTasks() error {
 
 	totalEstimated := 0
 	totalActual := 0
+	meetingMinutes := 0
+	timed := 0
+	var checklist []Task
+
+	nonWorkMinutes := 0
+	for _, task := range tasks {
+		if isNonWork(task) {
+			nonWorkMinutes += task.Actual
+			continue
+		}
+		// Estimate-less tasks are checklist items, listed separately
+		if task.Estimated == 0 {
+			checklist = append(checklist, task)
+			continue
+		}
+		if timed > 0 {
+			fmt.Println() // Extra line between tasks
+		}
+		timed++
 
-	for i, task := range tasks {
-		fmt.Printf("[%d] %s\n", i+1, task.Title)
+		fmt.Printf("[%d] %s\n", timed, task.Title)
 		fmt.Printf("    Status: %s\n", task.Status)
-		fmt.Printf("    Estimated: %d minutes\n", task.Estimated)
-		fmt.Printf("    Actual: %d minutes\n", task.Actual)
+		fmt.Printf("    Estimated: %s\n", fmtMinutes(task.Estimated))
+		fmt.Printf("    Actual: %s\n", fmtMinutes(task.Actual))
 
-		if i < len(tasks)-1 {
-			fmt.Println() // Extra line between tasks
+		if hasTag(task, "meeting") {
+			meetingMinutes += task.Actual
+			continue
 		}
-
 		totalEstimated += task.Estimated
 		totalActual += task.Actual
 	}
 
-	fmt.Printf("\nSummary: %d tasks, %d/%d minutes (%.1f%%)\n",
-		len(tasks),
-		totalActual,
-		totalEstimated,
-		float64(totalActual)/float64(totalEstimated)*100)
+	checklistDone := 0
+	if len(checklist) > 0 {
+		fmt.Println("\nChecklist:")
+		for _, task := range checklist {
+			mark := " "
+			if task.Status == "done" {
+				mark = "x"
+				checklistDone++
+			}
+			fmt.Printf("  [%s] %s (%s)\n", mark, task.Title, task.Status)
+		}
+	}
+
+	fmt.Printf("\nSummary: %d tasks, %s (%.1f%%)\n",
+		timed,
+		fmtRatio(totalActual, totalEstimated),
+		ratioOf(totalActual, totalEstimated)*100)
+	if meetingMinutes > 0 {
+		fmt.Printf("Meetings: %s\n", fmtMinutes(meetingMinutes))
+	}
+	if nonWorkMinutes > 0 {
+		fmt.Printf("Non-work (commute/break/lunch): %s\n", fmtMinutes(nonWorkMinutes))
+	}
+	printTagTotals(tasks)
+	if len(checklist) > 0 {
+		fmt.Printf("Checklist: %d/%d done\n", checklistDone, len(checklist))
+	}
 
 	return nil
 }
@@ -343,26 +702,29 @@ func addTaskInteractive(tommorow bool) error {
 		return err
 	}
 
-	today := todayKey()
+	today := workDay()
 	if tommorow {
-		today = time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+		today = dayAfter(today)
 	}
 
-	title, err := promptWithCursor("Task Title", "")
+	history := buildTitleHistory(data)
+	title, err := promptTitleWithSuggestions("Task Title", history)
 	if err != nil {
 		if err.Error() == "interrupt" || err.Error() == "q" {
 			return nil
 		}
 		return err
 	}
+	defaultEst := ""
+	if h, ok := findTitleHistory(history, title); ok && h.typicalEstimate() > 0 {
+		defaultEst = strconv.Itoa(h.typicalEstimate())
+	}
 	estPrompt := promptui.Prompt{
-		Label: "Estimated Minutes",
+		Label:   "Estimated Minutes (1.5h for hours, 0 for a checklist item)",
+		Default: defaultEst,
 		Validate: func(input string) error {
-			val, err := strconv.Atoi(input)
-			if err != nil || val <= 0 {
-				return fmt.Errorf("please enter a valid number of minutes")
-			}
-			return nil
+			_, err := parseEstimate(input)
+			return err
 		},
 	}
 	estInput, err := estPrompt.Run()
@@ -372,88 +734,391 @@ func addTaskInteractive(tommorow bool) error {
 		}
 		return err
 	}
-	estimated, _ := strconv.Atoi(estInput)
-	total := 0
-	for _, t := range data[today] {
-		total += t.Estimated
+	estimated, _ := parseEstimate(estInput)
+	defaultTags := ""
+	if h, ok := findTitleHistory(history, title); ok {
+		defaultTags = strings.Join(h.Tags, ", ")
+	}
+	tagInput, err := promptWithCursor("Tags (comma-separated, optional)", defaultTags)
+	if err != nil {
+		if err.Error() == "interrupt" || err.Error() == "q" {
+			return nil
+		}
+		return err
+	}
+	priorityInput, err := promptWithCursor("Priority (high, medium, low, 1-9, optional)", "")
+	if err != nil {
+		if err.Error() == "interrupt" || err.Error() == "q" {
+			return nil
+		}
+		return err
+	}
+	priority, err := parsePriority(priorityInput)
+	if err != nil {
+		return err
 	}
-	if total+estimated > maxDailyMinutes {
-		fmt.Printf("total estimated time exceeds 8 hours")
+	task := Task{Title: title, Estimated: estimated, Status: "pending", Tags: parseTagList(tagInput), Priority: priority}
+	if err := confirmTagRules(&task); err != nil {
+		if err.Error() == "interrupt" || err.Error() == "q" {
+			return nil
+		}
+		return err
 	}
-	task := Task{Title: title, Estimated: estimated, Status: "pending", StartedAt: 0}
+	warnIfOverCapacity(today, data[today], estimated)
+	warnUnknownVocabulary(task)
 	data[today] = append(data[today], task)
 	return saveTasks(data)
 }
 
-func remainingMinutesToday(now time.Time) int {
-	workStart := time.Date(now.Year(), now.Month(), now.Day(), 8, 30, 0, 0, now.Location())
-	lunchStart := time.Date(now.Year(), now.Month(), now.Day(), 12, 30, 0, 0, now.Location())
-	lunchEnd := time.Date(now.Year(), now.Month(), now.Day(), 13, 30, 0, 0, now.Location())
-	workEnd := time.Date(now.Year(), now.Month(), now.Day(), 17, 30, 0, 0, now.Location())
-
-	if now.Before(workStart) {
-		return 480
+// warnIfOverCapacity warns when adding `extra` minutes to a day's plan would
+// exceed the configured daily maximum, less the day's reserved blocks
+func warnIfOverCapacity(day string, tasks []Task, extra int) {
+	total := 0
+	for _, t := range tasks {
+		if isNonWork(t) {
+			continue
+		}
+		total += t.Estimated
 	}
-	if now.After(workEnd) {
-		return 0
+	limit := config.WorkHours.MaxDailyMinutes
+	date, err := time.ParseInLocation("2006-01-02", day, time.Local)
+	if err == nil {
+		limit = dayCapacity(date)
 	}
-
-	minutes := 0
-	if now.Before(lunchStart) {
-		minutes += int(lunchStart.Sub(now).Minutes())
-		minutes += 240 // afternoon session (13:30–17:30)
-	} else if now.Before(lunchEnd) {
-		minutes += 240 // just afternoon session left
-	} else if now.Before(workEnd) {
-		minutes += int(workEnd.Sub(now).Minutes())
+	if total+extra <= limit {
+		return
+	}
+	if limit < config.WorkHours.MaxDailyMinutes {
+		fmt.Printf("Warning: total estimated time (%d min) exceeds the %d min left after reserved blocks\n", total+extra, limit)
+		return
 	}
-	return minutes
+	fmt.Printf("Warning: total estimated time (%d min) exceeds the daily maximum of %d min\n", total+extra, limit)
 }
 
-func listTasksInteractive(tommorow bool) error {
-	data, err := loadTasks()
+// addTaskFromFlags adds a task without prompting, for scripts and aliases
+func addTaskFromFlags(title, est, day string, tags []string, priorityStr, project, at, context, epic string) error {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return fmt.Errorf("--title is required")
+	}
+	if _, err := time.Parse("2006-01-02", day); err != nil {
+		return fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", day)
+	}
+	estimated, err := parseEstimate(est)
 	if err != nil {
 		return err
 	}
-	today := todayKey()
-	if tommorow {
-		today = time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+	priority, err := parsePriority(priorityStr)
+	if err != nil {
+		return err
 	}
-	tasks := data[today]
-	if len(tasks) == 0 {
-		fmt.Println("No tasks available.")
-		return nil
+	plannedAt, err := checkPlannedAt(at)
+	if err != nil {
+		return err
 	}
-	totalActual := 0
-	totalEst := 0
-	remainingWork := 0
-	achievedWork := 0
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	warnIfOverCapacity(day, data[day], estimated)
+	warnIfPastWorkEnd(day, plannedAt, estimated)
+	task := Task{Title: title, Estimated: estimated, Status: "pending", Tags: normalizeTags(tags), Priority: priority, Project: strings.TrimSpace(project), PlannedAt: plannedAt, Context: normalizeContext(context), Epic: strings.TrimSpace(epic)}
+	applyTagRules(&task)
+	warnUnknownVocabulary(task)
+	data[day] = append(data[day], task)
+	if err := saveTasks(data); err != nil {
+		return err
+	}
+	fmt.Printf("Added '%s' (%d min) to %s.\n", title, estimated, day)
+	return nil
+}
+
+// parseTagList parses a comma-separated list of tags as typed in a prompt
+func parseTagList(input string) []string {
+	return normalizeTags(strings.Split(input, ","))
+}
+
+// tagTotal sums the minutes of the tasks carrying one tag
+type tagTotal struct {
+	Tag       string
+	Estimated int
+	Actual    int
+}
+
+// tagTotals sums estimated and actual minutes per tag, largest plan first
+func tagTotals(tasks []Task) []tagTotal {
+	byTag := map[string]*tagTotal{}
+	var order []string
 	for _, t := range tasks {
-		totalActual += t.Actual
-		totalEst += t.Estimated
-		if t.Status == "done" {
-			achievedWork += t.Estimated
-		} else if t.Status != "done" && t.Status != "cancelled" {
-			remainingTime := t.Estimated - t.Actual
-			if remainingTime < 0 {
-				remainingTime = 0
+		if isNonWork(t) {
+			continue
+		}
+		for _, tag := range t.Tags {
+			total, ok := byTag[tag]
+			if !ok {
+				total = &tagTotal{Tag: tag}
+				byTag[tag] = total
+				order = append(order, tag)
 			}
-			remainingWork += remainingTime
+			total.Estimated += t.Estimated
+			total.Actual += t.Actual
 		}
 	}
+	totals := make([]tagTotal, 0, len(order))
+	for _, tag := range order {
+		totals = append(totals, *byTag[tag])
+	}
+	sort.SliceStable(totals, func(i, j int) bool { return totals[i].Estimated > totals[j].Estimated })
+	return totals
+}
 
-	templates := &promptui.SelectTemplates{
-		Label:    "{{ . }}",
-		Active:   "→ {{ .Title | cyan }} ({{ .Status | yellow }}, est: {{ .Estimated }}min, act: {{ .Actual }}min)",
-		Inactive: "  {{ .Title }} ({{ .Status | yellow }}, est: {{ .Estimated }}min, act: {{ .Actual }}min)",
-		Selected: "✔ {{ .Title }}",
+// printTagTotals prints the per-tag split of the day and reports whether
+// anything was printed
+func printTagTotals(tasks []Task) bool {
+	totals := tagTotals(tasks)
+	if len(totals) == 0 {
+		return false
+	}
+	planned := 0
+	for _, t := range tasks {
+		if !isNonWork(t) {
+			planned += t.Estimated
+		}
 	}
+	fmt.Println("By tag:")
+	for _, t := range totals {
+		fmt.Printf("  #%-16s est: %8s  act: %8s  (%.0f%% of plan)\n",
+			t.Tag, fmtMinutes(t.Estimated), fmtMinutes(t.Actual), ratioOf(t.Estimated, planned)*100)
+	}
+	return true
+}
 
-	actualProgressPercent := float64(totalActual) / float64(maxDailyMinutes)
-	estProgressPercent := float64(totalEst) / float64(maxDailyMinutes)
-	achievedWorkPercent := float64(achievedWork) / float64(totalEst)
-	actualProgressBar := progress.New(setColorGradient(actualProgressPercent, false))
-	estProgressBar := progress.New(setColorGradient(estProgressPercent, true))
+// normalizeTags trims, lowercases, and de-duplicates tags, dropping a leading #
+func normalizeTags(tags []string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		out = append(out, tag)
+	}
+	return out
+}
+
+// hasTag reports whether a task carries the given tag
+func hasTag(t Task, tag string) bool {
+	for _, tg := range t.Tags {
+		if strings.EqualFold(tg, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeContext lowercases a context and drops a leading @
+func normalizeContext(context string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(context), "@"))
+}
+
+// doableIn reports whether a task can be done in a context; tasks without a
+// context can be done anywhere
+func doableIn(t Task, context string) bool {
+	return t.Context == "" || t.Context == normalizeContext(context)
+}
+
+// logMeeting records an already held meeting as a completed task tagged #meeting
+func logMeeting(title string, minutesArg string) error {
+	minutes, err := parseEstimate(minutesArg)
+	if err != nil || minutes <= 0 {
+		return fmt.Errorf("invalid meeting duration %q", minutesArg)
+	}
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	today := todayKey()
+	task := Task{
+		Title:     title,
+		Estimated: minutes,
+		Actual:    minutes,
+		Status:    "done",
+		Tags:      []string{"meeting"},
+	}
+	data[today] = append(data[today], task)
+	if err := saveTasks(data); err != nil {
+		return err
+	}
+	fmt.Printf("Logged meeting '%s' (%d min).\n", title, minutes)
+	return nil
+}
+
+// nonWorkCategories are logged for completeness but never count as work capacity
+var nonWorkCategories = []string{"commute", "break", "lunch"}
+
+// isNonWork reports whether a task belongs to an excluded, non-work category
+func isNonWork(t Task) bool {
+	for _, c := range nonWorkCategories {
+		if strings.EqualFold(t.Category, c) {
+			return true
+		}
+	}
+	return false
+}
+
+// logNonWork records time spent on a non-work category such as a commute or lunch
+func logNonWork(category string, minutesArg string, description string) error {
+	category = strings.ToLower(category)
+	valid := false
+	for _, c := range nonWorkCategories {
+		if c == category {
+			valid = true
+		}
+	}
+	if !valid {
+		return fmt.Errorf("unknown category %q (expected %s)", category, strings.Join(nonWorkCategories, ", "))
+	}
+	minutes, err := parseEstimate(minutesArg)
+	if err != nil || minutes <= 0 {
+		return fmt.Errorf("invalid duration %q", minutesArg)
+	}
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	title := description
+	if title == "" {
+		title = strings.ToUpper(category[:1]) + category[1:]
+	}
+	today := todayKey()
+	data[today] = append(data[today], Task{
+		Title:     title,
+		Estimated: minutes,
+		Actual:    minutes,
+		Status:    "done",
+		Category:  category,
+	})
+	if err := saveTasks(data); err != nil {
+		return err
+	}
+	fmt.Printf("Logged %d min of %s (not counted as work).\n", minutes, category)
+	return nil
+}
+
+// parseMeetingArgs splits `<title words...> <minutes>` into title and duration
+func parseMeetingArgs(args []string) (string, string, error) {
+	if len(args) < 2 {
+		return "", "", fmt.Errorf("usage: meeting <title> <minutes>")
+	}
+	title := strings.TrimSpace(strings.Join(args[:len(args)-1], " "))
+	if title == "" {
+		return "", "", fmt.Errorf("meeting title cannot be empty")
+	}
+	return title, args[len(args)-1], nil
+}
+
+// remainingMinutesToday returns the working minutes left today according to
+// the configured work hours, skipping the lunch break
+func remainingMinutesToday(now time.Time) int {
+	workStart, lunchStart, lunchEnd, workEnd := config.WorkHours.bounds(now)
+
+	if now.Before(workStart) {
+		now = workStart
+	}
+	if !now.Before(workEnd) {
+		return 0
+	}
+
+	minutes := 0
+	if now.Before(lunchStart) {
+		minutes += int(lunchStart.Sub(now).Minutes())
+		minutes += int(workEnd.Sub(lunchEnd).Minutes()) // afternoon session
+	} else if now.Before(lunchEnd) {
+		minutes += int(workEnd.Sub(lunchEnd).Minutes()) // just afternoon session left
+	} else {
+		minutes += int(workEnd.Sub(now).Minutes())
+	}
+	return max(0, minutes-reservedMinutes(now, now))
+}
+
+// listOptions narrows and arranges the ls view
+type listOptions struct {
+	GroupBy string
+	Tag     string
+	Project string
+	Context string
+}
+
+// includes reports whether a task passes the ls filters
+func (o listOptions) includes(t Task) bool {
+	if o.Tag != "" && !hasTag(t, o.Tag) {
+		return false
+	}
+	if o.Project != "" && !inProject(t, o.Project) {
+		return false
+	}
+	if o.Context != "" && !doableIn(t, o.Context) {
+		return false
+	}
+	return true
+}
+
+func listTasksInteractive(tommorow bool, opts listOptions) error {
+	if err := checkGroupBy(opts.GroupBy); err != nil {
+		return err
+	}
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	today := workDay()
+	if tommorow {
+		today = dayAfter(today)
+	}
+	tasks := data[today]
+	if len(tasks) == 0 {
+		fmt.Println("No tasks available.")
+		return nil
+	}
+	totalActual := 0
+	totalEst := 0
+	remainingWork := 0
+	achievedWork := 0
+	nonWork := 0
+	for _, t := range tasks {
+		if isNonWork(t) {
+			nonWork += t.Actual
+			continue
+		}
+		totalActual += t.Actual
+		totalEst += t.Estimated
+		if t.Status == "done" {
+			achievedWork += t.Estimated
+		} else if t.Status != "done" && t.Status != "cancelled" {
+			remainingTime := t.Estimated - t.Actual
+			if remainingTime < 0 {
+				remainingTime = 0
+			}
+			remainingWork += remainingTime
+		}
+	}
+
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   "→ {{ .ID | faint }} " + priorityTemplate + "{{ .Title | cyan }}{{ if .Context }} @{{ .Context }}{{ end }} ({{ .Status | yellow }}, {{ if .Estimated }}est: {{ .Estimated | dur }}, act: {{ .Actual | dur }}{{ else }}checklist{{ end }})" + waitsForTemplate,
+		Inactive: "  {{ .ID | faint }} " + priorityTemplate + "{{ .Title }}{{ if .Context }} @{{ .Context }}{{ end }} ({{ .Status | yellow }}, {{ if .Estimated }}est: {{ .Estimated | dur }}, act: {{ .Actual | dur }}{{ else }}checklist{{ end }})" + waitsForTemplate,
+		Selected: "✔ {{ .Title }}",
+	}
+
+	date, _ := time.ParseInLocation("2006-01-02", today, time.Local)
+	capacity := dayCapacity(date)
+	actualProgressPercent := ratioOf(totalActual, capacity)
+	estProgressPercent := ratioOf(totalEst, capacity)
+	achievedWorkPercent := ratioOf(achievedWork, totalEst)
+	actualProgressBar := progress.New(setColorGradient(actualProgressPercent, false))
+	estProgressBar := progress.New(setColorGradient(estProgressPercent, true))
 	achievedWorkProgressBar := progress.New(setColorGradient(achievedWorkPercent, false))
 	actualBar := actualProgressBar.ViewAs(actualProgressPercent)
 	achievedWorkBar := achievedWorkProgressBar.ViewAs(achievedWorkPercent)
@@ -470,20 +1135,87 @@ func listTasksInteractive(tommorow bool) error {
 	availableProgressBar := progress.New(setColorGradient(ratio, true))
 	availableBar := availableProgressBar.ViewAs(ratio)
 
-	fmt.Printf("Daily Plan: %s [%d/%d min planned]\n\n", estBar, totalEst, maxDailyMinutes)
-	if !tommorow {
-		fmt.Printf("Daily Worked: %s [%d/%d min worked]\n\n", actualBar, totalActual, maxDailyMinutes)
-		fmt.Printf("Daily Achieved: %s [%d/%d min achieved]\n\n", achievedWorkBar, achievedWork, totalEst)
-		fmt.Printf("Remaining Work vs Time Left: %s [%d min left vs %d min to do]\n\n", availableBar, minutesLeft, remainingWork)
+	fmt.Printf("Daily Plan: %s [%s planned]\n\n", estBar, fmtRatio(totalEst, capacity))
+	if today <= todayKey() {
+		fmt.Printf("Daily Worked: %s [%s worked]\n\n", actualBar, fmtRatio(totalActual, capacity))
+		fmt.Printf("Daily Achieved: %s [%s achieved]\n\n", achievedWorkBar, fmtRatio(achievedWork, totalEst))
+		if today == todayKey() {
+			fmt.Printf("Remaining Work vs Time Left: %s [%s left vs %s to do]\n\n", availableBar, fmtMinutes(minutesLeft), fmtMinutes(remainingWork))
+		}
+		if nonWork > 0 {
+			fmt.Printf("Non-work time logged: %s (not counted above)\n\n", fmtMinutes(nonWork))
+		}
+		if count, minutes := interruptionTotals(tasks); count > 0 || minutes > 0 {
+			fmt.Printf("Interruptions: %d (%s spent on them)\n\n", count, fmtMinutes(minutes))
+		}
+	}
+	if reserved := describeReservations(date); reserved != "" {
+		fmt.Printf("%s\n\n", reserved)
+	}
+	warnIfMeetingsCrowdOut(today, tasks)
+	if printTagTotals(tasks) {
+		fmt.Println()
+	}
+
+	var visible []int
+	for i, t := range tasks {
+		if opts.includes(t) {
+			visible = append(visible, i)
+		}
+	}
+	if len(visible) == 0 {
+		fmt.Println("No tasks match the filter.")
+		return nil
 	}
+	sortByPriority(tasks, visible)
+
+	collapsed := map[string]bool{}
 	for {
-		prompt := promptui.Select{Label: "View/Edit Tasks",
-			Items:     tasks,
-			Templates: templates,
-			Size:      10,
-			HideHelp:  true,
+		var index int
+		if opts.GroupBy != "" {
+			rows := buildGroupRows(tasks, opts.GroupBy, collapsed, opts.includes)
+			for i := range rows {
+				if !rows[i].Header {
+					rows[i].WaitsFor = strings.Join(waitingFor(tasks, rows[i].Task), ", ")
+				}
+			}
+			prompt := groupedSelect(rows)
+			i, _, err := prompt.Run()
+			if err != nil {
+				if err.Error() == "interrupt" || err.Error() == "q" {
+					return nil
+				}
+				return err
+			}
+			if rows[i].Header {
+				collapsed[rows[i].Group] = !collapsed[rows[i].Group]
+				continue
+			}
+			index = rows[i].Index
+		} else {
+			items := make([]listItem, len(visible))
+			for i, idx := range visible {
+				items[i] = listItem{Task: tasks[idx], WaitsFor: strings.Join(waitingFor(tasks, tasks[idx]), ", ")}
+			}
+			prompt := promptui.Select{Label: "View/Edit Tasks",
+				Items:     items,
+				Templates: templates,
+				Size:      10,
+				HideHelp:  true,
+			}
+			i, _, err := prompt.Run()
+			if err != nil {
+				if err.Error() == "interrupt" || err.Error() == "q" {
+					return nil
+				}
+				return err
+			}
+			index = visible[i]
 		}
-		index, _, err := prompt.Run()
+
+		task := &tasks[index]
+		printTaskNotes(*task)
+		title, err := promptWithCursor("Title", task.Title)
 		if err != nil {
 			if err.Error() == "interrupt" || err.Error() == "q" {
 				return nil
@@ -491,8 +1223,7 @@ func listTasksInteractive(tommorow bool) error {
 			return err
 		}
 
-		task := &tasks[index]
-		title, err := promptWithCursor("Title", task.Title)
+		estStr, err := promptWithCursor("Estimated (minutes)", strconv.Itoa(task.Estimated))
 		if err != nil {
 			if err.Error() == "interrupt" || err.Error() == "q" {
 				return nil
@@ -500,7 +1231,7 @@ func listTasksInteractive(tommorow bool) error {
 			return err
 		}
 
-		estStr, err := promptWithCursor("Estimated (minutes)", strconv.Itoa(task.Estimated))
+		actualStr, err := promptWithCursor("Actual (minutes)", strconv.Itoa(task.Actual))
 		if err != nil {
 			if err.Error() == "interrupt" || err.Error() == "q" {
 				return nil
@@ -508,7 +1239,15 @@ func listTasksInteractive(tommorow bool) error {
 			return err
 		}
 
-		actualStr, err := promptWithCursor("Actual (minutes)", strconv.Itoa(task.Actual))
+		project, err := promptWithCursor("Project", task.Project)
+		if err != nil {
+			if err.Error() == "interrupt" || err.Error() == "q" {
+				return nil
+			}
+			return err
+		}
+
+		context, err := promptWithCursor("Context (office, home, errand..., or empty for anywhere)", task.Context)
 		if err != nil {
 			if err.Error() == "interrupt" || err.Error() == "q" {
 				return nil
@@ -516,12 +1255,50 @@ func listTasksInteractive(tommorow bool) error {
 			return err
 		}
 
-		estimated, _ := strconv.Atoi(estStr)
+		tagStr, err := promptWithCursor("Tags (comma-separated)", strings.Join(task.Tags, ", "))
+		if err != nil {
+			if err.Error() == "interrupt" || err.Error() == "q" {
+				return nil
+			}
+			return err
+		}
+
+		priorityStr, err := promptWithCursor("Priority (high, medium, low, 1-9, or empty)", priorityLabel(task.Priority))
+		if err != nil {
+			if err.Error() == "interrupt" || err.Error() == "q" {
+				return nil
+			}
+			return err
+		}
+
+		atStr, err := promptWithCursor("Start time (HH:MM, or empty for any time)", task.PlannedAt)
+		if err != nil {
+			if err.Error() == "interrupt" || err.Error() == "q" {
+				return nil
+			}
+			return err
+		}
+
+		estimated, err := parseEstimate(estStr)
+		if err != nil {
+			fmt.Println("Error:", err)
+			continue
+		}
+		priority, err := parsePriority(priorityStr)
+		if err != nil {
+			fmt.Println("Error:", err)
+			continue
+		}
+		plannedAt, err := checkPlannedAt(atStr)
+		if err != nil {
+			fmt.Println("Error:", err)
+			continue
+		}
 		actual, _ := strconv.Atoi(actualStr)
 
 		statusPrompt := promptui.Select{
 			Label:    "Set status",
-			Items:    []string{"pending", "started", "done", "cancelled"},
+			Items:    []string{"pending", "started", "paused", "done", "cancelled"},
 			HideHelp: true,
 		}
 		_, status, err := statusPrompt.Run()
@@ -532,43 +1309,61 @@ func listTasksInteractive(tommorow bool) error {
 			return err
 		}
 
+		now := time.Now()
 		task.Title = title
-		task.Estimated = estimated
+		task.setEstimate(estimated, now)
 		task.Actual = actual
-		task.Status = status
+		task.Project = strings.TrimSpace(project)
+		task.Context = normalizeContext(context)
+		task.Tags = parseTagList(tagStr)
+		task.Priority = priority
+		task.PlannedAt = plannedAt
+		// setStatus opens or closes the clock's segment with the status
+		if status != task.Status {
+			task.setStatus(status, now)
+		}
 
 		data[today] = tasks
-		saveTasks(data)
+		if err := saveTasks(data); err != nil {
+			return err
+		}
 	}
 }
 
 func setColorGradient(ratio float64, inverted bool) progress.Option {
+	return progress.WithSolidFill(gradientColor(ratio, inverted))
+}
+
+// gradientColor picks the theme color of a ratio: higher is better, or worse
+// when inverted, as for time used against an estimate
+func gradientColor(ratio float64, inverted bool) string {
+	theme := activeTheme()
 	if inverted {
 		if ratio >= 1.0 {
-			return progress.WithSolidFill("#f53333") // red
+			return theme.Bad
 		} else if ratio >= 0.9 {
-			return progress.WithSolidFill("#f56a33") // dark orange
+			return theme.DarkOrange
 		} else if ratio >= 0.8 {
-			return progress.WithSolidFill("#f58e33") // orange
+			return theme.Orange
 		} else if ratio >= 0.7 {
-			return progress.WithSolidFill("#f5ce33") // yellow
+			return theme.Yellow
 		} else if ratio >= 0.6 {
-			return progress.WithSolidFill("#33f56d") // green
+			return theme.Good
 		}
-		return progress.WithSolidFill("#03befc") // blue
+		return theme.Best
 	} else {
 		if ratio >= 1.0 {
-			return progress.WithSolidFill("#03befc") // blue
+			return theme.Best
 		} else if ratio >= 0.9 {
-			return progress.WithSolidFill("#33f56d") // green
+			return theme.Good
 		} else if ratio >= 0.7 {
-			return progress.WithSolidFill("#f5ce33") // yellow
+			return theme.Yellow
 		} else if ratio >= 0.6 {
-			return progress.WithSolidFill("#f58e33") // orange
+			return theme.Orange
 		} else if ratio >= 0.5 {
-			return progress.WithSolidFill("#f56a33") // dark orange
+			return theme.DarkOrange
 		}
-		return progress.WithSolidFill("#f53333") // red
+		return theme.Bad
 	}
 }
 
@@ -582,26 +1377,41 @@ func updateStatus(index int, status string) error {
 	if index < 0 || index >= len(tasks) {
 		return fmt.Errorf("invalid task index")
 	}
-	t := &tasks[index]
+	tasks[index].setStatus(status, time.Now())
+	data[today] = tasks
+	return saveTasks(data)
+}
+
+// setStatus moves a task to a new status, starting or stopping its clock
+func (t *Task) setStatus(status string, now time.Time) {
+	t.logChange("status", t.Status, status, now)
 	switch status {
 	case "started":
-		t.StartedAt = time.Now().Unix()
+		t.startSession(now.Unix())
+		if t.Estimated > 0 {
+			t.FinishBy = projectedFinish(*t, now).Unix()
+		}
 		t.Status = "started"
+	case "paused":
+		t.endSession(now.Unix())
+		t.FinishBy = 0
+		t.Status = "paused"
 	case "done", "cancelled", "pending":
-		if t.StartedAt != 0 {
-			elapsed := int(time.Now().Unix()-t.StartedAt) / 60
-			t.Actual += elapsed
-			t.StartedAt = 0
+		if status == "pending" && t.Status == "started" {
+			t.Interruptions++
 		}
+		t.endSession(now.Unix())
+		if status == "done" || status == "cancelled" {
+			t.ResumeNote = ""
+		}
+		t.FinishBy = 0
 		t.Status = status
 	default:
 		t.Status = status
 	}
-	data[today] = tasks
-	return saveTasks(data)
 }
 
-func startNextPendingTask() error {
+func startNextPendingTask(context string) error {
 	data, err := loadTasks()
 	if err != nil {
 		return err
@@ -615,8 +1425,18 @@ func startNextPendingTask() error {
 			return nil
 		}
 	}
-	for i, t := range tasks {
-		if t.Status == "pending" {
+	// Offer the highest-priority pending task first
+	order := make([]int, len(tasks))
+	for i := range order {
+		order[i] = i
+	}
+	sortByPriority(tasks, order)
+	for _, i := range order {
+		t := tasks[i]
+		if t.Status == "pending" && (context == "" || doableIn(t, context)) {
+			if isBlocked(tasks, t) {
+				continue
+			}
 			prompt := promptui.Select{
 				Label:    fmt.Sprintf("Next Task: %s (%d min)", t.Title, t.Estimated),
 				Items:    []string{"Start", "Skip"},
@@ -631,7 +1451,14 @@ func startNextPendingTask() error {
 			}
 			if choice == "Start" {
 				fmt.Printf("Starting '%s'...\n", t.Title)
-				return updateStatus(i, "started")
+				if err := updateStatus(i, "started"); err != nil {
+					return err
+				}
+				if t.Estimated > 0 {
+					fmt.Printf("Should finish by %s\n", projectedFinish(t, time.Now()).Format("15:04"))
+				}
+				printResumeNote(t)
+				return nil
 			} else {
 				continue
 			}
@@ -650,12 +1477,26 @@ func currentTask() error {
 	tasks := data[today]
 	for i, t := range tasks {
 		if t.Status == "started" {
-			elapsed := int(time.Now().Unix()-t.StartedAt) / 60
-			clock := float64(elapsed) / float64(t.Estimated)
-			clockProgressBar := progress.New(setColorGradient(clock, true))
-			clockBar := clockProgressBar.ViewAs(clock)
-			fmt.Printf("Task Clock: %s [%d/%d min used]\n\n", clockBar, elapsed, t.Estimated)
-			fmt.Printf("Current task: [%d] %s - started %dmin ago\n", i, t.Title, elapsed)
+			now := time.Now().Unix()
+			total := t.trackedMinutes(now)
+			segment := int(t.sessionSeconds(now)) / 60
+			if t.Estimated > 0 {
+				clock := ratioOf(total, t.Estimated)
+				clockProgressBar := progress.New(setColorGradient(clock, true))
+				clockBar := clockProgressBar.ViewAs(clock)
+				fmt.Printf("Task Clock: %s [%d/%d min used]\n\n", clockBar, total, t.Estimated)
+			}
+			fmt.Printf("Current task: [%d] %s - %dmin in total, this segment started %dmin ago\n", i, t.Title, total, segment)
+			printResumeNote(t)
+			if t.FinishBy != 0 {
+				finishBy := time.Unix(t.FinishBy, 0)
+				if time.Now().After(finishBy) {
+					fmt.Printf("\033[31mShould have finished by %s (%s over)\033[0m\n",
+						finishBy.Format("15:04"), formatDuration(time.Since(finishBy)))
+				} else {
+					fmt.Printf("\033[32mShould finish by %s\033[0m\n", finishBy.Format("15:04"))
+				}
+			}
 			return nil
 		}
 	}
@@ -672,280 +1513,1453 @@ func finishCurrentTask() error {
 	tasks := data[today]
 	for i, t := range tasks {
 		if t.Status == "started" {
-			return updateStatus(i, "done")
+			if err := updateStatus(i, "done"); err != nil {
+				return err
+			}
+			printUnblocked(tasks, t.ID)
+			return nil
 		}
 	}
 	fmt.Println("No task is currently started.")
 	return nil
 }
 
-func stopCurrentTask() error {
+// stopCurrentTask stops the running task and attaches a "where I left off"
+// note to it, prompting for one when none is given
+func stopCurrentTask(note string) error {
 	data, err := loadTasks()
 	if err != nil {
 		return err
 	}
 	today := todayKey()
 	tasks := data[today]
+	if endAside(data, today) {
+		return saveTasks(data)
+	}
 	for i, t := range tasks {
 		if t.Status == "started" {
 			fmt.Printf("Stopping task '%s'...\n", t.Title)
-			return updateStatus(i, "pending")
+			if err := updateStatus(i, "pending"); err != nil {
+				return err
+			}
+			// Scripts and hooks without a terminal stop without a note
+			if note == "" && isTerminal() {
+				note, err = promptWithCursor("Where did you leave off? (optional)", "")
+				if err != nil {
+					if err.Error() == "interrupt" || err.Error() == "q" {
+						return nil
+					}
+					return err
+				}
+			}
+			note = strings.TrimSpace(note)
+			if note == "" {
+				return nil
+			}
+			return setResumeNote(i, note)
 		}
 	}
 	fmt.Println("No task is currently started.")
 	return nil
 }
 
-func deleteTaskInteractive() error {
+// pauseCurrentTask stops the clock on the running task without counting an
+// interruption; `resume` picks it up again
+func pauseCurrentTask() error {
 	data, err := loadTasks()
 	if err != nil {
 		return err
 	}
-	today := todayKey()
-	tasks := data[today]
-	if len(tasks) == 0 {
-		fmt.Println("No tasks to delete.")
-		return nil
+	for i, t := range data[todayKey()] {
+		if t.Status == "started" {
+			if err := updateStatus(i, "paused"); err != nil {
+				return err
+			}
+			now := time.Now().Unix()
+			segment := int(t.sessionSeconds(now)) / 60
+			fmt.Printf("Paused '%s' after %dmin (%dmin in total).\n", t.Title, segment, t.trackedMinutes(now))
+			return nil
+		}
+	}
+	fmt.Println("No task is currently started.")
+	return nil
+}
+
+// resumeTask ends a running break or interruption and restarts today's paused task
+func resumeTask() error {
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	if endAside(data, todayKey()) {
+		if err := saveTasks(data); err != nil {
+			return err
+		}
+	}
+	tasks := data[todayKey()]
+	for _, t := range tasks {
+		if t.Status == "started" {
+			fmt.Printf("'%s' is already running.\n", t.Title)
+			return nil
+		}
+	}
+	for i, t := range tasks {
+		if t.Status == "paused" {
+			if err := updateStatus(i, "started"); err != nil {
+				return err
+			}
+			fmt.Printf("Resumed '%s' (%dmin so far).\n", t.Title, t.Actual)
+			printResumeNote(t)
+			return nil
+		}
+	}
+	fmt.Println("No paused task.")
+	return nil
+}
+
+// setResumeNote stores the note shown when the task is resumed
+func setResumeNote(index int, note string) error {
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	today := todayKey()
+	tasks := data[today]
+	if index < 0 || index >= len(tasks) {
+		return fmt.Errorf("invalid task index")
+	}
+	tasks[index].ResumeNote = note
+	data[today] = tasks
+	return saveTasks(data)
+}
+
+// printResumeNote reminds where work on a task was left off
+func printResumeNote(t Task) {
+	if t.ResumeNote != "" {
+		fmt.Printf("Where you left off: %s\n", t.ResumeNote)
+	}
+	printPersonNotes(t)
+}
+
+func selectTaskAndSetStatus() error {
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	day := workDay()
+	tasks := data[day]
+	if len(tasks) == 0 {
+		fmt.Println("No tasks available.")
+		return nil
+	}
+
+	indexes, err := multiSelect("Select the tasks to update", taskLabels(tasks), nil)
+	if err != nil {
+		if err.Error() == "interrupt" {
+			return nil
+		}
+		return err
+	}
+	if len(indexes) == 0 {
+		fmt.Println("No task selected.")
+		return nil
+	}
+
+	statusPrompt := promptui.Select{
+		Label:    "Set status",
+		Items:    []string{"pending", "started", "paused", "done", "cancelled"},
+		HideHelp: true,
+	}
+	_, result, err := statusPrompt.Run()
+	if err != nil {
+		if err.Error() == "interrupt" || err.Error() == "q" {
+			return nil
+		}
+		return err
+	}
+
+	if err := setTaskStatuses(data, day, indexes, result); err != nil {
+		return err
+	}
+	if result == "started" {
+		started := data[day][indexes[0]]
+		if started.Estimated > 0 {
+			fmt.Printf("Should finish by %s\n", projectedFinish(started, time.Now()).Format("15:04"))
+		}
+		printResumeNote(started)
+	}
+	return nil
+}
+
+// --- CLI Command Setup ---
+
+// Setup all cobra commands and return the root command
+func setupCommands() *cobra.Command {
+	// Note command: add or show notes for today
+	var noteAudio, noteTask, noteFrom, noteTo string
+	var noteRaw bool
+	noteCmd := &cobra.Command{
+		Use:   "note [text|show|search|edit|edit-yesterday|lock|unlock] [date]",
+		Short: "Add, show, search, or edit notes for a day",
+		Args:  cobra.ArbitraryArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if noteTask != "" {
+				text := strings.Join(args, " ")
+				if noteAudio != "" {
+					transcribed, err := transcribeAudio(noteAudio)
+					if err != nil {
+						fmt.Println("Error:", err)
+						return
+					}
+					text = transcribed
+				}
+				var err error
+				if text == "" {
+					err = showTaskNotes(workDay(), noteTask)
+				} else {
+					err = addTaskNote(workDay(), noteTask, text)
+				}
+				if err != nil {
+					fmt.Println("Error:", err)
+				}
+				return
+			}
+			if noteAudio != "" {
+				text, err := transcribeAudio(noteAudio)
+				if err != nil {
+					fmt.Println("Error:", err)
+					return
+				}
+				if err := addDayNote(workDay(), text); err != nil {
+					fmt.Println("Error:", err)
+					return
+				}
+				fmt.Printf("Note added for %s: %s\n", dayLabel(workDay()), text)
+				return
+			}
+			if len(args) > 0 && (args[0] == "lock" || args[0] == "unlock") {
+				day := parseNoteDayArg(args[1:])
+				lock := lockNotes
+				if args[0] == "unlock" {
+					lock = unlockNotes
+				}
+				if err := lock(day); err != nil {
+					fmt.Println("Error:", err)
+				}
+				return
+			}
+			if len(args) > 0 && args[0] == "edit-yesterday" {
+				day := yesterdayKey()
+				if err := editNoteForDay(day); err != nil {
+					fmt.Println("Error:", err)
+				} else {
+					fmt.Printf("Notes for %s updated.\n", day)
+				}
+				return
+			}
+			if len(args) > 0 && args[0] == "edit" {
+				day := parseNoteDayArg(args[1:])
+				if err := editNoteForDay(day); err != nil {
+					fmt.Println("Error:", err)
+				} else {
+					fmt.Printf("Notes for %s updated.\n", day)
+				}
+				return
+			}
+			if len(args) > 0 && args[0] == "search" {
+				if err := searchNotes(strings.Join(args[1:], " "), noteFrom, noteTo); err != nil {
+					fmt.Println("Error:", err)
+				}
+				return
+			}
+			if len(args) == 0 || args[0] == "show" {
+				day := workDay()
+				if len(args) > 0 {
+					day = parseNoteDayArg(args[1:])
+				}
+				if err := showNotesForDay(day, noteRaw); err != nil {
+					fmt.Println("Error:", err)
+				}
+				return
+			}
+			note := strings.Join(args, " ")
+			if err := addDayNote(workDay(), note); err != nil {
+				fmt.Println("Error:", err)
+			} else {
+				fmt.Printf("Note added for %s.\n", dayLabel(workDay()))
+			}
+		},
+	}
+	noteCmd.Flags().StringVar(&noteAudio, "audio", "", "Transcribe an audio file (e.g. memo.m4a) into a note")
+	noteCmd.Flags().StringVar(&noteFrom, "from", "", "With search: first day to search (YYYY-MM-DD)")
+	noteCmd.Flags().StringVar(&noteTo, "to", "", "With search: last day to search (YYYY-MM-DD)")
+	noteCmd.Flags().BoolVar(&noteRaw, "raw", false, "Show notes as written instead of rendering their Markdown")
+	noteCmd.Flags().StringVar(&noteTask, "task", "", "Attach the note to a task (its number today, or part of its title); without text, list its notes")
+
+	var offlineFlag, noIntegrations bool
+	rootCmd := &cobra.Command{
+		Use:   "daily",
+		Short: "Daily task management CLI",
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			currentOperation = strings.TrimPrefix(cmd.CommandPath(), "daily ")
+			if offlineFlag || noIntegrations {
+				enterOfflineMode()
+			}
+			// Polled commands stay quiet and leave the heartbeat alone
+			if cmd.Name() == "completion" || strings.HasPrefix(cmd.Name(), "__complete") || cmd.Name() == "statusline" {
+				return
+			}
+			// Only now is it known whether the network may be used
+			if err := refreshTeamConfig(); err != nil {
+				fmt.Println("Error:", err)
+			}
+			// Before anything is saved, which would carry the change along
+			if cmd.Name() != "verify" {
+				warnIfTampered()
+			}
+			// One question about the running timer is enough
+			if asked, err := checkRunningTimer(time.Now()); err != nil {
+				fmt.Println("Error:", err)
+			} else if !asked {
+				if err := checkLongSession(time.Now()); err != nil {
+					fmt.Println("Error:", err)
+				}
+			}
+			// A task still running on a day that is over gets its time split,
+			// after the questions above had their say on how much counts
+			if message, err := rolloverStaleStarts(); err != nil {
+				fmt.Println("Error:", err)
+			} else if message != "" {
+				fmt.Println(message)
+			}
+			if err := schedulePrepTasks(todayKey()); err != nil {
+				fmt.Println("Error:", err)
+			}
+			if cmd.Name() != "backfill" {
+				if err := offerBackfill(time.Now()); err != nil {
+					fmt.Println("Error:", err)
+				}
+			}
+			startHeartbeat()
+		},
+	}
+	rootCmd.PersistentFlags().BoolVar(&offlineFlag, "offline", false, "Disable all integrations: no network calls, editor, or notifications")
+	rootCmd.PersistentFlags().BoolVar(&noIntegrations, "no-integrations", false, "Same as --offline")
+	rootCmd.PersistentFlags().Var(newDayValue(&selectedDay), "date", "Day to work on: YYYY-MM-DD, today, yesterday, tomorrow, -2, +3 or a weekday (the last one)")
+
+	var addTitle, addEst, addPriority, addProject, addAt, addContext, addEpic string
+	var addTags []string
+	addCmd := &cobra.Command{
+		Use:   "add [text]",
+		Short: "Add a new task for today",
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			addDate := workDay()
+			// Prompt unless the task was described with flags or text
+			if len(args) > 0 && addTitle == "" {
+				q := parseQuickAdd(strings.Join(args, " "), dayOf(time.Now()))
+				if !cmd.Flags().Changed("est") && q.HasEstimate {
+					addEst = strconv.Itoa(q.Estimated)
+				}
+				if !cmd.Flags().Changed("date") && q.Day != "" {
+					addDate = q.Day
+				}
+				if !cmd.Flags().Changed("at") && q.At != "" {
+					addAt = q.At
+				}
+				if !cmd.Flags().Changed("context") && q.Context != "" {
+					addContext = q.Context
+				}
+				err = addTaskFromFlags(q.Title, addEst, addDate, append(addTags, q.Tags...), addPriority, addProject, addAt, addContext, addEpic)
+			} else if cmd.Flags().NFlag() == 0 || (cmd.Flags().NFlag() == 1 && cmd.Flags().Changed("date")) {
+				err = addTaskInteractive(false)
+			} else {
+				err = addTaskFromFlags(addTitle, addEst, addDate, addTags, addPriority, addProject, addAt, addContext, addEpic)
+			}
+			if err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+	addCmd.Flags().StringVar(&addTitle, "title", "", "Task title (skips the interactive prompt)")
+	addCmd.Flags().StringVar(&addEst, "est", "", "Estimate in minutes (45) or hours (1.5h)")
+	addCmd.Flags().StringSliceVar(&addTags, "tag", nil, "Tag to attach (repeatable or comma-separated)")
+	addCmd.Flags().StringVar(&addPriority, "priority", "", "Priority: high, medium, low, or 1-9")
+	addCmd.Flags().StringVar(&addProject, "project", "", "Project the task belongs to")
+	addCmd.Flags().StringVar(&addAt, "at", "", "Planned start time (HH:MM) for the agenda")
+	addCmd.Flags().StringVar(&addContext, "context", "", "Where the task can be done: office, home, errand...")
+	addCmd.Flags().StringVar(&addEpic, "epic", "", "Epic the task is part of, for `daily effort`")
+
+	addTommorowCmd := &cobra.Command{
+		Use:   "addt",
+		Short: "Add a new task for tomorrow",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := addTaskInteractive(true); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+
+	var listOpts listOptions
+	listCmd := &cobra.Command{
+		Use:   "ls",
+		Short: "List and edit today's tasks",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := listTasksInteractive(false, listOpts); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+	listCmd.Flags().StringVar(&listOpts.GroupBy, "group-by", "", "Group tasks by project, tag, or status")
+	listCmd.Flags().StringVar(&listOpts.Tag, "tag", "", "Only show tasks with this tag")
+	listCmd.Flags().StringVar(&listOpts.Project, "project", "", "Only show tasks of this project")
+	listCmd.Flags().StringVar(&listOpts.Context, "context", "", "Only show tasks that can be done in this context")
+
+	listTommorowCmd := &cobra.Command{
+		Use:   "lst",
+		Short: "List and edit tomorrow's tasks",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := listTasksInteractive(true, listOpts); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+	listTommorowCmd.Flags().StringVar(&listOpts.GroupBy, "group-by", "", "Group tasks by project, tag, or status")
+	listTommorowCmd.Flags().StringVar(&listOpts.Tag, "tag", "", "Only show tasks with this tag")
+	listTommorowCmd.Flags().StringVar(&listOpts.Project, "project", "", "Only show tasks of this project")
+	listTommorowCmd.Flags().StringVar(&listOpts.Context, "context", "", "Only show tasks that can be done in this context")
+
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Select tasks and update their status",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := selectTaskAndSetStatus(); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+
+	var nextContext string
+	nextCmd := &cobra.Command{
+		Use:   "next",
+		Short: "Start the next pending task",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := startNextPendingTask(nextContext); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+	nextCmd.Flags().StringVar(&nextContext, "context", "", "Only offer tasks that can be done in this context")
+
+	var statusFormat, statusColor string
+	var statusMaxTitle int
+	statuslineCmd := &cobra.Command{
+		Use:   "statusline",
+		Short: "Print one line about the running task and the day for a status bar",
+		Long: `Prints the running task with its time against the estimate, and the share of
+the day's capacity worked, on one line. Meant to be polled by tmux status-right,
+a polybar custom module or i3blocks; it doesn't prompt or write anything.
+
+--format takes a Go template with the fields .Title, .Status, .Elapsed,
+.Estimate, .Clock, .Worked, .Capacity, .DayPercent and .Day. .Clock and .Day
+carry the colors of --color: ansi escapes, tmux #[fg=...] or polybar %{F...}.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := printStatusLine(statusFormat, statusColor, statusMaxTitle); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+	statuslineCmd.Flags().StringVar(&statusFormat, "format", "", "Go template for the line")
+	statuslineCmd.Flags().StringVar(&statusColor, "color", "none", "Color codes: none, ansi, tmux, or polybar")
+	statuslineCmd.Flags().IntVar(&statusMaxTitle, "max-title", 30, "Shorten the task title to this many characters, 0 for no limit")
+
+	currentCmd := &cobra.Command{
+		Use:   "current",
+		Short: "Show the currently active task",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := currentTask(); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+
+	finishCmd := &cobra.Command{
+		Use:   "finish",
+		Short: "Mark the current task as done",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := finishCurrentTask(); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+
+	var deleteAllPending bool
+	deleteCmd := &cobra.Command{
+		Use:   "delete [task...]",
+		Short: "Delete tasks, given by ID, number or title, or picked from a list",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runDelete(workDay(), args, deleteAllPending); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+	deleteCmd.Flags().BoolVar(&deleteAllPending, "all-pending", false, "Delete every pending task of the day")
+
+	var doneAllPending bool
+	doneCmd := &cobra.Command{
+		Use:   "done <task...>",
+		Short: "Mark tasks as done, given by ID, number or title",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runBulkStatus(workDay(), args, doneAllPending, "done"); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+	doneCmd.Flags().BoolVar(&doneAllPending, "all-pending", false, "Mark every pending task of the day as done")
+
+	var mvTo, cpTo string
+	mvCmd := &cobra.Command{
+		Use:   "mv <task...> --to <day>",
+		Short: "Move tasks to another day",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runMoveTasks(workDay(), args, mvTo, false); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+	mvCmd.Flags().StringVar(&mvTo, "to", "", "Day to move to: YYYY-MM-DD, tomorrow, +3 or a weekday (the next one)")
+
+	cpCmd := &cobra.Command{
+		Use:   "cp <task...> --to <day>",
+		Short: "Copy tasks to another day, as new pending tasks",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runMoveTasks(workDay(), args, cpTo, true); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+	cpCmd.Flags().StringVar(&cpTo, "to", "", "Day to copy to: YYYY-MM-DD, tomorrow, +3 or a weekday (the next one)")
+
+	var cancelAllPending bool
+	cancelCmd := &cobra.Command{
+		Use:   "cancel <task...>",
+		Short: "Cancel tasks, given by ID, number or title",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runBulkStatus(workDay(), args, cancelAllPending, "cancelled"); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+	cancelCmd.Flags().BoolVar(&cancelAllPending, "all-pending", false, "Cancel every pending task of the day")
+
+	var stopNote string
+	stopCmd := &cobra.Command{
+		Use:   "stop [note]",
+		Short: "Stop the current task",
+		Run: func(cmd *cobra.Command, args []string) {
+			note := stopNote
+			if note == "" {
+				note = strings.Join(args, " ")
+			}
+			if err := stopCurrentTask(note); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+	stopCmd.Flags().StringVarP(&stopNote, "note", "m", "", "Where you left off, shown when the task is resumed")
+
+	pauseCmd := &cobra.Command{
+		Use:   "pause",
+		Short: "Pause the current task",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := pauseCurrentTask(); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+
+	resumeCmd := &cobra.Command{
+		Use:   "resume",
+		Short: "Resume the paused task",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := resumeTask(); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+
+	breakCmd := &cobra.Command{
+		Use:   "break [reason]",
+		Short: "Pause the current task and time a break",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := startBreak(strings.Join(args, " ")); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+
+	interruptCmd := &cobra.Command{
+		Use:   "interrupt <description>",
+		Short: "Pause the current task and time what interrupted it",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := startInterruption(strings.Join(args, " ")); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+
+	var pomodoro bool
+	var pomodoroWork, pomodoroBreak int
+	followCmd := &cobra.Command{
+		Use:   "follow",
+		Short: "Follow progress of the current task",
+		Run: func(cmd *cobra.Command, args []string) {
+			if pomodoro {
+				if err := followPomodoro(pomodoroWork, pomodoroBreak); err != nil {
+					fmt.Println("Error:", err)
+				}
+				return
+			}
+			followStartedTask()
+		},
+	}
+	followCmd.Flags().BoolVar(&pomodoro, "pomodoro", false, "Work in timed cycles with breaks; breaks don't count as work")
+	followCmd.Flags().IntVar(&pomodoroWork, "work", 25, "Pomodoro work length in minutes")
+	followCmd.Flags().IntVar(&pomodoroBreak, "break", 5, "Pomodoro break length in minutes")
+
+	yesterdayCmd := &cobra.Command{
+		Use:   "yesterday",
+		Short: "Show tasks from yesterday",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := showYesterdayTasks(); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+
+	meetingCmd := &cobra.Command{
+		Use:   "meeting <title> <minutes>",
+		Short: "Log a meeting you attended today",
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			title, minutes, err := parseMeetingArgs(args)
+			if err == nil {
+				err = logMeeting(title, minutes)
+			}
+			if err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+
+	logCmd := &cobra.Command{
+		Use:       "log <commute|break|lunch> <minutes> [description]",
+		Short:     "Log non-work time that is excluded from capacity",
+		Args:      cobra.MinimumNArgs(2),
+		ValidArgs: nonWorkCategories,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := logNonWork(args[0], args[1], strings.Join(args[2:], " ")); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+
+	var invoiceMonth, invoiceFormat, invoiceOutput string
+	var invoiceRate float64
+	invoiceCmd := &cobra.Command{
+		Use:   "invoice <client>",
+		Short: "Generate an invoice from the time tracked for a client",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := writeInvoice(args[0], invoiceMonth, invoiceFormat, invoiceOutput, invoiceRate); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+	invoiceCmd.Flags().StringVar(&invoiceMonth, "month", dayOf(time.Now()).Format("2006-01"), "Month to invoice (YYYY-MM)")
+	invoiceCmd.Flags().StringVar(&invoiceFormat, "format", "md", "Output format: md, html, or pdf")
+	invoiceCmd.Flags().StringVarP(&invoiceOutput, "output", "o", "", "Write the invoice to this file")
+	invoiceCmd.Flags().Float64Var(&invoiceRate, "rate", 0, "Hourly rate, overriding billing.yaml")
+
+	var pairServe bool
+	var pairAddr string
+	pairCmd := &cobra.Command{
+		Use:   "pair --serve",
+		Short: "Share a read-only live view of the current task",
+		Run: func(cmd *cobra.Command, args []string) {
+			if !pairServe {
+				cmd.Help()
+				return
+			}
+			if err := servePairView(pairAddr); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+	pairCmd.Flags().BoolVar(&pairServe, "serve", false, "Serve the live view over HTTP")
+	pairCmd.Flags().StringVar(&pairAddr, "addr", ":8765", "Address to listen on")
+
+	var publishFormat, publishOutput string
+	var publishNotes, publishGist bool
+	publishCmd := &cobra.Command{
+		Use:   "publish [date]",
+		Short: "Render a day without private tasks to a public page",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := publishDay(parseNoteDayArg(args), publishFormat, publishOutput, publishNotes, publishGist); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+	publishCmd.Flags().StringVar(&publishFormat, "format", "md", "Output format: md or html")
+	publishCmd.Flags().StringVarP(&publishOutput, "output", "o", "", "Output file (default daily-<date>.<format>)")
+	publishCmd.Flags().BoolVar(&publishNotes, "with-notes", false, "Include the day's notes and the notes on its tasks")
+	publishCmd.Flags().BoolVar(&publishGist, "gist", false, "Also push the page to a GitHub gist (needs GITHUB_TOKEN)")
+
+	calendarCmd := &cobra.Command{
+		Use:   "calendar",
+		Short: "Work with your calendar",
+	}
+	var calendarSource string
+	calendarImportCmd := &cobra.Command{
+		Use:   "import [date]",
+		Short: "Import the day's meetings as #meeting tasks",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := importCalendar(parseNoteDayArg(args), calendarSource); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+	calendarImportCmd.Flags().StringVar(&calendarSource, "source", "", "Calendar backend: outlook (default calendar.source)")
+	calendarCmd.AddCommand(calendarImportCmd)
+
+	planCmd := &cobra.Command{
+		Use:   "plan [date]",
+		Short: "Plan a day: pick from yesterday's leftovers and recurring tasks, add new ones",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runPlanWizard(parseNoteDayArg(args)); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+	planJiraCmd := &cobra.Command{
+		Use:   "jira [date]",
+		Short: "Pick issues from the active Jira sprint as tasks",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := planFromJira(parseNoteDayArg(args)); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+	planCmd.AddCommand(planJiraCmd)
+	planForecastCmd := &cobra.Command{
+		Use:   "forecast [date]",
+		Short: "Estimate the chance of finishing a day's plan from similar past days",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := showForecast(parseNoteDayArg(args)); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+	planCmd.AddCommand(planForecastCmd)
+	planCheckCmd := &cobra.Command{
+		Use:   "check [date]",
+		Short: "Compare the work left in a day with the time left, and suggest tasks to move",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runPlanCheck(parseNoteDayArg(args), false); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+	planCmd.AddCommand(planCheckCmd)
+	planFitCmd := &cobra.Command{
+		Use:   "fit [date]",
+		Short: "Move the suggested low-priority tasks to the next workday so the day fits",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runPlanCheck(parseNoteDayArg(args), true); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+	planCmd.AddCommand(planFitCmd)
+	var planWeekFrom string
+	planWeekCmd := &cobra.Command{
+		Use:   "week [date]",
+		Short: "Spread candidates from the inbox, GitHub and Jira over the week's workdays",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runPlanWeek(parseNoteDayArg(args), planWeekFrom); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+	planWeekCmd.Flags().StringVar(&planWeekFrom, "from", "", "Sources to gather from: inbox, github, jira (default every configured one)")
+	planCmd.AddCommand(planWeekCmd)
+
+	journalCmd := &cobra.Command{
+		Use:   "journal [date]",
+		Short: "Write the notes of the week containing date to the yearly journal",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := archiveWeekNotes(parseNoteDayArg(args)); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+
+	var exportFrom, exportTo, exportOutput, exportFormat, exportProject string
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export tracked time for other systems",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := exportTasks(exportFormat, exportFrom, exportTo, exportOutput, exportProject); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+	exportCmd.Flags().StringVar(&exportFormat, "format", "csv", "Output format (csv)")
+	exportCmd.Flags().StringVar(&exportFrom, "from", "", "First day (default: start of the current month)")
+	exportCmd.Flags().StringVar(&exportTo, "to", "", "Last day (default: today)")
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Output file (default: stdout)")
+	exportCmd.Flags().StringVar(&exportProject, "project", "", "Only export tasks of this project")
+	exportSessionsCmd := &cobra.Command{
+		Use:   "sessions-csv",
+		Short: "Export one CSV row per work session",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := exportSessionsCSV(exportFrom, exportTo, exportOutput, exportProject); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+	exportSessionsCmd.Flags().StringVar(&exportFrom, "from", "", "First day (default: start of the current month)")
+	exportSessionsCmd.Flags().StringVar(&exportTo, "to", "", "Last day (default: today)")
+	exportSessionsCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Output file (default: stdout)")
+	exportSessionsCmd.Flags().StringVar(&exportProject, "project", "", "Only export sessions of this project")
+	exportCmd.AddCommand(exportSessionsCmd)
+	var icalOutput string
+	exportICalCmd := &cobra.Command{
+		Use:   "ical",
+		Short: "Export a day's tasks as calendar events, back to back from the start of work",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := exportICal(workDay(), icalOutput); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+	exportICalCmd.Flags().StringVarP(&icalOutput, "output", "o", "", "Output .ics file (default: stdout)")
+	exportCmd.AddCommand(exportICalCmd)
+
+	var projectFrom, projectTo string
+	projectCmd := &cobra.Command{
+		Use:   "project",
+		Short: "List projects with their cumulative time",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := listProjects(projectFrom, projectTo); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+	projectShowCmd := &cobra.Command{
+		Use:   "show <project>",
+		Short: "Show a project's time across all days, by month",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := showProject(args[0], projectFrom, projectTo); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+	projectCmd.PersistentFlags().StringVar(&projectFrom, "from", "", "First day (default: no limit)")
+	projectCmd.PersistentFlags().StringVar(&projectTo, "to", "", "Last day (default: no limit)")
+	projectCmd.AddCommand(projectShowCmd)
+
+	var statsDays int
+	statsCmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Compare estimates with tracked time overall, per tag and per weekday",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := showStats(statsDays); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+	statsCmd.Flags().IntVar(&statsDays, "days", 0, "Only look at the last N days (default: all history)")
+
+	var unplannedWeeks int
+	unplannedCmd := &cobra.Command{
+		Use:   "unplanned",
+		Short: "Show how much work was added during the day rather than planned ahead",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := showUnplanned(unplannedWeeks); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+	unplannedCmd.Flags().IntVar(&unplannedWeeks, "weeks", 4, "Number of weeks to show")
+
+	agendaCmd := &cobra.Command{
+		Use:   "agenda [date]",
+		Short: "Show the day as a timeline, with overlaps and overflow past work end",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := showAgenda(parseNoteDayArg(args)); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+
+	var archiveBefore string
+	var archiveDryRun bool
+	archiveCmd := &cobra.Command{
+		Use:   "archive --before <date>",
+		Short: "Move old days out of the task store into monthly archives",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := archiveTasks(archiveBefore, archiveDryRun); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+	archiveCmd.Flags().StringVar(&archiveBefore, "before", "", "Archive the days before this date (YYYY-MM-DD)")
+	archiveCmd.Flags().BoolVar(&archiveDryRun, "dry-run", false, "Show what would be archived without changing anything")
+	archiveCmd.MarkFlagRequired("before")
+
+	var restoreNotes bool
+	restoreCmd := &cobra.Command{
+		Use:   "restore [backup]",
+		Short: "List the backups of tasks.yaml, or roll back to one",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			name := "tasks.yaml"
+			if restoreNotes {
+				name = "notes.yaml"
+			}
+			var err error
+			if len(args) == 0 {
+				err = listBackups(name)
+			} else if n, convErr := strconv.Atoi(args[0]); convErr != nil || n < 1 {
+				err = fmt.Errorf("invalid backup number %q", args[0])
+			} else {
+				err = restoreBackup(name, n)
+			}
+			if err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+	restoreCmd.Flags().BoolVar(&restoreNotes, "notes", false, "Restore notes.yaml instead of tasks.yaml")
+
+	editDayCmd := &cobra.Command{
+		Use:   "edit-day [date]",
+		Short: "Edit a day's tasks as a table in your editor",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := editDay(parseNoteDayArg(args)); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+
+	syncCmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Commit the data to git and pull and push sync.remote",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := syncData(); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+
+	reserveCmd := &cobra.Command{
+		Use:   "reserve",
+		Short: "List the blocks reserved on a day",
+		Long: `Reserved blocks are stretches of the day kept for something other than
+tasks and meetings, like a support rotation. They take time out of the day's
+capacity and show in the agenda. Repeating blocks are configured under
+` + "`reservations`" + ` in config.yaml; the subcommands change a single day.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := showReservations(workDay()); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+	reserveAddCmd := &cobra.Command{
+		Use:   "add <name> <HH:MM-HH:MM>",
+		Short: "Reserve a block on one day",
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := reserveBlock(workDay(), strings.Join(args[:len(args)-1], " "), args[len(args)-1]); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+	reserveRemoveCmd := &cobra.Command{
+		Use:   "remove <name>",
+		Short: "Free a reserved block on one day, configured ones included",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := unreserveBlock(workDay(), strings.Join(args, " ")); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+	reserveCmd.AddCommand(reserveAddCmd, reserveRemoveCmd)
+
+	var standupPost string
+	var standupDryRun bool
+	standupCmd := &cobra.Command{
+		Use:   "standup",
+		Short: "Write a standup message from the last working day, today's plan and noted blockers",
+		Long: `Lists the tasks finished on the last day with tasks, today's plan, and the
+notes of both days that start with "blocker:" or "blocked:". Private tasks are
+left out. Without --post the message is printed.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runStandup(standupPost, standupDryRun); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
 	}
+	standupCmd.Flags().StringVar(&standupPost, "post", "", "Post to the webhook of slack or teams")
+	standupCmd.Flags().BoolVar(&standupDryRun, "dry-run", false, "Show the message as it would be posted without posting it")
 
-	prompt := promptui.Select{
-		Label: "Select task to delete",
-		Items: tasks,
-		Templates: &promptui.SelectTemplates{
-			Label:    "{{ . }}",
-			Active:   "→ {{ .Title | red }} ({{ .Status }})",
-			Inactive: "  {{ .Title }} ({{ .Status }})",
-			Selected: "✔ {{ .Title }}",
+	jiraCmd := &cobra.Command{
+		Use:   "jira",
+		Short: "Import Jira issues and log time back to them",
+	}
+	jiraPullCmd := &cobra.Command{
+		Use:   "pull [date]",
+		Short: "Add the Jira issues assigned to you as tasks",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := pullFromJira(parseNoteDayArg(args)); err != nil {
+				fmt.Println("Error:", err)
+			}
 		},
-		Size:     10,
-		HideHelp: true,
 	}
-	index, _, err := prompt.Run()
-	if err != nil {
-		if err.Error() == "interrupt" || err.Error() == "q" {
-			return nil
-		}
-		return err
+	var jiraDryRun bool
+	jiraPushCmd := &cobra.Command{
+		Use:   "push [date]",
+		Short: "Log the time of finished Jira tasks as worklogs",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := pushToJira(parseNoteDayArg(args), jiraDryRun); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
 	}
-
-	data[today] = append(tasks[:index], tasks[index+1:]...)
-	return saveTasks(data)
-}
-
-func selectTaskAndSetStatus() error {
-	data, err := loadTasks()
-	if err != nil {
-		return err
+	jiraPushCmd.Flags().BoolVar(&jiraDryRun, "dry-run", false, "Show the worklogs without logging them")
+	jiraCmd.AddCommand(jiraPullCmd, jiraPushCmd)
+
+	var pushDays int
+	var pushDryRun bool
+	pushCmd := &cobra.Command{
+		Use:   "push toggl|clockify [date]",
+		Short: "Push finished work sessions to Toggl Track or Clockify",
+		Long: `Sends each finished session of the day (or of the --days up to it) to Toggl
+Track or Clockify as a time entry. A session is pushed once; breaks and other
+non-work time stay out. The entry's project comes from the first of the task's
+tags, or else its project, listed under push.toggl.projects or
+push.clockify.projects in config.yaml.`,
+		Args: cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := pushSessions(args[0], parseNoteDayArg(args[1:]), pushDays, pushDryRun); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
 	}
-	today := todayKey()
-	tasks := data[today]
-	if len(tasks) == 0 {
-		fmt.Println("No tasks available.")
-		return nil
+	pushCmd.Flags().IntVar(&pushDays, "days", 1, "Number of days up to the date to push")
+	pushCmd.Flags().BoolVar(&pushDryRun, "dry-run", false, "List the sessions without pushing them")
+
+	var personDiscussed bool
+	personCmd := &cobra.Command{
+		Use:   "person [name] [talking point]",
+		Short: "Show or add to the 1:1 thread of a person",
+		Long: `Without arguments, lists the people you hold 1:1s with and the threads kept.
+With a name, shows the next 1:1 and the talking points; with more text, adds
+it as a talking point. People with a schedule under ` + "`people`" + ` in
+config.yaml get a prep task on the day of each 1:1.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			switch {
+			case len(args) == 0:
+				err = listPeople()
+			case personDiscussed:
+				err = markDiscussed(args[0])
+			case len(args) > 1:
+				err = addPersonNote(args[0], strings.Join(args[1:], " "))
+			default:
+				err = showPerson(args[0])
+			}
+			if err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
 	}
-
-	templates := &promptui.SelectTemplates{
-		Label:    "{{ . }}",
-		Active:   "→ {{ .Title | cyan }} ({{ .Status }})",
-		Inactive: "  {{ .Title }} ({{ .Status }})",
-		Selected: "✔ {{ .Title }}",
+	personCmd.Flags().BoolVar(&personDiscussed, "discussed", false, "Mark the open talking points as discussed")
+
+	var showJSON, showMarkdown bool
+	showCmd := &cobra.Command{
+		Use:   "show <task>",
+		Short: "Print a task's full record",
+		Long: `Prints everything kept about a task: its fields, links, comments, the
+sessions tracked and the history of its status and estimate. A number picks
+the task on --date; otherwise the title, or a unique part of it, is looked up
+on every day, most recent first. Markdown by default, or JSON with --json.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := showTaskRecord(strings.Join(args, " "), workDay(), showJSON); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
 	}
+	showCmd.Flags().BoolVar(&showJSON, "json", false, "Print JSON")
+	showCmd.Flags().BoolVar(&showMarkdown, "md", false, "Print Markdown (the default)")
+	showCmd.MarkFlagsMutuallyExclusive("json", "md")
 
-	prompt := promptui.Select{
-		Label:     "Select task to update",
-		Items:     tasks,
-		Templates: templates,
-		Size:      10,
-		HideHelp:  true,
+	var verifyAccept bool
+	verifyCmd := &cobra.Command{
+		Use:   "verify [file...]",
+		Short: "Check the data files against their checksums, or accept changes made outside daily",
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) > 0 && !verifyAccept {
+				fmt.Println("Error: files can only be named with --accept")
+				return
+			}
+			if err := runVerify(verifyAccept, args); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
 	}
-
-	index, _, err := prompt.Run()
-	if err != nil {
-		if err.Error() == "interrupt" || err.Error() == "q" {
-			return nil
-		}
-		return err
+	verifyCmd.Flags().BoolVar(&verifyAccept, "accept", false, "Trust the files as they are now and record new checksums")
+
+	var effortJSON bool
+	effortCmd := &cobra.Command{
+		Use:   "effort <task|epic>",
+		Short: "Break down the time invested in a task or an epic by day",
+		Long: `Adds up, day by day, the time tracked on a piece of work that spans days:
+a task carried over from day to day (matched by its issue key, or its title),
+or every task of an epic (set with add --epic, or the parent epic of a Jira
+issue). A number picks the task on --date; otherwise an epic, then a task
+title or a unique part of it, is looked up.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := showEffort(strings.Join(args, " "), workDay(), effortJSON); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
 	}
-
-	statusPrompt := promptui.Select{
-		Label:    "Set status",
-		Items:    []string{"pending", "started", "done", "cancelled"},
-		HideHelp: true,
+	effortCmd.Flags().BoolVar(&effortJSON, "json", false, "Print JSON")
+
+	var backfillDaysFlag int
+	backfillCmd := &cobra.Command{
+		Use:   "backfill",
+		Short: "Go through workdays with nothing recorded: mark them off or add what you did",
+		Long: `Lists the workdays with no tasks and no notes since the first day with any,
+leaving out weekends, the holidays in config.yaml and the days marked off, and
+asks about each: were you off, or did you forget to track? A day off is
+recorded as such; for a day you worked, add what you did and how long it took.
+The first command of a day offers the same when it finds such days.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runBackfill(backfillDaysFlag); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
 	}
-	_, result, err := statusPrompt.Run()
-	if err != nil {
-		if err.Error() == "interrupt" || err.Error() == "q" {
-			return nil
-		}
-		return err
+	backfillCmd.Flags().IntVar(&backfillDaysFlag, "days", idleLookback, "How many days back to look")
+
+	var offClear bool
+	offCmd := &cobra.Command{
+		Use:   "off [date] [reason]",
+		Short: "Mark a day off, or list the days off",
+		Long: `Marks a day as off (pto by default, or any reason such as sick), ahead of time
+or after the fact. Days off aren't offered for backfill and show as off in the
+week and trend reports. Without arguments, lists the days off of the last
+month and those to come.`,
+		Args: cobra.MaximumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			switch {
+			case len(args) == 0:
+				err = listDaysOff(dayOf(time.Now()).AddDate(0, -1, 0).Format("2006-01-02"))
+			case offClear:
+				err = clearDayOff(args[0])
+			case len(args) == 2:
+				err = markDayOff(args[0], args[1])
+			default:
+				err = markDayOff(args[0], "")
+			}
+			if err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
 	}
+	offCmd.Flags().BoolVar(&offClear, "clear", false, "Make the day a workday again")
 
-	return updateStatus(index, result)
-}
+	var trendDays int
+	var trendBars bool
+	trendCmd := &cobra.Command{
+		Use:   "trend",
+		Short: "Chart worked minutes and completion rate for recent days",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := showTrend(trendDays, trendBars); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+	trendCmd.Flags().IntVar(&trendDays, "days", 30, "Number of days to show")
+	trendCmd.Flags().BoolVar(&trendBars, "bars", false, "Also draw one bar per day")
 
-// --- CLI Command Setup ---
+	tuiCmd := &cobra.Command{
+		Use:   "tui",
+		Short: "Open the full-screen dashboard",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runDashboard(); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
 
-// Setup all cobra commands and return the root command
-func setupCommands() *cobra.Command {
-	// Note command: add or show notes for today
-	noteCmd := &cobra.Command{
-		Use:   "note [text|edit|edit-yesterday] [date]",
-		Short: "Add, show, or edit notes for a day",
-		Args:  cobra.ArbitraryArgs,
+	boardCmd := &cobra.Command{
+		Use:   "board [date]",
+		Short: "Show the day's tasks as a board with a column per status, and move them between columns",
+		Args:  cobra.MaximumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			if len(args) > 0 && args[0] == "edit-yesterday" {
-				day := yesterdayKey()
-				if err := editNoteForDay(day); err != nil {
-					fmt.Println("Error:", err)
-				} else {
-					fmt.Printf("Notes for %s updated.\n", day)
-				}
-				return
+			if err := runBoard(parseNoteDayArg(args)); err != nil {
+				fmt.Println("Error:", err)
 			}
-			if len(args) > 0 && args[0] == "edit" {
-				day := todayKey()
-				if len(args) > 1 {
-					day = args[1]
-				}
-				if err := editNoteForDay(day); err != nil {
-					fmt.Println("Error:", err)
-				} else {
-					fmt.Printf("Notes for %s updated.\n", day)
-				}
-				return
+		},
+	}
+
+	var undoList bool
+	undoCmd := &cobra.Command{
+		Use:   "undo",
+		Short: "Revert the most recent change to tasks or notes",
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			if undoList {
+				err = showUndoLog()
+			} else {
+				err = undoLast()
 			}
-			if len(args) == 0 {
-				if err := showNotesForToday(); err != nil {
-					fmt.Println("Error:", err)
-				}
-				return
+			if err != nil {
+				fmt.Println("Error:", err)
 			}
-			note := strings.Join(args, " ")
-			if err := addNoteForToday(note); err != nil {
+		},
+	}
+	undoCmd.Flags().BoolVar(&undoList, "list", false, "List the changes that can be undone")
+
+	diffCmd := &cobra.Command{
+		Use:   "diff [date]",
+		Short: "Compare the plan at the start of the day with how it ended",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := showPlanDiff(parseNoteDayArg(args)); err != nil {
 				fmt.Println("Error:", err)
-			} else {
-				fmt.Println("Note added for today.")
 			}
 		},
 	}
-	rootCmd := &cobra.Command{
-		Use:   "daily",
-		Short: "Daily task management CLI",
+
+	var calibrateRounds int
+	calibrateCmd := &cobra.Command{
+		Use:   "calibrate",
+		Short: "Estimate tasks blind, then compare with what similar tasks really took",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runCalibration(calibrateRounds); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
 	}
+	calibrateCmd.Flags().IntVar(&calibrateRounds, "rounds", 5, "Number of tasks to estimate")
 
-	addCmd := &cobra.Command{
-		Use:   "add",
-		Short: "Add a new task for today",
+	var searchRegex bool
+	searchCmd := &cobra.Command{
+		Use:   "search <query>",
+		Short: "Find tasks and notes across all days",
+		Args:  cobra.MinimumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := addTaskInteractive(false); err != nil {
+			if err := searchAll(strings.Join(args, " "), searchRegex); err != nil {
 				fmt.Println("Error:", err)
 			}
 		},
 	}
+	searchCmd.Flags().BoolVar(&searchRegex, "regex", false, "Treat the query as a regular expression")
 
-	addTommorowCmd := &cobra.Command{
-		Use:   "addt",
-		Short: "Add a new task for tomorrow",
+	watchCmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Notify when the running task passes its estimate",
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := addTaskInteractive(true); err != nil {
+			if err := runWatch(); err != nil {
 				fmt.Println("Error:", err)
 			}
 		},
 	}
 
-	listCmd := &cobra.Command{
-		Use:   "ls",
-		Short: "List and edit today's tasks",
+	reorderCmd := &cobra.Command{
+		Use:   "reorder",
+		Short: "Move today's tasks up and down",
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := listTasksInteractive(false); err != nil {
+			if err := reorderTasks(); err != nil {
 				fmt.Println("Error:", err)
 			}
 		},
 	}
 
-	listTommorowCmd := &cobra.Command{
-		Use:   "lst",
-		Short: "List and edit tomorrow's tasks",
+	initCmd := &cobra.Command{
+		Use:   "init",
+		Short: "Set up config, theme and an example day interactively",
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := listTasksInteractive(true); err != nil {
+			if err := runInit(); err != nil {
+				if err.Error() == "interrupt" || err.Error() == "^C" {
+					fmt.Println("Setup cancelled")
+					return
+				}
 				fmt.Println("Error:", err)
 			}
 		},
 	}
 
-	statusCmd := &cobra.Command{
-		Use:   "status",
-		Short: "Select a task and update its status",
+	var migrateForce bool
+	migrateCmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Import tasks.yaml and notes.yaml into the SQLite database",
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := selectTaskAndSetStatus(); err != nil {
+			if err := migrateToSQLite(migrateForce); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+	migrateCmd.Flags().BoolVar(&migrateForce, "force", false, "Replace tasks already in the database")
+	migrateFromCmd := &cobra.Command{
+		Use:   "from [file]",
+		Short: "Import time entries exported from Toggl, Clockify, ATracker or RescueTime",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			path := ""
+			if len(args) > 0 {
+				path = args[0]
+			}
+			if err := runTrackerImport(path); err != nil {
+				if err.Error() == "interrupt" || err.Error() == "^C" {
+					fmt.Println("Import cancelled")
+					return
+				}
 				fmt.Println("Error:", err)
 			}
 		},
 	}
+	migrateCmd.AddCommand(migrateFromCmd)
 
-	nextCmd := &cobra.Command{
-		Use:   "next",
-		Short: "Start the next pending task",
+	var dedupeAll bool
+	dedupeCmd := &cobra.Command{
+		Use:   "dedupe [date]",
+		Short: "Find and merge duplicate tasks in a day",
+		Args:  cobra.MaximumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := startNextPendingTask(); err != nil {
+			if err := dedupeTasks(parseNoteDayArg(args), dedupeAll); err != nil {
 				fmt.Println("Error:", err)
 			}
 		},
 	}
+	dedupeCmd.Flags().BoolVar(&dedupeAll, "all", false, "Check every day")
 
-	currentCmd := &cobra.Command{
-		Use:   "current",
-		Short: "Show the currently active task",
+	var inboxDirFlag string
+	var inboxWatch bool
+	inboxCmd := &cobra.Command{
+		Use:   "inbox",
+		Short: "Ingest tasks and notes dropped as files into the inbox folder",
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := currentTask(); err != nil {
+			if err := runInbox(inboxDirFlag, inboxWatch); err != nil {
 				fmt.Println("Error:", err)
 			}
 		},
 	}
+	inboxCmd.Flags().StringVar(&inboxDirFlag, "dir", "", "Drop folder (default inbox.dir)")
+	inboxCmd.Flags().BoolVar(&inboxWatch, "watch", false, "Keep scanning the folder for new files")
 
-	finishCmd := &cobra.Command{
-		Use:   "finish",
-		Short: "Mark the current task as done",
+	var serveHost string
+	var servePort int
+	var serveMetrics, serveWebhook, serveUI, serveAPI bool
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run the HTTP server",
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := finishCurrentTask(); err != nil {
+			if err := runServer(serveHost, servePort, serveMetrics, serveWebhook, serveUI, serveAPI); err != nil {
+				fmt.Println("Error:", err)
+			}
+		},
+	}
+	serveCmd.Flags().StringVar(&serveHost, "host", "", "Address to listen on (default serve.host or 127.0.0.1; 0.0.0.0 for the whole network)")
+	serveCmd.Flags().IntVar(&servePort, "port", 0, "Port to listen on (default serve.port or 8080)")
+	serveCmd.Flags().BoolVar(&serveMetrics, "metrics", false, "Serve Prometheus metrics at /metrics")
+	serveCmd.Flags().BoolVar(&serveWebhook, "webhook", false, "Accept authenticated POSTs that create tasks and notes")
+	serveCmd.Flags().BoolVar(&serveAPI, "api", false, "Serve the REST API for tasks, the timer and notes (needs serve.api_token)")
+	serveCmd.Flags().BoolVar(&serveUI, "ui", false, "Serve a read-only dashboard of today's tasks, the timer and the week")
+
+	weekCmd := &cobra.Command{
+		Use:   "week [date]",
+		Short: "Show the weekly report with focus scores",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := showWeekReport(parseNoteDayArg(args)); err != nil {
 				fmt.Println("Error:", err)
 			}
 		},
 	}
 
-	deleteCmd := &cobra.Command{
-		Use:   "delete",
-		Short: "Delete a task",
+	closeCmd := &cobra.Command{
+		Use:   "close [date]",
+		Short: "Close a day: carry open tasks over and record its metrics",
+		Args:  cobra.MaximumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := deleteTaskInteractive(); err != nil {
+			if err := closeDay(parseNoteDayArg(args)); err != nil {
 				fmt.Println("Error:", err)
 			}
 		},
 	}
 
-	stopCmd := &cobra.Command{
-		Use:   "stop",
-		Short: "Stop the current task",
+	reviewCmd := &cobra.Command{
+		Use:   "review [date]",
+		Short: "End the day: settle each open task, fill in missing times, write a retrospective and close it",
+		Args:  cobra.MaximumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := stopCurrentTask(); err != nil {
+			if err := runReview(parseNoteDayArg(args)); err != nil {
 				fmt.Println("Error:", err)
 			}
 		},
 	}
 
-	followCmd := &cobra.Command{
-		Use:   "follow",
-		Short: "Follow progress of the current task",
+	var dependClear bool
+	dependCmd := &cobra.Command{
+		Use:   "depend <task> [prerequisite...]",
+		Short: "Make a task wait for other tasks of the day, or show what it waits for",
+		Long: `Makes a task wait for other tasks of the same day. Tasks are given by the
+ID ls shows, by number or by title. ` + "`next`" + ` skips a task until what it waits for
+is done or cancelled, and finishing a task lists the tasks it unblocked.`,
+		Args: cobra.MinimumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			followStartedTask()
+			if err := runDepend(workDay(), args[0], args[1:], dependClear); err != nil {
+				fmt.Println("Error:", err)
+			}
 		},
 	}
+	dependCmd.Flags().BoolVar(&dependClear, "clear", false, "Stop the task waiting for other tasks")
 
-	yesterdayCmd := &cobra.Command{
-		Use:   "yesterday",
-		Short: "Show tasks from yesterday",
+	examplesCmd := &cobra.Command{
+		Use:   "examples [topic|command]",
+		Short: "Show examples to copy, by topic or for one command",
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := showYesterdayTasks(); err != nil {
+			if err := runExamples(cmd.Root(), args); err != nil {
 				fmt.Println("Error:", err)
 			}
 		},
@@ -1000,20 +3014,76 @@ func setupCommands() *cobra.Command {
 	rootCmd.AddCommand(currentCmd)
 	rootCmd.AddCommand(finishCmd)
 	rootCmd.AddCommand(deleteCmd)
+	rootCmd.AddCommand(doneCmd)
+	rootCmd.AddCommand(cancelCmd)
+	rootCmd.AddCommand(mvCmd)
+	rootCmd.AddCommand(cpCmd)
 	rootCmd.AddCommand(stopCmd)
+	rootCmd.AddCommand(pauseCmd)
+	rootCmd.AddCommand(resumeCmd)
+	rootCmd.AddCommand(breakCmd)
+	rootCmd.AddCommand(interruptCmd)
 	rootCmd.AddCommand(followCmd)
 	rootCmd.AddCommand(yesterdayCmd)
+	rootCmd.AddCommand(closeCmd)
+	rootCmd.AddCommand(reviewCmd)
+	rootCmd.AddCommand(weekCmd)
+	rootCmd.AddCommand(publishCmd)
+	rootCmd.AddCommand(calendarCmd)
+	rootCmd.AddCommand(planCmd)
+	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(inboxCmd)
+	rootCmd.AddCommand(dedupeCmd)
+	rootCmd.AddCommand(journalCmd)
+	rootCmd.AddCommand(initCmd)
+	rootCmd.AddCommand(reorderCmd)
+	rootCmd.AddCommand(watchCmd)
+	rootCmd.AddCommand(searchCmd)
+	rootCmd.AddCommand(calibrateCmd)
+	rootCmd.AddCommand(undoCmd)
+	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(projectCmd)
+	rootCmd.AddCommand(statsCmd)
+	rootCmd.AddCommand(unplannedCmd)
+	rootCmd.AddCommand(agendaCmd)
+	rootCmd.AddCommand(archiveCmd)
+	rootCmd.AddCommand(restoreCmd)
+	rootCmd.AddCommand(editDayCmd)
+	rootCmd.AddCommand(syncCmd)
+	rootCmd.AddCommand(reserveCmd)
+	rootCmd.AddCommand(standupCmd)
+	rootCmd.AddCommand(jiraCmd)
+	rootCmd.AddCommand(pushCmd)
+	rootCmd.AddCommand(statuslineCmd)
+	rootCmd.AddCommand(personCmd)
+	rootCmd.AddCommand(showCmd)
+	rootCmd.AddCommand(effortCmd)
+	rootCmd.AddCommand(verifyCmd)
+	rootCmd.AddCommand(backfillCmd)
+	rootCmd.AddCommand(offCmd)
+	rootCmd.AddCommand(migrateCmd)
+	rootCmd.AddCommand(tuiCmd)
+	rootCmd.AddCommand(boardCmd)
+	rootCmd.AddCommand(trendCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(meetingCmd)
+	rootCmd.AddCommand(logCmd)
+	rootCmd.AddCommand(invoiceCmd)
+	rootCmd.AddCommand(pairCmd)
 	rootCmd.AddCommand(completionCmd)
 	rootCmd.AddCommand(shellCmd)
 	rootCmd.AddCommand(noteCmd)
+	rootCmd.AddCommand(dependCmd)
+	rootCmd.AddCommand(examplesCmd)
 
+	setupHelp(rootCmd)
 	return rootCmd
 }
 
 // --- Shell Mode ---
 
-// runInteractiveShell starts the interactive shell mode
-func runInteractiveShell() { // ASCII art for the title
+// printShellBanner prints the ASCII art title of the shell
+func printShellBanner() {
 	cyan := "\033[36m"
 	reset := "\033[0m"
 	fmt.Println(cyan + "   ___       _ __       _______   ____" + reset)
@@ -1024,39 +3094,75 @@ func runInteractiveShell() { // ASCII art for the title
 	fmt.Println("Daily Task Manager Interactive Shell")
 	fmt.Println("Type 'help' for available commands or 'exit' to quit")
 	fmt.Println("----------------")
+}
+
+// shellCommands returns the commands that can be run from the shell
+func shellCommands() []*cobra.Command {
+	var cmds []*cobra.Command
+	for _, c := range setupCommands().Commands() {
+		if c.IsAvailableCommand() && c.Name() != "shell" && c.Name() != "completion" {
+			cmds = append(cmds, c)
+		}
+	}
+	return cmds
+}
+
+// runShellLine runs one shell line through a fresh command tree, so that
+// flags given on one line don't carry over to the next
+func runShellLine(args []string) {
+	rootCmd := setupCommands()
+	rootCmd.InitDefaultHelpCmd()
+	if cmd, _, err := rootCmd.Find(args); err != nil || cmd == rootCmd {
+		fmt.Printf("Unknown command: %s\nType 'help' for available commands\n", args[0])
+		return
+	} else if cmd.Name() == "shell" {
+		fmt.Println("Already in the shell.")
+		return
+	}
+	rootCmd.SetArgs(args)
+	// Cobra has already printed the error and usage
+	rootCmd.Execute()
+}
 
-	// Map of commands for quick lookup and tab completion
-	commands := map[string]struct{}{
-		"add":       {},
-		"addt":      {},
-		"ls":        {},
-		"lst":       {},
-		"status":    {},
-		"next":      {},
-		"current":   {},
-		"finish":    {},
-		"delete":    {},
-		"stop":      {},
-		"follow":    {},
-		"yesterday": {},
-		"note":      {},
-		"clear":     {},
-		"help":      {},
-		"exit":      {},
-	}
-
-	// Start a scanner to read user input
-	scanner := bufio.NewScanner(os.Stdin)
+// runInteractiveShell starts the interactive shell mode
+func runInteractiveShell() {
+	printShellBanner()
+
+	// Without a terminal (input piped in), read plain lines
+	var scanner *bufio.Scanner
+	var completer shellCompleter
+	if !isTerminal() {
+		scanner = bufio.NewScanner(os.Stdin)
+	} else {
+		completer = newShellCompleter()
+	}
+	history := loadShellHistory()
 	var lastCmd string
 
 	for {
-		fmt.Print("\n> ")
-		if !scanner.Scan() {
-			break
+		var input string
+		if scanner != nil {
+			fmt.Print("\n> ")
+			if !scanner.Scan() {
+				break
+			}
+			input = strings.TrimSpace(scanner.Text())
+		} else {
+			fmt.Println()
+			line, err := readShellLine(history, completer)
+			if errors.Is(err, errShellExit) {
+				break
+			}
+			if err != nil {
+				if err.Error() == "interrupt" {
+					continue
+				}
+				fmt.Println("Error:", err)
+				break
+			}
+			input = line
 		}
 
-		input := strings.TrimSpace(scanner.Text())
-
 		// Handle empty input - repeat the last command
 		if input == "" && lastCmd != "" {
 			input = lastCmd
@@ -1064,20 +3170,15 @@ func runInteractiveShell() { // ASCII art for the title
 			continue
 		}
 
-		// Save the command for potential repeat
+		// Save the command for potential repeat and the history
 		lastCmd = input
-
-		// Handle tab completion for when user presses tab (simulate with ?)
-		if strings.HasSuffix(input, "?") {
-			prefix := strings.TrimSuffix(input, "?")
-			fmt.Println("Available commands:")
-			for cmd := range commands {
-				if strings.HasPrefix(cmd, prefix) {
-					fmt.Printf("  %s\n", cmd)
-				}
+		if len(history) == 0 || history[len(history)-1] != input {
+			history = append(history, input)
+			if err := saveShellHistory(history); err != nil {
+				fmt.Println("Error saving shell history:", err)
 			}
-			continue
 		}
+
 		// Exit command
 		if input == "exit" || input == "quit" {
 			break
@@ -1085,105 +3186,52 @@ func runInteractiveShell() { // ASCII art for the title
 
 		// Clear command - clears the screen but keeps the ASCII title
 		if input == "clear" {
-			// Clear the screen
 			fmt.Print("\033[H\033[2J")
-			// Print the ASCII title again
-			cyan := "\033[36m"
-			reset := "\033[0m"
-			fmt.Println(cyan + "   ___       _ __       _______   ____" + reset)
-			fmt.Println(cyan + "  / _ \\___ _(_) /_ __  / ___/ /  /  _/" + reset)
-			fmt.Println(cyan + " / // / _ `/ / / // / / /__/ /___/ /  " + reset)
-			fmt.Println(cyan + "/____/\\_,_/_/_/\\_, /  \\___/____/___/  " + reset)
-			fmt.Println(cyan + "              /___/                   " + reset)
-			fmt.Println("Daily Task Manager Interactive Shell")
-			fmt.Println("Type 'help' for available commands or 'exit' to quit")
-			fmt.Println("----------------")
+			printShellBanner()
 			continue
 		}
 
 		// Help command
 		if input == "help" {
 			fmt.Println("Available commands:")
-			fmt.Println("  add        - Add a new task for today")
-			fmt.Println("  addt       - Add a new task for tomorrow")
-			fmt.Println("  ls         - List and edit today's tasks")
-			fmt.Println("  lst        - List and edit tomorrow's tasks")
-			fmt.Println("  status     - Select a task and update its status")
-			fmt.Println("  next       - Start the next pending task")
-			fmt.Println("  current    - Show the currently active task")
-			fmt.Println("  finish     - Mark the current task as done")
-			fmt.Println("  delete     - Delete a task")
-			fmt.Println("  stop       - Stop the current task")
-			fmt.Println("  follow     - Follow progress of the current task")
-			fmt.Println("  yesterday  - Show tasks from yesterday")
-			fmt.Println("  note       - Add, show, or edit daily notes")
+			for _, cmd := range shellCommands() {
+				fmt.Printf("  %-11s- %s\n", cmd.Name(), cmd.Short)
+			}
 			fmt.Println("  clear      - Clear the screen")
-			fmt.Println("  exit/quit  - Exit the shell")
+			fmt.Println("  exit/quit  - Exit the shell (or Ctrl+D)")
 			fmt.Println()
-			fmt.Println("Note: Press 'q' to exit from any interactive menu")
+			fmt.Println("Commands take the same arguments and flags as on the command line;")
+			fmt.Println("'help <command>' shows them, and 'examples <topic>' has examples to copy.")
 			fmt.Println()
-			fmt.Println("Notes usage:")
-			fmt.Println("  note <text>           - Add a note for today")
-			fmt.Println("  note                  - Show today's notes")
-			fmt.Println("  note edit             - Edit today's notes in nano")
-			fmt.Println("  note edit <YYYY-MM-DD> - Edit notes for a specific day")
-			fmt.Println("  note edit-yesterday    - Edit yesterday's notes in nano")
+			fmt.Println("Tab completes commands, flags and dates; Up/Down browse the history")
+			fmt.Println("and Ctrl+R searches it.")
+			fmt.Println()
+			fmt.Println("Note: Press 'q' to exit from any interactive menu")
 			continue
 		}
 
-		// Handle the command
-		args := strings.Fields(input)
+		args := splitCommand(input)
 		if len(args) == 0 {
 			continue
 		}
-
-		command := args[0]
-
-		// Execute the command
-		switch command {
-		case "add":
-			addTaskInteractive(false)
-		case "addt":
-			addTaskInteractive(true)
-		case "ls":
-			listTasksInteractive(false)
-		case "lst":
-			listTasksInteractive(true)
-		case "status":
-			selectTaskAndSetStatus()
-		case "next":
-			startNextPendingTask()
-		case "current":
-			currentTask()
-		case "finish":
-			finishCurrentTask()
-		case "delete":
-			deleteTaskInteractive()
-		case "stop":
-			stopCurrentTask()
-		case "follow":
-			followStartedTask()
-		case "yesterday":
-			showYesterdayTasks()
-		default:
-			fmt.Printf("Unknown command: %s\nType 'help' for available commands\n", command)
-		case "note":
-			// Pass note args to main note handler
-			if len(args) > 1 {
-				os.Args = append([]string{os.Args[0], "note"}, args[1:]...)
-			} else {
-				os.Args = []string{os.Args[0], "note"}
-			}
-			main()
-			// After running note, break to avoid duplicate prompt
-			break
-		}
+		runShellLine(args)
 	}
 }
 
 // --- Utilities ---
 
 func main() {
+	if err := applyConfig(); err != nil {
+		fmt.Println("Error loading config:", err)
+	}
+	if len(os.Args) < 2 || (os.Args[1] != "init" && os.Args[1] != "completion" && !strings.HasPrefix(os.Args[1], "__complete")) {
+		if err := offerSyncedDataDir(); err != nil {
+			fmt.Println("Error choosing the data directory:", err)
+		}
+	}
+	if err := migrateLegacyData(); err != nil {
+		fmt.Println("Error migrating data files:", err)
+	}
 	rootCmd := setupCommands()
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
@@ -1193,45 +3241,31 @@ func main() {
 
 // followStartedTask displays a progress bar for the currently started task
 func followStartedTask() {
-	data, err := loadTasks()
+	startedTask, err := startedTaskToday()
 	if err != nil {
 		fmt.Println("Error loading tasks:", err)
 		return
 	}
-	today := todayKey()
-	tasks := data[today]
-	// Find the started task
-	var startedTask *Task
-	for _, t := range tasks {
-		if t.Status == "started" {
-			taskCopy := t
-			startedTask = &taskCopy
-			break
-		}
-	}
 	if startedTask == nil {
 		fmt.Println("No task is currently started.")
 		return
 	}
-	// Calculate the total duration
-	totalDuration := time.Duration(startedTask.Estimated) * time.Minute
+	if startedTask.Estimated == 0 {
+		fmt.Printf("'%s' is a checklist item without an estimate; nothing to follow.\n", startedTask.Title)
+		return
+	}
 	progressBar := progress.New(
-		progress.WithDefaultGradient(),
 		progress.WithWidth(50),
-		progress.WithSolidFill("#03befc"),
+		progress.WithSolidFill(activeTheme().Accent),
 	)
-	m := taskModel{
-		progress:      progressBar,
-		task:          startedTask,
-		startTime:     time.Unix((startedTask.StartedAt - int64(startedTask.Actual*60)), 0),
-		totalDuration: totalDuration,
-	}
+	m := taskModel{progress: progressBar, day: newDayWatcher()}
+	m.follow(startedTask)
 	initialElapsed := time.Since(m.startTime)
 	fmt.Printf("Initial elapsed time: %s\n", initialElapsed)
-	initialPercent := math.Min(1.0, float64(initialElapsed)/float64(totalDuration))
+	initialPercent := math.Min(1.0, float64(initialElapsed)/float64(m.totalDuration))
 	progressBar.SetPercent(initialPercent)
-	fmt.Printf("Following task: %s (%d min)\nPress q or Ctrl+C to exit\n\n",
-		startedTask.Title, startedTask.Estimated)
+	fmt.Printf("Following task: %s (%d min)\nPress %s or Ctrl+C to exit\n\n",
+		startedTask.Title, startedTask.Estimated, keys.Quit.Help().Key)
 	if _, err := tea.NewProgram(m).Run(); err != nil {
 		fmt.Println("Error running progress bar:", err)
 	}
@@ -0,0 +1,90 @@
+// metrics.go - Prometheus metrics
+// Serves today's numbers in the Prometheus text format at /metrics for Grafana dashboards
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// promStatuses are always reported so that series don't disappear at zero
+var promStatuses = []string{"pending", "started", "paused", "done", "cancelled"}
+
+func promLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// writeMetrics renders the metrics for one day's tasks
+func writeMetrics(w io.Writer, tasks []Task, now time.Time) {
+	gauge := func(name, help string) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+	}
+
+	m := computeDayMetrics(tasks)
+	counts := map[string]int{}
+	for _, s := range promStatuses {
+		counts[s] = 0
+	}
+	var running *Task
+	for i, t := range tasks {
+		if isNonWork(t) {
+			continue
+		}
+		counts[t.Status]++
+		if t.Status == "started" {
+			running = &tasks[i]
+			m.Worked += t.trackedMinutes(now.Unix()) - t.Actual
+		}
+	}
+
+	gauge("daily_worked_minutes", "Minutes worked today, including the running task.")
+	fmt.Fprintf(w, "daily_worked_minutes %d\n", m.Worked)
+	gauge("daily_planned_minutes", "Minutes estimated for today's tasks.")
+	fmt.Fprintf(w, "daily_planned_minutes %d\n", m.Planned)
+	gauge("daily_achieved_minutes", "Estimated minutes of the tasks done today.")
+	fmt.Fprintf(w, "daily_achieved_minutes %d\n", m.Achieved)
+	gauge("daily_meeting_minutes", "Minutes spent in meetings today.")
+	fmt.Fprintf(w, "daily_meeting_minutes %d\n", m.Meetings)
+	gauge("daily_interruptions", "Interruptions counted today.")
+	fmt.Fprintf(w, "daily_interruptions %d\n", m.Interruptions)
+
+	gauge("daily_tasks", "Today's tasks by status.")
+	statuses := make([]string, 0, len(counts))
+	for s := range counts {
+		statuses = append(statuses, s)
+	}
+	sort.Strings(statuses)
+	for _, s := range statuses {
+		fmt.Fprintf(w, "daily_tasks{status=\"%s\"} %d\n", promLabel(s), counts[s])
+	}
+
+	// Titles stay out of the labels: they can be private, and every task
+	// would start a new series
+	gauge("daily_current_task", "1 while a task is running, labelled with its project.")
+	if running == nil {
+		fmt.Fprintln(w, "daily_current_task 0")
+		return
+	}
+	fmt.Fprintf(w, "daily_current_task{project=\"%s\"} 1\n", promLabel(running.Project))
+	gauge("daily_current_task_elapsed_minutes", "Minutes tracked on the running task.")
+	fmt.Fprintf(w, "daily_current_task_elapsed_minutes %d\n", running.trackedMinutes(now.Unix()))
+	gauge("daily_current_task_estimated_minutes", "Estimate of the running task.")
+	fmt.Fprintf(w, "daily_current_task_estimated_minutes %d\n", running.Estimated)
+}
+
+func metricsHandler(w http.ResponseWriter, r *http.Request) {
+	storeMu.Lock()
+	data, err := loadTasks()
+	storeMu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	writeMetrics(w, data[todayKey()], time.Now())
+}
@@ -0,0 +1,89 @@
+// movetask.go - Move and copy tasks between days
+// `daily mv` pushes tasks to another day and `daily cp` copies them there, so a slipping task doesn't need to be typed again
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// parseTargetDay reads the day tasks go to: a weekday is the next one after
+// today, as in quick-add; the other forms are those of --date
+func parseTargetDay(s string, now time.Time) (string, error) {
+	if day, ok := parseQuickDay(strings.ToLower(strings.TrimSpace(s)), quickWords(), dayOf(now)); ok {
+		return day, nil
+	}
+	return parseDay(s, now)
+}
+
+// copyOfTask is a new pending task without time or history: what is left of
+// an open task, or all of a closed one, to do again
+func copyOfTask(t Task) Task {
+	c := carryOver(t)
+	if !isOpenStatus(t.Status) {
+		c.Estimated = t.Estimated
+	}
+	c.ID = ""
+	c.Status = "pending"
+	c.FinishBy = 0
+	c.CreatedAt = 0
+	c.History = nil
+	c.DependsOn = nil
+	c.JiraLogged = 0
+	return c
+}
+
+// runMoveTasks moves tasks of a day to another day, or copies them there
+func runMoveTasks(day string, queries []string, to string, copying bool) error {
+	if to == "" {
+		return fmt.Errorf("give the day with --to, e.g. --to tomorrow")
+	}
+	now := time.Now()
+	target, err := parseTargetDay(to, now)
+	if err != nil {
+		return err
+	}
+	if target == day && !copying {
+		return fmt.Errorf("the tasks are already on %s", day)
+	}
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	indexes, err := resolveDayTasks(data[day], queries, false)
+	if err != nil {
+		return err
+	}
+	date, _ := time.Parse("2006-01-02", target)
+	verb := "Moved"
+	var titles []string
+	for _, i := range indexes {
+		t := data[day][i]
+		titles = append(titles, t.Title)
+		if copying {
+			data[target] = append(data[target], copyOfTask(t))
+			continue
+		}
+		// Time stays on the day it was spent; plan fit leaves these tasks too
+		if t.Status == "started" {
+			return fmt.Errorf("'%s' is running; stop it first", t.Title)
+		}
+		if minutes := t.trackedMinutes(now.Unix()); minutes > 0 {
+			return fmt.Errorf("'%s' has %s on %s; `daily cp` copies what is left of it", t.Title, fmtMinutes(minutes), day)
+		}
+	}
+	if copying {
+		verb = "Copied"
+	} else {
+		moveTasks(data, day, target, indexes, now)
+	}
+	if err := saveTasks(data); err != nil {
+		return err
+	}
+	for _, title := range titles {
+		fmt.Printf("%s '%s' to %s.\n", verb, title, date.Format("Monday Jan 2"))
+	}
+	return nil
+}
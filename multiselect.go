@@ -0,0 +1,111 @@
+// multiselect.go - Multi-select list
+// A Bubble Tea list where space toggles items and enter confirms the selection
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type multiSelectModel struct {
+	label     string
+	items     []string
+	selected  map[int]bool
+	cursor    int
+	offset    int
+	size      int
+	cancelled bool
+}
+
+func (m multiSelectModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m multiSelectModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	k, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch {
+	case keys.IsQuit(k) || k.Type == tea.KeyEsc:
+		m.cancelled = true
+		return m, tea.Quit
+	case k.Type == tea.KeyEnter:
+		return m, tea.Quit
+	case k.Type == tea.KeySpace:
+		m.selected[m.cursor] = !m.selected[m.cursor]
+	case k.String() == "a":
+		// Toggle all: select everything unless everything is already selected
+		all := len(m.items) > 0
+		for i := range m.items {
+			all = all && m.selected[i]
+		}
+		for i := range m.items {
+			m.selected[i] = !all
+		}
+	case key.Matches(k, keys.Up) || k.Type == tea.KeyUp:
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case key.Matches(k, keys.Down) || k.Type == tea.KeyDown:
+		if m.cursor < len(m.items)-1 {
+			m.cursor++
+		}
+	}
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	} else if m.cursor >= m.offset+m.size {
+		m.offset = m.cursor - m.size + 1
+	}
+	return m, nil
+}
+
+func (m multiSelectModel) View() string {
+	var b strings.Builder
+	b.WriteString(m.label + "\n")
+	end := m.offset + m.size
+	if end > len(m.items) {
+		end = len(m.items)
+	}
+	for i := m.offset; i < end; i++ {
+		pointer := "  "
+		if i == m.cursor {
+			pointer = "→ "
+		}
+		box := "[ ]"
+		if m.selected[i] {
+			box = "[x]"
+		}
+		fmt.Fprintf(&b, "%s%s %s\n", pointer, box, m.items[i])
+	}
+	b.WriteString("space: toggle • a: all • enter: confirm • q: cancel\n")
+	return b.String()
+}
+
+// multiSelect lets the user pick several items and returns their indexes in
+// list order. It returns the "interrupt" error when cancelled, like promptui.
+func multiSelect(label string, items []string, preselected []int) ([]int, error) {
+	m := multiSelectModel{label: label, items: items, selected: map[int]bool{}, size: 15}
+	for _, i := range preselected {
+		m.selected[i] = true
+	}
+	result, err := tea.NewProgram(m).Run()
+	if err != nil {
+		return nil, err
+	}
+	final := result.(multiSelectModel)
+	if final.cancelled {
+		return nil, fmt.Errorf("interrupt")
+	}
+	var picked []int
+	for i := range items {
+		if final.selected[i] {
+			picked = append(picked, i)
+		}
+	}
+	return picked, nil
+}
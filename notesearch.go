@@ -0,0 +1,127 @@
+// notesearch.go - Note search
+// Notes can carry hashtags (#meeting, #idea); `daily note search` finds notes by text or tag across all days, so the notes read as a work journal
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// noteTagPattern finds hashtags: a # at the start of a word, then a letter,
+// so Markdown headings and issue numbers (#12) aren't tags
+var noteTagPattern = regexp.MustCompile(`(?:^|\s|\()#(\pL[\pL\pN_-]*)`)
+
+// noteTags returns the hashtags of a note, lowercased, without the #
+func noteTags(note string) []string {
+	var tags []string
+	for _, m := range noteTagPattern.FindAllStringSubmatch(note, -1) {
+		if tag := strings.ToLower(m[1]); !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// noteHit is a note that matches, and the task it was written on, if any
+type noteHit struct {
+	Task string
+	Text string
+}
+
+// noteMatcher matches a #tag exactly, or any other term as a case-insensitive
+// substring
+func noteMatcher(query string) func(string) bool {
+	query = strings.TrimSpace(query)
+	if tag, ok := strings.CutPrefix(query, "#"); ok && tag != "" {
+		tag = strings.ToLower(tag)
+		return func(note string) bool { return slices.Contains(noteTags(note), tag) }
+	}
+	needle := strings.ToLower(query)
+	return func(note string) bool { return strings.Contains(strings.ToLower(note), needle) }
+}
+
+// searchNotes prints the day and task notes matching query, grouped by day,
+// newest first. from and to bound the days when set.
+func searchNotes(query, from, to string) error {
+	if strings.TrimSpace(query) == "" || strings.TrimSpace(query) == "#" {
+		return fmt.Errorf("empty query")
+	}
+	for _, day := range []string{from, to} {
+		if _, err := time.Parse("2006-01-02", day); day != "" && err != nil {
+			return fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", day)
+		}
+	}
+	inRange := func(day string) bool {
+		return (from == "" || day >= from) && (to == "" || day <= to)
+	}
+	match := noteMatcher(query)
+	notes, err := loadNotes()
+	if err != nil {
+		return err
+	}
+	tasks, err := loadTasksRange(from, to)
+	if err != nil {
+		return err
+	}
+	hits := map[string][]noteHit{}
+	locked := 0
+	for day, list := range notes {
+		if !inRange(day) {
+			continue
+		}
+		if isSealed(list) {
+			locked++
+			continue
+		}
+		for _, note := range list {
+			if match(note) {
+				hits[day] = append(hits[day], noteHit{Text: note})
+			}
+		}
+	}
+	for day, list := range tasks {
+		if !inRange(day) {
+			continue
+		}
+		for _, t := range list {
+			for _, n := range t.Notes {
+				if match(n.Text) {
+					hits[day] = append(hits[day], noteHit{Task: t.Title, Text: n.Text})
+				}
+			}
+		}
+	}
+	if locked > 0 {
+		fmt.Printf("(%d locked day(s) not searched)\n", locked)
+	}
+	if len(hits) == 0 {
+		fmt.Printf("No notes match %q.\n", query)
+		return nil
+	}
+	days := make([]string, 0, len(hits))
+	total := 0
+	for day, list := range hits {
+		days = append(days, day)
+		total += len(list)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(days)))
+	for _, day := range days {
+		date, _ := time.Parse("2006-01-02", day)
+		fmt.Println(date.Format("Mon 2006-01-02"))
+		for _, h := range hits[day] {
+			text := strings.ReplaceAll(h.Text, "\n", "\n    ")
+			if h.Task != "" {
+				fmt.Printf("  - [%s] %s\n", h.Task, text)
+			} else {
+				fmt.Printf("  - %s\n", text)
+			}
+		}
+	}
+	fmt.Printf("\n%d note(s) on %d day(s)\n", total, len(days))
+	return nil
+}
@@ -0,0 +1,61 @@
+// notesrender.go - Notes rendering
+// Notes are written in Markdown; shown in a terminal, their headings, lists and code blocks are rendered with glamour
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/x/term"
+)
+
+// maxNoteWidth keeps rendered notes readable on wide terminals
+const maxNoteWidth = 100
+
+// noteRenderer returns the renderer for notes, or nil when they should be
+// printed as written: output that isn't a terminal, or the mono theme
+func noteRenderer() *glamour.TermRenderer {
+	if !term.IsTerminal(os.Stdout.Fd()) {
+		return nil
+	}
+	style := "dark"
+	switch {
+	case config.Theme == "mono":
+		style = "notty"
+	case lightBackground():
+		style = "light"
+	}
+	width := maxNoteWidth
+	if w, _, err := term.GetSize(os.Stdout.Fd()); err == nil && w > 0 {
+		width = min(w, maxNoteWidth)
+	}
+	r, err := glamour.NewTermRenderer(glamour.WithStandardStyle(style), glamour.WithWordWrap(width-4), glamour.WithEmoji())
+	if err != nil {
+		return nil
+	}
+	return r
+}
+
+// printNotes prints a day's numbered notes, rendered unless raw is set
+func printNotes(notes []string, raw bool) {
+	var r *glamour.TermRenderer
+	if !raw {
+		r = noteRenderer()
+	}
+	for i, note := range notes {
+		if r == nil {
+			fmt.Printf("%d. %s\n", i+1, note)
+			continue
+		}
+		rendered, err := r.Render(note)
+		if err != nil {
+			fmt.Printf("%d. %s\n", i+1, note)
+			continue
+		}
+		// Rendered notes may start with a heading, so the number goes above
+		fmt.Printf("%d.\n%s\n", i+1, strings.Trim(rendered, "\n"))
+	}
+}
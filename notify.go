@@ -0,0 +1,69 @@
+// notify.go - Desktop notifications
+// Sends notifications through the platform's notifier command
+
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// notifierCommand returns the command used to show desktop notifications on
+// this platform, or an error when none is installed
+func notifierCommand() (string, error) {
+	var name string
+	switch runtime.GOOS {
+	case "darwin":
+		name = "osascript"
+	case "windows":
+		name = "powershell"
+	default:
+		name = "notify-send"
+	}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", fmt.Errorf("%s not found in PATH", name)
+	}
+	return path, nil
+}
+
+// sendNotification shows a desktop notification unless notifications are
+// quiet right now, in which case it returns errMuted with the reason
+func sendNotification(title, body string) error {
+	if reason := quietReason(time.Now()); reason != "" {
+		return fmt.Errorf("%w (%s)", errMuted, reason)
+	}
+	return showNotification(title, body)
+}
+
+// showNotification shows a desktop notification regardless of quiet hours
+func showNotification(title, body string) error {
+	if err := requireOnline("Notifications"); err != nil {
+		return err
+	}
+	path, err := notifierCommand()
+	if err != nil {
+		return err
+	}
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %q with title %q", body, title)
+		cmd = exec.Command(path, "-e", script)
+	case "windows":
+		quote := func(s string) string { return "'" + strings.ReplaceAll(s, "'", "''") + "'" }
+		script := "[reflection.assembly]::loadwithpartialname('System.Windows.Forms') | Out-Null; " +
+			"$n = New-Object System.Windows.Forms.NotifyIcon; $n.Icon = [System.Drawing.SystemIcons]::Information; " +
+			"$n.Visible = $true; $n.ShowBalloonTip(5000, " + quote(title) + ", " + quote(body) + ", 'Info')"
+		cmd = exec.Command(path, "-NoProfile", "-Command", script)
+	default:
+		cmd = exec.Command(path, "--app-name=daily", title, body)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %v %s", path, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+// offline.go - Safe mode
+// `--offline` (alias `--no-integrations`) turns off every integration: network
+// calls, editor and notifier spawns, and external transcription commands
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// offline is set by the --offline and --no-integrations flags and stays set
+// for the rest of the process, including shell mode
+var offline bool
+
+// errOffline is returned by integrations refused in offline mode
+type errOffline struct {
+	what string
+}
+
+func (e errOffline) Error() string {
+	return fmt.Sprintf("%s is disabled in offline mode (--offline)", e.what)
+}
+
+// requireOnline guards an integration; call it before doing anything that
+// leaves the process
+func requireOnline(what string) error {
+	if offline {
+		return errOffline{what}
+	}
+	return nil
+}
+
+// offlineTransport fails every request, so that a call path missing its
+// requireOnline guard still can't reach the network
+type offlineTransport struct{}
+
+func (offlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, errOffline{"network access to " + req.URL.Host}
+}
+
+// enterOfflineMode installs the refusing transport for all HTTP clients that
+// use the default one
+func enterOfflineMode() {
+	offline = true
+	http.DefaultTransport = offlineTransport{}
+}
@@ -0,0 +1,138 @@
+// pair.go - Remote pairing view
+// Serves a read-only live page of the current task and timer for a pairing partner
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// currentSnapshot is the read-only view of the running task shared with others
+type currentSnapshot struct {
+	Day       string `json:"day"`
+	Running   bool   `json:"running"`
+	Title     string `json:"title,omitempty"`
+	Estimated int    `json:"estimated,omitempty"`
+	Actual    int    `json:"actual,omitempty"`
+	StartedAt int64  `json:"started_at,omitempty"`
+	FinishBy  int64  `json:"finish_by,omitempty"`
+	Now       int64  `json:"now"`
+}
+
+// takeCurrentSnapshot reads the task store and describes the started task, if any
+func takeCurrentSnapshot() (currentSnapshot, error) {
+	data, err := loadTasks()
+	if err != nil {
+		return currentSnapshot{}, err
+	}
+	today := todayKey()
+	snap := currentSnapshot{Day: today, Now: time.Now().Unix()}
+	for _, t := range data[today] {
+		if t.Status == "started" {
+			snap.Running = true
+			snap.Title = t.Title
+			snap.Estimated = t.Estimated
+			snap.Actual = t.Actual
+			snap.StartedAt = t.runningSince()
+			snap.FinishBy = t.FinishBy
+			break
+		}
+	}
+	return snap, nil
+}
+
+// pairPage polls current.json and ticks the timer locally between polls
+const pairPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>daily - current task</title>
+<style>
+body{font-family:sans-serif;background:#111;color:#eee;display:flex;align-items:center;justify-content:center;height:100vh;margin:0}
+main{text-align:center}
+h1{font-size:2.2em;margin:0 0 .4em}
+#timer{font-size:4em;font-variant-numeric:tabular-nums}
+#bar{width:60vw;height:12px;background:#333;border-radius:6px;margin:1em auto;overflow:hidden}
+#fill{height:100%;background:#03befc;width:0}
+.over #fill{background:#f53333}
+#meta{color:#999}
+</style>
+</head>
+<body>
+<main id="main">
+<h1 id="title">Loading…</h1>
+<div id="timer"></div>
+<div id="bar"><div id="fill"></div></div>
+<div id="meta"></div>
+</main>
+<script>
+let snap = null, fetchedAt = 0;
+function pad(n){return String(n).padStart(2,"0")}
+function fmt(s){s=Math.max(0,Math.floor(s));return Math.floor(s/3600)+":"+pad(Math.floor(s/60)%60)+":"+pad(s%60)}
+function render(){
+  if(!snap){return}
+  const main=document.getElementById("main");
+  if(!snap.running){
+    document.getElementById("title").textContent="No task running";
+    document.getElementById("timer").textContent="";
+    document.getElementById("meta").textContent=snap.day;
+    document.getElementById("fill").style.width="0";
+    main.className="";
+    return;
+  }
+  const now=snap.now+(Date.now()-fetchedAt)/1000;
+  const elapsed=snap.actual*60+(snap.started_at?now-snap.started_at:0);
+  const pct=snap.estimated>0?Math.min(1,elapsed/(snap.estimated*60)):0;
+  document.getElementById("title").textContent=snap.title;
+  document.getElementById("timer").textContent=fmt(elapsed);
+  document.getElementById("fill").style.width=(pct*100)+"%";
+  let meta=snap.estimated>0?"Estimate: "+snap.estimated+" min":"No estimate";
+  if(snap.finish_by){meta+=" · should finish by "+new Date(snap.finish_by*1000).toLocaleTimeString([], {hour:"2-digit",minute:"2-digit"})}
+  document.getElementById("meta").textContent=meta;
+  main.className=snap.finish_by&&now>snap.finish_by?"over":"";
+}
+async function poll(){
+  try{const r=await fetch("current.json");snap=await r.json();fetchedAt=Date.now()}catch(e){}
+  render();
+}
+poll();setInterval(poll,15000);setInterval(render,1000);
+</script>
+</body>
+</html>
+`
+
+// servePairView starts a read-only HTTP server showing the current task
+func servePairView(addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, pairPage)
+	})
+	mux.HandleFunc("/current.json", func(w http.ResponseWriter, r *http.Request) {
+		snap, err := takeCurrentSnapshot()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(snap)
+	})
+	fmt.Printf("Sharing current task on http://%s (read-only). Press Ctrl+C to stop.\n", displayAddr(addr))
+	return http.ListenAndServe(addr, mux)
+}
+
+// displayAddr makes a listen address like ":8765" clickable
+func displayAddr(addr string) string {
+	if len(addr) > 0 && addr[0] == ':' {
+		return "localhost" + addr
+	}
+	return addr
+}
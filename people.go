@@ -0,0 +1,330 @@
+// people.go - 1:1 prep
+// People you hold recurring 1:1s with get a prep task on the day of each 1:1 and a rolling thread of talking points, kept with `daily person`
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Person is an entry of `people` in config.yaml
+type Person struct {
+	Name  string `yaml:"name"`
+	Every string `yaml:"every"` // weekly wed, biweekly wed, every 3 weeks wed
+	Since string `yaml:"since"` // date of one 1:1, needed when weeks are skipped
+	Prep  int    `yaml:"prep"`  // minutes of prep, default 15
+}
+
+const defaultPrepMinutes = 15
+
+func (p Person) rule() (recurrence, error) {
+	r, err := parseRecurrence(p.Every, p.Since)
+	if err != nil {
+		return r, fmt.Errorf("people: %s: %w", p.Name, err)
+	}
+	return r, nil
+}
+
+func (p Person) prepMinutes() int {
+	if p.Prep <= 0 {
+		return defaultPrepMinutes
+	}
+	return p.Prep
+}
+
+// personKey identifies a person in people.yaml and in prep task IDs
+func personKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), "-"))
+}
+
+// PersonNote is a talking point of a thread
+type PersonNote struct {
+	Day       string `yaml:"day"`
+	Text      string `yaml:"text"`
+	Discussed string `yaml:"discussed,omitempty"` // day of the 1:1 it was covered in
+}
+
+// PersonThread is what is kept about one person
+type PersonThread struct {
+	Notes    []PersonNote `yaml:"notes,omitempty"`
+	Prepared string       `yaml:"prepared,omitempty"` // last 1:1 a prep task was added for
+}
+
+// PeopleData stores the threads by person key
+type PeopleData map[string]PersonThread
+
+func getPeopleFilePath() (string, error) {
+	return dataFilePath("people.yaml")
+}
+
+func loadPeople() (PeopleData, error) {
+	path, err := getPeopleFilePath()
+	if err != nil {
+		return nil, err
+	}
+	data := PeopleData{}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return data, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = yaml.Unmarshal(content, &data)
+	return data, err
+}
+
+func savePeople(data PeopleData) error {
+	path, err := getPeopleFilePath()
+	if err != nil {
+		return err
+	}
+	content, err := yaml.Marshal(&data)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, content, 0644)
+}
+
+// findPerson looks a configured person up by name or name prefix
+func findPerson(name string) (Person, bool) {
+	key := personKey(name)
+	var matches []Person
+	for _, p := range config.People {
+		if personKey(p.Name) == key {
+			return p, true
+		}
+		if strings.HasPrefix(personKey(p.Name), key) {
+			matches = append(matches, p)
+		}
+	}
+	if len(matches) == 1 {
+		return matches[0], true
+	}
+	return Person{Name: name}, false
+}
+
+// openPoints returns the talking points not discussed yet
+func (t PersonThread) openPoints() []PersonNote {
+	var open []PersonNote
+	for _, n := range t.Notes {
+		if n.Discussed == "" {
+			open = append(open, n)
+		}
+	}
+	return open
+}
+
+// schedulePrepTasks adds the prep task of every 1:1 falling on day, once:
+// a prep task that was deleted doesn't come back
+func schedulePrepTasks(day string) error {
+	if len(config.People) == 0 {
+		return nil
+	}
+	date, err := time.ParseInLocation("2006-01-02", day, time.Local)
+	if err != nil {
+		return err
+	}
+	people, err := loadPeople()
+	if err != nil {
+		return err
+	}
+	var due []Person
+	for _, p := range config.People {
+		r, err := p.rule()
+		if err != nil {
+			return err
+		}
+		if r.occursOn(date) && people[personKey(p.Name)].Prepared < day {
+			due = append(due, p)
+		}
+	}
+	if len(due) == 0 {
+		return nil
+	}
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	for _, p := range due {
+		task := Task{
+			Title:      fmt.Sprintf("1:1 with %s prep", p.Name),
+			Estimated:  p.prepMinutes(),
+			Status:     "pending",
+			Tags:       []string{"1on1"},
+			ExternalID: "person:" + personKey(p.Name),
+		}
+		applyTagRules(&task)
+		data[day] = append(data[day], task)
+		thread := people[personKey(p.Name)]
+		thread.Prepared = day
+		people[personKey(p.Name)] = thread
+		fmt.Printf("Added '%s' (%s) for the 1:1 today; `daily person %s` shows the talking points.\n",
+			task.Title, fmtMinutes(task.Estimated), strings.ToLower(strings.Fields(p.Name)[0]))
+	}
+	if err := saveTasks(data); err != nil {
+		return err
+	}
+	return savePeople(people)
+}
+
+// printPersonNotes shows the open talking points when a prep task is worked on
+func printPersonNotes(t Task) {
+	key, ok := strings.CutPrefix(t.ExternalID, "person:")
+	if !ok {
+		return
+	}
+	people, err := loadPeople()
+	if err != nil {
+		return
+	}
+	open := people[key].openPoints()
+	if len(open) == 0 {
+		fmt.Println("No open talking points.")
+		return
+	}
+	fmt.Println("Talking points:")
+	for _, n := range open {
+		fmt.Printf("  - %s (%s)\n", n.Text, n.Day)
+	}
+}
+
+// listPeople prints everyone with a schedule or a thread
+func listPeople() error {
+	people, err := loadPeople()
+	if err != nil {
+		return err
+	}
+	if len(config.People) == 0 && len(people) == 0 {
+		fmt.Println("No people yet. Add them under `people` in config.yaml, or start a thread with `daily person <name> <note>`.")
+		return nil
+	}
+	listed := map[string]bool{}
+	for _, p := range config.People {
+		key := personKey(p.Name)
+		listed[key] = true
+		next := ""
+		if r, err := p.rule(); err == nil {
+			next = r.next(time.Now()).Format("Mon Jan 2")
+		}
+		fmt.Printf("  %-20s %-22s next %-12s %d open points\n", p.Name, p.Every, next, len(people[key].openPoints()))
+	}
+	var others []string
+	for key := range people {
+		if !listed[key] {
+			others = append(others, key)
+		}
+	}
+	sort.Strings(others)
+	for _, key := range others {
+		fmt.Printf("  %-20s %-22s %-17s %d open points\n", key, "no 1:1 scheduled", "", len(people[key].openPoints()))
+	}
+	return nil
+}
+
+// showPerson prints a person's next 1:1, open points and the points already
+// discussed, most recent first
+func showPerson(name string) error {
+	p, configured := findPerson(name)
+	people, err := loadPeople()
+	if err != nil {
+		return err
+	}
+	thread := people[personKey(p.Name)]
+	fmt.Println(p.Name)
+	if configured {
+		if r, err := p.rule(); err == nil {
+			fmt.Printf("1:1 %s, next on %s\n", p.Every, r.next(time.Now()).Format("Monday Jan 2"))
+		}
+	}
+	open := thread.openPoints()
+	fmt.Println()
+	if len(open) == 0 {
+		fmt.Println("No open talking points.")
+	} else {
+		fmt.Println("Open:")
+		for _, n := range open {
+			fmt.Printf("  - %s (%s)\n", n.Text, n.Day)
+		}
+	}
+	var discussed []PersonNote
+	for _, n := range thread.Notes {
+		if n.Discussed != "" {
+			discussed = append(discussed, n)
+		}
+	}
+	sort.SliceStable(discussed, func(i, j int) bool { return discussed[i].Discussed > discussed[j].Discussed })
+	if len(discussed) > 0 {
+		fmt.Println("\nDiscussed:")
+		last := ""
+		for i, n := range discussed {
+			if i == 10 {
+				fmt.Printf("  ... and %d older\n", len(discussed)-i)
+				break
+			}
+			if n.Discussed != last {
+				fmt.Printf("  %s\n", n.Discussed)
+				last = n.Discussed
+			}
+			fmt.Printf("    - %s\n", n.Text)
+		}
+	}
+	return nil
+}
+
+// addPersonNote adds a talking point to a person's thread
+func addPersonNote(name, text string) error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return fmt.Errorf("the note is empty")
+	}
+	p, _ := findPerson(name)
+	people, err := loadPeople()
+	if err != nil {
+		return err
+	}
+	key := personKey(p.Name)
+	thread := people[key]
+	thread.Notes = append(thread.Notes, PersonNote{Day: todayKey(), Text: text})
+	people[key] = thread
+	if err := savePeople(people); err != nil {
+		return err
+	}
+	fmt.Printf("Added a talking point for %s (%d open).\n", p.Name, len(thread.openPoints()))
+	return nil
+}
+
+// markDiscussed closes the open talking points after a 1:1
+func markDiscussed(name string) error {
+	p, _ := findPerson(name)
+	people, err := loadPeople()
+	if err != nil {
+		return err
+	}
+	key := personKey(p.Name)
+	thread := people[key]
+	count := 0
+	for i := range thread.Notes {
+		if thread.Notes[i].Discussed == "" {
+			thread.Notes[i].Discussed = todayKey()
+			count++
+		}
+	}
+	if count == 0 {
+		fmt.Printf("No open talking points for %s.\n", p.Name)
+		return nil
+	}
+	people[key] = thread
+	if err := savePeople(people); err != nil {
+		return err
+	}
+	fmt.Printf("Marked the talking points with %s as discussed (%d).\n", p.Name, count)
+	return nil
+}
@@ -0,0 +1,205 @@
+// planfit.go - Plan check
+// `daily plan check` compares the work left in a day with the time left for it and suggests which low-priority tasks to move to the next workday; `daily plan fit` moves them
+
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// planFit is how a day's remaining work compares with the time left for it
+type planFit struct {
+	Day       string
+	Next      string // the workday moved tasks go to
+	Remaining int    // estimated minutes of open work not yet tracked
+	Left      int    // working minutes left on the day
+	Movable   []int  // indexes of tasks that can move, the first to move first
+	Suggested []int  // the fewest of them that make the day fit
+}
+
+// Overflow is how much the remaining work exceeds the time left
+func (f planFit) Overflow() int {
+	return max(0, f.Remaining-f.Left)
+}
+
+// nextWorkday returns the first day after day that isn't a weekend, a holiday
+// or a day off
+func nextWorkday(day string) string {
+	date, err := time.ParseInLocation("2006-01-02", day, time.Local)
+	if err != nil {
+		return day
+	}
+	off, _ := loadDaysOff()
+	next := date.AddDate(0, 0, 1)
+	for i := 0; i < 30; i++ {
+		if _, isOff := dayOffReason(next, off); !isOff {
+			break
+		}
+		next = next.AddDate(0, 0, 1)
+	}
+	return next.Format("2006-01-02")
+}
+
+// movable reports whether a task can be moved to another day without losing
+// anything: open, untouched, unscheduled work
+func movable(t Task) bool {
+	return t.Status == "pending" && t.Actual == 0 && len(t.Sessions) == 0 &&
+		t.PlannedAt == "" && t.ExternalID == "" && t.Estimated > 0
+}
+
+// checkPlan measures a day's remaining work against the time left: the rest
+// of today's working hours, or a future day's capacity
+func checkPlan(day string, tasks []Task, now time.Time) (planFit, error) {
+	date, err := time.ParseInLocation("2006-01-02", day, time.Local)
+	if err != nil {
+		return planFit{}, fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", day)
+	}
+	f := planFit{Day: day, Next: nextWorkday(day)}
+	switch today := todayKey(); {
+	case day == today:
+		f.Left = remainingMinutesToday(now)
+	case day > today:
+		f.Left = dayCapacity(date)
+	default:
+		return f, fmt.Errorf("%s is over; plan check looks at today or a day to come", day)
+	}
+	for i, t := range tasks {
+		if isNonWork(t) || !isOpenStatus(t.Status) {
+			continue
+		}
+		f.Remaining += max(0, t.Estimated-t.trackedMinutes(now.Unix()))
+		if movable(t) {
+			f.Movable = append(f.Movable, i)
+		}
+	}
+	// Lowest priority first, and the last added first among equals
+	sort.SliceStable(f.Movable, func(i, j int) bool {
+		a, b := tasks[f.Movable[i]], tasks[f.Movable[j]]
+		if priorityRank(a.Priority) != priorityRank(b.Priority) {
+			return priorityRank(a.Priority) > priorityRank(b.Priority)
+		}
+		return f.Movable[i] > f.Movable[j]
+	})
+	freed := 0
+	for _, i := range f.Movable {
+		if freed >= f.Overflow() {
+			break
+		}
+		f.Suggested = append(f.Suggested, i)
+		freed += tasks[i].Estimated
+	}
+	return f, nil
+}
+
+// describeTask is a task as plan check lists it
+func describeTask(t Task) string {
+	label := fmt.Sprintf("%s (%s", t.Title, fmtMinutes(t.Estimated))
+	if p := priorityLabel(t.Priority); p != "" {
+		label += ", " + p
+	}
+	return label + ")"
+}
+
+// moveTasks moves tasks of day, by index, to another day
+func moveTasks(data TaskData, day, to string, indexes []int, now time.Time) {
+	for _, i := range indexes {
+		t := data[day][i]
+		t.logChange("day", day, to, now)
+		data[to] = append(data[to], t)
+	}
+	var kept []Task
+	for i, t := range data[day] {
+		if !slices.Contains(indexes, i) {
+			kept = append(kept, t)
+		}
+	}
+	data[day] = kept
+}
+
+// runPlanCheck reports whether a day's plan fits. When it doesn't, it
+// suggests tasks to move and, when apply is set or the user confirms, moves
+// them to the next workday.
+func runPlanCheck(day string, apply bool) error {
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	tasks := data[day]
+	f, err := checkPlan(day, tasks, now)
+	if err != nil {
+		return err
+	}
+	if f.Overflow() == 0 {
+		fmt.Printf("The plan fits: %s of work left, %s of time (%s to spare).\n",
+			fmtMinutes(f.Remaining), fmtMinutes(f.Left), fmtMinutes(f.Left-f.Remaining))
+		return nil
+	}
+	fmt.Printf("The plan doesn't fit: %s of work left, %s of time, %s over.\n",
+		fmtMinutes(f.Remaining), fmtMinutes(f.Left), fmtMinutes(f.Overflow()))
+	if len(f.Movable) == 0 {
+		fmt.Println("No task can move: the open ones are started, scheduled, or already have time on them.")
+		return nil
+	}
+	freed := 0
+	for _, i := range f.Suggested {
+		freed += tasks[i].Estimated
+	}
+	if freed < f.Overflow() {
+		fmt.Printf("Moving every task that can move frees %s, still %s short.\n", fmtMinutes(freed), fmtMinutes(f.Overflow()-freed))
+	}
+
+	picked := f.Suggested
+	if !apply {
+		fmt.Printf("Suggested for %s, lowest priority first:\n", f.Next)
+		for _, i := range f.Suggested {
+			fmt.Printf("  %s\n", describeTask(tasks[i]))
+		}
+		if !isTerminal() {
+			fmt.Println("Run `daily plan fit` to move them.")
+			return nil
+		}
+		items := make([]string, len(f.Movable))
+		var preselected []int
+		for n, i := range f.Movable {
+			items[n] = describeTask(tasks[i])
+			if slices.Contains(f.Suggested, i) {
+				preselected = append(preselected, n)
+			}
+		}
+		chosen, err := multiSelect(fmt.Sprintf("Move to %s", f.Next), items, preselected)
+		if err != nil {
+			if err.Error() == "interrupt" {
+				return nil
+			}
+			return err
+		}
+		picked = nil
+		for _, n := range chosen {
+			picked = append(picked, f.Movable[n])
+		}
+	}
+	if len(picked) == 0 {
+		fmt.Println("Nothing moved.")
+		return nil
+	}
+	moved := 0
+	var titles []string
+	for _, i := range picked {
+		moved += tasks[i].Estimated
+		titles = append(titles, "'"+tasks[i].Title+"'")
+	}
+	moveTasks(data, day, f.Next, picked, now)
+	if err := saveTasks(data); err != nil {
+		return err
+	}
+	fmt.Printf("Moved %d tasks (%s) to %s: %s.\n", len(picked), fmtMinutes(moved), f.Next, strings.Join(titles, ", "))
+	if left := f.Overflow() - moved; left > 0 {
+		fmt.Printf("Still %s over.\n", fmtMinutes(left))
+	}
+	return nil
+}
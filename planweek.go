@@ -0,0 +1,371 @@
+// planweek.go - Weekly planning
+// `daily plan week` gathers candidates from the inbox, GitHub and Jira, leaves out what is already planned, and lets me spread them over the week's workdays while each day's load is shown against its capacity
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// planSources are the sources plan week knows, in the order candidates are listed
+var planSources = []string{"inbox", "github", "jira"}
+
+// weekCandidate is something that could be planned this week
+type weekCandidate struct {
+	Source string
+	Task   Task
+	// Day and Index locate an inbox task, which moves instead of being added
+	Day   string
+	Index int
+}
+
+// planWeekDays returns the workdays of the week containing day, from today
+// on; the next week's when none are left
+func planWeekDays(day string, now time.Time) ([]string, error) {
+	date, err := time.ParseInLocation("2006-01-02", day, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", day)
+	}
+	off, err := loadDaysOff()
+	if err != nil {
+		return nil, err
+	}
+	today := dayOf(now)
+	monday := date.AddDate(0, 0, -((int(date.Weekday()) + 6) % 7))
+	for week := 0; week < 2; week++ {
+		var days []string
+		for i := 0; i < 7; i++ {
+			d := monday.AddDate(0, 0, 7*week+i)
+			if _, isOff := dayOffReason(d, off); isOff || d.Before(today) {
+				continue
+			}
+			days = append(days, d.Format("2006-01-02"))
+		}
+		if len(days) > 0 {
+			return days, nil
+		}
+	}
+	return nil, fmt.Errorf("no workdays left in the week of %s", day)
+}
+
+// parsePlanSources reads --from; empty means every configured source
+func parsePlanSources(from string) (sources []string, explicit bool, err error) {
+	if strings.TrimSpace(from) == "" {
+		sources = []string{"inbox"}
+		if os.Getenv("GITHUB_TOKEN") != "" {
+			sources = append(sources, "github")
+		}
+		if config.Jira.BaseURL != "" {
+			sources = append(sources, "jira")
+		}
+		return sources, false, nil
+	}
+	for _, s := range strings.Split(from, ",") {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if !slices.Contains(planSources, s) {
+			return nil, true, fmt.Errorf("unknown source %q (expected %s)", s, strings.Join(planSources, ", "))
+		}
+		if !slices.Contains(sources, s) {
+			sources = append(sources, s)
+		}
+	}
+	return sources, true, nil
+}
+
+// weekCandidates gathers the candidates of the sources, leaving out the work
+// already planned on one of the days or still open on any day
+func weekCandidates(data TaskData, days []string, sources []string, explicit bool) ([]weekCandidate, error) {
+	known := map[string]bool{}
+	for day, tasks := range data {
+		for _, t := range tasks {
+			if slices.Contains(t.Tags, "inbox") && isOpenStatus(t.Status) {
+				continue
+			}
+			if slices.Contains(days, day) || isOpenStatus(t.Status) {
+				known[duplicateKey(t)] = true
+			}
+		}
+	}
+	var candidates []weekCandidate
+	add := func(c weekCandidate) {
+		if k := duplicateKey(c.Task); k == "" || !known[k] {
+			known[k] = true
+			candidates = append(candidates, c)
+		}
+	}
+	last := days[len(days)-1]
+	for _, source := range planSources {
+		if !slices.Contains(sources, source) {
+			continue
+		}
+		switch source {
+		case "inbox":
+			for _, day := range sortedDays(data) {
+				if day > last {
+					continue
+				}
+				for i, t := range data[day] {
+					if slices.Contains(t.Tags, "inbox") && t.Status == "pending" {
+						add(weekCandidate{Source: source, Task: t, Day: day, Index: i})
+					}
+				}
+			}
+		case "github":
+			issues, err := searchGitHubIssues()
+			if err != nil {
+				if explicit {
+					return nil, fmt.Errorf("github: %w", err)
+				}
+				fmt.Println("Skipping GitHub:", err)
+				continue
+			}
+			for _, issue := range issues {
+				add(weekCandidate{Source: source, Task: githubTask(issue)})
+			}
+		case "jira":
+			client, err := newJiraClient()
+			var issues []jiraIssue
+			if err == nil {
+				issues, err = client.assignedIssues()
+			}
+			if err != nil {
+				if explicit {
+					return nil, fmt.Errorf("jira: %w", err)
+				}
+				fmt.Println("Skipping Jira:", err)
+				continue
+			}
+			for _, issue := range issues {
+				add(weekCandidate{Source: source, Task: jiraTask(client.cfg, issue)})
+			}
+		}
+	}
+	return candidates, nil
+}
+
+// weekPlanModel spreads candidates over the days. assigned holds a day index
+// per candidate, -1 for none.
+type weekPlanModel struct {
+	days      []string
+	base      []int // minutes of open work already planned on each day
+	capacity  []int
+	items     []weekCandidate
+	assigned  []int
+	cursor    int
+	offset    int
+	size      int
+	cancelled bool
+}
+
+func newWeekPlanModel(data TaskData, days []string, items []weekCandidate, now time.Time) weekPlanModel {
+	m := weekPlanModel{days: days, items: items, size: 12}
+	for _, day := range days {
+		date, _ := time.ParseInLocation("2006-01-02", day, time.Local)
+		capacity := dayCapacity(date)
+		if day == todayKey() {
+			capacity = min(capacity, remainingMinutesToday(now))
+		}
+		planned := 0
+		for _, t := range data[day] {
+			if isOpenStatus(t.Status) && !isNonWork(t) && !slices.Contains(t.Tags, "inbox") {
+				planned += max(0, t.Estimated-t.trackedMinutes(now.Unix()))
+			}
+		}
+		m.base = append(m.base, planned)
+		m.capacity = append(m.capacity, capacity)
+	}
+	for range items {
+		m.assigned = append(m.assigned, -1)
+	}
+	return m
+}
+
+// load returns the minutes planned on a day with the assignments so far
+func (m weekPlanModel) load(day int) int {
+	minutes := m.base[day]
+	for i, d := range m.assigned {
+		if d == day {
+			minutes += m.items[i].Task.Estimated
+		}
+	}
+	return minutes
+}
+
+func (m weekPlanModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m weekPlanModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	k, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch {
+	case keys.IsQuit(k) || k.Type == tea.KeyEsc:
+		m.cancelled = true
+		return m, tea.Quit
+	case k.Type == tea.KeyEnter:
+		return m, tea.Quit
+	case key.Matches(k, keys.Up) || k.Type == tea.KeyUp:
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case key.Matches(k, keys.Down) || k.Type == tea.KeyDown:
+		if m.cursor < len(m.items)-1 {
+			m.cursor++
+		}
+	case key.Matches(k, keys.Right) || k.Type == tea.KeyRight || k.Type == tea.KeySpace:
+		// Cycle through the days, then back to unplanned
+		if m.assigned[m.cursor]++; m.assigned[m.cursor] == len(m.days) {
+			m.assigned[m.cursor] = -1
+		}
+	case key.Matches(k, keys.Left) || k.Type == tea.KeyLeft:
+		if m.assigned[m.cursor]--; m.assigned[m.cursor] < -1 {
+			m.assigned[m.cursor] = len(m.days) - 1
+		}
+	case k.Type == tea.KeyBackspace || k.String() == "0":
+		m.assigned[m.cursor] = -1
+	case k.String() == "+" || k.String() == "=":
+		m.items[m.cursor].Task.Estimated += 15
+	case k.String() == "-":
+		m.items[m.cursor].Task.Estimated = max(0, m.items[m.cursor].Task.Estimated-15)
+	case len(k.String()) == 1 && k.String() >= "1" && k.String() <= "9":
+		if day := int(k.String()[0] - '1'); day < len(m.days) {
+			m.assigned[m.cursor] = day
+		}
+	}
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	} else if m.cursor >= m.offset+m.size {
+		m.offset = m.cursor - m.size + 1
+	}
+	return m, nil
+}
+
+func (m weekPlanModel) View() string {
+	var b strings.Builder
+	b.WriteString("Plan the week\n\n")
+	const width = 20
+	for d, day := range m.days {
+		date, _ := time.Parse("2006-01-02", day)
+		load, capacity := m.load(d), m.capacity[d]
+		ratio := ratioOf(load, capacity)
+		filled := min(width, int(ratio*width+0.5))
+		bar := lipgloss.NewStyle().Foreground(lipgloss.Color(gradientColor(ratio, true))).
+			Render(strings.Repeat("█", filled) + strings.Repeat("░", width-filled))
+		fmt.Fprintf(&b, "%d %s  %s  %s", d+1, date.Format("Mon Jan 2"), bar, fmtRatio(load, capacity))
+		if load > capacity {
+			fmt.Fprintf(&b, "  %s over", fmtMinutes(load-capacity))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	end := min(m.offset+m.size, len(m.items))
+	for i := m.offset; i < end; i++ {
+		pointer := "  "
+		if i == m.cursor {
+			pointer = "→ "
+		}
+		day := "   -   "
+		if d := m.assigned[i]; d >= 0 {
+			date, _ := time.Parse("2006-01-02", m.days[d])
+			day = date.Format("Mon 2") + strings.Repeat(" ", 7-len(date.Format("Mon 2")))
+		}
+		t := m.items[i].Task
+		fmt.Fprintf(&b, "%s%s  %-6s %-40s %s\n", pointer, day, m.items[i].Source, shorten(t.Title, 40), fmtMinutes(t.Estimated))
+	}
+	b.WriteString("\n←/→ or 1-9: day • 0: unplan • +/-: estimate • enter: save • q: cancel\n")
+	return b.String()
+}
+
+// runPlanWeek gathers the candidates and saves the tasks spread over the week
+func runPlanWeek(day, from string) error {
+	sources, explicit, err := parsePlanSources(from)
+	if err != nil {
+		return err
+	}
+	if !isTerminal() {
+		return fmt.Errorf("plan week needs a terminal")
+	}
+	now := time.Now()
+	days, err := planWeekDays(day, now)
+	if err != nil {
+		return err
+	}
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	candidates, err := weekCandidates(data, days, sources, explicit)
+	if err != nil {
+		return err
+	}
+	if len(candidates) == 0 {
+		fmt.Printf("Nothing new to plan from %s.\n", strings.Join(sources, ", "))
+		return nil
+	}
+	result, err := tea.NewProgram(newWeekPlanModel(data, days, candidates, now)).Run()
+	if err != nil {
+		return err
+	}
+	m := result.(weekPlanModel)
+	if m.cancelled {
+		fmt.Println("Plan not saved.")
+		return nil
+	}
+
+	added := make([]int, len(days))
+	moved := map[string][]int{} // inbox tasks to remove from their day, by index
+	for i, c := range m.items {
+		d := m.assigned[i]
+		if d < 0 {
+			continue
+		}
+		added[d]++
+		if c.Source == "inbox" {
+			c.Task = data[c.Day][c.Index]
+			c.Task.Tags = slices.DeleteFunc(slices.Clone(c.Task.Tags), func(tag string) bool { return tag == "inbox" })
+			c.Task.setEstimate(m.items[i].Task.Estimated, now)
+			c.Task.logChange("day", c.Day, days[d], now)
+			moved[c.Day] = append(moved[c.Day], c.Index)
+		}
+		data[days[d]] = append(data[days[d]], c.Task)
+	}
+	// Appending kept the indexes valid until now
+	for day, indexes := range moved {
+		var kept []Task
+		for i, t := range data[day] {
+			if !slices.Contains(indexes, i) {
+				kept = append(kept, t)
+			}
+		}
+		data[day] = kept
+	}
+	total := 0
+	for _, n := range added {
+		total += n
+	}
+	if total == 0 {
+		fmt.Println("Nothing planned.")
+		return nil
+	}
+	if err := saveTasks(data); err != nil {
+		return err
+	}
+	for d, n := range added {
+		if n > 0 {
+			date, _ := time.Parse("2006-01-02", days[d])
+			fmt.Printf("  %s  +%d tasks, %s planned\n", date.Format("Mon Jan 2"), n, fmtRatio(m.load(d), m.capacity[d]))
+		}
+	}
+	fmt.Printf("Planned %d tasks this week.\n", total)
+	return nil
+}
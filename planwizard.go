@@ -0,0 +1,182 @@
+// planwizard.go - Morning planning
+// `daily plan` composes a day in one pass: pick from what was left open on the last working day and the recurring tasks due, add new tasks, and see the time left as the list grows
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RecurringTask is an entry of the `recurring` section of config.yaml: a task
+// `daily plan` offers on the days it is due
+type RecurringTask struct {
+	Title   string   `yaml:"title"`
+	Every   string   `yaml:"every"`   // daily, weekly mon, biweekly fri, every 4 weeks mon
+	Since   string   `yaml:"since"`   // date of one occurrence, needed when weeks are skipped
+	Minutes int      `yaml:"minutes"` // estimate
+	Tags    []string `yaml:"tags"`
+}
+
+// dueOn reports whether the task falls on a day
+func (r RecurringTask) dueOn(date time.Time) (bool, error) {
+	if strings.EqualFold(strings.TrimSpace(r.Every), "daily") {
+		return true, nil
+	}
+	rule, err := parseRecurrence(r.Every, r.Since)
+	if err != nil {
+		return false, fmt.Errorf("recurring: %s: %w", r.Title, err)
+	}
+	return rule.occursOn(date), nil
+}
+
+// task is the task a recurring entry adds
+func (r RecurringTask) task() Task {
+	task := Task{
+		Title:     r.Title,
+		Estimated: r.Minutes,
+		Status:    "pending",
+		Tags:      normalizeTags(r.Tags),
+	}
+	applyTagRules(&task)
+	return task
+}
+
+// previousDay returns the last day before day that has tasks, or ""
+func previousDay(data TaskData, day string) string {
+	days := sortedDays(data)
+	for i := len(days) - 1; i >= 0; i-- {
+		if days[i] < day && len(data[days[i]]) > 0 {
+			return days[i]
+		}
+	}
+	return ""
+}
+
+// runPlanWizard composes a day's list: the open tasks of the previous day
+// and the recurring tasks due are offered, then new tasks are added one per
+// line, in quick-add form
+func runPlanWizard(day string) error {
+	date, err := time.ParseInLocation("2006-01-02", day, time.Local)
+	if err != nil {
+		return fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", day)
+	}
+	if !isTerminal() {
+		return fmt.Errorf("plan needs a terminal; add tasks with `daily add`")
+	}
+	now := time.Now()
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	fit, err := checkPlan(day, data[day], now)
+	if err != nil {
+		return err
+	}
+	present := map[string]bool{}
+	for _, t := range data[day] {
+		present[strings.ToLower(t.Title)] = true
+	}
+	fmt.Printf("Plan for %s: %s planned so far\n", date.Format("Monday Jan 2"), fmtRatio(fit.Remaining, fit.Left))
+
+	var offered []Task
+	var labels []string
+	if prev := previousDay(data, day); prev != "" {
+		prevDate, _ := time.Parse("2006-01-02", prev)
+		for _, t := range data[prev] {
+			if !isOpenStatus(t.Status) || isNonWork(t) || present[strings.ToLower(t.Title)] {
+				continue
+			}
+			carried := carryOver(t)
+			offered = append(offered, carried)
+			labels = append(labels, fmt.Sprintf("left on %s: %s", prevDate.Format("Mon"), describeTask(carried)))
+		}
+	}
+	for _, r := range config.Recurring {
+		due, err := r.dueOn(date)
+		if err != nil {
+			return err
+		}
+		if due && !present[strings.ToLower(r.Title)] {
+			offered = append(offered, r.task())
+			labels = append(labels, "recurring: "+describeTask(r.task()))
+		}
+	}
+
+	var planned []Task
+	if len(offered) > 0 {
+		all := make([]int, len(offered))
+		for i := range all {
+			all[i] = i
+		}
+		picked, err := multiSelect(fmt.Sprintf("Pick what goes on the list (%s free)", fmtMinutes(fit.Left-fit.Remaining)), labels, all)
+		if err != nil {
+			if err.Error() == "interrupt" {
+				fmt.Println("Plan not saved.")
+				return nil
+			}
+			return err
+		}
+		for _, i := range picked {
+			planned = append(planned, offered[i])
+		}
+	}
+
+	free := func() int {
+		minutes := fit.Left - fit.Remaining
+		for _, t := range planned {
+			minutes -= t.Estimated
+		}
+		return minutes
+	}
+	history := buildTitleHistory(data)
+	fmt.Printf("%d picked, %s free. Add new tasks, e.g. \"Write report 45min #work\".\n", len(planned), fmtMinutes(free()))
+	for {
+		text, err := promptWithCursor("New task (empty when done)", "")
+		if err != nil {
+			if err.Error() == "interrupt" || err.Error() == "q" {
+				fmt.Println("Plan not saved.")
+				return nil
+			}
+			return err
+		}
+		if strings.TrimSpace(text) == "" {
+			break
+		}
+		q := parseQuickAdd(text, date)
+		if q.Title == "" {
+			continue
+		}
+		t := Task{Title: q.Title, Estimated: q.Estimated, PlannedAt: q.At, Context: q.Context, Tags: q.Tags, Status: "pending"}
+		if h, ok := findTitleHistory(history, q.Title); ok && !q.HasEstimate {
+			t.Estimated = h.typicalEstimate()
+		}
+		applyTagRules(&t)
+		planned = append(planned, t)
+		fmt.Printf("  + %s, %s free\n", describeTask(t), fmtMinutes(free()))
+	}
+
+	if len(planned) == 0 {
+		fmt.Println("Nothing added to the plan.")
+		return nil
+	}
+	data[day] = append(data[day], planned...)
+	warnIfMeetingsCrowdOut(day, data[day])
+	if save, err := confirmForecast(data, day); err != nil || !save {
+		if err == nil || err.Error() == "interrupt" {
+			fmt.Println("Plan not saved.")
+			return nil
+		}
+		return err
+	}
+	if err := saveTasks(data); err != nil {
+		return err
+	}
+	total := fit.Left - free()
+	fmt.Printf("Added %d tasks to %s: %s planned\n", len(planned), date.Format("Monday Jan 2"), fmtRatio(total, fit.Left))
+	if total > fit.Left {
+		fmt.Printf("That's %s more than the time there is; `daily plan check` suggests what to move.\n", fmtMinutes(total-fit.Left))
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+// pomodoro.go - Pomodoro mode for follow
+// Splits the started task into work/break cycles; only work intervals count towards Actual
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/progress"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type pomodoroModel struct {
+	progress   progress.Model
+	id         string
+	title      string
+	estimated  int
+	workDur    time.Duration
+	breakDur   time.Duration
+	onBreak    bool
+	cycle      int
+	phaseStart time.Time
+	err        error
+}
+
+func (m pomodoroModel) Init() tea.Cmd {
+	return tea.Tick(time.Second, func(_ time.Time) tea.Msg {
+		return tickMsg{}
+	})
+}
+
+func (m pomodoroModel) phaseDuration() time.Duration {
+	if m.onBreak {
+		return m.breakDur
+	}
+	return m.workDur
+}
+
+func (m pomodoroModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if keys.IsQuit(msg) {
+			return m, tea.Quit
+		}
+	case tickMsg:
+		next := tea.Tick(time.Second, func(_ time.Time) tea.Msg {
+			return tickMsg{}
+		})
+		if time.Since(m.phaseStart) < m.phaseDuration() {
+			return m, next
+		}
+		now := time.Now()
+		var notice string
+		if m.onBreak {
+			m.err = updatePomodoroTask(m.id, func(t *Task) { t.setStatus("started", now) })
+			m.cycle++
+			notice = fmt.Sprintf("%sBreak over, starting cycle %d.", bell(), m.cycle)
+		} else {
+			m.err = updatePomodoroTask(m.id, func(t *Task) { t.setStatus("paused", now) })
+			notice = fmt.Sprintf("%sCycle %d done, take a %d min break.", bell(), m.cycle, int(m.breakDur.Minutes()))
+		}
+		if m.err != nil {
+			return m, tea.Quit
+		}
+		m.onBreak = !m.onBreak
+		m.phaseStart = now
+		return m, tea.Batch(tea.Println(notice), next)
+	}
+	return m, nil
+}
+
+func (m pomodoroModel) View() string {
+	elapsed := time.Since(m.phaseStart)
+	remaining := m.phaseDuration() - elapsed
+	if remaining < 0 {
+		remaining = 0
+	}
+	phase := fmt.Sprintf("Cycle %d · work", m.cycle)
+	if m.onBreak {
+		phase = fmt.Sprintf("Cycle %d · break", m.cycle)
+	}
+	return fmt.Sprintf(
+		"%s (est: %dmin)\n%s\n%s\nRemaining: %s\n",
+		m.title,
+		m.estimated,
+		phase,
+		m.progress.ViewAs(elapsed.Seconds()/m.phaseDuration().Seconds()),
+		formatDuration(remaining),
+	)
+}
+
+// updateStartedTask applies fn to today's started task and saves it
+func updateStartedTask(fn func(t *Task)) error {
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	today := todayKey()
+	for i := range data[today] {
+		if data[today][i].Status == "started" {
+			fn(&data[today][i])
+			return saveTasks(data)
+		}
+	}
+	return fmt.Errorf("no task is currently started")
+}
+
+// updatePomodoroTask applies fn to the task of today with this ID and saves
+// it. The task is paused during breaks, so it can't be found by its status.
+func updatePomodoroTask(id string, fn func(t *Task)) error {
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	today := todayKey()
+	for i := range data[today] {
+		if data[today][i].ID == id {
+			fn(&data[today][i])
+			return saveTasks(data)
+		}
+	}
+	return fmt.Errorf("the pomodoro task is no longer on today's list")
+}
+
+// followPomodoro runs the started task in work/break cycles. Each work
+// interval is recorded as its own session and the task is paused during
+// breaks; leaving during a break resumes it so it keeps running as before.
+func followPomodoro(work, rest int) error {
+	if work <= 0 || rest <= 0 {
+		return fmt.Errorf("work and break lengths must be positive")
+	}
+	var task Task
+	now := time.Now()
+	// Close the session so far so that cycles line up with recorded sessions
+	err := updateStartedTask(func(t *Task) {
+		t.endSession(now.Unix())
+		t.startSession(now.Unix())
+		task = *t
+	})
+	if err != nil {
+		return err
+	}
+	m := pomodoroModel{
+		progress: progress.New(
+			progress.WithWidth(50),
+			progress.WithSolidFill(activeTheme().Accent),
+		),
+		id:         task.ID,
+		title:      task.Title,
+		estimated:  task.Estimated,
+		workDur:    time.Duration(work) * time.Minute,
+		breakDur:   time.Duration(rest) * time.Minute,
+		cycle:      1,
+		phaseStart: now,
+	}
+	fmt.Printf("Pomodoro: %d min work / %d min break. Press %s or Ctrl+C to exit\n\n",
+		work, rest, keys.Quit.Help().Key)
+	result, err := tea.NewProgram(m).Run()
+	if err != nil {
+		return err
+	}
+	final := result.(pomodoroModel)
+	if final.err != nil {
+		return final.err
+	}
+	if final.onBreak {
+		return updatePomodoroTask(final.id, func(t *Task) { t.setStatus("started", time.Now()) })
+	}
+	return nil
+}
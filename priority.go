@@ -0,0 +1,85 @@
+// priority.go - Task priorities
+// Parses and orders task priorities: high, medium, low, or P1 (highest) to P9
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	priorityNone   = 0
+	priorityHigh   = 1
+	priorityMedium = 2
+	priorityLow    = 3
+	priorityMax    = 9
+)
+
+// priorityTemplate renders a color-coded priority marker in promptui templates
+const priorityTemplate = `{{ if eq .Priority 1 }}{{ "[high] " | red }}` +
+	`{{ else if eq .Priority 2 }}{{ "[med] " | yellow }}` +
+	`{{ else if eq .Priority 3 }}{{ "[low] " | faint }}` +
+	`{{ else if .Priority }}{{ printf "[P%d] " .Priority | cyan }}{{ end }}`
+
+// parsePriority accepts high/medium/low (or h/m/l), 1-9, or P1-P9. An empty
+// string means no priority.
+func parsePriority(input string) (int, error) {
+	s := strings.ToLower(strings.TrimSpace(input))
+	switch s {
+	case "", "none", "-":
+		return priorityNone, nil
+	case "high", "h":
+		return priorityHigh, nil
+	case "medium", "med", "m":
+		return priorityMedium, nil
+	case "low", "l":
+		return priorityLow, nil
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(s, "p"))
+	if err != nil || n < 1 || n > priorityMax {
+		return 0, fmt.Errorf("invalid priority %q (use high, medium, low, or 1-%d)", input, priorityMax)
+	}
+	return n, nil
+}
+
+// priorityLabel is the inverse of parsePriority
+func priorityLabel(p int) string {
+	switch p {
+	case priorityNone:
+		return ""
+	case priorityHigh:
+		return "high"
+	case priorityMedium:
+		return "medium"
+	case priorityLow:
+		return "low"
+	default:
+		return fmt.Sprintf("P%d", p)
+	}
+}
+
+// priorityRank orders priorities, highest first; tasks without a priority come last
+func priorityRank(p int) int {
+	if p == priorityNone {
+		return priorityMax + 1
+	}
+	return p
+}
+
+// statusOrder groups the list: work in progress first, finished work last
+var statusOrder = map[string]int{"started": 0, "paused": 1, "pending": 2, "done": 3, "cancelled": 4}
+
+// sortByPriority orders task indexes by status, then priority, keeping the
+// original order among equals
+func sortByPriority(tasks []Task, indexes []int) {
+	sort.SliceStable(indexes, func(i, j int) bool {
+		a, b := tasks[indexes[i]], tasks[indexes[j]]
+		if statusOrder[a.Status] != statusOrder[b.Status] {
+			return statusOrder[a.Status] < statusOrder[b.Status]
+		}
+		return priorityRank(a.Priority) < priorityRank(b.Priority)
+	})
+}
@@ -0,0 +1,173 @@
+// projects.go - Projects
+// Cumulative time per project across all days, for `daily project` and the --project filters
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// inProject reports whether a task belongs to project, ignoring case
+func inProject(t Task, project string) bool {
+	return strings.EqualFold(strings.TrimSpace(t.Project), strings.TrimSpace(project))
+}
+
+// filterProject returns the tasks of data belonging to project; an empty
+// project keeps everything
+func filterProject(data TaskData, project string) TaskData {
+	if project == "" {
+		return data
+	}
+	filtered := TaskData{}
+	for day, tasks := range data {
+		for _, t := range tasks {
+			if inProject(t, project) {
+				filtered[day] = append(filtered[day], t)
+			}
+		}
+	}
+	return filtered
+}
+
+// projectTotal sums a project's work between two days
+type projectTotal struct {
+	Project   string
+	Tasks     int
+	Done      int
+	Estimated int
+	Actual    int
+	FirstDay  string
+	LastDay   string
+	Days      map[string]bool
+}
+
+// projectTotals sums work per project between from and to (inclusive, empty
+// for no bound), most time first. Tasks without a project are grouped under
+// noProjectGroup.
+func projectTotals(data TaskData, from, to string, now time.Time) []projectTotal {
+	byProject := map[string]*projectTotal{}
+	for day, tasks := range data {
+		if (from != "" && day < from) || (to != "" && day > to) {
+			continue
+		}
+		for _, t := range tasks {
+			if isNonWork(t) {
+				continue
+			}
+			name := strings.TrimSpace(t.Project)
+			if name == "" {
+				name = noProjectGroup
+			}
+			key := strings.ToLower(name)
+			total, ok := byProject[key]
+			if !ok {
+				total = &projectTotal{Project: name, FirstDay: day, LastDay: day, Days: map[string]bool{}}
+				byProject[key] = total
+			}
+			total.Tasks++
+			if t.Status == "done" {
+				total.Done++
+			}
+			total.Estimated += t.Estimated
+			total.Actual += t.trackedMinutes(now.Unix())
+			total.Days[day] = true
+			if day < total.FirstDay {
+				total.FirstDay = day
+			}
+			if day > total.LastDay {
+				total.LastDay = day
+			}
+		}
+	}
+	totals := make([]projectTotal, 0, len(byProject))
+	for _, total := range byProject {
+		totals = append(totals, *total)
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].Actual != totals[j].Actual {
+			return totals[i].Actual > totals[j].Actual
+		}
+		return totals[i].Project < totals[j].Project
+	})
+	return totals
+}
+
+// projectRange checks optional --from/--to dates
+func projectRange(from, to string) error {
+	for _, d := range []string{from, to} {
+		if d == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			return fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", d)
+		}
+	}
+	return nil
+}
+
+// listProjects prints every project with its cumulative time
+func listProjects(from, to string) error {
+	if err := projectRange(from, to); err != nil {
+		return err
+	}
+	data, err := loadTasksRange(from, to)
+	if err != nil {
+		return err
+	}
+	totals := projectTotals(data, from, to, time.Now())
+	if len(totals) == 0 {
+		fmt.Println("No tasks in this range.")
+		return nil
+	}
+	fmt.Printf("%-24s %6s %5s %10s %10s  %s\n", "PROJECT", "TASKS", "DAYS", "EST", "ACT", "LAST")
+	for _, p := range totals {
+		fmt.Printf("%-24s %6d %5d %10s %10s  %s\n", p.Project, p.Tasks, len(p.Days), fmtMinutes(p.Estimated), fmtMinutes(p.Actual), p.LastDay)
+	}
+	return nil
+}
+
+// showProject prints a project's cumulative time with a monthly breakdown
+func showProject(name, from, to string) error {
+	if err := projectRange(from, to); err != nil {
+		return err
+	}
+	data, err := loadTasksRange(from, to)
+	if err != nil {
+		return err
+	}
+	data = filterProject(data, name)
+	now := time.Now()
+	totals := projectTotals(data, from, to, now)
+	if len(totals) == 0 {
+		return fmt.Errorf("no tasks for project %q in this range", name)
+	}
+	p := totals[0]
+	fmt.Printf("Project %s: %d tasks (%d done) on %d days, %s to %s\n",
+		p.Project, p.Tasks, p.Done, len(p.Days), p.FirstDay, p.LastDay)
+	fmt.Printf("Estimated %s, worked %s (%.0f%% of estimate)\n\n", fmtMinutes(p.Estimated), fmtMinutes(p.Actual), ratioOf(p.Actual, p.Estimated)*100)
+
+	months := map[string]int{}
+	for day, tasks := range data {
+		if (from != "" && day < from) || (to != "" && day > to) {
+			continue
+		}
+		for _, t := range tasks {
+			if !isNonWork(t) {
+				months[day[:7]] += t.trackedMinutes(now.Unix())
+			}
+		}
+	}
+	var keys []string
+	for month := range months {
+		keys = append(keys, month)
+	}
+	sort.Strings(keys)
+	fmt.Println("By month:")
+	for _, month := range keys {
+		fmt.Printf("  %s  %10s\n", month, fmtMinutes(months[month]))
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+// publish.go - Public day pages
+// Renders a day without its private tasks to Markdown or HTML and optionally pushes it to a gist
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// PublishConfig is the `publish` section of config.yaml
+type PublishConfig struct {
+	PrivateTags []string `yaml:"private_tags"`
+	Dir         string   `yaml:"dir"`
+	GistID      string   `yaml:"gist_id"`
+}
+
+// defaultPrivateTags are never published, whatever the config says
+var defaultPrivateTags = []string{"private"}
+
+// isPrivate reports whether a task must be left out of public pages
+func isPrivate(t Task) bool {
+	for _, tag := range append(defaultPrivateTags, config.Publish.PrivateTags...) {
+		if hasTag(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// publicTasks filters out private and non-work entries
+func publicTasks(tasks []Task) []Task {
+	var public []Task
+	for _, t := range tasks {
+		if isPrivate(t) || isNonWork(t) {
+			continue
+		}
+		public = append(public, t)
+	}
+	return public
+}
+
+func statusMark(status string) string {
+	switch status {
+	case "done":
+		return "✅"
+	case "started":
+		return "⏳"
+	case "cancelled":
+		return "❌"
+	}
+	return "⬜"
+}
+
+func renderDayMarkdown(day string, tasks []Task, notes []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", day)
+	if len(tasks) == 0 {
+		b.WriteString("_Nothing planned._\n")
+	}
+	worked, done := 0, 0
+	for _, t := range tasks {
+		fmt.Fprintf(&b, "- %s %s", statusMark(t.Status), t.Title)
+		if t.Actual > 0 {
+			fmt.Fprintf(&b, " (%d min)", t.Actual)
+		}
+		b.WriteString("\n")
+		for _, n := range t.Notes {
+			fmt.Fprintf(&b, "  - %s\n", n.Text)
+		}
+		worked += t.Actual
+		if t.Status == "done" {
+			done++
+		}
+	}
+	if len(tasks) > 0 {
+		fmt.Fprintf(&b, "\n%d/%d tasks done, %d min worked.\n", done, len(tasks), worked)
+	}
+	if len(notes) > 0 {
+		b.WriteString("\n## Notes\n\n")
+		for _, n := range notes {
+			fmt.Fprintf(&b, "- %s\n", n)
+		}
+	}
+	return b.String()
+}
+
+func renderDayHTML(day string, tasks []Task, notes []string) string {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&b, "<title>%s</title>\n", day)
+	b.WriteString("<style>body{font-family:sans-serif;max-width:40em;margin:2em auto}li{margin:.3em 0}.meta{color:#777}</style>\n")
+	b.WriteString("</head>\n<body>\n")
+	fmt.Fprintf(&b, "<h1>%s</h1>\n<ul>\n", day)
+	worked, done := 0, 0
+	for _, t := range tasks {
+		fmt.Fprintf(&b, "<li>%s %s", statusMark(t.Status), html.EscapeString(t.Title))
+		if t.Actual > 0 {
+			fmt.Fprintf(&b, " <span class=\"meta\">(%d min)</span>", t.Actual)
+		}
+		if len(t.Notes) > 0 {
+			b.WriteString("<ul>")
+			for _, n := range t.Notes {
+				fmt.Fprintf(&b, "<li class=\"meta\">%s</li>", html.EscapeString(n.Text))
+			}
+			b.WriteString("</ul>")
+		}
+		b.WriteString("</li>\n")
+		worked += t.Actual
+		if t.Status == "done" {
+			done++
+		}
+	}
+	b.WriteString("</ul>\n")
+	if len(tasks) > 0 {
+		fmt.Fprintf(&b, "<p class=\"meta\">%d/%d tasks done, %d min worked.</p>\n", done, len(tasks), worked)
+	} else {
+		b.WriteString("<p class=\"meta\">Nothing planned.</p>\n")
+	}
+	if len(notes) > 0 {
+		b.WriteString("<h2>Notes</h2>\n<ul>\n")
+		for _, n := range notes {
+			fmt.Fprintf(&b, "<li>%s</li>\n", html.EscapeString(n))
+		}
+		b.WriteString("</ul>\n")
+	}
+	b.WriteString("</body>\n</html>\n")
+	return b.String()
+}
+
+// publishDay renders a day to a file and optionally uploads it as a gist
+func publishDay(day, format, output string, withNotes, gist bool) error {
+	if _, err := time.Parse("2006-01-02", day); err != nil {
+		return fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", day)
+	}
+	data, err := loadTasksRange(day, day)
+	if err != nil {
+		return err
+	}
+	var notes []string
+	if withNotes {
+		noteData, err := loadNotes()
+		if err != nil {
+			return err
+		}
+		notes = visibleNotes(noteData[day])
+	}
+	tasks := publicTasks(data[day])
+	if !withNotes {
+		for i := range tasks {
+			tasks[i].Notes = nil
+		}
+	}
+
+	var content string
+	switch format {
+	case "md", "markdown":
+		format = "md"
+		content = renderDayMarkdown(day, tasks, notes)
+	case "html":
+		content = renderDayHTML(day, tasks, notes)
+	default:
+		return fmt.Errorf("unknown format %q (expected md or html)", format)
+	}
+
+	fileName := fmt.Sprintf("daily-%s.%s", day, format)
+	if output == "" {
+		output = fileName
+		if config.Publish.Dir != "" {
+			output = filepath.Join(expandHome(config.Publish.Dir), fileName)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(output, []byte(content), 0644); err != nil {
+		return err
+	}
+	fmt.Printf("Published %s to %s (%d tasks)\n", day, output, len(tasks))
+
+	if gist {
+		url, err := pushGist(fileName, content, "daily "+day)
+		if err != nil {
+			return fmt.Errorf("pushing gist: %w", err)
+		}
+		fmt.Println("Gist:", url)
+	}
+	return nil
+}
+
+// pushGist creates a gist, or updates publish.gist_id when configured.
+// The GitHub token is read from GITHUB_TOKEN.
+func pushGist(fileName, content, description string) (string, error) {
+	if err := requireOnline("Publishing to a gist"); err != nil {
+		return "", err
+	}
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		return "", fmt.Errorf("GITHUB_TOKEN is not set")
+	}
+	payload := map[string]interface{}{
+		"description": description,
+		"public":      true,
+		"files": map[string]interface{}{
+			fileName: map[string]string{"content": content},
+		},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	method, url := http.MethodPost, "https://api.github.com/gists"
+	if config.Publish.GistID != "" {
+		method, url = http.MethodPatch, url+"/"+config.Publish.GistID
+	}
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("GitHub returned %s", resp.Status)
+	}
+	var result struct {
+		HTMLURL string `json:"html_url"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	return result.HTMLURL, nil
+}
+
+// expandHome expands a leading ~ in a path
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, strings.TrimPrefix(path, "~"))
+		}
+	}
+	return path
+}
@@ -0,0 +1,248 @@
+// quickadd.go - Natural quick-add
+// Reads estimates, day words, #tags and an @context typed along with a task title, in English and the configured locale
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// quickLocale holds the words of one language
+type quickLocale struct {
+	hours    []string
+	minutes  []string
+	today    []string
+	tomorrow []string
+	dayAfter []string
+	weekdays [7][]string // Sunday first, like time.Weekday
+}
+
+var quickLocales = map[string]quickLocale{
+	"en": {
+		hours:    []string{"h", "hr", "hrs", "hour", "hours"},
+		minutes:  []string{"m", "min", "mins", "minute", "minutes"},
+		today:    []string{"today"},
+		tomorrow: []string{"tomorrow", "tmrw"},
+		dayAfter: []string{"day after tomorrow"},
+		weekdays: [7][]string{{"sunday", "sun"}, {"monday", "mon"}, {"tuesday", "tue"}, {"wednesday", "wed"}, {"thursday", "thu"}, {"friday", "fri"}, {"saturday", "sat"}},
+	},
+	"fr": {
+		hours:    []string{"h", "heure", "heures"},
+		minutes:  []string{"min", "mn", "minute", "minutes"},
+		today:    []string{"aujourd'hui", "aujourd’hui", "auj"},
+		tomorrow: []string{"demain"},
+		dayAfter: []string{"après-demain", "apres-demain"},
+		weekdays: [7][]string{{"dimanche"}, {"lundi"}, {"mardi"}, {"mercredi"}, {"jeudi"}, {"vendredi"}, {"samedi"}},
+	},
+	"de": {
+		hours:    []string{"h", "std", "stunde", "stunden"},
+		minutes:  []string{"min", "minute", "minuten"},
+		today:    []string{"heute"},
+		tomorrow: []string{"morgen"},
+		dayAfter: []string{"übermorgen", "uebermorgen"},
+		weekdays: [7][]string{{"sonntag"}, {"montag"}, {"dienstag"}, {"mittwoch"}, {"donnerstag"}, {"freitag"}, {"samstag"}},
+	},
+	"nl": {
+		hours:    []string{"u", "uur", "h"},
+		minutes:  []string{"m", "min", "minuut", "minuten"},
+		today:    []string{"vandaag"},
+		tomorrow: []string{"morgen"},
+		dayAfter: []string{"overmorgen"},
+		weekdays: [7][]string{{"zondag"}, {"maandag"}, {"dinsdag"}, {"woensdag"}, {"donderdag"}, {"vrijdag"}, {"zaterdag"}},
+	},
+	"es": {
+		hours:    []string{"h", "hora", "horas"},
+		minutes:  []string{"min", "minuto", "minutos"},
+		today:    []string{"hoy"},
+		tomorrow: []string{"mañana", "manana"},
+		dayAfter: []string{"pasado mañana", "pasado manana"},
+		weekdays: [7][]string{{"domingo"}, {"lunes"}, {"martes"}, {"miércoles", "miercoles"}, {"jueves"}, {"viernes"}, {"sábado", "sabado"}},
+	},
+}
+
+// checkLocale validates the locale setting
+func checkLocale(locale string) error {
+	if _, ok := quickLocales[locale]; locale != "" && !ok {
+		return fmt.Errorf("unknown locale %q (available: en, fr, de, nl, es)", locale)
+	}
+	return nil
+}
+
+// activeLocale returns the configured locale, else the language of LC_ALL or
+// LANG when it is supported, else English
+func activeLocale() string {
+	if config.Locale != "" {
+		return config.Locale
+	}
+	for _, env := range []string{"LC_ALL", "LANG"} {
+		if lang := strings.ToLower(os.Getenv(env)); len(lang) >= 2 {
+			if _, ok := quickLocales[lang[:2]]; ok {
+				return lang[:2]
+			}
+		}
+	}
+	return "en"
+}
+
+// quickWords merges the English words with those of the active locale
+func quickWords() quickLocale {
+	words := quickLocales["en"]
+	local, ok := quickLocales[activeLocale()]
+	if !ok || activeLocale() == "en" {
+		return words
+	}
+	merged := quickLocale{
+		hours:    append(append([]string(nil), local.hours...), words.hours...),
+		minutes:  append(append([]string(nil), local.minutes...), words.minutes...),
+		today:    append(append([]string(nil), local.today...), words.today...),
+		tomorrow: append(append([]string(nil), local.tomorrow...), words.tomorrow...),
+		dayAfter: append(append([]string(nil), local.dayAfter...), words.dayAfter...),
+	}
+	for i := range merged.weekdays {
+		merged.weekdays[i] = append(append([]string(nil), local.weekdays[i]...), words.weekdays[i]...)
+	}
+	return merged
+}
+
+// durationPattern matches "30min", "1.5h", "1,5 uur", "1h30", "1u30m" and the like
+func durationPattern(words quickLocale) *regexp.Regexp {
+	alt := func(units []string) string {
+		quoted := make([]string, len(units))
+		for i, u := range units {
+			quoted[i] = regexp.QuoteMeta(u)
+		}
+		return strings.Join(quoted, "|")
+	}
+	return regexp.MustCompile(`^(?:(\d+(?:[.,]\d+)?)\s*(?:` + alt(words.hours) + `)(?:\s*(\d{1,2})\s*(?:` + alt(words.minutes) + `)?)?|(\d+)\s*(?:` + alt(words.minutes) + `))$`)
+}
+
+// parseQuickDuration reads a duration phrase into minutes
+func parseQuickDuration(phrase string, pattern *regexp.Regexp) (int, bool) {
+	m := pattern.FindStringSubmatch(phrase)
+	if m == nil {
+		return 0, false
+	}
+	if m[3] != "" {
+		minutes, _ := strconv.Atoi(m[3])
+		return minutes, true
+	}
+	hours, _ := strconv.ParseFloat(strings.Replace(m[1], ",", ".", 1), 64)
+	minutes := int(math.Round(hours * 60))
+	if m[2] != "" {
+		extra, _ := strconv.Atoi(m[2])
+		minutes += extra
+	}
+	return minutes, true
+}
+
+// parseQuickDay reads a day phrase relative to now: today, tomorrow, the day
+// after, a weekday (the next one after today), or YYYY-MM-DD
+func parseQuickDay(phrase string, words quickLocale, now time.Time) (string, bool) {
+	if _, err := time.Parse("2006-01-02", phrase); err == nil {
+		return phrase, true
+	}
+	in := func(list []string) bool {
+		for _, w := range list {
+			if w == phrase {
+				return true
+			}
+		}
+		return false
+	}
+	switch {
+	case in(words.today):
+		return now.Format("2006-01-02"), true
+	case in(words.tomorrow):
+		return now.AddDate(0, 0, 1).Format("2006-01-02"), true
+	case in(words.dayAfter):
+		return now.AddDate(0, 0, 2).Format("2006-01-02"), true
+	}
+	for wd, names := range words.weekdays {
+		if in(names) {
+			ahead := (wd - int(now.Weekday()) + 7) % 7
+			if ahead == 0 {
+				ahead = 7
+			}
+			return now.AddDate(0, 0, ahead).Format("2006-01-02"), true
+		}
+	}
+	return "", false
+}
+
+// quickTask is what quick-add understood
+type quickTask struct {
+	Title       string
+	Estimated   int
+	HasEstimate bool
+	Day         string
+	At          string // planned start, HH:MM
+	Context     string // where it can be done, from @office
+	Tags        []string
+}
+
+// clockPattern matches a start time like "9:30" or "14:00"
+var clockPattern = regexp.MustCompile(`^\d{1,2}:\d{2}$`)
+
+// parseQuickAdd splits free text into title, estimate, day, start time,
+// context and tags. #tags and an @context may appear anywhere; the others are read from the words at the
+// start and end of the text, so a title like "Review tomorrow's plan" stays intact.
+func parseQuickAdd(text string, now time.Time) quickTask {
+	words := quickWords()
+	pattern := durationPattern(words)
+	var q quickTask
+	var tokens []string
+	for _, tok := range strings.Fields(text) {
+		if tag, ok := strings.CutPrefix(tok, "#"); ok && tag != "" {
+			q.Tags = append(q.Tags, tag)
+			continue
+		}
+		if context, ok := strings.CutPrefix(tok, "@"); ok && context != "" && q.Context == "" {
+			q.Context = normalizeContext(context)
+			continue
+		}
+		tokens = append(tokens, tok)
+	}
+	q.Tags = normalizeTags(q.Tags)
+
+	// match tries the phrase of n tokens at the start or end of what is left
+	match := func(phrase string) bool {
+		phrase = strings.ToLower(phrase)
+		if !q.HasEstimate {
+			if minutes, ok := parseQuickDuration(phrase, pattern); ok {
+				q.Estimated, q.HasEstimate = minutes, true
+				return true
+			}
+		}
+		if q.Day == "" {
+			if day, ok := parseQuickDay(phrase, words, now); ok {
+				q.Day = day
+				return true
+			}
+		}
+		if q.At == "" && clockPattern.MatchString(phrase) {
+			if at, err := checkPlannedAt(phrase); err == nil {
+				q.At = at
+				return true
+			}
+		}
+		return false
+	}
+	for progress := true; progress && len(tokens) > 0; {
+		progress = false
+		for n := min(3, len(tokens)); n >= 1 && !progress; n-- {
+			if len(tokens) > n && match(strings.Join(tokens[len(tokens)-n:], " ")) {
+				tokens, progress = tokens[:len(tokens)-n], true
+			} else if len(tokens) > n && match(strings.Join(tokens[:n], " ")) {
+				tokens, progress = tokens[n:], true
+			}
+		}
+	}
+	q.Title = strings.Join(tokens, " ")
+	return q
+}
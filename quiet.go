@@ -0,0 +1,165 @@
+// quiet.go - Quiet hours
+// Holds back notifications and bells during quiet hours, outside work hours, in meetings, and under the OS Do Not Disturb
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// NotificationsConfig is the `notifications` section of config.yaml
+type NotificationsConfig struct {
+	QuietHours       []string `yaml:"quiet_hours"`               // "HH:MM-HH:MM" windows, may wrap past midnight
+	OutsideWorkHours bool     `yaml:"notify_outside_work_hours"` // default false
+	InMeetings       bool     `yaml:"notify_in_meetings"`        // while a #meeting task runs or is planned
+	RespectDND       *bool    `yaml:"respect_dnd"`               // follow the OS Do Not Disturb, default true
+}
+
+// errMuted is returned by sendNotification when a notification was held back
+var errMuted = errors.New("notification muted")
+
+// parseQuietWindow parses "HH:MM-HH:MM" into minutes after midnight
+func parseQuietWindow(window string) (int, int, error) {
+	from, to, ok := strings.Cut(window, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid quiet hours %q (expected HH:MM-HH:MM)", window)
+	}
+	start, err := parseClock(strings.TrimSpace(from))
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := parseClock(strings.TrimSpace(to))
+	if err != nil {
+		return 0, 0, err
+	}
+	return start, end, nil
+}
+
+// validate checks the quiet hours windows
+func (n NotificationsConfig) validate() error {
+	for _, w := range n.QuietHours {
+		if _, _, err := parseQuietWindow(w); err != nil {
+			return fmt.Errorf("notifications.quiet_hours: %w", err)
+		}
+	}
+	return nil
+}
+
+// inQuietHours reports the configured window containing now, if any
+func inQuietHours(now time.Time) (string, bool) {
+	minute := now.Hour()*60 + now.Minute()
+	for _, w := range config.Notifications.QuietHours {
+		start, end, err := parseQuietWindow(w)
+		if err != nil {
+			continue
+		}
+		if start <= end && minute >= start && minute < end ||
+			start > end && (minute >= start || minute < end) {
+			return w, true
+		}
+	}
+	return "", false
+}
+
+// inMeeting reports whether a #meeting task of today is running or planned
+// for the current time
+func inMeeting(now time.Time) (string, bool) {
+	data, err := loadTasks()
+	if err != nil {
+		return "", false
+	}
+	for _, t := range data[dayOf(now).Format("2006-01-02")] {
+		if !hasTag(t, "meeting") {
+			continue
+		}
+		if t.Status == "started" {
+			return t.Title, true
+		}
+		if t.PlannedAt == "" || !isOpenStatus(t.Status) {
+			continue
+		}
+		start := clockOn(now, t.PlannedAt)
+		if !now.Before(start) && now.Before(start.Add(time.Duration(t.Estimated)*time.Minute)) {
+			return t.Title, true
+		}
+	}
+	return "", false
+}
+
+// dndActive reports whether the operating system is in Do Not Disturb mode.
+// This is detected on macOS (Focus) and on Linux desktops using GNOME or
+// dunst; elsewhere it always reports false.
+func dndActive() bool {
+	switch runtime.GOOS {
+	case "darwin":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return false
+		}
+		content, err := os.ReadFile(filepath.Join(home, "Library", "DoNotDisturb", "DB", "Assertions.json"))
+		if err != nil {
+			return false
+		}
+		var assertions struct {
+			Data []struct {
+				Records []json.RawMessage `json:"storeAssertionRecords"`
+			} `json:"data"`
+		}
+		if json.Unmarshal(content, &assertions) != nil {
+			return false
+		}
+		for _, d := range assertions.Data {
+			if len(d.Records) > 0 {
+				return true
+			}
+		}
+	case "linux", "freebsd", "openbsd":
+		if out, err := exec.Command("dunstctl", "is-paused").Output(); err == nil && strings.TrimSpace(string(out)) == "true" {
+			return true
+		}
+		if out, err := exec.Command("gsettings", "get", "org.gnome.desktop.notifications", "show-banners").Output(); err == nil && strings.TrimSpace(string(out)) == "false" {
+			return true
+		}
+	}
+	return false
+}
+
+// quietReason explains why notifications are held back at now, or returns ""
+// when they may be sent
+func quietReason(now time.Time) string {
+	n := config.Notifications
+	if w, ok := inQuietHours(now); ok {
+		return "quiet hours " + w
+	}
+	if !n.OutsideWorkHours {
+		workStart, _, _, workEnd := config.WorkHours.bounds(now)
+		if now.Before(workStart) || !now.Before(workEnd) {
+			return "outside work hours"
+		}
+	}
+	if !n.InMeetings {
+		if title, ok := inMeeting(now); ok {
+			return "in meeting " + title
+		}
+	}
+	if (n.RespectDND == nil || *n.RespectDND) && dndActive() {
+		return "Do Not Disturb"
+	}
+	return ""
+}
+
+// bell returns the terminal bell, or nothing while notifications are quiet
+func bell() string {
+	if quietReason(time.Now()) != "" {
+		return ""
+	}
+	return "\a"
+}
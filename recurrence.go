@@ -0,0 +1,98 @@
+// recurrence.go - Recurrence rules
+// Parses rules like "weekly wed", "biweekly wed" or "every 3 weeks mon" and tells which days they fall on
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// recurrence is a weekly rule, possibly skipping weeks
+type recurrence struct {
+	Weeks   int // 1 for every week, 2 for every other week...
+	Weekday time.Weekday
+	Anchor  time.Time // a day the rule falls on; needed when Weeks > 1
+}
+
+// parseWeekday reads a weekday by its English name or first three letters
+func parseWeekday(s string) (time.Weekday, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if len(s) >= 3 {
+		for i, name := range weekdayNames {
+			if strings.HasPrefix(s, name) {
+				return time.Weekday(i), nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unknown weekday %q", s)
+}
+
+// parseRecurrence reads "weekly <weekday>", "biweekly <weekday>" or
+// "every <n> weeks <weekday>". since is a date the rule falls on, required
+// when weeks are skipped so it is clear which ones.
+func parseRecurrence(rule, since string) (recurrence, error) {
+	fields := strings.Fields(strings.ToLower(rule))
+	r := recurrence{Weeks: 1}
+	switch {
+	case len(fields) == 2 && fields[0] == "weekly":
+	case len(fields) == 2 && (fields[0] == "biweekly" || fields[0] == "fortnightly"):
+		r.Weeks = 2
+	case len(fields) == 4 && fields[0] == "every" && strings.HasPrefix(fields[2], "week"):
+		n, err := strconv.Atoi(fields[1])
+		if err != nil || n < 1 {
+			return r, fmt.Errorf("invalid number of weeks in %q", rule)
+		}
+		r.Weeks = n
+	default:
+		return r, fmt.Errorf("invalid rule %q (expected weekly wed, biweekly wed or every 3 weeks wed)", rule)
+	}
+	weekday, err := parseWeekday(fields[len(fields)-1])
+	if err != nil {
+		return r, err
+	}
+	r.Weekday = weekday
+	if since == "" {
+		if r.Weeks > 1 {
+			return r, fmt.Errorf("%q needs `since`, the date of one occurrence", rule)
+		}
+		return r, nil
+	}
+	anchor, err := time.ParseInLocation("2006-01-02", since, time.Local)
+	if err != nil {
+		return r, fmt.Errorf("invalid since date %q (expected YYYY-MM-DD)", since)
+	}
+	if anchor.Weekday() != weekday {
+		return r, fmt.Errorf("since (%s) is a %s, not a %s", since, anchor.Weekday(), weekday)
+	}
+	r.Anchor = anchor
+	return r, nil
+}
+
+// occursOn reports whether the rule falls on the given day
+func (r recurrence) occursOn(day time.Time) bool {
+	if day.Weekday() != r.Weekday {
+		return false
+	}
+	if r.Weeks <= 1 || r.Anchor.IsZero() {
+		return true
+	}
+	date := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	anchor := time.Date(r.Anchor.Year(), r.Anchor.Month(), r.Anchor.Day(), 0, 0, 0, 0, time.UTC)
+	weeks := int(date.Sub(anchor).Hours()/24) / 7
+	return weeks%r.Weeks == 0
+}
+
+// next returns the first day from `from` on that the rule falls on
+func (r recurrence) next(from time.Time) time.Time {
+	day := dayOf(from)
+	for i := 0; i < 7*r.Weeks; i++ {
+		if r.occursOn(day) {
+			return day
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return day
+}
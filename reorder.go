@@ -0,0 +1,154 @@
+// reorder.go - Interactive reordering
+// `daily reorder` moves today's tasks up and down in a full-screen list
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type reorderModel struct {
+	tasks   []Task
+	order   []int // order[i] is the original index of the task shown at i
+	cursor  int
+	holding bool
+	saved   bool
+	moved   bool
+}
+
+func newReorderModel(tasks []Task) reorderModel {
+	order := make([]int, len(tasks))
+	for i := range order {
+		order[i] = i
+	}
+	return reorderModel{tasks: tasks, order: order}
+}
+
+func (m reorderModel) Init() tea.Cmd {
+	return nil
+}
+
+// move swaps the task under the cursor with its neighbour in direction delta
+func (m *reorderModel) move(delta int) {
+	to := m.cursor + delta
+	if to < 0 || to >= len(m.order) {
+		return
+	}
+	m.order[m.cursor], m.order[to] = m.order[to], m.order[m.cursor]
+	m.cursor = to
+	m.moved = true
+}
+
+func (m reorderModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	k, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch {
+	case k.String() == "esc" || k.String() == "ctrl+c":
+		return m, tea.Quit
+	case keys.IsQuit(k):
+		m.saved = m.moved
+		return m, tea.Quit
+	case k.String() == "shift+up":
+		m.move(-1)
+	case k.String() == "shift+down":
+		m.move(1)
+	case key.Matches(k, keys.Up) || k.Type == tea.KeyUp:
+		if m.holding {
+			m.move(-1)
+		} else if m.cursor > 0 {
+			m.cursor--
+		}
+	case key.Matches(k, keys.Down) || k.Type == tea.KeyDown:
+		if m.holding {
+			m.move(1)
+		} else if m.cursor < len(m.order)-1 {
+			m.cursor++
+		}
+	case key.Matches(k, keys.Select):
+		m.holding = !m.holding
+	}
+	return m, nil
+}
+
+func (m reorderModel) View() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Reorder "+todayKey()) + "\n\n")
+	for i, index := range m.order {
+		t := m.tasks[index]
+		pointer := "  "
+		if i == m.cursor {
+			pointer = "→ "
+			if m.holding {
+				pointer = "⇅ "
+			}
+		}
+		label := ""
+		if p := priorityLabel(t.Priority); p != "" {
+			label = "[" + p + "] "
+		}
+		line := fmt.Sprintf("%s%2d. %s%s (%s, %dmin)", pointer, i+1, label, t.Title, t.Status, t.Estimated)
+		if i == m.cursor && m.holding {
+			line = titleStyle.Render(line)
+		} else if t.Status == "done" || t.Status == "cancelled" {
+			line = dimStyle.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+	help := fmt.Sprintf("%s pick up/drop • %s/%s move • shift+↑/↓ move task • %s save • esc discard",
+		keys.Select.Help().Key, keys.Up.Help().Key, keys.Down.Help().Key, keys.Quit.Help().Key)
+	b.WriteString("\n" + dimStyle.Render(help))
+	return b.String()
+}
+
+// applyOrder rearranges tasks so that position i holds tasks[order[i]]
+func applyOrder(tasks []Task, order []int) []Task {
+	reordered := make([]Task, len(order))
+	for i, index := range order {
+		reordered[i] = tasks[index]
+	}
+	return reordered
+}
+
+// reorderTasks lets the user rearrange today's list, which sets the order
+// `next` and `ls` use among tasks of the same status and priority
+func reorderTasks() error {
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	today := todayKey()
+	if len(data[today]) < 2 {
+		fmt.Println("Nothing to reorder today.")
+		return nil
+	}
+	result, err := tea.NewProgram(newReorderModel(data[today]), tea.WithAltScreen()).Run()
+	if err != nil {
+		return err
+	}
+	m := result.(reorderModel)
+	if !m.saved {
+		fmt.Println("Order unchanged.")
+		return nil
+	}
+
+	// Reload so that time tracked while the list was open is kept
+	data, err = loadTasks()
+	if err != nil {
+		return err
+	}
+	if len(data[today]) != len(m.order) {
+		return fmt.Errorf("today's tasks changed while reordering, try again")
+	}
+	data[today] = applyOrder(data[today], m.order)
+	if err := saveTasks(data); err != nil {
+		return err
+	}
+	fmt.Println("New order saved.")
+	return nil
+}
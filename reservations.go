@@ -0,0 +1,294 @@
+// reservations.go - Reserved blocks
+// Named stretches of the day kept for duties like a support rotation; configured per weekday or added for one day, they take time out of the day's capacity and show in the agenda
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Reservation is a named block of the day. In config.yaml it repeats on its
+// weekdays; in reservations.yaml it holds for one day only.
+type Reservation struct {
+	Name     string   `yaml:"name"`
+	Start    string   `yaml:"start,omitempty"`
+	End      string   `yaml:"end,omitempty"`
+	Weekdays []string `yaml:"weekdays,omitempty"` // mon..sun; default Monday to Friday
+	Off      bool     `yaml:"off,omitempty"`      // for one day: the configured block of this name is off
+}
+
+// ReservationData stores the blocks added or called off per day
+type ReservationData map[string][]Reservation
+
+func getReservationsFilePath() (string, error) {
+	return dataFilePath("reservations.yaml")
+}
+
+func loadReservations() (ReservationData, error) {
+	path, err := getReservationsFilePath()
+	if err != nil {
+		return nil, err
+	}
+	data := ReservationData{}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return data, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = yaml.Unmarshal(content, &data)
+	return data, err
+}
+
+func saveReservations(data ReservationData) error {
+	path, err := getReservationsFilePath()
+	if err != nil {
+		return err
+	}
+	content, err := yaml.Marshal(&data)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, content, 0644)
+}
+
+var weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// check validates the block's times and weekdays
+func (r Reservation) check() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return fmt.Errorf("a reserved block needs a name")
+	}
+	start, err := parseClock(r.Start)
+	if err != nil {
+		return fmt.Errorf("%s: %w", r.Name, err)
+	}
+	end, err := parseClock(r.End)
+	if err != nil {
+		return fmt.Errorf("%s: %w", r.Name, err)
+	}
+	if end <= start {
+		return fmt.Errorf("%s: the block must end after it starts", r.Name)
+	}
+	for _, wd := range r.Weekdays {
+		if !slices.Contains(weekdayNames, strings.ToLower(wd)) {
+			return fmt.Errorf("%s: unknown weekday %q (use mon, tue, wed, thu, fri, sat or sun)", r.Name, wd)
+		}
+	}
+	return nil
+}
+
+// on reports whether a configured block repeats on the weekday of day
+func (r Reservation) on(day time.Time) bool {
+	if len(r.Weekdays) == 0 {
+		return day.Weekday() != time.Saturday && day.Weekday() != time.Sunday
+	}
+	return slices.ContainsFunc(r.Weekdays, func(wd string) bool {
+		return strings.EqualFold(wd, weekdayNames[day.Weekday()])
+	})
+}
+
+// parseClockRange parses "HH:MM-HH:MM"
+func parseClockRange(s string) (start, end string, err error) {
+	from, to, ok := strings.Cut(strings.ReplaceAll(s, "–", "-"), "-")
+	if !ok {
+		return "", "", fmt.Errorf("invalid range %q (expected HH:MM-HH:MM)", s)
+	}
+	if start, err = checkPlannedAt(from); err != nil {
+		return "", "", err
+	}
+	if end, err = checkPlannedAt(to); err != nil {
+		return "", "", err
+	}
+	return start, end, nil
+}
+
+// reservationsOn returns the blocks reserved on a day, by start time: the
+// configured ones for its weekday that aren't called off, and the ones added
+// for that day
+func reservationsOn(day time.Time) []Reservation {
+	data, err := loadReservations()
+	if err != nil {
+		data = ReservationData{}
+	}
+	adhoc := data[day.Format("2006-01-02")]
+	var blocks []Reservation
+	for _, r := range config.Reservations {
+		off := slices.ContainsFunc(adhoc, func(a Reservation) bool { return a.Off && strings.EqualFold(a.Name, r.Name) })
+		if r.on(day) && !off {
+			blocks = append(blocks, r)
+		}
+	}
+	for _, r := range adhoc {
+		if !r.Off {
+			blocks = append(blocks, r)
+		}
+	}
+	sort.SliceStable(blocks, func(i, j int) bool { return blocks[i].Start < blocks[j].Start })
+	return blocks
+}
+
+// overlap returns how much of [start, end) falls within [from, to)
+func overlap(start, end, from, to time.Time) time.Duration {
+	if start.Before(from) {
+		start = from
+	}
+	if end.After(to) {
+		end = to
+	}
+	if !end.After(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+// reservedMinutes is how much working time the day's blocks take from `from`
+// on, lunch excluded
+func reservedMinutes(day time.Time, from time.Time) int {
+	workStart, lunchStart, lunchEnd, workEnd := config.WorkHours.bounds(day)
+	if from.Before(workStart) {
+		from = workStart
+	}
+	var total time.Duration
+	for _, r := range reservationsOn(day) {
+		start, end := clockOn(day, r.Start), clockOn(day, r.End)
+		total += overlap(start, end, from, workEnd)
+		total -= overlap(start, end, maxTime(from, lunchStart), lunchEnd)
+	}
+	return int(total.Minutes())
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// dayCapacity is the daily maximum less the day's reserved blocks
+func dayCapacity(day time.Time) int {
+	return max(0, config.WorkHours.MaxDailyMinutes-reservedMinutes(day, day))
+}
+
+// describeReservations lists a day's blocks for the ls header, empty for none
+func describeReservations(day time.Time) string {
+	var parts []string
+	for _, r := range reservationsOn(day) {
+		parts = append(parts, fmt.Sprintf("%s %s-%s", r.Name, r.Start, r.End))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Reserved: %s (%s off the day's capacity)", strings.Join(parts, ", "), fmtMinutes(reservedMinutes(day, day)))
+}
+
+// showReservations prints the blocks of a day and where each comes from
+func showReservations(day string) error {
+	date, err := time.ParseInLocation("2006-01-02", day, time.Local)
+	if err != nil {
+		return fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", day)
+	}
+	data, err := loadReservations()
+	if err != nil {
+		return err
+	}
+	blocks := reservationsOn(date)
+	if len(blocks) == 0 {
+		fmt.Printf("Nothing reserved on %s.\n", day)
+	}
+	for _, r := range blocks {
+		origin := "this day only"
+		if slices.ContainsFunc(config.Reservations, func(c Reservation) bool { return strings.EqualFold(c.Name, r.Name) && c.on(date) }) &&
+			!slices.ContainsFunc(data[day], func(a Reservation) bool { return strings.EqualFold(a.Name, r.Name) }) {
+			origin = "every " + weekdayList(r)
+		}
+		fmt.Printf("  %s-%s  %-30s %8s  %s\n", r.Start, r.End, r.Name, fmtMinutes(int(clockOn(date, r.End).Sub(clockOn(date, r.Start)).Minutes())), origin)
+	}
+	for _, a := range data[day] {
+		if a.Off {
+			fmt.Printf("  %s is off on %s.\n", a.Name, day)
+		}
+	}
+	if len(blocks) > 0 {
+		fmt.Printf("Capacity: %s of %s.\n", fmtMinutes(dayCapacity(date)), fmtMinutes(config.WorkHours.MaxDailyMinutes))
+	}
+	return nil
+}
+
+func weekdayList(r Reservation) string {
+	if len(r.Weekdays) == 0 {
+		return "weekday"
+	}
+	return strings.Join(r.Weekdays, ", ")
+}
+
+// reserveBlock reserves a block on one day
+func reserveBlock(day, name, span string) error {
+	date, err := time.ParseInLocation("2006-01-02", day, time.Local)
+	if err != nil {
+		return fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", day)
+	}
+	start, end, err := parseClockRange(span)
+	if err != nil {
+		return err
+	}
+	r := Reservation{Name: strings.TrimSpace(name), Start: start, End: end}
+	if err := r.check(); err != nil {
+		return err
+	}
+	data, err := loadReservations()
+	if err != nil {
+		return err
+	}
+	data[day] = slices.DeleteFunc(data[day], func(a Reservation) bool { return strings.EqualFold(a.Name, r.Name) })
+	data[day] = append(data[day], r)
+	if err := saveReservations(data); err != nil {
+		return err
+	}
+	fmt.Printf("Reserved %s-%s for %s on %s; capacity is now %s.\n", start, end, r.Name, day, fmtMinutes(dayCapacity(date)))
+	return nil
+}
+
+// unreserveBlock removes a block from one day. A block added for that day is
+// deleted; a configured one is called off for that day.
+func unreserveBlock(day, name string) error {
+	date, err := time.ParseInLocation("2006-01-02", day, time.Local)
+	if err != nil {
+		return fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", day)
+	}
+	data, err := loadReservations()
+	if err != nil {
+		return err
+	}
+	same := func(r Reservation) bool { return strings.EqualFold(r.Name, strings.TrimSpace(name)) }
+	if slices.ContainsFunc(data[day], func(r Reservation) bool { return same(r) && r.Off }) {
+		return fmt.Errorf("%s is already off on %s", name, day)
+	}
+	before := len(data[day])
+	data[day] = slices.DeleteFunc(data[day], same)
+	removed := len(data[day]) < before
+	if slices.ContainsFunc(config.Reservations, func(r Reservation) bool { return same(r) && r.on(date) }) {
+		data[day] = append(data[day], Reservation{Name: strings.TrimSpace(name), Off: true})
+		removed = true
+	}
+	if !removed {
+		return fmt.Errorf("nothing named %q is reserved on %s", name, day)
+	}
+	if len(data[day]) == 0 {
+		delete(data, day)
+	}
+	if err := saveReservations(data); err != nil {
+		return err
+	}
+	fmt.Printf("Freed %s on %s.\n", name, day)
+	return nil
+}
@@ -0,0 +1,148 @@
+// review.go - End-of-day review
+// `daily review` walks through the day's tasks: close or carry over what is still open, fill in the time of what was done, write a short retrospective, then close the day
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/manifoldco/promptui"
+)
+
+// retroTag marks the retrospective note of a review, for `note search '#retro'`
+const retroTag = "#retro"
+
+// reviewChoices are offered for each open task, in this order
+var reviewChoices = []string{"Done", "Carry over to the next workday", "Cancel it"}
+
+// promptActual asks how long a done task took, defaulting to its estimate
+func promptActual(t Task) (int, error) {
+	input, err := validatedPrompt("How long did it take (e.g. 45 or 1.5h)", strconv.Itoa(t.Estimated), func(s string) error {
+		_, err := parseEstimate(strings.TrimSpace(s))
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return parseEstimate(input)
+}
+
+// reviewTasks asks about each task of the day that needs an answer. It
+// reports false when the review was stopped, and nothing should be saved.
+func reviewTasks(tasks []Task, now time.Time) (bool, error) {
+	asked := 0
+	for i := range tasks {
+		t := &tasks[i]
+		if isNonWork(*t) || t.Status == "cancelled" {
+			continue
+		}
+		open := isOpenStatus(t.Status)
+		if !open && (t.Actual > 0 || t.Estimated == 0) {
+			continue
+		}
+		asked++
+		fmt.Printf("\n%s  %s  est: %s, act: %s\n", t.Title, t.Status, fmtMinutes(t.Estimated), fmtMinutes(t.trackedMinutes(now.Unix())))
+		if open {
+			prompt := promptui.Select{Label: "What about it", Items: reviewChoices, HideHelp: true}
+			choice, _, err := prompt.Run()
+			if err != nil {
+				return false, ignoreInterrupt(err)
+			}
+			switch choice {
+			case 0:
+				t.setStatus("done", now)
+			case 1:
+				// Left open: closing the day stops it and carries it over
+				continue
+			case 2:
+				t.setStatus("cancelled", now)
+				continue
+			}
+		}
+		// Done with no time recorded
+		if t.Actual == 0 && t.Estimated > 0 {
+			minutes, err := promptActual(*t)
+			if err != nil {
+				return false, ignoreInterrupt(err)
+			}
+			t.Actual = minutes
+		}
+	}
+	if asked == 0 {
+		fmt.Println("Every task is settled.")
+	}
+	return true, nil
+}
+
+// ignoreInterrupt turns a prompt left with ctrl+c into a plain stop
+func ignoreInterrupt(err error) error {
+	if errors.Is(err, promptui.ErrInterrupt) || errors.Is(err, promptui.ErrEOF) {
+		return nil
+	}
+	return err
+}
+
+// runReview reviews a day's tasks, records a retrospective note and closes
+// the day
+func runReview(day string) error {
+	if _, err := time.Parse("2006-01-02", day); err != nil {
+		return fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", day)
+	}
+	if !isTerminal() {
+		return fmt.Errorf("review needs a terminal; use `daily close` to close the day as it is")
+	}
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	metrics, err := loadMetrics()
+	if err != nil {
+		return err
+	}
+	if _, closed := metrics[day]; closed {
+		fmt.Printf("%s is already closed: tasks left open won't be carried over again.\n", day)
+	}
+	tasks := data[day]
+	if len(tasks) == 0 {
+		fmt.Printf("No tasks for %s.\n", day)
+	} else {
+		date, _ := time.Parse("2006-01-02", day)
+		fmt.Printf("Review of %s\n", date.Format("Monday Jan 2"))
+		finished, err := reviewTasks(tasks, time.Now())
+		if err != nil {
+			return err
+		}
+		if !finished {
+			fmt.Println("Review stopped, nothing saved.")
+			return nil
+		}
+		data[day] = tasks
+		if err := saveTasks(data); err != nil {
+			return err
+		}
+	}
+
+	fmt.Println()
+	retro, err := promptWithCursor("How did the day go? (a line for the retrospective, empty to skip)", "")
+	if err != nil {
+		if err.Error() == "q" || ignoreInterrupt(err) == nil {
+			fmt.Println("Day not closed; run `daily close` when you're done.")
+			return nil
+		}
+		return err
+	}
+	if retro = strings.TrimSpace(retro); retro != "" {
+		if !strings.Contains(strings.ToLower(retro), retroTag) {
+			retro += " " + retroTag
+		}
+		if err := addDayNote(day, retro); err != nil {
+			return err
+		}
+	}
+	fmt.Println()
+	return closeDay(day)
+}
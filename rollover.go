@@ -0,0 +1,167 @@
+// rollover.go - Day rollover
+// Lets long-running views notice that the day changed and decide what happens to a task still running from the day before
+
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// RolloverConfig is the `rollover` section of config.yaml
+type RolloverConfig struct {
+	Started string `yaml:"started"`  // carry (default), stop, or keep
+	SplitAt string `yaml:"split_at"` // work_end (default) or boundary
+}
+
+// validate checks the rollover policy
+func (r RolloverConfig) validate() error {
+	switch r.Started {
+	case "", "stop", "carry", "keep":
+	default:
+		return fmt.Errorf("rollover.started: unknown policy %q (expected carry, stop or keep)", r.Started)
+	}
+	switch r.SplitAt {
+	case "", "boundary", "work_end":
+		return nil
+	}
+	return fmt.Errorf("rollover.split_at: unknown value %q (expected boundary or work_end)", r.SplitAt)
+}
+
+// dayStart returns the moment the given day begins, at work_hours.day_boundary
+func dayStart(day string) time.Time {
+	date, _ := time.ParseInLocation("2006-01-02", day, time.Local)
+	return date.Add(dayBoundary())
+}
+
+// splitTimes returns when the clock of a task started at since stops on
+// oldDay, and when it runs again on newDay: at the end and the start of work
+// hours, or with split_at: boundary at the day boundary
+func splitTimes(oldDay, newDay string, since int64, now time.Time) (stop, resume time.Time) {
+	stop, resume = dayStart(dayAfter(oldDay)), dayStart(newDay)
+	if config.Rollover.SplitAt != "boundary" {
+		if _, _, _, workEnd := config.WorkHours.bounds(dayStart(oldDay)); since < workEnd.Unix() {
+			stop = workEnd
+		}
+		if workStart, _, _, _ := config.WorkHours.bounds(dayStart(newDay)); workStart.After(resume) {
+			resume = workStart
+		}
+	}
+	// Time is never counted ahead, nor before the session started
+	if resume.After(now) {
+		resume = now
+	}
+	if stop.Unix() < since {
+		stop = time.Unix(since, 0)
+	}
+	return stop, resume
+}
+
+// rolloverStartedTask applies the rollover policy to a task still running on
+// oldDay once newDay has begun. The clock stops when oldDay ends (see
+// splitTimes), or at the idle limit unless the session was confirmed to
+// count in full, then:
+//
+//	carry  the rest of the task continues running on newDay
+//	stop   the task goes back to pending
+//	keep   nothing changes; the task keeps running on oldDay
+//
+// A stale rollover, on the first command of a day, resumes the task from
+// now: nothing says the night was spent on it. It returns a description of
+// what it did, or "" when there was nothing to do.
+func rolloverStartedTask(oldDay, newDay string, stale bool) (string, error) {
+	policy := config.Rollover.Started
+	if policy == "keep" || oldDay == newDay {
+		return "", nil
+	}
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	data, err := loadTasks()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	for i := range data[oldDay] {
+		t := &data[oldDay][i]
+		if t.Status != "started" {
+			continue
+		}
+		since := t.runningSince()
+		stop, resume := splitTimes(oldDay, newDay, since, now)
+		if limit := config.Idle.limit(*t); config.Idle.threshold() > 0 && !idleAcknowledged(since) {
+			if capped := time.Unix(since, 0).Add(limit); stop.After(capped) {
+				stop = capped
+			}
+		}
+		if stale {
+			resume = now
+		}
+		before := t.Actual
+		t.endSession(stop.Unix())
+		t.FinishBy = 0
+		t.logChange("status", t.Status, "pending", stop)
+		t.Status = "pending"
+		message := fmt.Sprintf("Stopped '%s' at the end of %s (%s tracked).", t.Title, oldDay, fmtMinutes(t.Actual))
+		if policy != "stop" {
+			carried := carryOver(*t)
+			// A task that overran isn't finished either: it keeps what was
+			// left of its estimate when the session started, or all of it
+			if carried.Estimated == 0 && t.Estimated > 0 {
+				carried.Estimated = t.Estimated - before
+				if carried.Estimated <= 0 {
+					carried.Estimated = t.Estimated
+				}
+			}
+			carried.Status = "started"
+			carried.startSession(resume.Unix())
+			data[newDay] = append(data[newDay], carried)
+			message = fmt.Sprintf("'%s' ran past the end of %s: %s counted there, and it keeps running on %s.", t.Title, oldDay, fmtMinutes(t.Actual), newDay)
+		}
+		return message, saveTasks(data)
+	}
+	return "", nil
+}
+
+// rolloverStaleStarts applies the rollover policy to a task left running on
+// a day that is over, so the first command of a new day doesn't count the
+// night on the old one
+func rolloverStaleStarts() (string, error) {
+	data, err := loadTasks()
+	if err != nil {
+		return "", err
+	}
+	today := todayKey()
+	for _, day := range sortedDays(data) {
+		if day >= today {
+			break
+		}
+		for _, t := range data[day] {
+			if t.Status == "started" {
+				return rolloverStartedTask(day, today, true)
+			}
+		}
+	}
+	return "", nil
+}
+
+// dayWatcher remembers the day a long-running view shows and notices when it
+// changes
+type dayWatcher struct {
+	day string
+}
+
+func newDayWatcher() dayWatcher {
+	return dayWatcher{day: todayKey()}
+}
+
+// check reports whether the day changed since the last call, applying the
+// rollover policy to the previous day when it did
+func (w *dayWatcher) check() (changed bool, message string, err error) {
+	today := todayKey()
+	if today == w.day {
+		return false, "", nil
+	}
+	message, err = rolloverStartedTask(w.day, today, false)
+	w.day = today
+	return true, message, err
+}
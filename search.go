@@ -0,0 +1,98 @@
+// search.go - Search
+// `daily search` finds tasks and notes across all days
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// searchHit is one matching task or note
+type searchHit struct {
+	kind string // "task" or "note"
+	text string
+}
+
+// newMatcher returns a case-insensitive matcher for a plain substring or,
+// with useRegex, a regular expression
+func newMatcher(query string, useRegex bool) (func(string) bool, error) {
+	if !useRegex {
+		query = regexp.QuoteMeta(query)
+	}
+	re, err := regexp.Compile("(?i)" + query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex: %w", err)
+	}
+	return re.MatchString, nil
+}
+
+// searchDays collects matches per day. Task titles, projects, tags and resume
+// notes are searched; encrypted notes are skipped.
+func searchDays(tasks TaskData, notes NoteData, match func(string) bool) map[string][]searchHit {
+	hits := map[string][]searchHit{}
+	for day, list := range tasks {
+		for _, t := range list {
+			fields := append([]string{t.Title, t.Project, t.ResumeNote, t.IssueKey}, t.Tags...)
+			for _, f := range fields {
+				if f != "" && match(f) {
+					text := fmt.Sprintf("%s [%s, %d/%d min]", t.Title, t.Status, t.Actual, t.Estimated)
+					hits[day] = append(hits[day], searchHit{"task", text})
+					break
+				}
+			}
+		}
+	}
+	for day, list := range notes {
+		if isSealed(list) {
+			continue
+		}
+		for _, note := range list {
+			if match(note) {
+				hits[day] = append(hits[day], searchHit{"note", note})
+			}
+		}
+	}
+	return hits
+}
+
+// searchAll prints the matches of query grouped by day, newest first
+func searchAll(query string, useRegex bool) error {
+	if strings.TrimSpace(query) == "" {
+		return fmt.Errorf("empty query")
+	}
+	match, err := newMatcher(query, useRegex)
+	if err != nil {
+		return err
+	}
+	tasks, err := loadTasksRange("", "")
+	if err != nil {
+		return err
+	}
+	notes, err := loadNotes()
+	if err != nil {
+		return err
+	}
+	hits := searchDays(tasks, notes, match)
+	if len(hits) == 0 {
+		fmt.Printf("No tasks or notes match %q.\n", query)
+		return nil
+	}
+	days := make([]string, 0, len(hits))
+	total := 0
+	for day, list := range hits {
+		days = append(days, day)
+		total += len(list)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(days)))
+	for _, day := range days {
+		fmt.Println(day)
+		for _, h := range hits[day] {
+			fmt.Printf("  %-4s  %s\n", h.kind, h.text)
+		}
+	}
+	fmt.Printf("\n%d match(es) on %d day(s)\n", total, len(days))
+	return nil
+}
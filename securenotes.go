@@ -0,0 +1,237 @@
+// securenotes.go - Encrypted notes
+// Notes of days marked sensitive are sealed with a passphrase (scrypt + AES-GCM)
+
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/manifoldco/promptui"
+	"golang.org/x/crypto/scrypt"
+	"gopkg.in/yaml.v3"
+)
+
+// sealedPrefix marks a day whose notes are stored as a single encrypted entry
+const sealedPrefix = "enc:v1:"
+
+// sealedPlaceholder stands in for encrypted notes in views that don't prompt
+const sealedPlaceholder = "🔒 encrypted notes (daily note unlock to read)"
+
+var errWrongPassphrase = errors.New("wrong passphrase or damaged notes")
+
+// isSealed reports whether a day's notes are encrypted
+func isSealed(notes []string) bool {
+	return len(notes) == 1 && strings.HasPrefix(notes[0], sealedPrefix)
+}
+
+// visibleNotes replaces encrypted notes with a placeholder, for views such as
+// the dashboard, journal, and publishing that never ask for the passphrase
+func visibleNotes(notes []string) []string {
+	if isSealed(notes) {
+		return []string{sealedPlaceholder}
+	}
+	return notes
+}
+
+func notesKey(passphrase string, salt []byte) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+}
+
+// sealNotes encrypts a day's notes into one entry
+func sealNotes(notes []string, passphrase string) (string, error) {
+	plain, err := json.Marshal(notes)
+	if err != nil {
+		return "", err
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key, err := notesKey(passphrase, salt)
+	if err != nil {
+		return "", err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	payload := append(append(salt, nonce...), gcm.Seal(nil, nonce, plain, nil)...)
+	return sealedPrefix + base64.StdEncoding.EncodeToString(payload), nil
+}
+
+// openSealed decrypts an entry written by sealNotes
+func openSealed(entry, passphrase string) ([]string, error) {
+	payload, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(entry, sealedPrefix))
+	if err != nil || len(payload) < 16 {
+		return nil, errWrongPassphrase
+	}
+	key, err := notesKey(passphrase, payload[:16])
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	rest := payload[16:]
+	if len(rest) < gcm.NonceSize() {
+		return nil, errWrongPassphrase
+	}
+	plain, err := gcm.Open(nil, rest[:gcm.NonceSize()], rest[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, errWrongPassphrase
+	}
+	var notes []string
+	if err := json.Unmarshal(plain, &notes); err != nil {
+		return nil, errWrongPassphrase
+	}
+	return notes, nil
+}
+
+// notesPassphrase reads DAILY_NOTES_PASSPHRASE, or prompts for the
+// passphrase (twice when confirm is set, for a new one)
+func notesPassphrase(label string, confirm bool) (string, error) {
+	if pass := os.Getenv("DAILY_NOTES_PASSPHRASE"); pass != "" {
+		return pass, nil
+	}
+	prompt := promptui.Prompt{Label: label, Mask: '*', Validate: func(input string) error {
+		if input == "" {
+			return fmt.Errorf("the passphrase can't be empty")
+		}
+		return nil
+	}}
+	pass, err := prompt.Run()
+	if err != nil || !confirm {
+		return pass, err
+	}
+	again := promptui.Prompt{Label: "Repeat passphrase", Mask: '*'}
+	repeat, err := again.Run()
+	if err != nil {
+		return "", err
+	}
+	if repeat != pass {
+		return "", fmt.Errorf("passphrases don't match")
+	}
+	return pass, nil
+}
+
+// readDayNotes returns a day's notes, asking for the passphrase when they are
+// encrypted. The passphrase is returned so that writeDayNotes can re-seal them.
+func readDayNotes(data NoteData, day string) (notes []string, passphrase string, err error) {
+	if !isSealed(data[day]) {
+		return data[day], "", nil
+	}
+	passphrase, err = notesPassphrase(fmt.Sprintf("Passphrase for the notes of %s", day), false)
+	if err != nil {
+		return nil, "", err
+	}
+	notes, err = openSealed(data[day][0], passphrase)
+	return notes, passphrase, err
+}
+
+// writeDayNotes stores a day's notes, encrypted when passphrase is set
+func writeDayNotes(data NoteData, day string, notes []string, passphrase string) error {
+	if passphrase == "" || len(notes) == 0 {
+		data[day] = notes
+		return nil
+	}
+	sealed, err := sealNotes(notes, passphrase)
+	if err != nil {
+		return err
+	}
+	data[day] = []string{sealed}
+	return nil
+}
+
+// lockNotes marks a day as sensitive and encrypts its notes
+func lockNotes(day string) error {
+	data, err := loadNotes()
+	if err != nil {
+		return err
+	}
+	if isSealed(data[day]) {
+		fmt.Printf("Notes for %s are already encrypted.\n", day)
+		return nil
+	}
+	if len(data[day]) == 0 {
+		return fmt.Errorf("no notes for %s", day)
+	}
+	passphrase, err := notesPassphrase("New passphrase", true)
+	if err != nil {
+		return err
+	}
+	if err := writeDayNotes(data, day, data[day], passphrase); err != nil {
+		return err
+	}
+	if err := saveNotes(data); err != nil {
+		return err
+	}
+	if err := forgetNotes(day); err != nil {
+		return fmt.Errorf("removing the notes of %s from the undo log: %w", day, err)
+	}
+	if err := sealBackupNotes(day, passphrase); err != nil {
+		return fmt.Errorf("encrypting the notes of %s in the backups: %w", day, err)
+	}
+	fmt.Printf("Notes for %s encrypted. Keep the passphrase safe: it can't be recovered.\n", day)
+	return nil
+}
+
+// sealBackupNotes encrypts a day's notes in the backups of notes.yaml too, so
+// that a locked day can't be read from an older copy
+func sealBackupNotes(day, passphrase string) error {
+	return rewriteBackups("notes.yaml", func(content []byte) ([]byte, error) {
+		var data NoteData
+		if err := yaml.Unmarshal(content, &data); err != nil {
+			return nil, err
+		}
+		if len(data[day]) == 0 || isSealed(data[day]) {
+			return content, nil
+		}
+		if err := writeDayNotes(data, day, data[day], passphrase); err != nil {
+			return nil, err
+		}
+		return yaml.Marshal(&data)
+	})
+}
+
+// unlockNotes decrypts a day's notes and stores them in clear again
+func unlockNotes(day string) error {
+	data, err := loadNotes()
+	if err != nil {
+		return err
+	}
+	if !isSealed(data[day]) {
+		fmt.Printf("Notes for %s are not encrypted.\n", day)
+		return nil
+	}
+	notes, _, err := readDayNotes(data, day)
+	if err != nil {
+		return err
+	}
+	data[day] = notes
+	if err := saveNotes(data); err != nil {
+		return err
+	}
+	fmt.Printf("Notes for %s decrypted.\n", day)
+	return nil
+}
@@ -0,0 +1,255 @@
+// server.go - HTTP server mode
+// `daily serve` hosts Prometheus metrics, the webhook intake used by automation services and phone shortcuts, the REST API and the dashboard
+
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ServeConfig is the `serve` section of config.yaml
+type ServeConfig struct {
+	Host         string `yaml:"host"` // address to listen on, default 127.0.0.1; 0.0.0.0 opens the server to the network
+	Port         int    `yaml:"port"`
+	WebhookToken string `yaml:"webhook_token"`
+	APIToken     string `yaml:"api_token"`
+}
+
+// storeMu serializes read-modify-write cycles on the data files between requests
+var storeMu sync.Mutex
+
+// maxBodyBytes caps the size of a request body, so that a client can't make
+// the server read without end
+const maxBodyBytes = 1 << 20
+
+// webhookToken returns the shared secret for inbound webhooks; the
+// environment wins over the config file
+func webhookToken() string {
+	if token := os.Getenv("DAILY_WEBHOOK_TOKEN"); token != "" {
+		return token
+	}
+	return config.Serve.WebhookToken
+}
+
+// authorized checks the bearer token, falling back to a ?token= query
+// parameter for services that cannot set headers
+func authorized(r *http.Request, token string) bool {
+	given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if given == "" {
+		given = r.URL.Query().Get("token")
+	}
+	return given != "" && subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1
+}
+
+// webhookPayload is accepted as JSON or as form fields
+type webhookPayload struct {
+	Title    string `json:"title"`
+	Estimate string `json:"estimate"`
+	Tags     string `json:"tags"`
+	Text     string `json:"text"`
+}
+
+func readWebhookPayload(r *http.Request) (webhookPayload, error) {
+	var p webhookPayload
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		err := json.NewDecoder(r.Body).Decode(&p)
+		return p, err
+	}
+	if err := r.ParseForm(); err != nil {
+		return p, err
+	}
+	p.Title = r.FormValue("title")
+	p.Estimate = r.FormValue("estimate")
+	p.Tags = r.FormValue("tags")
+	p.Text = r.FormValue("text")
+	return p, nil
+}
+
+// addInboxTask adds a task captured from outside, tagged #inbox for triage
+func addInboxTask(title, estimate string, tags []string) (Task, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return Task{}, fmt.Errorf("title is required")
+	}
+	estimated, err := parseEstimate(estimate)
+	if err != nil {
+		return Task{}, err
+	}
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	data, err := loadTasks()
+	if err != nil {
+		return Task{}, err
+	}
+	today := todayKey()
+	task := Task{
+		Title:     title,
+		Estimated: estimated,
+		Status:    "pending",
+		Tags:      normalizeTags(append([]string{"inbox"}, tags...)),
+	}
+	applyTagRules(&task)
+	data[today] = append(data[today], task)
+	if err := saveTasks(data); err != nil {
+		return Task{}, err
+	}
+	serverEvents.notify()
+	return task, nil
+}
+
+// errNotesLocked refuses a note captured from outside for a day whose notes
+// are encrypted: adding it would need the passphrase, and nobody is there to
+// type it
+var errNotesLocked = errors.New("notes locked")
+
+// addInboxNote appends a note captured from outside to today's notes
+func addInboxNote(text string) error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return fmt.Errorf("text is required")
+	}
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	data, err := loadNotes()
+	if err != nil {
+		return err
+	}
+	today := todayKey()
+	if isSealed(data[today]) {
+		return fmt.Errorf("%w: the notes of %s are encrypted", errNotesLocked, today)
+	}
+	data[today] = append(data[today], text)
+	if err := saveNotes(data); err != nil {
+		return err
+	}
+	serverEvents.notify()
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// registerWebhooks adds the inbound endpoints:
+//
+//	POST /webhook/task  title, estimate, tags
+//	POST /webhook/note  text
+func registerWebhooks(mux *http.ServeMux, token string) {
+	guard := func(h func(http.ResponseWriter, *http.Request, webhookPayload)) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				w.Header().Set("Allow", http.MethodPost)
+				writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("use POST"))
+				return
+			}
+			if !authorized(r, token) {
+				writeError(w, http.StatusUnauthorized, fmt.Errorf("invalid or missing token"))
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+			p, err := readWebhookPayload(r)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+			h(w, r, p)
+		}
+	}
+	mux.HandleFunc("/webhook/task", guard(func(w http.ResponseWriter, r *http.Request, p webhookPayload) {
+		task, err := addInboxTask(p.Title, p.Estimate, strings.Split(p.Tags, ","))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, task)
+	}))
+	mux.HandleFunc("/webhook/note", guard(func(w http.ResponseWriter, r *http.Request, p webhookPayload) {
+		if err := addInboxNote(p.Text); err != nil {
+			status := http.StatusBadRequest
+			if errors.Is(err, errNotesLocked) {
+				status = http.StatusLocked
+			}
+			writeError(w, status, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, map[string]string{"note": strings.TrimSpace(p.Text)})
+	}))
+}
+
+// runServer starts `daily serve` with the requested modes
+func runServer(host string, port int, metrics, webhook, ui, api bool) error {
+	if host == "" {
+		host = config.Serve.Host
+	}
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	if port == 0 {
+		port = config.Serve.Port
+	}
+	if port == 0 {
+		port = 8080
+	}
+	if !metrics && !webhook && !ui && !api {
+		return fmt.Errorf("nothing to serve: use --metrics, --webhook, --api or --ui")
+	}
+	mux := http.NewServeMux()
+	if metrics {
+		mux.HandleFunc("/metrics", metricsHandler)
+		fmt.Println("Prometheus metrics: GET /metrics")
+	}
+	if webhook {
+		if err := requireOnline("Webhook intake"); err != nil {
+			return err
+		}
+		token := webhookToken()
+		if token == "" {
+			return fmt.Errorf("set serve.webhook_token or DAILY_WEBHOOK_TOKEN before enabling --webhook")
+		}
+		registerWebhooks(mux, token)
+		fmt.Println("Webhook intake: POST /webhook/task, POST /webhook/note")
+	}
+	if api {
+		if err := requireOnline("The REST API"); err != nil {
+			return err
+		}
+		token := apiToken()
+		if token == "" {
+			return fmt.Errorf("set serve.api_token or DAILY_API_TOKEN before enabling --api")
+		}
+		registerAPI(mux, token)
+		fmt.Println("REST API: /api/tasks, /api/tasks/{id}, /api/tasks/{id}/start, /api/stop, /api/notes")
+	}
+	if ui {
+		token := apiToken()
+		if token == "" {
+			return fmt.Errorf("set serve.api_token or DAILY_API_TOKEN before enabling --ui")
+		}
+		registerDashboard(mux, token)
+		fmt.Println("Dashboard: GET /?token=<api token> (read-only API under /api/)")
+		if ip := net.ParseIP(host); ip != nil && ip.IsUnspecified() {
+			for _, url := range lanURLs(port) {
+				fmt.Println("  on your network:", url)
+			}
+		}
+	}
+
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	fmt.Printf("Serving on http://%s. Press Ctrl+C to stop.\n", addr)
+	return http.ListenAndServe(addr, mux)
+}
@@ -0,0 +1,319 @@
+// shellinput.go - Shell line editor
+// Reads shell lines with Tab completion of commands, flags and dates, arrow-key history, and Ctrl+R search
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// shellHistoryLimit is how many lines the shell history keeps
+const shellHistoryLimit = 500
+
+// errShellExit is returned when the user leaves the shell with Ctrl+D
+var errShellExit = errors.New("exit")
+
+var shellBuiltins = []string{"clear", "exit", "help", "quit"}
+
+func getShellHistoryPath() (string, error) {
+	return dataFilePath("shell_history")
+}
+
+// loadShellHistory returns the saved shell lines, oldest first
+func loadShellHistory() []string {
+	path, err := getShellHistoryPath()
+	if err != nil {
+		return nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// saveShellHistory writes the last shellHistoryLimit lines
+func saveShellHistory(lines []string) error {
+	if len(lines) > shellHistoryLimit {
+		lines = lines[len(lines)-shellHistoryLimit:]
+	}
+	path, err := getShellHistoryPath()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600)
+}
+
+// shellCompleter knows the words that can follow what has been typed
+type shellCompleter struct {
+	root *cobra.Command
+	days []string
+}
+
+func newShellCompleter() shellCompleter {
+	c := shellCompleter{root: setupCommands()}
+	c.root.InitDefaultHelpCmd()
+	now := dayOf(time.Now())
+	seen := map[string]bool{}
+	for _, d := range []time.Time{now, now.AddDate(0, 0, -1), now.AddDate(0, 0, 1)} {
+		day := d.Format("2006-01-02")
+		seen[day] = true
+		c.days = append(c.days, day)
+	}
+	if data, err := loadTasks(); err == nil {
+		var days []string
+		for day := range data {
+			if !seen[day] {
+				days = append(days, day)
+			}
+		}
+		sort.Sort(sort.Reverse(sort.StringSlice(days)))
+		c.days = append(c.days, days...)
+	}
+	return c
+}
+
+// candidates returns the words that may follow words: commands first, then
+// the subcommands and flags of the command typed so far, and dates
+func (c shellCompleter) candidates(words []string) []string {
+	if len(words) == 0 {
+		var names []string
+		for _, cmd := range shellCommands() {
+			names = append(names, cmd.Name())
+		}
+		return append(names, shellBuiltins...)
+	}
+	if words[0] == "help" {
+		return c.candidates(words[1:])
+	}
+	cmd, _, err := c.root.Find(words)
+	if err != nil || cmd == c.root {
+		return nil
+	}
+	var names []string
+	for _, sub := range cmd.Commands() {
+		if sub.IsAvailableCommand() {
+			names = append(names, sub.Name())
+		}
+	}
+	names = append(names, cmd.ValidArgs...)
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if !f.Hidden {
+			names = append(names, "--"+f.Name)
+		}
+	})
+	return append(names, c.days...)
+}
+
+// suggestions returns the full lines completing the last word of line
+func (c shellCompleter) suggestions(line string) []string {
+	cut := strings.LastIndex(line, " ") + 1
+	prefix, partial := line[:cut], line[cut:]
+	var lines []string
+	for _, word := range c.candidates(strings.Fields(prefix)) {
+		if strings.HasPrefix(word, partial) {
+			lines = append(lines, prefix+word+" ")
+		}
+	}
+	return lines
+}
+
+// shellInputModel edits one shell line
+type shellInputModel struct {
+	input     textinput.Model
+	completer shellCompleter
+	history   []string
+	// position in history while browsing with the arrows, len(history) when not
+	histIndex int
+	draft     string
+	// Ctrl+R search state
+	searching bool
+	query     string
+	match     int
+	listing   []string
+	done      bool
+	err       error
+}
+
+func newShellInput(history []string, completer shellCompleter) shellInputModel {
+	input := textinput.New()
+	input.Prompt = "> "
+	input.ShowSuggestions = true
+	input.KeyMap.NextSuggestion = key.NewBinding(key.WithKeys("ctrl+n"))
+	input.KeyMap.PrevSuggestion = key.NewBinding(key.WithKeys("ctrl+p"))
+	input.CompletionStyle = lipgloss.NewStyle().Faint(true)
+	input.Focus()
+	return shellInputModel{input: input, completer: completer, history: history, histIndex: len(history), match: -1}
+}
+
+func (m shellInputModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+// searchHistory finds the newest history line before `before` containing query
+func (m shellInputModel) searchHistory(query string, before int) int {
+	for i := min(before, len(m.history)) - 1; i >= 0; i-- {
+		if strings.Contains(m.history[i], query) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m shellInputModel) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlR:
+		if i := m.searchHistory(m.query, m.match); i >= 0 {
+			m.match = i
+		}
+	case tea.KeyBackspace:
+		if m.query != "" {
+			m.query = m.query[:len(m.query)-1]
+			m.match = m.searchHistory(m.query, len(m.history))
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		m.query += string(msg.Runes)
+		if msg.Type == tea.KeySpace {
+			m.query += " "
+		}
+		m.match = m.searchHistory(m.query, len(m.history))
+	case tea.KeyEsc, tea.KeyCtrlG, tea.KeyCtrlC:
+		m.searching = false
+	default:
+		// Any other key accepts the match and is handled as usual
+		m.searching = false
+		if m.match >= 0 {
+			m.input.SetValue(m.history[m.match])
+			m.input.CursorEnd()
+		}
+		return m.Update(msg)
+	}
+	return m, nil
+}
+
+func (m shellInputModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if ok && m.searching {
+		return m.updateSearch(keyMsg)
+	}
+	if ok {
+		m.listing = nil
+		switch keyMsg.Type {
+		case tea.KeyEnter:
+			m.done = true
+			return m, tea.Quit
+		case tea.KeyCtrlC:
+			if m.input.Value() == "" {
+				m.err = errors.New("interrupt")
+				m.done = true
+				return m, tea.Quit
+			}
+			m.input.SetValue("")
+			return m, nil
+		case tea.KeyCtrlD:
+			if m.input.Value() == "" {
+				m.err = errShellExit
+				m.done = true
+				return m, tea.Quit
+			}
+		case tea.KeyCtrlR:
+			m.searching, m.query, m.match = true, "", -1
+			return m, nil
+		case tea.KeyUp, tea.KeyDown:
+			if m.histIndex == len(m.history) {
+				m.draft = m.input.Value()
+			}
+			if keyMsg.Type == tea.KeyUp {
+				m.histIndex = max(0, m.histIndex-1)
+			} else {
+				m.histIndex = min(len(m.history), m.histIndex+1)
+			}
+			if m.histIndex == len(m.history) {
+				m.input.SetValue(m.draft)
+			} else if len(m.history) > 0 {
+				m.input.SetValue(m.history[m.histIndex])
+			}
+			m.input.CursorEnd()
+			return m, nil
+		case tea.KeyTab:
+			// Several completions and no common prefix: list them, like a shell
+			if options := m.completer.suggestions(m.input.Value()); len(options) > 1 {
+				common := options[0]
+				for _, o := range options[1:] {
+					for !strings.HasPrefix(o, common) {
+						common = common[:len(common)-1]
+					}
+				}
+				if len(common) > len(m.input.Value()) {
+					m.input.SetValue(strings.TrimSuffix(common, " "))
+					m.input.CursorEnd()
+				} else {
+					cut := strings.LastIndex(m.input.Value(), " ") + 1
+					for _, o := range options {
+						m.listing = append(m.listing, strings.TrimSpace(o[cut:]))
+					}
+				}
+				m.input.SetSuggestions(m.completer.suggestions(m.input.Value()))
+				return m, nil
+			}
+		}
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	if ok {
+		m.input.SetSuggestions(m.completer.suggestions(m.input.Value()))
+	}
+	return m, cmd
+}
+
+func (m shellInputModel) View() string {
+	if m.done {
+		return "> " + m.input.Value() + "\n"
+	}
+	if m.searching {
+		found := ""
+		if m.match >= 0 {
+			found = m.history[m.match]
+		}
+		return fmt.Sprintf("(reverse-i-search)`%s': %s", m.query, found)
+	}
+	view := m.input.View()
+	if len(m.listing) > 0 {
+		view += "\n" + strings.Join(m.listing, "  ")
+	}
+	return view
+}
+
+// readShellLine reads one line with completion and history
+func readShellLine(history []string, completer shellCompleter) (string, error) {
+	final, err := tea.NewProgram(newShellInput(history, completer)).Run()
+	if err != nil {
+		return "", err
+	}
+	m := final.(shellInputModel)
+	if m.err != nil {
+		return "", m.err
+	}
+	return strings.TrimSpace(m.input.Value()), nil
+}
@@ -0,0 +1,167 @@
+// snapshot.go - Morning plan snapshots
+// Keeps each day's plan as it stood when work started, and `daily diff` compares it with the outcome
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PlanSnapshot is a day's task list when the first task was started
+type PlanSnapshot struct {
+	TakenAt int64  `yaml:"taken_at"`
+	Tasks   []Task `yaml:"tasks"`
+}
+
+// SnapshotData stores the snapshot per day
+type SnapshotData map[string]PlanSnapshot
+
+func getSnapshotsFilePath() (string, error) {
+	return dataFilePath("plans.yaml")
+}
+
+func loadSnapshots() (SnapshotData, error) {
+	path, err := getSnapshotsFilePath()
+	if err != nil {
+		return nil, err
+	}
+	data := SnapshotData{}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return data, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = yaml.Unmarshal(content, &data)
+	return data, err
+}
+
+func saveSnapshots(data SnapshotData) error {
+	path, err := getSnapshotsFilePath()
+	if err != nil {
+		return err
+	}
+	content, err := yaml.Marshal(&data)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, content, 0644)
+}
+
+// hasStartedWork reports whether any task of the list has been worked on
+func hasStartedWork(tasks []Task) bool {
+	for _, t := range tasks {
+		if len(t.Sessions) > 0 && !isNonWork(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// snapshotPlan records a day's plan the first time work starts on it. before
+// is the list prior to the save, so the snapshot shows the plan just as the
+// first task was picked up.
+func snapshotPlan(day string, before, after []Task) error {
+	if hasStartedWork(before) || !hasStartedWork(after) {
+		return nil
+	}
+	snapshots, err := loadSnapshots()
+	if err != nil {
+		return err
+	}
+	if _, ok := snapshots[day]; ok {
+		return nil
+	}
+	snapshots[day] = PlanSnapshot{TakenAt: time.Now().Unix(), Tasks: before}
+	return saveSnapshots(snapshots)
+}
+
+// planChange is one difference between the morning plan and the outcome
+type planChange struct {
+	kind   string // added, dropped, estimate
+	title  string
+	detail string
+}
+
+// diffPlan compares a snapshot with the current list. Tasks are matched by
+// title, ignoring case; non-work entries are left out.
+func diffPlan(planned, actual []Task) (changes []planChange, plannedMin, actualMin int) {
+	key := func(t Task) string { return strings.ToLower(strings.TrimSpace(t.Title)) }
+	before := map[string]Task{}
+	for _, t := range planned {
+		if isNonWork(t) {
+			continue
+		}
+		before[key(t)] = t
+		plannedMin += t.Estimated
+	}
+	seen := map[string]bool{}
+	for _, t := range actual {
+		if isNonWork(t) {
+			continue
+		}
+		seen[key(t)] = true
+		old, existed := before[key(t)]
+		switch {
+		case !existed:
+			changes = append(changes, planChange{"added", t.Title, fmt.Sprintf("%s, %s", fmtMinutes(t.Estimated), t.Status)})
+		case t.Status == "cancelled" && old.Status != "cancelled":
+			changes = append(changes, planChange{"dropped", t.Title, "cancelled"})
+			continue
+		case t.Estimated != old.Estimated:
+			changes = append(changes, planChange{"estimate", t.Title, fmt.Sprintf("%s → %s", fmtMinutes(old.Estimated), fmtMinutes(t.Estimated))})
+		}
+		if t.Status != "cancelled" {
+			actualMin += t.Estimated
+		}
+	}
+	for _, t := range planned {
+		if !isNonWork(t) && !seen[key(t)] {
+			changes = append(changes, planChange{"dropped", t.Title, "removed"})
+		}
+	}
+	return changes, plannedMin, actualMin
+}
+
+// showPlanDiff prints how a day's plan changed after work started
+func showPlanDiff(day string) error {
+	if _, err := time.Parse("2006-01-02", day); err != nil {
+		return fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", day)
+	}
+	snapshots, err := loadSnapshots()
+	if err != nil {
+		return err
+	}
+	snapshot, ok := snapshots[day]
+	if !ok {
+		return fmt.Errorf("no plan snapshot for %s; one is taken when the day's first task is started", day)
+	}
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	changes, plannedMin, actualMin := diffPlan(snapshot.Tasks, data[day])
+	fmt.Printf("Plan of %s at %s vs now\n\n", day, time.Unix(snapshot.TakenAt, 0).Format("15:04"))
+	if len(changes) == 0 {
+		fmt.Println("No changes: the day went as planned.")
+		return nil
+	}
+	labels := map[string]string{"added": "+ Added", "dropped": "- Dropped", "estimate": "~ Re-estimated"}
+	for _, kind := range []string{"added", "dropped", "estimate"} {
+		for _, c := range changes {
+			if c.kind == kind {
+				fmt.Printf("%-15s %s (%s)\n", labels[kind], c.title, c.detail)
+			}
+		}
+	}
+	growth := ratioOf(actualMin-plannedMin, plannedMin) * 100
+	fmt.Printf("\nScope: %s planned → %s now (%+.0f%%)\n", fmtMinutes(plannedMin), fmtMinutes(actualMin), growth)
+	return nil
+}
@@ -0,0 +1,206 @@
+// standup.go - Standup post
+// `daily standup` turns the last working day's done tasks, today's plan and the blockers noted in the notes into a standup message, and posts it to a Slack or Teams webhook
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// StandupConfig is the `standup` section of config.yaml
+type StandupConfig struct {
+	SlackWebhook string `yaml:"slack_webhook"` // incoming webhook URL
+	TeamsWebhook string `yaml:"teams_webhook"` // incoming webhook URL
+}
+
+// blockerPrefixes mark a note as a blocker, e.g. "blocked: waiting on the API keys"
+var blockerPrefixes = []string{"blocker:", "blocked:", "blocker -", "blocked -"}
+
+// standupReport is what goes into the message
+type standupReport struct {
+	Since    string // the last earlier day with tasks
+	Done     []Task
+	Today    []Task
+	Blockers []string
+}
+
+// blockerNote returns the text of a note that reports a blocker
+func blockerNote(note string) (string, bool) {
+	lower := strings.ToLower(strings.TrimSpace(note))
+	for _, prefix := range blockerPrefixes {
+		if strings.HasPrefix(lower, prefix) {
+			return strings.TrimSpace(strings.TrimSpace(note)[len(prefix):]), true
+		}
+	}
+	return "", false
+}
+
+// buildStandup gathers the report for `today`. The previous day is the last
+// one with tasks, so a Monday standup covers Friday.
+func buildStandup(today string) (standupReport, error) {
+	report := standupReport{Since: dayOf(time.Now()).AddDate(0, 0, -1).Format("2006-01-02")}
+	data, err := loadTasks()
+	if err != nil {
+		return report, err
+	}
+	for _, day := range sortedDays(data) {
+		if day < today && len(publicTasks(data[day])) > 0 {
+			report.Since = day
+		}
+	}
+	for _, t := range publicTasks(data[report.Since]) {
+		if t.Status == "done" {
+			report.Done = append(report.Done, t)
+		}
+	}
+	for _, t := range publicTasks(data[today]) {
+		if t.Status != "cancelled" {
+			report.Today = append(report.Today, t)
+		}
+	}
+	for _, day := range []string{report.Since, today} {
+		for _, t := range publicTasks(data[day]) {
+			if t.Status == "done" || t.Status == "cancelled" {
+				continue
+			}
+			for _, n := range t.Notes {
+				blocker, ok := blockerNote(n.Text)
+				if line := t.Title + ": " + blocker; ok && blocker != "" && !slices.Contains(report.Blockers, line) {
+					report.Blockers = append(report.Blockers, line)
+				}
+			}
+		}
+	}
+	notes, err := loadNotes()
+	if err != nil {
+		return report, err
+	}
+	for _, day := range []string{report.Since, today} {
+		if isSealed(notes[day]) {
+			continue
+		}
+		for _, n := range notes[day] {
+			if text, ok := blockerNote(n); ok && text != "" {
+				report.Blockers = append(report.Blockers, text)
+			}
+		}
+	}
+	return report, nil
+}
+
+// renderStandup writes the report as a message. Slack bolds with *single*
+// stars; Teams and the terminal get Markdown.
+func renderStandup(r standupReport, target string) string {
+	heading, bullet := "**%s**\n", "- "
+	if target == "slack" {
+		heading, bullet = "*%s*\n", "• "
+	}
+	var b strings.Builder
+	section := func(title string, lines []string, empty string) {
+		fmt.Fprintf(&b, heading, title)
+		if len(lines) == 0 {
+			lines = []string{empty}
+		}
+		for _, line := range lines {
+			b.WriteString(bullet + line + "\n")
+		}
+		b.WriteString("\n")
+	}
+	since := r.Since
+	if r.Since == yesterdayKey() {
+		since = "Yesterday"
+	} else if date, err := time.Parse("2006-01-02", r.Since); err == nil {
+		since = date.Format("Monday, Jan 2")
+	}
+	var done, today []string
+	for _, t := range r.Done {
+		done = append(done, t.Title)
+	}
+	for _, t := range r.Today {
+		line := t.Title
+		switch {
+		case t.Status == "done":
+			line += " (done)"
+		case t.Status == "started":
+			line += " (in progress)"
+		case t.Estimated > 0:
+			line += " (" + fmtMinutes(t.Estimated) + ")"
+		}
+		today = append(today, line)
+	}
+	section(since, done, "nothing finished")
+	section("Today", today, "nothing planned yet")
+	section("Blockers", r.Blockers, "none")
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+// standupWebhook returns the configured webhook of a target, empty when
+// none is set
+func standupWebhook(target string) (url, key string, err error) {
+	switch target {
+	case "slack":
+		return config.Standup.SlackWebhook, "standup.slack_webhook", nil
+	case "teams":
+		return config.Standup.TeamsWebhook, "standup.teams_webhook", nil
+	}
+	return "", "", fmt.Errorf("unknown target %q (expected slack or teams)", target)
+}
+
+// postStandup sends a message to an incoming webhook
+func postStandup(url, message string) error {
+	if err := requireOnline("Posting the standup"); err != nil {
+		return err
+	}
+	body, err := json.Marshal(map[string]string{"text": message})
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("the webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// runStandup prints the standup message, or posts it to `target`
+func runStandup(target string, dryRun bool) error {
+	target = strings.ToLower(target)
+	var url, key string
+	if target != "" {
+		var err error
+		if url, key, err = standupWebhook(target); err != nil {
+			return err
+		}
+		if url == "" && !dryRun {
+			return fmt.Errorf("set %s in config.yaml to post there", key)
+		}
+	}
+	report, err := buildStandup(todayKey())
+	if err != nil {
+		return err
+	}
+	message := renderStandup(report, target)
+	if target == "" || dryRun {
+		fmt.Print(message)
+		if dryRun && target != "" {
+			fmt.Printf("\n(dry run: not posted to %s)\n", target)
+		}
+		return nil
+	}
+	if err := postStandup(url, message); err != nil {
+		return err
+	}
+	fmt.Printf("Posted the standup to %s.\n", target)
+	return nil
+}
@@ -0,0 +1,147 @@
+// stats.go - Estimate accuracy statistics
+// `daily stats` compares estimates with tracked time overall, per tag and per weekday
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// minStatsSamples is how many finished tasks a tag or weekday needs to be shown
+const minStatsSamples = 3
+
+// accuracy collects the estimate/actual pairs of one group
+type accuracy struct {
+	Name      string
+	Estimated int
+	Actual    int
+	Ratios    []float64
+	Over      int
+}
+
+func (a *accuracy) add(t Task) {
+	a.Estimated += t.Estimated
+	a.Actual += t.Actual
+	ratio := float64(t.Actual) / float64(t.Estimated)
+	a.Ratios = append(a.Ratios, ratio)
+	if t.Actual > t.Estimated {
+		a.Over++
+	}
+}
+
+// median is the typical actual/estimate ratio, robust to the odd task that ran
+// for a whole day
+func (a accuracy) median() float64 {
+	if len(a.Ratios) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), a.Ratios...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// estimateStats groups the finished tasks since `since` (empty for all)
+func estimateStats(data TaskData, since string) (overall accuracy, tags, weekdays []accuracy) {
+	overall.Name = "All tasks"
+	byTag := map[string]*accuracy{}
+	byWeekday := make([]accuracy, 7)
+	for i := range byWeekday {
+		byWeekday[i].Name = time.Weekday(i).String()
+	}
+	for day, tasks := range data {
+		if since != "" && day < since {
+			continue
+		}
+		date, err := time.Parse("2006-01-02", day)
+		if err != nil {
+			continue
+		}
+		for _, t := range tasks {
+			if t.Status != "done" || t.Estimated <= 0 || t.Actual <= 0 || isNonWork(t) {
+				continue
+			}
+			overall.add(t)
+			byWeekday[date.Weekday()].add(t)
+			for _, tag := range t.Tags {
+				if byTag[tag] == nil {
+					byTag[tag] = &accuracy{Name: "#" + tag}
+				}
+				byTag[tag].add(t)
+			}
+		}
+	}
+	for _, a := range byTag {
+		if len(a.Ratios) >= minStatsSamples {
+			tags = append(tags, *a)
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool { return len(tags[i].Ratios) > len(tags[j].Ratios) })
+	// Monday first
+	for i := 1; i <= 7; i++ {
+		if a := byWeekday[i%7]; len(a.Ratios) >= minStatsSamples {
+			weekdays = append(weekdays, a)
+		}
+	}
+	return overall, tags, weekdays
+}
+
+func printAccuracy(a accuracy) {
+	fmt.Printf("  %-16s %5d %10s %10s %6.2f× %5.0f%%\n", a.Name, len(a.Ratios),
+		fmtMinutes(a.Estimated), fmtMinutes(a.Actual), a.median(), ratioOf(a.Over, len(a.Ratios))*100)
+}
+
+// showStats prints how estimates compare with tracked time over the last
+// `days` days (0 for all history)
+func showStats(days int) error {
+	if days < 0 {
+		return fmt.Errorf("--days must not be negative")
+	}
+	since := ""
+	if days > 0 {
+		since = dayOf(time.Now()).AddDate(0, 0, -days+1).Format("2006-01-02")
+	}
+	data, err := loadTasksRange(since, "")
+	if err != nil {
+		return err
+	}
+	overall, tags, weekdays := estimateStats(data, since)
+	if len(overall.Ratios) < minStatsSamples {
+		fmt.Println("Not enough finished tasks with tracked time yet; come back after a few days of tracking.")
+		return nil
+	}
+
+	fmt.Printf("  %-16s %5s %10s %10s %7s %6s\n", "", "TASKS", "EST", "ACT", "TYPICAL", "OVER")
+	printAccuracy(overall)
+	if len(tags) > 0 {
+		fmt.Println("\nBy tag:")
+		for _, a := range tags {
+			printAccuracy(a)
+		}
+	}
+	if len(weekdays) > 0 {
+		fmt.Println("\nBy weekday:")
+		for _, a := range weekdays {
+			printAccuracy(a)
+		}
+	}
+
+	factor := overall.median()
+	fmt.Println()
+	switch {
+	case factor >= 1.1:
+		fmt.Printf("You typically take %.1f× your estimate: multiply estimates by %.1f, or plan %s of a full day.\n",
+			factor, factor, fmtMinutes(int(float64(config.WorkHours.MaxDailyMinutes)/factor)))
+	case factor <= 0.9:
+		fmt.Printf("You typically need only %.1f× your estimate: your plans have room to spare.\n", factor)
+	default:
+		fmt.Println("Your estimates are typically within 10% of the time tracked.")
+	}
+	fmt.Printf("(TYPICAL is the median of actual/estimate per task; groups need %d tasks to be shown.)\n", minStatsSamples)
+	return nil
+}
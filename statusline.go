@@ -0,0 +1,107 @@
+// statusline.go - Status bar line
+// `daily statusline` prints one compact line about the running task and the day, for tmux status-right, polybar or i3blocks to poll
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// defaultStatusFormat is the line printed without --format
+const defaultStatusFormat = `{{if .Title}}{{.Title}} {{.Clock}}{{else}}idle{{end}} · {{.Day}}`
+
+// statusLine is what a --format template can use. Clock and Day carry the
+// color codes of --color; the other fields are plain.
+type statusLine struct {
+	Title      string // the running task, shortened to --max-title
+	Status     string // started, or empty when nothing runs
+	Elapsed    string // time tracked on the running task
+	Estimate   string // its estimate, empty for none
+	Clock      string // elapsed/estimate, colored by how much of the estimate is used
+	Worked     string // time worked today
+	Capacity   string // the day's capacity
+	DayPercent int    // worked time as a percentage of capacity
+	Day        string // DayPercent with a percent sign, colored by progress
+}
+
+// statusColors wraps text in the color codes of a status bar
+var statusColors = map[string]func(hex, text string) string{
+	"none": func(hex, text string) string { return text },
+	"ansi": func(hex, text string) string {
+		var r, g, b int
+		fmt.Sscanf(strings.TrimPrefix(hex, "#"), "%02x%02x%02x", &r, &g, &b)
+		return fmt.Sprintf("\033[38;2;%d;%d;%dm%s\033[0m", r, g, b, text)
+	},
+	"tmux":    func(hex, text string) string { return "#[fg=" + hex + "]" + text + "#[default]" },
+	"polybar": func(hex, text string) string { return "%{F" + hex + "}" + text + "%{F-}" },
+}
+
+// shorten cuts a title to max characters, ending it with an ellipsis
+func shorten(title string, max int) string {
+	runes := []rune(title)
+	if max <= 0 || len(runes) <= max {
+		return title
+	}
+	if max == 1 {
+		return "…"
+	}
+	return string(runes[:max-1]) + "…"
+}
+
+// buildStatusLine gathers the fields of the line from today's tasks
+func buildStatusLine(tasks []Task, now time.Time, color func(hex, text string) string, maxTitle int) statusLine {
+	var line statusLine
+	worked := 0
+	for _, t := range tasks {
+		tracked := t.trackedMinutes(now.Unix())
+		if !isNonWork(t) {
+			worked += tracked
+		}
+		if t.Status != "started" {
+			continue
+		}
+		line.Title = shorten(t.Title, maxTitle)
+		line.Status = t.Status
+		line.Elapsed = fmtMinutes(tracked)
+		line.Clock = line.Elapsed
+		if t.Estimated > 0 {
+			line.Estimate = fmtMinutes(t.Estimated)
+			line.Clock = color(gradientColor(ratioOf(tracked, t.Estimated), true), fmtRatio(tracked, t.Estimated))
+		}
+	}
+	capacity := dayCapacity(dayOf(now))
+	line.Worked = fmtMinutes(worked)
+	line.Capacity = fmtMinutes(capacity)
+	line.DayPercent = int(ratioOf(worked, capacity) * 100)
+	line.Day = color(gradientColor(ratioOf(worked, capacity), false), strconv.Itoa(line.DayPercent)+"%")
+	return line
+}
+
+// printStatusLine prints the line from a --format template
+func printStatusLine(format, colorMode string, maxTitle int) error {
+	color, ok := statusColors[colorMode]
+	if !ok {
+		return fmt.Errorf("unknown --color %q (expected none, ansi, tmux, or polybar)", colorMode)
+	}
+	if format == "" {
+		format = defaultStatusFormat
+	}
+	tmpl, err := template.New("statusline").Parse(format)
+	if err != nil {
+		return fmt.Errorf("invalid --format: %w", err)
+	}
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, buildStatusLine(data[todayKey()], time.Now(), color, maxTitle)); err != nil {
+		return fmt.Errorf("invalid --format: %w", err)
+	}
+	fmt.Println(strings.ReplaceAll(out.String(), "\n", " "))
+	return nil
+}
@@ -0,0 +1,122 @@
+// storage.go - Data file locations
+// Resolves the data directory (XDG_DATA_HOME, %APPDATA% on Windows) and moves
+// files left next to the executable by older versions
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// dataFiles are the files kept in the data directory
+var dataFiles = []string{"tasks.yaml", "notes.yaml", "metrics.yaml", "billing.yaml", "daily.db", "undo.yaml", "plans.yaml"}
+
+// getDataDir returns the directory holding the YAML data files. The
+// data_dir setting wins over the platform defaults.
+func getDataDir() (string, error) {
+	if config.DataDir != "" {
+		return expandHome(config.DataDir), nil
+	}
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "daily"), nil
+	}
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv("APPDATA"); dir != "" {
+			return filepath.Join(dir, "daily"), nil
+		}
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", "daily"), nil
+}
+
+// dataFilePath returns the path of a data file, creating the data directory if needed
+func dataFilePath(name string) (string, error) {
+	dir, err := getDataDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// legacyDataDir is where versions before the data directory stored their files
+func legacyDataDir() (string, error) {
+	exePath, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Dir(exePath), nil
+}
+
+// migrateLegacyData moves data files from the executable's directory into the
+// data directory. Files already present in the data directory are left alone.
+func migrateLegacyData() error {
+	legacyDir, err := legacyDataDir()
+	if err != nil {
+		return err
+	}
+	dataDir, err := getDataDir()
+	if err != nil {
+		return err
+	}
+	if filepath.Clean(legacyDir) == filepath.Clean(dataDir) {
+		return nil
+	}
+	for _, name := range dataFiles {
+		oldPath := filepath.Join(legacyDir, name)
+		if _, err := os.Stat(oldPath); err != nil {
+			continue
+		}
+		newPath, err := dataFilePath(name)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(newPath); err == nil {
+			continue
+		}
+		if err := moveFile(oldPath, newPath); err != nil {
+			return fmt.Errorf("migrating %s: %w", name, err)
+		}
+		fmt.Printf("Moved %s to %s\n", oldPath, newPath)
+	}
+	return nil
+}
+
+// moveFile renames a file, falling back to copy and delete across filesystems
+func moveFile(from, to string) error {
+	if err := os.Rename(from, to); err == nil {
+		return nil
+	}
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(to)
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	src.Close()
+	if err := os.Remove(from); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+// store.go - Storage backends
+// Tasks and notes are kept in YAML files by default, or in a SQLite database
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// StorageConfig is the `storage` section of config.yaml
+type StorageConfig struct {
+	Backend string `yaml:"backend"` // yaml (default) or sqlite
+	Path    string `yaml:"path"`    // SQLite database, default <data dir>/daily.db
+	Backups *int   `yaml:"backups"` // backups kept of tasks.yaml and notes.yaml, default 5
+}
+
+// TaskStore loads and saves all tasks, keyed by day
+type TaskStore interface {
+	LoadTasks() (TaskData, error)
+	SaveTasks(data TaskData) error
+}
+
+// NoteStore loads and saves all notes, keyed by day
+type NoteStore interface {
+	LoadNotes() (NoteData, error)
+	SaveNotes(data NoteData) error
+}
+
+// Store is a complete storage backend
+type Store interface {
+	TaskStore
+	NoteStore
+}
+
+// activeStore is opened on first use and kept for the life of the process
+var activeStore Store
+
+// openStore returns the backend selected by storage.backend
+func openStore() (Store, error) {
+	if activeStore != nil {
+		return activeStore, nil
+	}
+	var err error
+	switch config.Storage.Backend {
+	case "", "yaml":
+		activeStore = yamlStore{}
+	case "sqlite":
+		activeStore, err = openSQLiteStore(config.Storage.Path)
+	default:
+		err = fmt.Errorf("unknown storage.backend %q (expected yaml or sqlite)", config.Storage.Backend)
+	}
+	return activeStore, err
+}
+
+// yamlStore keeps tasks.yaml and notes.yaml in the data directory
+type yamlStore struct{}
+
+// warnCombinedOnce keeps the note about repeated days to one per process,
+// however many times the tasks are loaded
+var warnCombinedOnce sync.Once
+
+func (yamlStore) LoadTasks() (TaskData, error) {
+	filePath, err := dataFilePath("tasks.yaml")
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.ReadFile(filePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return TaskData{}, nil
+		}
+		return nil, err
+	}
+	data, combined, err := decodeTaskData(file)
+	if err != nil {
+		return nil, err
+	}
+	if len(combined) > 0 {
+		warnCombinedOnce.Do(func() {
+			for _, day := range combined {
+				fmt.Fprintf(os.Stderr, "Note: %s appears more than once in tasks.yaml; run `daily dedupe %s` to merge duplicate tasks.\n", day, day)
+			}
+		})
+	}
+	return data, nil
+}
+
+func (yamlStore) SaveTasks(data TaskData) error {
+	return writeYAMLFile("tasks.yaml", &data)
+}
+
+func (yamlStore) LoadNotes() (NoteData, error) {
+	filePath, err := dataFilePath("notes.yaml")
+	if err != nil {
+		return nil, err
+	}
+	data := NoteData{}
+	file, err := os.ReadFile(filePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return NoteData{}, nil
+		}
+		return nil, err
+	}
+	err = yaml.Unmarshal(file, &data)
+	return data, err
+}
+
+func (yamlStore) SaveNotes(data NoteData) error {
+	return writeYAMLFile("notes.yaml", &data)
+}
+
+func writeYAMLFile(name string, v interface{}) error {
+	filePath, err := dataFilePath(name)
+	if err != nil {
+		return err
+	}
+	file, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return writeWithBackup(filePath, file)
+}
+
+// migrateToSQLite copies tasks.yaml and notes.yaml into the SQLite database.
+// It refuses to overwrite a database that already holds data unless force is set.
+func migrateToSQLite(force bool) error {
+	src := yamlStore{}
+	tasks, err := src.LoadTasks()
+	if err != nil {
+		return err
+	}
+	notes, err := src.LoadNotes()
+	if err != nil {
+		return err
+	}
+	dst, err := openSQLiteStore(config.Storage.Path)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+	if !force {
+		existing, err := dst.LoadTasks()
+		if err != nil {
+			return err
+		}
+		if len(existing) > 0 {
+			return fmt.Errorf("%s already holds tasks; use --force to replace them", dst.path)
+		}
+	}
+	if err := dst.SaveTasks(tasks); err != nil {
+		return err
+	}
+	if err := dst.SaveNotes(notes); err != nil {
+		return err
+	}
+	taskCount, noteCount := 0, 0
+	for _, day := range tasks {
+		taskCount += len(day)
+	}
+	for _, day := range notes {
+		noteCount += len(day)
+	}
+	fmt.Printf("Imported %d tasks and %d notes into %s.\n", taskCount, noteCount, dst.path)
+	if config.Storage.Backend != "sqlite" {
+		fmt.Println("Set `storage: {backend: sqlite}` in config.yaml to start using it.")
+	}
+	return nil
+}
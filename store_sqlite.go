@@ -0,0 +1,228 @@
+// store_sqlite.go - SQLite storage backend
+// Keeps tasks and notes in a queryable database instead of the YAML files
+
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"path/filepath"
+	"sort"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchema keeps the commonly queried task fields as columns; the full
+// task is stored as JSON so new fields need no schema change
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS tasks (
+	day       TEXT    NOT NULL,
+	position  INTEGER NOT NULL,
+	title     TEXT    NOT NULL,
+	status    TEXT    NOT NULL,
+	estimated INTEGER NOT NULL,
+	actual    INTEGER NOT NULL,
+	project   TEXT    NOT NULL DEFAULT '',
+	data      TEXT    NOT NULL,
+	PRIMARY KEY (day, position)
+);
+CREATE INDEX IF NOT EXISTS tasks_status ON tasks (status);
+CREATE TABLE IF NOT EXISTS archived_tasks (
+	day       TEXT    NOT NULL,
+	position  INTEGER NOT NULL,
+	title     TEXT    NOT NULL,
+	status    TEXT    NOT NULL,
+	estimated INTEGER NOT NULL,
+	actual    INTEGER NOT NULL,
+	project   TEXT    NOT NULL DEFAULT '',
+	data      TEXT    NOT NULL,
+	PRIMARY KEY (day, position)
+);
+CREATE TABLE IF NOT EXISTS notes (
+	day      TEXT    NOT NULL,
+	position INTEGER NOT NULL,
+	text     TEXT    NOT NULL,
+	PRIMARY KEY (day, position)
+);
+`
+
+type sqliteStore struct {
+	db   *sql.DB
+	path string
+}
+
+func openSQLiteStore(path string) (*sqliteStore, error) {
+	if path == "" {
+		var err error
+		if path, err = dataFilePath("daily.db"); err != nil {
+			return nil, err
+		}
+	} else {
+		path = expandHome(path)
+	}
+	db, err := sql.Open("sqlite", "file:"+filepath.ToSlash(path)+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &sqliteStore{db: db, path: path}, nil
+}
+
+func (s *sqliteStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *sqliteStore) LoadTasks() (TaskData, error) {
+	rows, err := s.db.Query(`SELECT day, data FROM tasks ORDER BY day, position`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	data := TaskData{}
+	for rows.Next() {
+		var day, raw string
+		if err := rows.Scan(&day, &raw); err != nil {
+			return nil, err
+		}
+		var t Task
+		if err := json.Unmarshal([]byte(raw), &t); err != nil {
+			return nil, err
+		}
+		data[day] = append(data[day], t)
+	}
+	return data, rows.Err()
+}
+
+// SaveTasks replaces the stored tasks in one transaction
+func (s *sqliteStore) SaveTasks(data TaskData) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM tasks`); err != nil {
+		return err
+	}
+	insert, err := tx.Prepare(`INSERT INTO tasks (day, position, title, status, estimated, actual, project, data) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+	for _, day := range sortedDays(data) {
+		for i, t := range data[day] {
+			raw, err := json.Marshal(t)
+			if err != nil {
+				return err
+			}
+			if _, err := insert.Exec(day, i, t.Title, t.Status, t.Estimated, t.Actual, t.Project, string(raw)); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// ArchiveTasks moves days into the archived_tasks table, after any tasks
+// already archived for the same day
+func (s *sqliteStore) ArchiveTasks(data TaskData) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	insert, err := tx.Prepare(`INSERT INTO archived_tasks (day, position, title, status, estimated, actual, project, data) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+	for _, day := range sortedDays(data) {
+		var next int
+		if err := tx.QueryRow(`SELECT COALESCE(MAX(position) + 1, 0) FROM archived_tasks WHERE day = ?`, day).Scan(&next); err != nil {
+			return err
+		}
+		for i, t := range data[day] {
+			raw, err := json.Marshal(t)
+			if err != nil {
+				return err
+			}
+			if _, err := insert.Exec(day, next+i, t.Title, t.Status, t.Estimated, t.Actual, t.Project, string(raw)); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *sqliteStore) LoadArchivedTasks(from, to string) (TaskData, error) {
+	if to == "" {
+		to = "9999-12-31"
+	}
+	rows, err := s.db.Query(`SELECT day, data FROM archived_tasks WHERE day >= ? AND day <= ? ORDER BY day, position`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	data := TaskData{}
+	for rows.Next() {
+		var day, raw string
+		if err := rows.Scan(&day, &raw); err != nil {
+			return nil, err
+		}
+		var t Task
+		if err := json.Unmarshal([]byte(raw), &t); err != nil {
+			return nil, err
+		}
+		data[day] = append(data[day], t)
+	}
+	return data, rows.Err()
+}
+
+func (s *sqliteStore) LoadNotes() (NoteData, error) {
+	rows, err := s.db.Query(`SELECT day, text FROM notes ORDER BY day, position`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	data := NoteData{}
+	for rows.Next() {
+		var day, text string
+		if err := rows.Scan(&day, &text); err != nil {
+			return nil, err
+		}
+		data[day] = append(data[day], text)
+	}
+	return data, rows.Err()
+}
+
+// SaveNotes replaces the stored notes in one transaction
+func (s *sqliteStore) SaveNotes(data NoteData) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM notes`); err != nil {
+		return err
+	}
+	for day, notes := range data {
+		for i, text := range notes {
+			if _, err := tx.Exec(`INSERT INTO notes (day, position, text) VALUES (?, ?, ?)`, day, i, text); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// sortedDays returns the day keys of data in date order
+func sortedDays(data TaskData) []string {
+	days := make([]string, 0, len(data))
+	for day := range data {
+		days = append(days, day)
+	}
+	sort.Strings(days)
+	return days
+}
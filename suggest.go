@@ -0,0 +1,137 @@
+// suggest.go - Title suggestions for task entry
+// Offers previously used task titles while typing, with their typical estimate and tags
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// titleHistory summarizes how a task title has been used in the past
+type titleHistory struct {
+	Title     string
+	Count     int
+	Estimates []int
+	Tags      []string
+	lastDay   string
+}
+
+// typicalEstimate returns the median estimate used for this title
+func (h titleHistory) typicalEstimate() int {
+	if len(h.Estimates) == 0 {
+		return 0
+	}
+	sorted := append([]int(nil), h.Estimates...)
+	sort.Ints(sorted)
+	return sorted[len(sorted)/2]
+}
+
+// buildTitleHistory collects past titles across all days, most used first
+func buildTitleHistory(data TaskData) []titleHistory {
+	byKey := map[string]*titleHistory{}
+	for day, tasks := range data {
+		for _, t := range tasks {
+			title := strings.TrimSpace(t.Title)
+			if title == "" {
+				continue
+			}
+			key := strings.ToLower(title)
+			h, ok := byKey[key]
+			if !ok {
+				h = &titleHistory{Title: title}
+				byKey[key] = h
+			}
+			h.Count++
+			if t.Estimated > 0 {
+				h.Estimates = append(h.Estimates, t.Estimated)
+			}
+			// Carry over the tags used the most recent time
+			if day >= h.lastDay {
+				h.lastDay = day
+				h.Tags = t.Tags
+			}
+		}
+	}
+	history := make([]titleHistory, 0, len(byKey))
+	for _, h := range byKey {
+		history = append(history, *h)
+	}
+	sort.Slice(history, func(i, j int) bool {
+		if history[i].Count != history[j].Count {
+			return history[i].Count > history[j].Count
+		}
+		return history[i].Title < history[j].Title
+	})
+	return history
+}
+
+// findTitleHistory returns the history entry matching a title, if any
+func findTitleHistory(history []titleHistory, title string) (titleHistory, bool) {
+	for _, h := range history {
+		if strings.EqualFold(h.Title, strings.TrimSpace(title)) {
+			return h, true
+		}
+	}
+	return titleHistory{}, false
+}
+
+// --- Bubble Tea Title Input Model ---
+
+type titleInputModel struct {
+	input     textinput.Model
+	label     string
+	cancelled bool
+}
+
+func (m titleInputModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m titleInputModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyEsc:
+			m.cancelled = true
+			return m, tea.Quit
+		case tea.KeyEnter:
+			return m, tea.Quit
+		}
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m titleInputModel) View() string {
+	return fmt.Sprintf("%s: %s\n(tab to accept a suggestion, ↑/↓ to cycle)\n", m.label, m.input.View())
+}
+
+// promptTitleWithSuggestions reads a task title, suggesting past titles as you type.
+// It returns the same "interrupt" error as promptui when cancelled.
+func promptTitleWithSuggestions(label string, history []titleHistory) (string, error) {
+	input := textinput.New()
+	input.Prompt = ""
+	input.ShowSuggestions = true
+	suggestions := make([]string, 0, len(history))
+	for _, h := range history {
+		suggestions = append(suggestions, h.Title)
+	}
+	input.SetSuggestions(suggestions)
+	input.Focus()
+
+	result, err := tea.NewProgram(titleInputModel{input: input, label: label}).Run()
+	if err != nil {
+		return "", err
+	}
+	m := result.(titleInputModel)
+	value := strings.TrimSpace(m.input.Value())
+	if m.cancelled || value == "q" {
+		return "", fmt.Errorf("interrupt")
+	}
+	return value, nil
+}
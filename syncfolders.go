@@ -0,0 +1,172 @@
+// syncfolders.go - Synced data location
+// Detects Dropbox, iCloud Drive, OneDrive and Google Drive folders and offers them
+// as the data directory so the same tasks follow you between machines
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/manifoldco/promptui"
+)
+
+// syncedFolder is a cloud-synced directory found on this machine
+type syncedFolder struct {
+	Service string
+	Path    string
+}
+
+// detectSyncedFolders looks in the usual install locations of the common sync clients
+func detectSyncedFolders() []syncedFolder {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	var found []syncedFolder
+	seen := map[string]bool{}
+	add := func(service string, paths ...string) {
+		for _, p := range paths {
+			if p == "" || seen[p] {
+				continue
+			}
+			if info, err := os.Stat(p); err == nil && info.IsDir() {
+				seen[p] = true
+				found = append(found, syncedFolder{service, p})
+			}
+		}
+	}
+	glob := func(pattern string) []string {
+		matches, _ := filepath.Glob(filepath.Join(home, pattern))
+		return matches
+	}
+
+	add("Dropbox", filepath.Join(home, "Dropbox"))
+	add("Dropbox", glob("Dropbox (*)")...)
+	add("Dropbox", glob("Library/CloudStorage/Dropbox*")...)
+	switch runtime.GOOS {
+	case "darwin":
+		add("iCloud Drive", filepath.Join(home, "Library", "Mobile Documents", "com~apple~CloudDocs"))
+	case "windows":
+		add("iCloud Drive", filepath.Join(home, "iCloudDrive"))
+	}
+	add("OneDrive", os.Getenv("OneDrive"), os.Getenv("OneDriveConsumer"), os.Getenv("OneDriveCommercial"))
+	add("OneDrive", filepath.Join(home, "OneDrive"))
+	add("OneDrive", glob("OneDrive - *")...)
+	add("OneDrive", glob("Library/CloudStorage/OneDrive-*")...)
+	add("Google Drive", filepath.Join(home, "Google Drive"), filepath.Join(home, "My Drive"))
+	add("Google Drive", glob("Library/CloudStorage/GoogleDrive-*/My Drive")...)
+	return found
+}
+
+// chooseDataDir offers the current data directory, a "daily" folder in each
+// synced folder, or a custom path
+func chooseDataDir(current string) (string, error) {
+	folders := detectSyncedFolders()
+	items := []string{"Keep " + current}
+	paths := []string{current}
+	for _, f := range folders {
+		dir := filepath.Join(f.Path, "daily")
+		if filepath.Clean(dir) == filepath.Clean(current) {
+			continue
+		}
+		items = append(items, fmt.Sprintf("%s (%s)", f.Service, dir))
+		paths = append(paths, dir)
+	}
+	items = append(items, "Other…")
+	prompt := promptui.Select{Label: "Data directory", Items: items, HideHelp: true, Size: 8}
+	i, _, err := prompt.Run()
+	if err != nil {
+		return "", err
+	}
+	if i < len(paths) {
+		return paths[i], nil
+	}
+	return validatedPrompt("Data directory", current, func(input string) error {
+		if strings.TrimSpace(input) == "" {
+			return fmt.Errorf("enter a directory")
+		}
+		return nil
+	})
+}
+
+// moveDataFiles moves the data files from one data directory to another,
+// leaving files that already exist at the destination untouched
+func moveDataFiles(from, to string) (int, error) {
+	if filepath.Clean(expandHome(from)) == filepath.Clean(expandHome(to)) {
+		return 0, nil
+	}
+	if err := os.MkdirAll(expandHome(to), 0755); err != nil {
+		return 0, err
+	}
+	moved := 0
+	for _, name := range dataFiles {
+		oldPath := filepath.Join(expandHome(from), name)
+		newPath := filepath.Join(expandHome(to), name)
+		if _, err := os.Stat(oldPath); err != nil {
+			continue
+		}
+		if _, err := os.Stat(newPath); err == nil {
+			fmt.Printf("Kept %s, %s already exists\n", oldPath, newPath)
+			continue
+		}
+		if err := moveFile(oldPath, newPath); err != nil {
+			return moved, fmt.Errorf("moving %s: %w", name, err)
+		}
+		moved++
+	}
+	return moved, nil
+}
+
+// hasDataFiles reports whether dir holds any data file
+func hasDataFiles(dir string) bool {
+	for _, name := range dataFiles {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// isTerminal reports whether stdin is interactive
+func isTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// offerSyncedDataDir runs once on a fresh install: when there is no config
+// file and no data yet but a synced folder exists, it asks where to keep the
+// data and records the answer as data_dir
+func offerSyncedDataDir() error {
+	if config.DataDir != "" || !isTerminal() {
+		return nil
+	}
+	path, err := getConfigFilePath()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	current, err := getDataDir()
+	if err != nil {
+		return err
+	}
+	legacy, _ := legacyDataDir()
+	if hasDataFiles(current) || (legacy != "" && hasDataFiles(legacy)) || len(detectSyncedFolders()) == 0 {
+		return nil
+	}
+	fmt.Println("Welcome to daily! A synced folder was found; keeping your data there makes it available on all your machines.")
+	dir, err := chooseDataDir(current)
+	if err != nil {
+		return err
+	}
+	if err := writeInitConfig(path, map[string]interface{}{"data_dir": dir}); err != nil {
+		return err
+	}
+	fmt.Printf("Data will be stored in %s (saved to %s; run `daily init` to change it).\n\n", dir, path)
+	return applyConfig()
+}
@@ -0,0 +1,69 @@
+// tagrules.go - Keyword tagging rules
+// Suggests tags from words in a task title, e.g. titles mentioning "review" get #review
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/manifoldco/promptui"
+)
+
+// TagRule adds Tags to any task whose title contains one of Keywords
+// (case-insensitive)
+type TagRule struct {
+	Keywords []string `yaml:"keywords"`
+	Tags     []string `yaml:"tags"`
+}
+
+// suggestTags returns the rule tags matching a title that the task does not carry yet
+func suggestTags(title string, existing []string) []string {
+	lower := strings.ToLower(title)
+	var suggested []string
+	for _, rule := range config.TagRules {
+		matched := false
+		for _, kw := range rule.Keywords {
+			if kw = strings.ToLower(strings.TrimSpace(kw)); kw != "" && strings.Contains(lower, kw) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+		for _, tag := range normalizeTags(rule.Tags) {
+			if !hasTag(Task{Tags: existing}, tag) && !hasTag(Task{Tags: suggested}, tag) {
+				suggested = append(suggested, tag)
+			}
+		}
+	}
+	return suggested
+}
+
+// applyTagRules adds the suggested tags without asking, for imports and scripts
+func applyTagRules(t *Task) {
+	t.Tags = append(t.Tags, suggestTags(t.Title, t.Tags)...)
+}
+
+// confirmTagRules offers the suggested tags for a task and adds them if accepted
+func confirmTagRules(t *Task) error {
+	suggested := suggestTags(t.Title, t.Tags)
+	if len(suggested) == 0 {
+		return nil
+	}
+	prompt := promptui.Prompt{
+		Label:     fmt.Sprintf("Add suggested tags #%s", strings.Join(suggested, " #")),
+		IsConfirm: true,
+		Default:   "y",
+	}
+	if _, err := prompt.Run(); err != nil {
+		if errors.Is(err, promptui.ErrAbort) {
+			return nil
+		}
+		return err
+	}
+	t.Tags = append(t.Tags, suggested...)
+	return nil
+}
@@ -0,0 +1,83 @@
+// tasknotes.go - Task notes
+// `daily note --task` attaches a note to one task instead of the day; task notes are kept with the task and shown by ls, show, publish and standup
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TaskNote is a note written about one task, e.g. "waiting on review"
+type TaskNote struct {
+	At   int64  `yaml:"at" json:"at"`
+	Text string `yaml:"text" json:"text"`
+}
+
+// taskNoteLines returns a task's notes with the time each was written
+func taskNoteLines(t Task) []string {
+	var lines []string
+	for _, n := range t.Notes {
+		lines = append(lines, time.Unix(n.At, 0).Format("Jan 2 15:04")+"  "+n.Text)
+	}
+	return lines
+}
+
+// printTaskNotes lists a task's notes under it
+func printTaskNotes(t Task) {
+	if len(t.Notes) == 0 {
+		return
+	}
+	fmt.Println("Notes:")
+	for _, line := range taskNoteLines(t) {
+		fmt.Printf("  %s\n", line)
+	}
+}
+
+// addTaskNote attaches a note to the task found by its number on day or by
+// its title
+func addTaskNote(day, query, text string) error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return fmt.Errorf("the note is empty")
+	}
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	found, i, err := findTaskRecord(data, day, query)
+	if err != nil {
+		return err
+	}
+	t := &data[found][i]
+	t.Notes = append(t.Notes, TaskNote{At: time.Now().Unix(), Text: text})
+	if err := saveTasks(data); err != nil {
+		return err
+	}
+	fmt.Printf("Note added to '%s'.\n", t.Title)
+	return nil
+}
+
+// showTaskNotes prints the notes of the task found by its number on day or
+// by its title
+func showTaskNotes(day, query string) error {
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	found, i, err := findTaskRecord(data, day, query)
+	if err != nil {
+		return err
+	}
+	t := data[found][i]
+	if len(t.Notes) == 0 {
+		fmt.Printf("No notes on '%s'.\n", t.Title)
+		return nil
+	}
+	fmt.Printf("Notes on '%s' (%s):\n", t.Title, found)
+	for i, line := range taskNoteLines(t) {
+		fmt.Printf("%d. %s\n", i+1, line)
+	}
+	return nil
+}
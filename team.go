@@ -0,0 +1,141 @@
+// team.go - Team config
+// A shared config file, from a path or a URL, is layered under the personal config so a team can agree on tags, projects, work hours and report formats
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// teamCacheMaxAge is how long a team config fetched from a URL is reused
+// before it is downloaded again
+const teamCacheMaxAge = time.Hour
+
+// teamSource returns where the team config lives: DAILY_TEAM, else the
+// `team` setting of the personal config, empty for none
+func teamSource(personal []byte) string {
+	if source := os.Getenv("DAILY_TEAM"); source != "" {
+		return source
+	}
+	var cfg struct {
+		Team string `yaml:"team"`
+	}
+	yaml.Unmarshal(personal, &cfg)
+	return strings.TrimSpace(cfg.Team)
+}
+
+func isTeamURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// readTeamConfig returns the team config. A relative path is relative to the
+// personal config file. A URL is read from its cached copy, empty until
+// refreshTeamConfig first downloads it: the config is loaded before --offline
+// is known.
+func readTeamConfig(source, configPath string) ([]byte, error) {
+	if !isTeamURL(source) {
+		path := expandHome(source)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(configPath), path)
+		}
+		return os.ReadFile(path)
+	}
+	cache, err := teamCachePath()
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(cache)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return content, err
+}
+
+// refreshTeamConfig downloads a team config URL whose cached copy is over an
+// hour old, and applies the config again when it changed. Offline, or when
+// the download fails, the cached copy stays in use.
+func refreshTeamConfig() error {
+	source := config.Team
+	if !isTeamURL(source) || requireOnline("The team config") != nil {
+		return nil
+	}
+	cache, err := teamCachePath()
+	if err != nil {
+		return err
+	}
+	cached, cacheErr := os.ReadFile(cache)
+	if info, err := os.Stat(cache); err == nil && time.Since(info.ModTime()) < teamCacheMaxAge {
+		return nil
+	}
+	content, err := fetchTeamConfig(source)
+	if err != nil {
+		if cacheErr == nil {
+			return nil
+		}
+		return fmt.Errorf("team config: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(cache), 0755); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(cache, content, 0644); err != nil {
+		return err
+	}
+	if string(content) == string(cached) {
+		return nil
+	}
+	return applyConfig()
+}
+
+func teamCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "daily", "team-config.yaml"), nil
+}
+
+func fetchTeamConfig(url string) ([]byte, error) {
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// warnUnknownVocabulary warns when a task uses a project or tag outside the
+// lists the config declares, so a team keeps reporting on the same names
+func warnUnknownVocabulary(t Task) {
+	if t.Project != "" && len(config.Projects) > 0 && !slices.ContainsFunc(config.Projects, func(p string) bool {
+		return strings.EqualFold(p, t.Project)
+	}) {
+		fmt.Printf("Warning: project '%s' is not one of the known projects (%s)\n", t.Project, strings.Join(config.Projects, ", "))
+	}
+	if len(config.Tags) == 0 {
+		return
+	}
+	known := normalizeTags(config.Tags)
+	var unknown []string
+	for _, tag := range t.Tags {
+		if !slices.Contains(known, tag) {
+			unknown = append(unknown, "#"+tag)
+		}
+	}
+	if len(unknown) > 0 {
+		fmt.Printf("Warning: %s not among the known tags (%s)\n", strings.Join(unknown, ", "), "#"+strings.Join(known, ", #"))
+	}
+}
@@ -0,0 +1,114 @@
+// theme.go - Color themes
+// Palettes for the progress bars and full-screen views, selected with `theme` in config.yaml, in a variant for dark or light terminal backgrounds
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Theme is the color scale used by progress bars, from worst to best, plus
+// the accent used for neutral bars
+type Theme struct {
+	Bad        string
+	DarkOrange string
+	Orange     string
+	Yellow     string
+	Good       string
+	Best       string
+	Accent     string
+}
+
+var themes = map[string]Theme{
+	"default": {
+		Bad: "#f53333", DarkOrange: "#f56a33", Orange: "#f58e33", Yellow: "#f5ce33",
+		Good: "#33f56d", Best: "#03befc", Accent: "#03befc",
+	},
+	// Darker shades that stay readable on light terminal backgrounds
+	"light": {
+		Bad: "#c41a1a", DarkOrange: "#c2410c", Orange: "#b45309", Yellow: "#a16207",
+		Good: "#15803d", Best: "#1d4ed8", Accent: "#1d4ed8",
+	},
+	// Blue to orange, distinguishable with red-green color blindness
+	"colorblind": {
+		Bad: "#d55e00", DarkOrange: "#e69f00", Orange: "#f0c05a", Yellow: "#cccccc",
+		Good: "#56b4e9", Best: "#0072b2", Accent: "#0072b2",
+	},
+	"mono": {
+		Bad: "#ffffff", DarkOrange: "#dddddd", Orange: "#bbbbbb", Yellow: "#999999",
+		Good: "#777777", Best: "#555555", Accent: "#aaaaaa",
+	},
+}
+
+// lightVariants replace the themes made for dark backgrounds on a light one
+var lightVariants = map[string]Theme{
+	"default": themes["light"],
+	"colorblind": {
+		Bad: "#a64500", DarkOrange: "#b37a00", Orange: "#8a6d1f", Yellow: "#6b6b6b",
+		Good: "#1f77b4", Best: "#004c7a", Accent: "#004c7a",
+	},
+	"mono": {
+		Bad: "#000000", DarkOrange: "#222222", Orange: "#444444", Yellow: "#555555",
+		Good: "#666666", Best: "#777777", Accent: "#444444",
+	},
+}
+
+// themeNames lists the available themes, sorted
+func themeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// checkTheme validates the `theme` setting
+func checkTheme(name string) error {
+	if _, ok := themes[name]; ok || name == "" {
+		return nil
+	}
+	return fmt.Errorf("unknown theme %q (available: %v)", name, themeNames())
+}
+
+// checkBackground validates the `background` setting
+func checkBackground(background string) error {
+	switch background {
+	case "", "auto", "dark", "light":
+		return nil
+	}
+	return fmt.Errorf("background must be auto, dark, or light")
+}
+
+// terminalIsDark asks the terminal for its background color once. Terminals
+// that don't answer, and output that isn't a terminal, count as dark.
+var terminalIsDark = sync.OnceValue(lipgloss.HasDarkBackground)
+
+// lightBackground reports whether output goes to a light background, as
+// configured or as the terminal reports it
+func lightBackground() bool {
+	switch config.Background {
+	case "light":
+		return true
+	case "dark":
+		return false
+	}
+	return !terminalIsDark()
+}
+
+// activeTheme returns the configured theme, in its light variant on a light
+// background
+func activeTheme() Theme {
+	name := config.Theme
+	if _, ok := themes[name]; !ok {
+		name = "default"
+	}
+	if variant, ok := lightVariants[name]; ok && lightBackground() {
+		return variant
+	}
+	return themes[name]
+}
@@ -0,0 +1,289 @@
+// timepush.go - Time tracker push
+// `daily push toggl|clockify` sends finished work sessions to Toggl Track or Clockify as time entries, once each, mapping tags to their projects
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PushConfig is the `push` section of config.yaml. The credentials are read
+// from TOGGL_API_TOKEN and CLOCKIFY_API_KEY so they never have to be written
+// to disk.
+type PushConfig struct {
+	Toggl    TogglConfig    `yaml:"toggl"`
+	Clockify ClockifyConfig `yaml:"clockify"`
+}
+
+type TogglConfig struct {
+	BaseURL     string           `yaml:"base_url"` // default https://api.track.toggl.com/api/v9
+	WorkspaceID int64            `yaml:"workspace_id"`
+	Projects    map[string]int64 `yaml:"projects"` // tag or project -> Toggl project ID
+}
+
+type ClockifyConfig struct {
+	BaseURL     string            `yaml:"base_url"` // default https://api.clockify.me/api/v1; regional and self-hosted servers differ
+	WorkspaceID string            `yaml:"workspace_id"`
+	Projects    map[string]string `yaml:"projects"` // tag or project -> Clockify project ID
+}
+
+// timeEntry is one finished session, as a tracker receives it
+type timeEntry struct {
+	Description string
+	Start, End  time.Time
+	Tags        []string
+}
+
+// timeTracker is a service sessions can be pushed to
+type timeTracker interface {
+	// project returns the tracker's project ID configured for a tag or project
+	project(name string) (string, bool)
+	// create adds a time entry and returns its ID
+	create(e timeEntry, projectID string) (string, error)
+}
+
+const (
+	defaultTogglURL    = "https://api.track.toggl.com/api/v9"
+	defaultClockifyURL = "https://api.clockify.me/api/v1"
+)
+
+type togglTracker struct {
+	cfg   TogglConfig
+	token string
+}
+
+func (t togglTracker) project(name string) (string, bool) {
+	id, ok := t.cfg.Projects[name]
+	return strconv.FormatInt(id, 10), ok
+}
+
+func (t togglTracker) create(e timeEntry, projectID string) (string, error) {
+	body := map[string]any{
+		"workspace_id": t.cfg.WorkspaceID,
+		"description":  e.Description,
+		"start":        e.Start.UTC().Format(time.RFC3339),
+		"stop":         e.End.UTC().Format(time.RFC3339),
+		"duration":     int64(e.End.Sub(e.Start).Seconds()),
+		"created_with": "daily",
+	}
+	if len(e.Tags) > 0 {
+		body["tags"] = e.Tags
+	}
+	if projectID != "" {
+		id, _ := strconv.ParseInt(projectID, 10, 64)
+		body["project_id"] = id
+	}
+	var created struct {
+		ID int64 `json:"id"`
+	}
+	path := fmt.Sprintf("/workspaces/%d/time_entries", t.cfg.WorkspaceID)
+	err := postTimeEntry("Toggl", t.cfg.BaseURL+path, body, func(req *http.Request) {
+		req.SetBasicAuth(t.token, "api_token")
+	}, &created)
+	return strconv.FormatInt(created.ID, 10), err
+}
+
+type clockifyTracker struct {
+	cfg ClockifyConfig
+	key string
+}
+
+func (c clockifyTracker) project(name string) (string, bool) {
+	id, ok := c.cfg.Projects[name]
+	return id, ok
+}
+
+func (c clockifyTracker) create(e timeEntry, projectID string) (string, error) {
+	body := map[string]any{
+		"description": e.Description,
+		"start":       e.Start.UTC().Format(time.RFC3339),
+		"end":         e.End.UTC().Format(time.RFC3339),
+	}
+	if projectID != "" {
+		body["projectId"] = projectID
+	}
+	var created struct {
+		ID string `json:"id"`
+	}
+	path := "/workspaces/" + c.cfg.WorkspaceID + "/time-entries"
+	err := postTimeEntry("Clockify", c.cfg.BaseURL+path, body, func(req *http.Request) {
+		req.Header.Set("X-Api-Key", c.key)
+	}, &created)
+	return created.ID, err
+}
+
+// postTimeEntry sends a JSON body and decodes the created entry into out
+func postTimeEntry(service, url string, body any, auth func(*http.Request), out any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	auth(req)
+	req.Header.Set("Content-Type", "application/json")
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned %s", service, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// newTimeTracker checks the configuration of a target and returns its client
+func newTimeTracker(target string) (timeTracker, error) {
+	switch target {
+	case "toggl":
+		cfg := config.Push.Toggl
+		if cfg.WorkspaceID == 0 {
+			return nil, fmt.Errorf("push.toggl.workspace_id must be configured")
+		}
+		token := os.Getenv("TOGGL_API_TOKEN")
+		if token == "" {
+			return nil, fmt.Errorf("TOGGL_API_TOKEN is not set")
+		}
+		if cfg.BaseURL == "" {
+			cfg.BaseURL = defaultTogglURL
+		}
+		cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+		return togglTracker{cfg: cfg, token: token}, nil
+	case "clockify":
+		cfg := config.Push.Clockify
+		if cfg.WorkspaceID == "" {
+			return nil, fmt.Errorf("push.clockify.workspace_id must be configured")
+		}
+		key := os.Getenv("CLOCKIFY_API_KEY")
+		if key == "" {
+			return nil, fmt.Errorf("CLOCKIFY_API_KEY is not set")
+		}
+		if cfg.BaseURL == "" {
+			cfg.BaseURL = defaultClockifyURL
+		}
+		cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+		return clockifyTracker{cfg: cfg, key: key}, nil
+	}
+	return nil, fmt.Errorf("unknown target %q (expected toggl or clockify)", target)
+}
+
+// pushedID returns where the ID of the entry created for a session is kept
+func pushedID(s *Session, target string) *string {
+	if target == "toggl" {
+		return &s.Toggl
+	}
+	return &s.Clockify
+}
+
+// trackerProject picks the tracker's project of a task: the first of its
+// tags with a project configured, else its own project
+func trackerProject(tracker timeTracker, t Task) (name, id string) {
+	for _, tag := range t.Tags {
+		if id, ok := tracker.project(tag); ok {
+			return tag, id
+		}
+	}
+	if id, ok := tracker.project(t.Project); ok && t.Project != "" {
+		return t.Project, id
+	}
+	return "", ""
+}
+
+// pushSessions sends the finished sessions of the days from `day` back over
+// `days` days that weren't pushed to target yet. Breaks and other non-work
+// time stay out.
+func pushSessions(target, day string, days int, dryRun bool) error {
+	target = strings.ToLower(target)
+	if target != "toggl" && target != "clockify" {
+		return fmt.Errorf("unknown target %q (expected toggl or clockify)", target)
+	}
+	last, err := time.Parse("2006-01-02", day)
+	if err != nil {
+		return fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", day)
+	}
+	if days < 1 {
+		return fmt.Errorf("--days must be at least 1")
+	}
+	var tracker timeTracker
+	if !dryRun {
+		if err := requireOnline("Pushing time entries"); err != nil {
+			return err
+		}
+		if tracker, err = newTimeTracker(target); err != nil {
+			return err
+		}
+	}
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	pushed, total := 0, 0
+	for i := days - 1; i >= 0; i-- {
+		key := last.AddDate(0, 0, -i).Format("2006-01-02")
+		for ti := range data[key] {
+			t := &data[key][ti]
+			if isNonWork(*t) {
+				continue
+			}
+			for si := range t.Sessions {
+				s := &t.Sessions[si]
+				id := pushedID(s, target)
+				if s.End == 0 || *id != "" {
+					continue
+				}
+				e := timeEntry{
+					Description: t.Title,
+					Start:       time.Unix(s.Start, 0),
+					End:         time.Unix(s.End, 0),
+					Tags:        t.Tags,
+				}
+				minutes := int(e.End.Sub(e.Start).Minutes())
+				if dryRun {
+					fmt.Printf("  %s %s-%s %8s  %s\n", key, e.Start.Format("15:04"), e.End.Format("15:04"), fmtMinutes(minutes), t.Title)
+					pushed++
+					total += minutes
+					continue
+				}
+				project, projectID := trackerProject(tracker, *t)
+				created, err := tracker.create(e, projectID)
+				if err != nil {
+					if pushed > 0 {
+						saveTasks(data)
+					}
+					return fmt.Errorf("'%s' at %s: %w", t.Title, e.Start.Format("Jan 2 15:04"), err)
+				}
+				*id = created
+				pushed++
+				total += minutes
+				if project != "" {
+					project = " to " + project
+				}
+				fmt.Printf("Pushed %s of '%s'%s.\n", fmtMinutes(minutes), t.Title, project)
+			}
+		}
+	}
+	switch {
+	case pushed == 0:
+		fmt.Printf("No finished sessions left to push to %s.\n", target)
+		return nil
+	case dryRun:
+		fmt.Printf("(dry run: %d sessions, %s, not pushed to %s)\n", pushed, fmtMinutes(total), target)
+		return nil
+	}
+	if err := saveTasks(data); err != nil {
+		return err
+	}
+	fmt.Printf("Pushed %d sessions (%s) to %s.\n", pushed, fmtMinutes(total), target)
+	return nil
+}
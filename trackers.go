@@ -0,0 +1,519 @@
+// trackers.go - Import from other time trackers
+// `daily migrate from` detects Toggl, Clockify, ATracker and RescueTime exports and maps their columns into tasks and sessions
+
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/manifoldco/promptui"
+)
+
+// trackerFormat describes a known export: the header columns that identify
+// it and the default column mapping
+type trackerFormat struct {
+	Name string
+	// Signature columns must all be present in the header
+	Signature []string
+	Title     []string // joined with " - " when several are set
+	Project   string
+	Tags      string
+	StartDate string
+	StartTime string // empty when StartDate holds date and time
+	EndDate   string
+	EndTime   string
+	Duration  string
+	// DurationUnit is how Duration is written: clock (h:mm:ss), hours, or seconds
+	DurationUnit string
+}
+
+var trackerFormats = []trackerFormat{
+	{
+		Name:      "Toggl",
+		Signature: []string{"Description", "Start date", "Start time", "Duration"},
+		Title:     []string{"Description"}, Project: "Project", Tags: "Tags",
+		StartDate: "Start date", StartTime: "Start time", EndDate: "End date", EndTime: "End time",
+		Duration: "Duration", DurationUnit: "clock",
+	},
+	{
+		Name:      "Clockify",
+		Signature: []string{"Description", "Start Date", "Start Time", "Duration (h)"},
+		Title:     []string{"Description"}, Project: "Project", Tags: "Tags",
+		StartDate: "Start Date", StartTime: "Start Time", EndDate: "End Date", EndTime: "End Time",
+		Duration: "Duration (h)", DurationUnit: "clock",
+	},
+	{
+		Name:      "ATracker",
+		Signature: []string{"Task name", "Start time", "End time"},
+		Title:     []string{"Task name"}, Tags: "Tag",
+		StartDate: "Start time", EndDate: "End time",
+		Duration: "Duration", DurationUnit: "clock",
+	},
+	{
+		Name:      "RescueTime",
+		Signature: []string{"Date", "Time Spent (seconds)", "Activity"},
+		Title:     []string{"Activity"}, Project: "Category",
+		StartDate: "Date",
+		Duration:  "Time Spent (seconds)", DurationUnit: "seconds",
+	},
+}
+
+// dateTimeLayouts are the date and time formats seen in tracker exports
+var dateTimeLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02 3:04:05 PM",
+	"2006-01-02 3:04 PM",
+	"01/02/2006 15:04:05",
+	"01/02/2006 15:04",
+	"01/02/2006 03:04:05 PM",
+	"01/02/2006 3:04 PM",
+	"02.01.2006 15:04:05",
+	"02.01.2006 15:04",
+	"Jan 2, 2006 at 3:04 PM",
+	"Jan 2, 2006 at 15:04",
+	"Jan 2, 2006 3:04 PM",
+	"Jan 2, 2006 15:04",
+	time.RFC3339,
+}
+
+// parseTrackerTime reads a date and time written in any of the known layouts
+func parseTrackerTime(value string) (time.Time, error) {
+	value = strings.Join(strings.Fields(value), " ")
+	for _, layout := range dateTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date %q", value)
+}
+
+// parseTrackerDuration reads a duration as h:mm:ss, decimal hours, or seconds
+func parseTrackerDuration(value, unit string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if strings.Contains(value, ":") {
+		parts := strings.Split(value, ":")
+		var total time.Duration
+		units := []time.Duration{time.Hour, time.Minute, time.Second}
+		for i, p := range parts {
+			if i >= len(units) {
+				return 0, fmt.Errorf("unrecognized duration %q", value)
+			}
+			n, err := strconv.Atoi(p)
+			if err != nil {
+				return 0, fmt.Errorf("unrecognized duration %q", value)
+			}
+			total += time.Duration(n) * units[i]
+		}
+		return total, nil
+	}
+	n, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64)
+	if err != nil {
+		return 0, fmt.Errorf("unrecognized duration %q", value)
+	}
+	if unit == "seconds" {
+		return time.Duration(n * float64(time.Second)), nil
+	}
+	return time.Duration(n * float64(time.Hour)), nil
+}
+
+// readTrackerCSV returns the header and rows of an export
+func readTrackerCSV(path string) ([]string, [][]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	header, err := r.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+	var rows [][]string
+	for {
+		row, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading %s: %w", path, err)
+		}
+		rows = append(rows, row)
+	}
+	return header, rows, nil
+}
+
+// detectTrackerFormat returns the known format whose signature matches the header
+func detectTrackerFormat(header []string) (trackerFormat, bool) {
+	has := map[string]bool{}
+	for _, h := range header {
+		has[strings.TrimSpace(h)] = true
+	}
+	for _, f := range trackerFormats {
+		matched := true
+		for _, col := range f.Signature {
+			if !has[col] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return f, true
+		}
+	}
+	return trackerFormat{}, false
+}
+
+// trackerExport is an export file found on disk
+type trackerExport struct {
+	Path   string
+	Format trackerFormat
+}
+
+// findTrackerExports looks for recognizable CSV exports in the current
+// directory and in Downloads
+func findTrackerExports() []trackerExport {
+	dirs := []string{"."}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, "Downloads"))
+	}
+	var found []trackerExport
+	for _, dir := range dirs {
+		matches, _ := filepath.Glob(filepath.Join(dir, "*.csv"))
+		for _, path := range matches {
+			header, _, err := readTrackerCSV(path)
+			if err != nil {
+				continue
+			}
+			if format, ok := detectTrackerFormat(header); ok {
+				found = append(found, trackerExport{path, format})
+			}
+		}
+	}
+	return found
+}
+
+// columnMapping holds the header index for each field, -1 when unused
+type columnMapping struct {
+	Title                                  []int
+	Project, Tags                          int
+	StartDate, StartTime, EndDate, EndTime int
+	Duration                               int
+	DurationUnit                           string
+}
+
+func indexOf(header []string, name string) int {
+	for i, h := range header {
+		if name != "" && strings.EqualFold(strings.TrimSpace(h), name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// defaultMapping applies a format's column names to the header
+func defaultMapping(header []string, f trackerFormat) columnMapping {
+	m := columnMapping{
+		Project: indexOf(header, f.Project), Tags: indexOf(header, f.Tags),
+		StartDate: indexOf(header, f.StartDate), StartTime: indexOf(header, f.StartTime),
+		EndDate: indexOf(header, f.EndDate), EndTime: indexOf(header, f.EndTime),
+		Duration: indexOf(header, f.Duration), DurationUnit: f.DurationUnit,
+	}
+	for _, name := range f.Title {
+		if i := indexOf(header, name); i >= 0 {
+			m.Title = append(m.Title, i)
+		}
+	}
+	return m
+}
+
+// chooseColumn lets the user pick the header column for a field
+func chooseColumn(label string, header []string, current int, optional bool) (int, error) {
+	items := append([]string(nil), header...)
+	if optional {
+		items = append(items, "(none)")
+	}
+	cursor := current
+	if cursor < 0 {
+		cursor = len(items) - 1
+	}
+	prompt := promptui.Select{Label: label, Items: items, CursorPos: cursor, Size: 12, HideHelp: true}
+	i, _, err := prompt.Run()
+	if err != nil {
+		return current, err
+	}
+	if i >= len(header) {
+		return -1, nil
+	}
+	return i, nil
+}
+
+// editMapping walks through the fields so the user can correct the columns
+func editMapping(header []string, m columnMapping) (columnMapping, error) {
+	title := -1
+	if len(m.Title) > 0 {
+		title = m.Title[0]
+	}
+	var err error
+	if title, err = chooseColumn("Task title", header, title, false); err != nil {
+		return m, err
+	}
+	m.Title = []int{title}
+	fields := []struct {
+		label string
+		index *int
+	}{
+		{"Project", &m.Project},
+		{"Tags", &m.Tags},
+		{"Start date (or date and time)", &m.StartDate},
+		{"Start time", &m.StartTime},
+		{"End date (or date and time)", &m.EndDate},
+		{"End time", &m.EndTime},
+		{"Duration", &m.Duration},
+	}
+	for _, f := range fields {
+		if *f.index, err = chooseColumn(f.label, header, *f.index, true); err != nil {
+			return m, err
+		}
+	}
+	if m.Duration >= 0 {
+		units := []string{"clock", "hours", "seconds"}
+		prompt := promptui.Select{Label: "Duration is written as", Items: []string{"h:mm:ss", "decimal hours", "seconds"}, HideHelp: true}
+		i, _, err := prompt.Run()
+		if err != nil {
+			return m, err
+		}
+		m.DurationUnit = units[i]
+	}
+	return m, nil
+}
+
+// describeMapping prints the column used for each field
+func describeMapping(header []string, m columnMapping) {
+	name := func(i int) string {
+		if i < 0 || i >= len(header) {
+			return "-"
+		}
+		return header[i]
+	}
+	var titles []string
+	for _, i := range m.Title {
+		titles = append(titles, name(i))
+	}
+	fmt.Printf("  Title:    %s\n", strings.Join(titles, " + "))
+	fmt.Printf("  Project:  %s\n", name(m.Project))
+	fmt.Printf("  Tags:     %s\n", name(m.Tags))
+	fmt.Printf("  Start:    %s %s\n", name(m.StartDate), name(m.StartTime))
+	fmt.Printf("  End:      %s %s\n", name(m.EndDate), name(m.EndTime))
+	fmt.Printf("  Duration: %s (%s)\n", name(m.Duration), m.DurationUnit)
+}
+
+// trackerEntry is one time entry read from an export
+type trackerEntry struct {
+	Title   string
+	Project string
+	Tags    []string
+	Start   time.Time
+	End     time.Time
+}
+
+// parseTrackerRows turns rows into entries, returning how many were skipped
+func parseTrackerRows(rows [][]string, m columnMapping) ([]trackerEntry, int) {
+	var entries []trackerEntry
+	skipped := 0
+	for _, row := range rows {
+		cell := func(i int) string {
+			if i < 0 || i >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[i])
+		}
+		var parts []string
+		for _, i := range m.Title {
+			if v := cell(i); v != "" {
+				parts = append(parts, v)
+			}
+		}
+		e := trackerEntry{Title: strings.Join(parts, " - "), Project: cell(m.Project)}
+		if e.Title == "" {
+			e.Title = e.Project
+		}
+		for _, tag := range strings.FieldsFunc(cell(m.Tags), func(r rune) bool { return r == ',' || r == ';' }) {
+			if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+				e.Tags = append(e.Tags, tag)
+			}
+		}
+		start, err := parseTrackerTime(strings.TrimSpace(cell(m.StartDate) + " " + cell(m.StartTime)))
+		if err != nil || e.Title == "" {
+			skipped++
+			continue
+		}
+		e.Start = start
+		if end, err := parseTrackerTime(strings.TrimSpace(cell(m.EndDate) + " " + cell(m.EndTime))); err == nil && end.After(start) {
+			e.End = end
+		} else if d, err := parseTrackerDuration(cell(m.Duration), m.DurationUnit); err == nil && d > 0 {
+			e.End = start.Add(d)
+		} else {
+			skipped++
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, skipped
+}
+
+// mergeTrackerEntries adds entries to data as done tasks, one per day, title
+// and project, with a session per entry. Sessions already imported are skipped,
+// so running the import twice is harmless. It returns the tasks created and
+// the sessions added.
+func mergeTrackerEntries(data TaskData, entries []trackerEntry, source string) (int, int) {
+	created, added := 0, 0
+	for _, e := range entries {
+		day := e.Start.Format("2006-01-02")
+		id := fmt.Sprintf("%s:%s|%s", strings.ToLower(source), strings.ToLower(e.Title), strings.ToLower(e.Project))
+		idx := -1
+		for i, t := range data[day] {
+			if t.ExternalID == id {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			task := Task{Title: e.Title, Project: e.Project, Status: "done", Tags: e.Tags, ExternalID: id}
+			applyTagRules(&task)
+			data[day] = append(data[day], task)
+			idx = len(data[day]) - 1
+			created++
+		}
+		t := &data[day][idx]
+		duplicate := false
+		for _, s := range t.Sessions {
+			if s.Start == e.Start.Unix() {
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			continue
+		}
+		t.Sessions = append(t.Sessions, Session{Start: e.Start.Unix(), End: e.End.Unix()})
+		sort.Slice(t.Sessions, func(i, j int) bool { return t.Sessions[i].Start < t.Sessions[j].Start })
+		t.Actual = int(t.closedSeconds() / 60)
+		t.Estimated = t.Actual
+		added++
+	}
+	return created, added
+}
+
+// runTrackerImport is the wizard: pick an export, check the column mapping,
+// preview, and import
+func runTrackerImport(path string) error {
+	var format trackerFormat
+	if path == "" {
+		found := findTrackerExports()
+		items := []string{}
+		for _, f := range found {
+			items = append(items, fmt.Sprintf("%s (%s)", f.Path, f.Format.Name))
+		}
+		items = append(items, "Other file…")
+		prompt := promptui.Select{Label: "Export to import", Items: items, HideHelp: true, Size: 8}
+		i, _, err := prompt.Run()
+		if err != nil {
+			return err
+		}
+		if i < len(found) {
+			path, format = found[i].Path, found[i].Format
+		} else {
+			path, err = validatedPrompt("CSV file", "", func(input string) error {
+				_, err := os.Stat(expandHome(strings.TrimSpace(input)))
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			path = expandHome(strings.TrimSpace(path))
+		}
+	}
+
+	header, rows, err := readTrackerCSV(path)
+	if err != nil {
+		return err
+	}
+	if format.Name == "" {
+		if detected, ok := detectTrackerFormat(header); ok {
+			format = detected
+		}
+	}
+	mapping := defaultMapping(header, format)
+	custom := format.Name == ""
+	if custom {
+		fmt.Println("Unrecognized export; choose which column holds each field.")
+		format.Name = "csv"
+	} else {
+		fmt.Printf("Detected a %s export with %d rows:\n", format.Name, len(rows))
+		describeMapping(header, mapping)
+		ok, err := confirmPrompt("Use this mapping")
+		if err != nil {
+			return err
+		}
+		custom = !ok
+	}
+	if custom {
+		if mapping, err = editMapping(header, mapping); err != nil {
+			return err
+		}
+	}
+
+	entries, skipped := parseTrackerRows(rows, mapping)
+	if len(entries) == 0 {
+		return fmt.Errorf("no usable entries in %s (%d rows skipped)", path, skipped)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Start.Before(entries[j].Start) })
+	var total time.Duration
+	for _, e := range entries {
+		total += e.End.Sub(e.Start)
+	}
+	fmt.Printf("%d entries from %s to %s, %s in total", len(entries),
+		entries[0].Start.Format("2006-01-02"), entries[len(entries)-1].Start.Format("2006-01-02"), fmtMinutes(int(total.Minutes())))
+	if skipped > 0 {
+		fmt.Printf(" (%d rows skipped)", skipped)
+	}
+	fmt.Println()
+	for _, e := range entries[:min(5, len(entries))] {
+		fmt.Printf("  %s %s-%s  %s\n", e.Start.Format("2006-01-02"), e.Start.Format("15:04"), e.End.Format("15:04"), e.Title)
+	}
+	ok, err := confirmPrompt("Import")
+	if err != nil || !ok {
+		return err
+	}
+
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	created, added := mergeTrackerEntries(data, entries, format.Name)
+	if added == 0 {
+		fmt.Println("Everything in this export was already imported.")
+		return nil
+	}
+	if err := saveTasks(data); err != nil {
+		return err
+	}
+	fmt.Printf("Imported %d sessions into %d new tasks.\n", added, created)
+	return nil
+}
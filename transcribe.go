@@ -0,0 +1,158 @@
+// transcribe.go - Voice memo transcription
+// Turns an audio file into note text with a local whisper binary or a transcription API
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// TranscribeConfig is the `transcribe` section of config.yaml
+type TranscribeConfig struct {
+	Backend   string `yaml:"backend"`     // whisper (default) or api
+	Command   string `yaml:"command"`     // whisper binary, default "whisper"
+	Model     string `yaml:"model"`       // whisper model or API model name
+	Language  string `yaml:"language"`    // optional language hint, e.g. "en"
+	APIURL    string `yaml:"api_url"`     // OpenAI-compatible /audio/transcriptions endpoint
+	APIKeyEnv string `yaml:"api_key_env"` // variable holding the API key, default OPENAI_API_KEY
+}
+
+// transcribeAudio returns the transcript of an audio file using the configured backend
+func transcribeAudio(path string) (string, error) {
+	if err := requireOnline("Transcription"); err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return "", err
+	}
+	cfg := config.Transcribe
+	var (
+		text string
+		err  error
+	)
+	switch cfg.Backend {
+	case "", "whisper":
+		text, err = transcribeWithWhisper(cfg, path)
+	case "api":
+		text, err = transcribeWithAPI(cfg, path)
+	default:
+		return "", fmt.Errorf("unknown transcribe.backend %q (expected whisper or api)", cfg.Backend)
+	}
+	if err != nil {
+		return "", err
+	}
+	text = strings.Join(strings.Fields(text), " ")
+	if text == "" {
+		return "", fmt.Errorf("transcription of %s is empty", path)
+	}
+	return text, nil
+}
+
+// transcribeWithWhisper runs the openai-whisper CLI and reads back its .txt output
+func transcribeWithWhisper(cfg TranscribeConfig, path string) (string, error) {
+	command := cfg.Command
+	if command == "" {
+		command = "whisper"
+	}
+	model := cfg.Model
+	if model == "" {
+		model = "base"
+	}
+	outDir, err := os.MkdirTemp("", "daily-transcribe")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(outDir)
+
+	args := []string{path, "--model", model, "--output_format", "txt", "--output_dir", outDir}
+	if cfg.Language != "" {
+		args = append(args, "--language", cfg.Language)
+	}
+	cmd := exec.Command(command, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	fmt.Println("Transcribing with", command, "...")
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s failed: %v %s", command, err, strings.TrimSpace(stderr.String()))
+	}
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	out, err := os.ReadFile(filepath.Join(outDir, base+".txt"))
+	if err != nil {
+		return "", fmt.Errorf("%s produced no transcript: %v", command, err)
+	}
+	return string(out), nil
+}
+
+// transcribeWithAPI uploads the audio to an OpenAI-compatible transcription endpoint
+func transcribeWithAPI(cfg TranscribeConfig, path string) (string, error) {
+	keyEnv := cfg.APIKeyEnv
+	if keyEnv == "" {
+		keyEnv = "OPENAI_API_KEY"
+	}
+	apiKey := os.Getenv(keyEnv)
+	if apiKey == "" {
+		return "", fmt.Errorf("%s is not set", keyEnv)
+	}
+	endpoint := cfg.APIURL
+	if endpoint == "" {
+		endpoint = "https://api.openai.com/v1/audio/transcriptions"
+	}
+	model := cfg.Model
+	if model == "" {
+		model = "whisper-1"
+	}
+
+	audio, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer audio.Close()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("model", model)
+	if cfg.Language != "" {
+		form.WriteField("language", cfg.Language)
+	}
+	part, err := form.CreateFormFile("file", filepath.Base(path))
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(part, audio); err != nil {
+		return "", err
+	}
+	form.Close()
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, &body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	fmt.Println("Uploading audio for transcription...")
+	resp, err := (&http.Client{Timeout: 5 * time.Minute}).Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("transcription API returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	var result struct {
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	return result.Text, nil
+}
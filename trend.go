@@ -0,0 +1,111 @@
+// trend.go - Recent trend in the terminal
+// Sparklines of worked minutes and completion rate over the last weeks
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+var sparkLevels = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline renders values scaled to top; negative values (no data) are blank
+func sparkline(values []float64, top float64) string {
+	var b strings.Builder
+	for _, v := range values {
+		if v < 0 {
+			b.WriteRune(' ')
+			continue
+		}
+		level := 0
+		if top > 0 {
+			level = int(math.Round(v / top * float64(len(sparkLevels)-1)))
+		}
+		level = max(0, min(level, len(sparkLevels)-1))
+		b.WriteRune(sparkLevels[level])
+	}
+	return b.String()
+}
+
+// showTrend prints worked minutes and completion rate for the last `days` days
+func showTrend(days int, bars bool) error {
+	if days < 2 {
+		return fmt.Errorf("--days must be at least 2")
+	}
+	today := dayOf(time.Now())
+	data, err := loadTasksRange(today.AddDate(0, 0, 1-days).Format("2006-01-02"), "")
+	if err != nil {
+		return err
+	}
+	off, err := loadDaysOff()
+	if err != nil {
+		return err
+	}
+	worked := make([]float64, days)
+	completion := make([]float64, days)
+	labels := make([]string, days)
+	offReasons := make([]string, days)
+	top, total, active, daysOff := 0.0, 0.0, 0, 0
+	for i := 0; i < days; i++ {
+		day := today.AddDate(0, 0, i-days+1)
+		labels[i] = day.Format("Mon 01-02")
+		m := computeDayMetrics(data[day.Format("2006-01-02")])
+		if m.Tasks == 0 && m.Worked == 0 {
+			worked[i], completion[i] = -1, -1
+			if reason, ok := dayOffReason(day, off); ok && reason != "weekend" {
+				offReasons[i] = reason
+				daysOff++
+			}
+			continue
+		}
+		worked[i] = float64(m.Worked)
+		completion[i] = ratioOf(m.Done, m.Tasks)
+		top = max(top, worked[i])
+		total += worked[i]
+		active++
+	}
+	if active == 0 {
+		fmt.Printf("No tracked days in the last %d days.\n", days)
+		return nil
+	}
+
+	start := today.AddDate(0, 0, 1-days).Format("Jan 2")
+	fmt.Printf("Last %d days (%s – %s), %d with tasks", days, start, today.Format("Jan 2"), active)
+	if daysOff > 0 {
+		fmt.Printf(", %d off", daysOff)
+	}
+	fmt.Print("\n\n")
+	fmt.Printf("Worked     %s  avg %.0f min, max %.0f min\n", sparkline(worked, top), total/float64(active), top)
+	doneSum := 0.0
+	for _, c := range completion {
+		if c >= 0 {
+			doneSum += c
+		}
+	}
+	fmt.Printf("Completed  %s  avg %.0f%% of tasks done\n", sparkline(completion, 1), doneSum/float64(active)*100)
+
+	if !bars {
+		return nil
+	}
+	fmt.Println()
+	const width = 40
+	for i := range worked {
+		if worked[i] < 0 {
+			label := "-"
+			if offReasons[i] != "" {
+				label = "off (" + offReasons[i] + ")"
+			}
+			fmt.Printf("%s  %s\n", labels[i], label)
+			continue
+		}
+		n := 0
+		if top > 0 {
+			n = int(math.Round(worked[i] / top * width))
+		}
+		fmt.Printf("%s  %-*s %4.0f min  %3.0f%%\n", labels[i], width, strings.Repeat("█", n), worked[i], completion[i]*100)
+	}
+	return nil
+}
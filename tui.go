@@ -0,0 +1,356 @@
+// tui.go - Full-screen dashboard
+// `daily tui` shows today's tasks, the running timer, progress, and notes in one screen
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	paneStyle  = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1)
+	titleStyle = lipgloss.NewStyle().Bold(true)
+	dimStyle   = lipgloss.NewStyle().Faint(true)
+)
+
+// reloadEvery is how many one-second ticks pass between re-reading the store,
+// so changes made from other terminals show up
+const reloadEvery = 10
+
+type dashboardModel struct {
+	tasks     []Task
+	notes     []string
+	cursor    int
+	width     int
+	height    int
+	ticks     int
+	taskBar   progress.Model
+	dayBar    progress.Model
+	editing   bool
+	editField int // 0: title, 1: estimate
+	input     textinput.Model
+	message   string
+	showHelp  bool
+	day       dayWatcher
+}
+
+func newDashboardModel() dashboardModel {
+	input := textinput.New()
+	input.Prompt = ""
+	m := dashboardModel{
+		taskBar: progress.New(progress.WithSolidFill(activeTheme().Accent), progress.WithWidth(30)),
+		dayBar:  progress.New(progress.WithSolidFill(activeTheme().Good), progress.WithWidth(30)),
+		input:   input,
+		day:     newDayWatcher(),
+	}
+	m.reload()
+	return m
+}
+
+func (m *dashboardModel) reload() {
+	data, err := loadTasks()
+	if err != nil {
+		m.message = "Error: " + err.Error()
+		return
+	}
+	notes, err := loadNotes()
+	if err != nil {
+		m.message = "Error: " + err.Error()
+		return
+	}
+	m.tasks = data[todayKey()]
+	m.notes = visibleNotes(notes[todayKey()])
+	if m.cursor >= len(m.tasks) {
+		m.cursor = max(0, len(m.tasks)-1)
+	}
+}
+
+// startedIndex returns the index of the running task, or -1
+func (m dashboardModel) startedIndex() int {
+	for i, t := range m.tasks {
+		if t.Status == "started" {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m dashboardModel) Init() tea.Cmd {
+	return tea.Tick(time.Second, func(_ time.Time) tea.Msg {
+		return tickMsg{}
+	})
+}
+
+func (m dashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		return m, nil
+	case tickMsg:
+		m.ticks++
+		// Day changes and reloads wait until an edit is saved
+		if !m.editing {
+			if changed, message, err := m.day.check(); changed {
+				m.cursor = 0
+				m.reload()
+				m.message = "It's " + todayKey() + ". " + message
+				if err != nil {
+					m.message = "Error: " + err.Error()
+				}
+			} else if m.ticks%reloadEvery == 0 {
+				m.reload()
+			}
+		}
+		return m, tea.Tick(time.Second, func(_ time.Time) tea.Msg {
+			return tickMsg{}
+		})
+	case tea.KeyMsg:
+		if m.editing {
+			return m.updateEditor(msg)
+		}
+		return m.updateKeys(msg)
+	}
+	return m, nil
+}
+
+func (m dashboardModel) updateKeys(k tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.message = ""
+	switch {
+	case keys.IsQuit(k):
+		return m, tea.Quit
+	case key.Matches(k, keys.Up) || k.Type == tea.KeyUp:
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case key.Matches(k, keys.Down) || k.Type == tea.KeyDown:
+		if m.cursor < len(m.tasks)-1 {
+			m.cursor++
+		}
+	case key.Matches(k, keys.Help):
+		m.showHelp = !m.showHelp
+	case len(m.tasks) == 0:
+		m.message = "No tasks for today. Add some with `daily add`."
+	case key.Matches(k, keys.Start):
+		if running := m.startedIndex(); running >= 0 {
+			m.message = fmt.Sprintf("'%s' is already running; stop it first.", m.tasks[running].Title)
+			break
+		}
+		if t := m.tasks[m.cursor]; t.Status == "done" || t.Status == "cancelled" {
+			m.message = fmt.Sprintf("'%s' is %s.", t.Title, t.Status)
+			break
+		}
+		m.setStatus(m.cursor, "started")
+	case key.Matches(k, keys.Stop):
+		running := m.startedIndex()
+		if running < 0 {
+			m.message = "No task is running."
+			break
+		}
+		m.setStatus(running, "pending")
+	case key.Matches(k, keys.Finish):
+		m.setStatus(m.cursor, "done")
+	case key.Matches(k, keys.Edit):
+		m.editing = true
+		m.editField = 0
+		m.input.SetValue(m.tasks[m.cursor].Title)
+		m.input.CursorEnd()
+		return m, m.input.Focus()
+	}
+	return m, nil
+}
+
+func (m *dashboardModel) setStatus(index int, status string) {
+	title := m.tasks[index].Title
+	if err := updateStatus(index, status); err != nil {
+		m.message = "Error: " + err.Error()
+		return
+	}
+	m.message = fmt.Sprintf("'%s' is now %s.", title, status)
+	m.reload()
+}
+
+// updateEditor edits the selected task's title, then its estimate
+func (m dashboardModel) updateEditor(k tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch k.Type {
+	case tea.KeyEsc, tea.KeyCtrlC:
+		m.editing = false
+		m.input.Blur()
+		m.message = "Edit cancelled."
+		return m, nil
+	case tea.KeyEnter:
+		value := strings.TrimSpace(m.input.Value())
+		if m.editField == 0 {
+			if value == "" {
+				m.message = "Title can't be empty."
+				return m, nil
+			}
+			m.tasks[m.cursor].Title = value
+			m.editField = 1
+			m.input.SetValue(strconv.Itoa(m.tasks[m.cursor].Estimated))
+			m.input.CursorEnd()
+			m.message = ""
+			return m, nil
+		}
+		estimated, err := parseEstimate(value)
+		if err != nil {
+			m.message = err.Error()
+			return m, nil
+		}
+		m.editing = false
+		m.input.Blur()
+		if err := saveTaskEdit(m.cursor, m.tasks[m.cursor].Title, estimated); err != nil {
+			m.message = "Error: " + err.Error()
+		} else {
+			m.message = "Task updated."
+		}
+		m.reload()
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(k)
+	return m, cmd
+}
+
+// saveTaskEdit stores a new title and estimate for one of today's tasks
+func saveTaskEdit(index int, title string, estimated int) error {
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	today := todayKey()
+	if index < 0 || index >= len(data[today]) {
+		return fmt.Errorf("invalid task index")
+	}
+	t := &data[today][index]
+	t.Title = title
+	t.setEstimate(estimated, time.Now())
+	if t.Status == "started" && t.Estimated > 0 {
+		t.FinishBy = projectedFinish(*t, time.Now()).Unix()
+	}
+	return saveTasks(data)
+}
+
+func (m dashboardModel) View() string {
+	width := m.width
+	if width == 0 {
+		width = 100
+	}
+	leftWidth := width/2 - 2
+	rightWidth := width - leftWidth - 6
+
+	tasks := paneStyle.Width(leftWidth).Render(m.viewTasks())
+	status := paneStyle.Width(rightWidth).Render(m.viewTimer() + "\n\n" + m.viewProgress())
+	top := lipgloss.JoinHorizontal(lipgloss.Top, tasks, status)
+	notes := paneStyle.Width(width - 4).Render(m.viewNotes())
+
+	footer := m.message
+	if m.editing {
+		label := "Title"
+		if m.editField == 1 {
+			label = "Estimate (min, or 1.5h)"
+		}
+		footer = fmt.Sprintf("%s: %s  (enter to save, esc to cancel)", label, m.input.View())
+	} else if footer == "" {
+		footer = dimStyle.Render(m.helpLine())
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, top, notes, footer)
+}
+
+func (m dashboardModel) viewTasks() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Today "+todayKey()) + "\n\n")
+	if len(m.tasks) == 0 {
+		b.WriteString(dimStyle.Render("No tasks yet."))
+	}
+	for i, t := range m.tasks {
+		pointer := "  "
+		if i == m.cursor {
+			pointer = "→ "
+		}
+		mark := map[string]string{"done": "✔", "started": "▶", "paused": "⏸", "cancelled": "✗"}[t.Status]
+		if mark == "" {
+			mark = "·"
+		}
+		line := fmt.Sprintf("%s%s %s (%s)", pointer, mark, t.Title, fmtRatio(t.Actual, t.Estimated))
+		if t.Status == "done" || t.Status == "cancelled" {
+			line = dimStyle.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func (m dashboardModel) viewTimer() string {
+	running := m.startedIndex()
+	if running < 0 {
+		return titleStyle.Render("Running") + "\n\n" + dimStyle.Render("Nothing running.")
+	}
+	t := m.tasks[running]
+	segment := time.Duration(t.sessionSeconds(time.Now().Unix())) * time.Second
+	total := time.Duration(t.trackedMinutes(time.Now().Unix())) * time.Minute
+	view := fmt.Sprintf("%s\n\n%s\nThis session: %s  Total: %s",
+		titleStyle.Render("Running"), t.Title, formatDuration(segment), formatDuration(total))
+	if t.Estimated > 0 {
+		percent := total.Minutes() / float64(t.Estimated)
+		view += "\n" + m.taskBar.ViewAs(min(1, percent))
+		if t.FinishBy != 0 {
+			view += "\nFinish by " + time.Unix(t.FinishBy, 0).Format("15:04")
+		}
+	}
+	return view
+}
+
+func (m dashboardModel) viewProgress() string {
+	metrics := computeDayMetrics(m.tasks)
+	if running := m.startedIndex(); running >= 0 {
+		t := m.tasks[running]
+		metrics.Worked += t.trackedMinutes(time.Now().Unix()) - t.Actual
+	}
+	done := 0.0
+	if metrics.Planned > 0 {
+		done = float64(metrics.Achieved) / float64(metrics.Planned)
+	}
+	left := remainingMinutesToday(time.Now())
+	return fmt.Sprintf("%s\n\nDone: %d/%d tasks, %s\n%s\nWorked: %s  Left today: %dh%02dm",
+		titleStyle.Render("Progress"), metrics.Done, metrics.Tasks, fmtRatio(metrics.Achieved, metrics.Planned),
+		m.dayBar.ViewAs(min(1, done)), fmtMinutes(metrics.Worked), left/60, left%60)
+}
+
+func (m dashboardModel) viewNotes() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Notes") + "\n")
+	if len(m.notes) == 0 {
+		b.WriteString(dimStyle.Render("No notes for today."))
+	}
+	for _, note := range m.notes {
+		b.WriteString("• " + note + "\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func (m dashboardModel) helpLine() string {
+	bindings := []key.Binding{keys.Up, keys.Down, keys.Start, keys.Stop, keys.Finish, keys.Edit, keys.Help, keys.Quit}
+	if !m.showHelp {
+		bindings = []key.Binding{keys.Start, keys.Stop, keys.Finish, keys.Edit, keys.Help, keys.Quit}
+	}
+	var parts []string
+	for _, b := range bindings {
+		parts = append(parts, b.Help().Key+" "+b.Help().Desc)
+	}
+	return strings.Join(parts, " • ")
+}
+
+// runDashboard opens the full-screen dashboard
+func runDashboard() error {
+	_, err := tea.NewProgram(newDashboardModel(), tea.WithAltScreen()).Run()
+	return err
+}
@@ -0,0 +1,234 @@
+// undo.go - Undo log
+// Every save records the previous state of the days it changes, so `daily undo` can revert the latest change
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// undoLimit is how many changes the undo log keeps
+const undoLimit = 50
+
+// undoEntry is one recorded change: the days it touched as they were before.
+// A day that didn't exist before the change is stored empty.
+type undoEntry struct {
+	At        int64               `yaml:"at"`
+	Operation string              `yaml:"operation"`
+	Tasks     map[string][]Task   `yaml:"tasks,omitempty"`
+	Notes     map[string][]string `yaml:"notes,omitempty"`
+}
+
+// currentOperation names the command being run, for the undo log
+var currentOperation = "change"
+
+// undoing is set while an undo is saved so that it isn't journaled itself
+var undoing bool
+
+func getUndoFilePath() (string, error) {
+	return dataFilePath("undo.yaml")
+}
+
+func loadUndoLog() ([]undoEntry, error) {
+	path, err := getUndoFilePath()
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var log []undoEntry
+	err = yaml.Unmarshal(content, &log)
+	return log, err
+}
+
+func saveUndoLog(log []undoEntry) error {
+	if len(log) > undoLimit {
+		log = log[len(log)-undoLimit:]
+	}
+	path, err := getUndoFilePath()
+	if err != nil {
+		return err
+	}
+	content, err := yaml.Marshal(log)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, content, 0644)
+}
+
+// changedDays returns the previous value of every day that differs between
+// before and after
+func changedDays[T any](before, after map[string][]T) map[string][]T {
+	changed := map[string][]T{}
+	for day, list := range after {
+		if old, ok := before[day]; !ok || !reflect.DeepEqual(old, list) {
+			changed[day] = old
+		}
+	}
+	for day, old := range before {
+		if _, ok := after[day]; !ok {
+			changed[day] = old
+		}
+	}
+	return changed
+}
+
+// journalTasks records the days a task save is about to change
+func journalTasks(before, after TaskData) error {
+	changed := changedDays(before, after)
+	if len(changed) == 0 {
+		return nil
+	}
+	return appendUndo(undoEntry{Tasks: changed})
+}
+
+// journalNotes records the days a notes save is about to change. A day
+// being encrypted is left out, so its notes don't stay readable in the log.
+func journalNotes(before, after NoteData) error {
+	changed := changedDays(before, after)
+	for day := range changed {
+		if isSealed(after[day]) && !isSealed(before[day]) {
+			delete(changed, day)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	return appendUndo(undoEntry{Notes: changed})
+}
+
+// forgetNotes drops the notes of a day from the undo log, once they are
+// encrypted. Entries left with nothing to restore are dropped.
+func forgetNotes(day string) error {
+	log, err := loadUndoLog()
+	if err != nil {
+		return err
+	}
+	var kept []undoEntry
+	changed := false
+	for _, e := range log {
+		if _, ok := e.Notes[day]; ok {
+			delete(e.Notes, day)
+			changed = true
+			if len(e.Notes) == 0 && len(e.Tasks) == 0 {
+				continue
+			}
+		}
+		kept = append(kept, e)
+	}
+	if !changed {
+		return nil
+	}
+	return saveUndoLog(kept)
+}
+
+func appendUndo(entry undoEntry) error {
+	log, err := loadUndoLog()
+	if err != nil {
+		return err
+	}
+	entry.At = time.Now().Unix()
+	entry.Operation = currentOperation
+	return saveUndoLog(append(log, entry))
+}
+
+// describeUndo summarizes an entry for the undo output
+func describeUndo(e undoEntry) string {
+	var days []string
+	for day := range e.Tasks {
+		days = append(days, day)
+	}
+	for day := range e.Notes {
+		if _, ok := e.Tasks[day]; !ok {
+			days = append(days, day)
+		}
+	}
+	sort.Strings(days)
+	what := "tasks"
+	if len(e.Tasks) == 0 {
+		what = "notes"
+	}
+	return fmt.Sprintf("%s at %s (%s of %s)", e.Operation, time.Unix(e.At, 0).Format("Jan 2 15:04"), what, strings.Join(days, ", "))
+}
+
+// undoLast restores the days changed by the most recent recorded change
+func undoLast() error {
+	log, err := loadUndoLog()
+	if err != nil {
+		return err
+	}
+	if len(log) == 0 {
+		fmt.Println("Nothing to undo.")
+		return nil
+	}
+	entry := log[len(log)-1]
+
+	undoing = true
+	defer func() { undoing = false }()
+	if len(entry.Tasks) > 0 {
+		data, err := loadTasks()
+		if err != nil {
+			return err
+		}
+		for day, tasks := range entry.Tasks {
+			if len(tasks) == 0 {
+				delete(data, day)
+			} else {
+				data[day] = tasks
+			}
+		}
+		if err := saveTasks(data); err != nil {
+			return err
+		}
+	}
+	if len(entry.Notes) > 0 {
+		data, err := loadNotes()
+		if err != nil {
+			return err
+		}
+		for day, notes := range entry.Notes {
+			if len(notes) == 0 {
+				delete(data, day)
+			} else {
+				data[day] = notes
+			}
+		}
+		if err := saveNotes(data); err != nil {
+			return err
+		}
+	}
+	if err := saveUndoLog(log[:len(log)-1]); err != nil {
+		return err
+	}
+	fmt.Println("Undid", describeUndo(entry))
+	return nil
+}
+
+// showUndoLog lists the recorded changes, most recent first
+func showUndoLog() error {
+	log, err := loadUndoLog()
+	if err != nil {
+		return err
+	}
+	if len(log) == 0 {
+		fmt.Println("Nothing to undo.")
+		return nil
+	}
+	for i := len(log) - 1; i >= 0; i-- {
+		fmt.Printf("%2d. %s\n", len(log)-i, describeUndo(log[i]))
+	}
+	return nil
+}
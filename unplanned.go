@@ -0,0 +1,143 @@
+// unplanned.go - Planned vs unplanned work
+// Tells tasks planned before the day started from those added during it, per day and per week
+
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// stampCreated sets CreatedAt on the tasks of a day that weren't in its
+// previous list. Tasks are matched by title; older tasks keep an unknown
+// creation time.
+func stampCreated(before, after []Task, now int64) {
+	known := map[string]bool{}
+	for _, t := range before {
+		known[t.Title] = true
+	}
+	for i := range after {
+		if after[i].CreatedAt == 0 && !known[after[i].Title] {
+			after[i].CreatedAt = now
+		}
+	}
+}
+
+// planClass tells whether a task was planned before its day started
+type planClass int
+
+const (
+	planUnknown planClass = iota
+	planPlanned
+	planUnplanned
+)
+
+// classifyPlan compares a task's creation time with the start of work on its
+// day. Tasks created after the day was over (backfilled or imported) and
+// tasks from before creation times were kept are unknown.
+func classifyPlan(t Task, day string) planClass {
+	date, err := time.ParseInLocation("2006-01-02", day, time.Local)
+	if t.CreatedAt == 0 || err != nil {
+		return planUnknown
+	}
+	created := time.Unix(t.CreatedAt, 0)
+	workStart, _, _, _ := config.WorkHours.bounds(date)
+	switch {
+	case created.Before(workStart):
+		return planPlanned
+	case created.Before(date.AddDate(0, 0, 1)):
+		return planUnplanned
+	default:
+		return planUnknown
+	}
+}
+
+// planSplit counts planned and unplanned work
+type planSplit struct {
+	Planned, Unplanned             int // tasks
+	PlannedWorked, UnplannedWorked int // tracked minutes
+}
+
+func (s *planSplit) add(o planSplit) {
+	s.Planned += o.Planned
+	s.Unplanned += o.Unplanned
+	s.PlannedWorked += o.PlannedWorked
+	s.UnplannedWorked += o.UnplannedWorked
+}
+
+// known reports whether any task could be classified
+func (s planSplit) known() bool {
+	return s.Planned+s.Unplanned > 0
+}
+
+// ratio is the unplanned share of the classified tasks
+func (s planSplit) ratio() float64 {
+	return ratioOf(s.Unplanned, s.Planned+s.Unplanned)
+}
+
+// workedRatio is the unplanned share of the time tracked on classified tasks
+func (s planSplit) workedRatio() float64 {
+	return ratioOf(s.UnplannedWorked, s.PlannedWorked+s.UnplannedWorked)
+}
+
+// splitDay classifies the work tasks of a day
+func splitDay(tasks []Task, day string) planSplit {
+	var s planSplit
+	for _, t := range tasks {
+		if isNonWork(t) {
+			continue
+		}
+		switch classifyPlan(t, day) {
+		case planPlanned:
+			s.Planned++
+			s.PlannedWorked += t.Actual
+		case planUnplanned:
+			s.Unplanned++
+			s.UnplannedWorked += t.Actual
+		}
+	}
+	return s
+}
+
+// describeSplit renders a split as "2/8 tasks (25%), 40 min of 300 min worked (13%)"
+func describeSplit(s planSplit) string {
+	return fmt.Sprintf("%d/%d tasks (%.0f%%), %s of %s worked (%.0f%%)",
+		s.Unplanned, s.Planned+s.Unplanned, s.ratio()*100,
+		fmtMinutes(s.UnplannedWorked), fmtMinutes(s.PlannedWorked+s.UnplannedWorked), s.workedRatio()*100)
+}
+
+// showUnplanned prints the unplanned share of the last `weeks` weeks
+func showUnplanned(weeks int) error {
+	if weeks < 1 {
+		return fmt.Errorf("--weeks must be at least 1")
+	}
+	monday := weekMonday(dayOf(time.Now())).AddDate(0, 0, -7*(weeks-1))
+	data, err := loadTasksRange(monday.Format("2006-01-02"), "")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%-12s %7s %9s %10s %9s\n", "Week of", "Tasks", "Unplanned", "Worked", "Unplanned")
+	var total planSplit
+	for w := 0; w < weeks; w++ {
+		start := monday.AddDate(0, 0, 7*w)
+		var week planSplit
+		for i := 0; i < 7; i++ {
+			day := start.AddDate(0, 0, i).Format("2006-01-02")
+			week.add(splitDay(data[day], day))
+		}
+		if !week.known() {
+			fmt.Printf("%-12s %7s\n", start.Format("2006-01-02"), "-")
+			continue
+		}
+		fmt.Printf("%-12s %7d %8.0f%% %10s %8.0f%%\n", start.Format("2006-01-02"),
+			week.Planned+week.Unplanned, week.ratio()*100, fmtMinutes(week.PlannedWorked+week.UnplannedWorked), week.workedRatio()*100)
+		total.add(week)
+	}
+	if !total.known() {
+		fmt.Println("\nNo tasks with a recorded creation time yet; tasks added from now on are classified.")
+		return nil
+	}
+	fmt.Printf("\nUnplanned: %s\n", describeSplit(total))
+	fmt.Println("Tasks added before the start of work (work_hours.start) count as planned, tasks added later that day as unplanned.")
+	return nil
+}
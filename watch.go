@@ -0,0 +1,109 @@
+// watch.go - Overrun notifications
+// `daily watch` runs in the background and notifies when the running task passes its estimate
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// WatchConfig is the `watch` section of config.yaml
+type WatchConfig struct {
+	Interval     time.Duration  `yaml:"interval"`      // how often to check, default 30s
+	OverrunEvery *time.Duration `yaml:"overrun_every"` // repeat after the estimate, default 15m; 0s notifies once
+}
+
+// overrunAlert tracks the notifications sent for one running task
+type overrunAlert struct {
+	task string // title and segment start of the task the alerts belong to
+	sent int    // notifications sent so far
+}
+
+// nextOverrunAlert returns the tracked minutes at which notification n
+// (counting from 0) is due
+func nextOverrunAlert(estimated int, every time.Duration, n int) int {
+	return estimated + n*int(every.Minutes())
+}
+
+// checkOverrun sends a notification when the running task has passed its
+// estimate, or the next overrun interval since the last notification
+func checkOverrun(alert *overrunAlert, every time.Duration, now time.Time) error {
+	data, err := loadTasks()
+	if err != nil {
+		return err
+	}
+	var running *Task
+	for i, t := range data[todayKey()] {
+		if t.Status == "started" {
+			running = &data[todayKey()][i]
+			break
+		}
+	}
+	if running == nil || running.Estimated <= 0 {
+		*alert = overrunAlert{}
+		return nil
+	}
+	key := fmt.Sprintf("%s@%d", running.Title, running.runningSince())
+	if alert.task != key {
+		*alert = overrunAlert{task: key}
+	}
+	if alert.sent > 0 && every <= 0 {
+		return nil
+	}
+	tracked := running.trackedMinutes(now.Unix())
+	if tracked < nextOverrunAlert(running.Estimated, every, alert.sent) {
+		return nil
+	}
+	// Skip alerts missed while the watcher wasn't running
+	for every > 0 && tracked >= nextOverrunAlert(running.Estimated, every, alert.sent+1) {
+		alert.sent++
+	}
+	over := tracked - running.Estimated
+	body := fmt.Sprintf("%s: %d min tracked, estimate was %d min", running.Title, tracked, running.Estimated)
+	title := "Estimate reached"
+	if over > 0 {
+		title = fmt.Sprintf("%d min over estimate", over)
+	}
+	alert.sent++
+	err = sendNotification(title, body)
+	if errors.Is(err, errMuted) {
+		fmt.Printf("%s  %s — %s [%v]\n", now.Format("15:04"), title, body, err)
+		return nil
+	}
+	fmt.Printf("%s  %s — %s\n", now.Format("15:04"), title, body)
+	return err
+}
+
+// runWatch checks the running task until interrupted
+func runWatch() error {
+	if _, err := notifierCommand(); err != nil {
+		return err
+	}
+	if err := requireOnline("Notifications"); err != nil {
+		return err
+	}
+	interval := config.Watch.Interval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	every := 15 * time.Minute
+	if config.Watch.OverrunEvery != nil {
+		every = *config.Watch.OverrunEvery
+	}
+	fmt.Printf("Watching the running task every %s (Ctrl+C to stop)\n", interval)
+	var alert overrunAlert
+	day := newDayWatcher()
+	for {
+		if _, message, err := day.check(); err != nil {
+			fmt.Println("Error:", err)
+		} else if message != "" {
+			fmt.Printf("%s  %s\n", time.Now().Format("15:04"), message)
+		}
+		if err := checkOverrun(&alert, every, time.Now()); err != nil {
+			fmt.Println("Error:", err)
+		}
+		time.Sleep(interval)
+	}
+}
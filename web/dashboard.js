@@ -0,0 +1,81 @@
+let snap=null, fetchedAt=0;
+function pad(n){return String(n).padStart(2,"0")}
+function clock(s){s=Math.max(0,Math.floor(s));return Math.floor(s/3600)+":"+pad(Math.floor(s/60)%60)+":"+pad(s%60)}
+function mins(m){return m>=60?Math.floor(m/60)+"h"+(m%60?pad(m%60):""):m+"m"}
+function el(tag,cls,text){const e=document.createElement(tag);if(cls){e.className=cls}if(text!==undefined){e.textContent=text}return e}
+function renderTimer(){
+  if(!snap){return}
+  const box=document.getElementById("timerbox");
+  if(!snap.running){
+    document.getElementById("current").textContent="No task running";
+    document.getElementById("timer").textContent="";
+    document.getElementById("fill").style.width="0";
+    box.className="timer";
+    return;
+  }
+  const now=snap.now+(Date.now()-fetchedAt)/1000;
+  const elapsed=snap.actual*60+(snap.started_at?now-snap.started_at:0);
+  const pct=snap.estimated>0?Math.min(1,elapsed/(snap.estimated*60)):0;
+  document.getElementById("current").textContent=snap.title;
+  document.getElementById("timer").textContent=clock(elapsed)+(snap.estimated>0?" / "+mins(snap.estimated):"");
+  document.getElementById("fill").style.width=(pct*100)+"%";
+  box.className="timer"+(snap.estimated>0&&elapsed>snap.estimated*60?" over":"");
+}
+function renderDay(day){
+  document.getElementById("day").textContent=new Date(day.day+"T12:00:00").toLocaleDateString([], {weekday:"long",year:"numeric",month:"long",day:"numeric"});
+  const table=document.getElementById("tasks");
+  table.replaceChildren();
+  if(day.tasks.length===0){const tr=el("tr");tr.append(el("td","","No tasks planned"));table.append(tr)}
+  for(const t of day.tasks){
+    const tr=el("tr",t.status);
+    const title=el("td","",t.title);
+    if(t.project){title.append(el("span","tag",t.project))}
+    if(t.context){title.append(el("span","tag","@"+t.context))}
+    for(const tag of t.tags||[]){title.append(el("span","tag","#"+tag))}
+    tr.append(el("td","num",t.planned_at||""),title,el("td","num",mins(t.actual)+" / "+mins(t.estimated)),el("td","num",t.status));
+    table.append(tr);
+  }
+  const done=day.tasks.filter(t=>t.status==="done").length;
+  document.getElementById("totals").textContent=done+"/"+day.tasks.length+" done · "+mins(day.worked)+" worked of "+mins(day.planned)+" planned"+(day.budget?" · budget "+mins(day.budget):"");
+}
+function renderWeek(week,today){
+  const chart=document.getElementById("week");
+  chart.replaceChildren();
+  const top=Math.max(60,...week.map(d=>Math.max(d.planned,d.worked)));
+  for(const d of week){
+    const col=el("div","col"+(d.day===today?" today":""));
+    col.title=d.day+": "+mins(d.worked)+" worked of "+mins(d.planned)+" planned";
+    const bars=el("div","bars");
+    const planned=el("div","planned"), worked=el("div","worked");
+    planned.style.height=(d.planned/top*100)+"%";
+    worked.style.height=(d.worked/top*100)+"%";
+    bars.append(planned,worked);
+    col.append(bars,el("div","label",new Date(d.day+"T12:00:00").toLocaleDateString([], {weekday:"short"})));
+    chart.append(col);
+  }
+}
+function update(current,day,week){
+  snap=current;fetchedAt=Date.now();
+  renderDay(day);renderWeek(week,day.day);
+  renderTimer();
+}
+// The API needs the token the page was opened with
+function withToken(url){
+  const token=new URLSearchParams(location.search).get("token");
+  return token?url+(url.includes("?")?"&":"?")+"token="+encodeURIComponent(token):url;
+}
+async function poll(){
+  try{
+    const [current,day,week]=await Promise.all(["api/current","api/day","api/week"].map(u=>fetch(withToken(u)).then(r=>r.json())));
+    update(current,day,week);
+  }catch(e){}
+}
+// The server pushes the plan whenever it changes; polling is the fallback for
+// browsers or proxies without server-sent events
+if(window.EventSource){
+  const events=new EventSource(withToken("api/events"));
+  events.addEventListener("update",e=>{const u=JSON.parse(e.data);update(u.current,u.day,u.week)});
+}else{
+  poll();setInterval(poll,15000);
+}
+setInterval(renderTimer,1000);